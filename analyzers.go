@@ -0,0 +1,151 @@
+package playbookd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/registry"
+
+	// Register the Bleve language analyzers so any of them can be used as
+	// IndexerConfig.Analyzer. "standard", "simple", and "keyword" are always available.
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ar"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/bg"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ca"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ckb"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/cs"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/da"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/de"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/el"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/en"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/es"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/eu"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/fa"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/fi"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ga"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/gl"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hi"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hr"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hu"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/hy"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/id"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/in"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/it"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/nl"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/no"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/pl"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/pt"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ro"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/sv"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/tr"
+)
+
+// textAnalyzerType is the Bleve analyzer type registered for indexes with
+// custom stop words or synonyms. Its configuration is stored in the index
+// mapping, so an index reopens with the vocabulary it was built with.
+const textAnalyzerType = "playbookd_text"
+
+func init() {
+	registry.RegisterAnalyzer(textAnalyzerType, newTextAnalyzer)
+}
+
+// textAnalyzerConfig is the stored configuration of a textAnalyzerType analyzer.
+type textAnalyzerConfig struct {
+	Base      string              `json:"base"`
+	StopWords []string            `json:"stop_words,omitempty"`
+	Synonyms  map[string][]string `json:"synonyms,omitempty"`
+}
+
+func (c textAnalyzerConfig) equal(o textAnalyzerConfig) bool {
+	return c.Base == o.Base && slices.Equal(c.StopWords, o.StopWords) &&
+		maps.EqualFunc(c.Synonyms, o.Synonyms, slices.Equal)
+}
+
+// String describes the configuration for error messages.
+func (c textAnalyzerConfig) String() string {
+	if len(c.StopWords) == 0 && len(c.Synonyms) == 0 {
+		return fmt.Sprintf("analyzer %q", c.Base)
+	}
+	return fmt.Sprintf("analyzer %q with %d stop word(s) and %d synonym group(s)", c.Base, len(c.StopWords), len(c.Synonyms))
+}
+
+// textAnalyzer runs a base analyzer, drops stop words, and replaces every term
+// of a synonym group with the group's canonical term. Applied at both index
+// and query time, this makes synonyms match each other.
+type textAnalyzer struct {
+	base     analysis.Analyzer
+	stop     map[string]bool
+	synonyms map[string]string // analyzed term -> analyzed canonical term
+}
+
+func newTextAnalyzer(config map[string]interface{}, cache *registry.Cache) (analysis.Analyzer, error) {
+	// The config arrives either as built by textAnalyzerMapping or decoded
+	// from the stored mapping; a JSON round trip handles both.
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var cfg textAnalyzerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	base, err := cache.AnalyzerNamed(cfg.Base)
+	if err != nil {
+		return nil, fmt.Errorf("base analyzer %q: %w", cfg.Base, err)
+	}
+	a := &textAnalyzer{base: base, stop: make(map[string]bool), synonyms: make(map[string]string)}
+
+	// Stop words and synonyms are analyzed like document text, so they match
+	// whatever case and stemming the base analyzer applies.
+	for _, word := range cfg.StopWords {
+		for _, tok := range base.Analyze([]byte(word)) {
+			a.stop[string(tok.Term)] = true
+		}
+	}
+	for key, values := range cfg.Synonyms {
+		canonical, err := a.singleTerm(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			term, err := a.singleTerm(v)
+			if err != nil {
+				return nil, err
+			}
+			a.synonyms[term] = canonical
+		}
+	}
+	return a, nil
+}
+
+// singleTerm analyzes a synonym with the base analyzer, which must produce
+// exactly one term.
+func (a *textAnalyzer) singleTerm(word string) (string, error) {
+	tokens := a.base.Analyze([]byte(word))
+	if len(tokens) != 1 {
+		return "", fmt.Errorf("synonym %q must be a single non-stop word", word)
+	}
+	return string(tokens[0].Term), nil
+}
+
+func (a *textAnalyzer) Analyze(input []byte) analysis.TokenStream {
+	tokens := a.base.Analyze(input)
+	out := tokens[:0]
+	for _, tok := range tokens {
+		term := string(tok.Term)
+		if a.stop[term] {
+			continue
+		}
+		if canonical, ok := a.synonyms[term]; ok {
+			tok.Term = []byte(canonical)
+		}
+		out = append(out, tok)
+	}
+	return out
+}
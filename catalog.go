@@ -0,0 +1,56 @@
+package playbookd
+
+import (
+	"context"
+	"sort"
+)
+
+// CategoryGroup is the playbooks of one category, as returned by Catalog.
+type CategoryGroup struct {
+	Category      string      `json:"category"` // Empty for playbooks without a category
+	Count         int         `json:"count"`
+	AvgConfidence float64     `json:"avg_confidence"`
+	Playbooks     []*Playbook `json:"playbooks"`
+}
+
+// Catalog returns the playbooks matching filter grouped by category, for
+// browsing. Groups are sorted by category name, with uncategorized playbooks
+// last; within a group, playbooks keep the order filter asks List for.
+// filter.Limit caps the playbooks before they are grouped.
+func (pm *PlaybookManager) Catalog(ctx context.Context, filter ListFilter) ([]CategoryGroup, error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return groupByCategory(playbooks), nil
+}
+
+// groupByCategory groups playbooks by category, keeping their order within
+// each group, and sorts the groups as Catalog documents.
+func groupByCategory(playbooks []*Playbook) []CategoryGroup {
+	index := make(map[string]int)
+	var groups []CategoryGroup
+	for _, pb := range playbooks {
+		i, ok := index[pb.Category]
+		if !ok {
+			i = len(groups)
+			index[pb.Category] = i
+			groups = append(groups, CategoryGroup{Category: pb.Category})
+		}
+		g := &groups[i]
+		g.Playbooks = append(g.Playbooks, pb)
+		g.Count++
+		g.AvgConfidence += pb.Confidence
+	}
+	for i := range groups {
+		groups[i].AvgConfidence /= float64(groups[i].Count)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i].Category, groups[j].Category
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		return a < b
+	})
+	return groups
+}
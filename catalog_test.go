@@ -0,0 +1,59 @@
+package playbookd
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestManagerCatalog(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	for _, c := range []struct {
+		name, category string
+		confidence     float64
+	}{
+		{"Deploy Service", "deployment", 0.8},
+		{"Canary Release", "deployment", 0.4},
+		{"Restore Backup", "database", 0.5},
+		{"Scratch Notes", "", 0.1},
+	} {
+		pb := samplePlaybook(c.name)
+		pb.Category = c.category
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		pb.Confidence = c.confidence
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	groups, err := pm.Catalog(ctx, ListFilter{SortBy: SortByName, Ascending: true})
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	var categories []string
+	for _, g := range groups {
+		categories = append(categories, g.Category)
+	}
+	if len(groups) != 3 || categories[0] != "database" || categories[1] != "deployment" || categories[2] != "" {
+		t.Fatalf("categories = %q, want database, deployment, then uncategorized", categories)
+	}
+	deploy := groups[1]
+	if deploy.Count != 2 || math.Abs(deploy.AvgConfidence-0.6) > 1e-9 {
+		t.Errorf("deployment = %d playbooks at %.2f, want 2 at 0.60", deploy.Count, deploy.AvgConfidence)
+	}
+	if deploy.Playbooks[0].Name != "Canary Release" || deploy.Playbooks[1].Name != "Deploy Service" {
+		t.Errorf("deployment playbooks = %s, %s; want them sorted by name", deploy.Playbooks[0].Name, deploy.Playbooks[1].Name)
+	}
+
+	stats, err := pm.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(stats.ByCategory) != 2 || stats.ByCategory["deployment"] != 2 || stats.ByCategory["database"] != 1 {
+		t.Errorf("Stats.ByCategory = %v, want the same counts as Catalog", stats.ByCategory)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+// checkResult is the outcome of one check run by "playbookd check".
+type checkResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // "pass", "fail", or "skip"
+	Message string `json:"message"`
+}
+
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	results := runChecks(context.Background())
+
+	failed := 0
+	for _, r := range results {
+		if r.Status == "fail" {
+			failed++
+		}
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, r := range results {
+			fmt.Printf("[%s] %-10s %s\n", strings.ToUpper(r.Status), r.Name, r.Message)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d check(s) failed", failed, len(results))
+	}
+	return nil
+}
+
+// runChecks verifies the configuration, data directory, index, search, and
+// embedding provider in order. A check that later ones depend on stops the
+// run when it fails.
+func runChecks(ctx context.Context) []checkResult {
+	var results []checkResult
+	add := func(name, status, format string, a ...any) {
+		results = append(results, checkResult{name, status, fmt.Sprintf(format, a...)})
+	}
+	pass := func(name, format string, a ...any) { add(name, "pass", format, a...) }
+	fail := func(name, format string, a ...any) { add(name, "fail", format, a...) }
+
+	mgrCfg, err := loadManagerConfig()
+	if err != nil {
+		fail("config", "%v", err)
+		return results
+	}
+	if _, err := os.Stat(configPath); err == nil {
+		pass("config", "loaded %s", configPath)
+	} else {
+		pass("config", "no %s; using defaults (BM25 only)", configPath)
+	}
+
+	if err := os.MkdirAll(mgrCfg.DataDir, 0755); err != nil {
+		fail("data dir", "cannot create %s: %v", mgrCfg.DataDir, err)
+		return results
+	}
+	probe, err := os.CreateTemp(mgrCfg.DataDir, ".check-*")
+	if err != nil {
+		fail("data dir", "%s is not writable: %v", mgrCfg.DataDir, err)
+		return results
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	pass("data dir", "%s is writable", mgrCfg.DataDir)
+
+	mgr, err := playbookd.NewPlaybookManager(mgrCfg)
+	if err != nil {
+		fail("index", "%v (if the index is corrupt or was built with other settings, delete %s/index and run \"playbookd reindex\")", err, mgrCfg.DataDir)
+		return results
+	}
+	defer mgr.Close()
+	stats, err := mgr.Stats(ctx)
+	if err != nil {
+		fail("index", "opened, but reading the store failed: %v", err)
+		return results
+	}
+	pass("index", "opened; %d playbook(s) in the store", stats.TotalPlaybooks)
+
+	found, err := mgr.Search(ctx, playbookd.SearchQuery{Text: "playbook", Mode: playbookd.SearchModeBM25})
+	if err != nil {
+		fail("search", "%v", err)
+	} else {
+		pass("search", "BM25 search ran and returned %d result(s)", len(found))
+	}
+
+	if mgrCfg.EmbedModel == "" || mgrCfg.EmbedFunc == nil {
+		add("embedding", "skip", "no embedding provider configured")
+		return results
+	}
+	emb, err := mgrCfg.EmbedFunc(ctx, "playbookd health check")
+	switch {
+	case err != nil:
+		fail("embedding", "%s is not reachable: %v", mgrCfg.EmbedModel, err)
+	case len(emb) == 0:
+		fail("embedding", "%s returned an empty embedding", mgrCfg.EmbedModel)
+	case mgrCfg.EmbedDims > 0 && len(emb) != mgrCfg.EmbedDims:
+		fail("embedding", "%s returned %d dimensions, but dimensions is %d", mgrCfg.EmbedModel, len(emb), mgrCfg.EmbedDims)
+	case mgrCfg.EmbedDims == 0:
+		pass("embedding", "%s returned %d dimensions; set dimensions = %d to index vectors", mgrCfg.EmbedModel, len(emb), len(emb))
+	default:
+		pass("embedding", "%s returned %d dimensions", mgrCfg.EmbedModel, len(emb))
+	}
+	return results
+}
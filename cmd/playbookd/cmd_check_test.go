@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withCLIConfig points the global --config and --data-dir options at a
+// temporary config file and data directory for the duration of the test.
+func withCLIConfig(t *testing.T, config string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "playbookd.toml")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	origConfig, origData := configPath, dataDirFlag
+	configPath, dataDirFlag = path, filepath.Join(dir, "data")
+	t.Cleanup(func() { configPath, dataDirFlag = origConfig, origData })
+}
+
+func TestRunCheck(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	var err error
+	out := captureStdout(t, func() { err = runCheck(nil) })
+	if err != nil {
+		t.Fatalf("runCheck: %v\n%s", err, out)
+	}
+	for _, want := range []string{"[PASS] config", "[PASS] data dir", "[PASS] index", "[PASS] search", "[SKIP] embedding"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunCheckEmbeddingDimensions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"embedding": [0.1, 0.2, 0.3]}`)
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		dims    int
+		wantErr bool
+		want    string
+	}{
+		{3, false, "[PASS] embedding  ollama/nomic-embed-text-v2-moe returned 3 dimensions"},
+		{4, true, "[FAIL] embedding  ollama/nomic-embed-text-v2-moe returned 3 dimensions, but dimensions is 4"},
+	} {
+		withCLIConfig(t, fmt.Sprintf("[embedding]\nprovider = \"ollama\"\nurl = %q\ndimensions = %d\n", srv.URL, tt.dims))
+
+		var err error
+		out := captureStdout(t, func() { err = runCheck(nil) })
+		if (err != nil) != tt.wantErr {
+			t.Errorf("dims=%d: error = %v, want error: %v", tt.dims, err, tt.wantErr)
+		}
+		if !strings.Contains(out, tt.want) {
+			t.Errorf("dims=%d: output missing %q:\n%s", tt.dims, tt.want, out)
+		}
+	}
+}
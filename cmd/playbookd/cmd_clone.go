@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func runClone(args []string) error {
+	fs := flag.NewFlagSet("clone", flag.ContinueOnError)
+	nameFlag := fs.String("name", "", "name for the new playbook (required)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 || *nameFlag == "" {
+		return fmt.Errorf("usage: playbookd clone -name \"New Name\" ID|SLUG")
+	}
+	ref := fs.Arg(0)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	src, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	clone, err := mgr.Clone(ctx, src.ID, *nameFlag)
+	if err != nil {
+		return fmt.Errorf("clone playbook: %w", err)
+	}
+
+	fmt.Printf("Cloned %q into %q.\n\n", src.Name, clone.Name)
+	printPlaybook(clone, mgr.ConfidenceInterval(clone))
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+// completionCommands lists the commands offered by shell completion.
+var completionCommands = []string{
+	"init", "init-template", "list", "count", "tree", "search", "suggest", "use", "get", "plan", "create", "edit", "rename", "clone", "delete", "trash",
+	"promote", "deprecate", "diff", "validate", "export", "import", "stats", "reflect", "lessons", "warmup", "prune", "restore", "reindex", "reembed", "index-drift", "repair", "split-embeddings", "watch", "check", "completion", "version",
+}
+
+// playbookArgCommands take a playbook ID or slug as their first argument.
+var playbookArgCommands = []string{"get", "plan", "edit", "rename", "clone", "delete", "promote", "deprecate", "diff", "reflect", "reembed"}
+
+func runCompletion(args []string) error {
+	fs := flag.NewFlagSet("completion", flag.ContinueOnError)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd completion bash|zsh|fish")
+	}
+
+	commands := strings.Join(completionCommands, " ")
+	withPlaybooks := strings.Join(playbookArgCommands, " ")
+
+	switch fs.Arg(0) {
+	case "bash":
+		fmt.Printf(bashCompletion, commands, strings.ReplaceAll(withPlaybooks, " ", "|"))
+	case "zsh":
+		fmt.Printf(zshCompletion, commands, strings.ReplaceAll(withPlaybooks, " ", "|"))
+	case "fish":
+		fmt.Printf(fishCompletion, commands, withPlaybooks)
+	default:
+		return fmt.Errorf("unsupported shell %q (expected bash, zsh, or fish)", fs.Arg(0))
+	}
+	return nil
+}
+
+// runCompletePlaybooks prints the IDs and slugs of active playbooks, one per
+// line. It backs the dynamic part of the completion scripts.
+func runCompletePlaybooks(args []string) error {
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	playbooks, err := mgr.List(context.Background(), playbookd.ListFilter{})
+	if err != nil {
+		return err
+	}
+	for _, pb := range playbooks {
+		if pb.Slug != "" {
+			fmt.Println(pb.Slug)
+		}
+		fmt.Println(pb.ID)
+	}
+	return nil
+}
+
+const bashCompletion = `# bash completion for playbookd
+_playbookd() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local cmd="" i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --config|-config|--data-dir|-data-dir) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    if [[ -z "$cmd" ]]; then
+        COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        return
+    fi
+
+    case "$cmd" in
+        %s)
+            [[ "$cur" == -* ]] && return
+            COMPREPLY=($(compgen -W "$(playbookd __complete-playbooks 2>/dev/null)" -- "$cur"))
+            ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+            ;;
+    esac
+}
+complete -F _playbookd playbookd
+`
+
+const zshCompletion = `#compdef playbookd
+# zsh completion for playbookd
+_playbookd() {
+    if (( CURRENT == 2 )); then
+        compadd -- %s
+        return
+    fi
+
+    case "$words[2]" in
+        %s)
+            compadd -- ${(f)"$(playbookd __complete-playbooks 2>/dev/null)"}
+            ;;
+        completion)
+            compadd -- bash zsh fish
+            ;;
+    esac
+}
+
+if [[ "$funcstack[1]" == "_playbookd" ]]; then
+    _playbookd "$@"
+else
+    compdef _playbookd playbookd
+fi
+`
+
+const fishCompletion = `# fish completion for playbookd
+complete -c playbookd -f
+complete -c playbookd -n "__fish_use_subcommand" -a "%s"
+complete -c playbookd -n "__fish_seen_subcommand_from %s" -a "(playbookd __complete-playbooks 2>/dev/null)"
+complete -c playbookd -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompletionCommandsMatchUsage(t *testing.T) {
+	_, commands, ok := strings.Cut(usage, "Commands:\n")
+	if !ok {
+		t.Fatal("usage has no Commands section")
+	}
+	commands, _, _ = strings.Cut(commands, "\n\n")
+
+	var listed []string
+	for _, line := range strings.Split(commands, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			listed = append(listed, fields[0])
+		}
+	}
+
+	if !slices.Equal(listed, completionCommands) {
+		t.Errorf("completionCommands = %v, want the commands in usage: %v", completionCommands, listed)
+	}
+	for _, cmd := range playbookArgCommands {
+		if !slices.Contains(completionCommands, cmd) {
+			t.Errorf("playbookArgCommands has unknown command %q", cmd)
+		}
+	}
+}
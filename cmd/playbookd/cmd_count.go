@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runCount(args []string) error {
+	fs := flag.NewFlagSet("count", flag.ContinueOnError)
+	archivedFlag := fs.Bool("archived", false, "count only archived playbooks")
+	allFlag := fs.Bool("all", false, "include archived playbooks")
+	categoryFlag := fs.String("category", "", "filter by category")
+	tagFlag := fs.String("tag", "", "filter by tags (comma-separated, all must match)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	n, err := mgr.Count(context.Background(), playbookd.ListFilter{
+		IncludeArchived: *allFlag,
+		OnlyArchived:    *archivedFlag,
+		Category:        *categoryFlag,
+		Tags:            splitList(*tagFlag),
+	})
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+	fmt.Println(n)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runCreate(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	templateFlag := fs.String("template", "", "template to start from (see init-template)")
+	nameFlag := fs.String("name", "", "name for the new playbook (required with -template)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	usage := fmt.Errorf("usage: playbookd create -template NAME -name \"New Name\"\n       playbookd create FILE")
+	if (*templateFlag == "") == (fs.NArg() == 0) || (*templateFlag != "" && *nameFlag == "") {
+		return usage
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	var pb *playbookd.Playbook
+	if *templateFlag != "" {
+		pb, err = mgr.InstantiateTemplate(ctx, *templateFlag, *nameFlag)
+		if errors.Is(err, playbookd.ErrNotFound) {
+			return templateNotFound(ctx, mgr, *templateFlag)
+		}
+	} else {
+		pb, err = readPlaybookFile(fs.Arg(0))
+		if err == nil && *nameFlag != "" {
+			pb.Name = *nameFlag
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := mgr.Create(ctx, pb); err != nil {
+		return fmt.Errorf("create playbook: %w", err)
+	}
+
+	fmt.Printf("Created %q.\n\n", pb.Name)
+	printPlaybook(pb, mgr.ConfidenceInterval(pb))
+	return nil
+}
+
+// readPlaybookFile parses a playbook JSON or YAML file, chosen by extension.
+func readPlaybookFile(path string) (*playbookd.Playbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := parsePlaybook(data, formatForPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return pb, nil
+}
+
+// templateNotFound reports a missing template along with the ones that exist.
+func templateNotFound(ctx context.Context, mgr *playbookd.PlaybookManager, name string) error {
+	templates, err := mgr.ListTemplates(ctx)
+	if err != nil || len(templates) == 0 {
+		return fmt.Errorf("template %q not found; save one with \"playbookd init-template\"", name)
+	}
+	names := make([]string, len(templates))
+	for i, t := range templates {
+		names[i] = t.Name
+	}
+	return fmt.Errorf("template %q not found (available: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func TestRunCreateFromTemplate(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	// Create the source playbook from a YAML file.
+	file := filepath.Join(t.TempDir(), "deploy.yaml")
+	yaml := "name: Deploy Service\ncategory: deployment\nsteps:\n  - order: 1\n    action: Build\n  - order: 2\n    action: Ship\n"
+	if err := os.WriteFile(file, []byte(yaml), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var err error
+	captureStdout(t, func() { err = runCreate([]string{file}) })
+	if err != nil {
+		t.Fatalf("create FILE: %v", err)
+	}
+
+	out := captureStdout(t, func() { err = runInitTemplate([]string{"-from", "deploy-service", "deploy"}) })
+	if err != nil {
+		t.Fatalf("init-template: %v", err)
+	}
+	if !strings.Contains(out, `Saved template "deploy"`) {
+		t.Errorf("output = %q, want the saved template", out)
+	}
+
+	captureStdout(t, func() { err = runCreate([]string{"-template", "deploy", "-name", "Deploy Worker"}) })
+	if err != nil {
+		t.Fatalf("create -template: %v", err)
+	}
+	captureStdout(t, func() { err = runCreate([]string{"-template", "nope", "-name", "X"}) })
+	if err == nil || !strings.Contains(err.Error(), "available: deploy") {
+		t.Errorf("missing template error = %v, want the available templates", err)
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	defer mgr.Close()
+	pbs, err := mgr.List(context.Background(), playbookd.ListFilter{Category: "deployment"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(pbs) != 2 {
+		t.Errorf("got %d deployment playbooks, want 2", len(pbs))
+	}
+}
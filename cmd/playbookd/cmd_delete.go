@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runDelete(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	forceFlag := fs.Bool("force", false, "delete without asking for confirmation")
+	hardFlag := fs.Bool("hard", false, "delete permanently instead of moving to the trash (also purges a trashed playbook)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	usage := fmt.Errorf("usage: playbookd delete [-force] [-hard] ID|SLUG")
+	if fs.NArg() < 1 {
+		return usage
+	}
+	ref := fs.Arg(0)
+
+	// Flags may also follow the ID
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usage
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil && *hardFlag && errors.Is(err, playbookd.ErrNotFound) {
+		pb, err = resolveTrashed(ctx, mgr, ref)
+	}
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	if !*hardFlag {
+		if !*forceFlag {
+			ok, err := confirm(fmt.Sprintf("Move playbook %q (%s) to the trash?", pb.Name, pb.ID))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Aborted.")
+				return nil
+			}
+		}
+		if err := mgr.Delete(ctx, pb.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Moved playbook %q (%s) to the trash.\n", pb.Name, pb.ID)
+		fmt.Printf("Run \"playbookd trash restore %s\" to bring it back.\n", pb.ID)
+		return nil
+	}
+
+	execs, err := mgr.ListExecutions(ctx, pb.ID, 0)
+	if err != nil {
+		return fmt.Errorf("list executions: %w", err)
+	}
+
+	if !*forceFlag {
+		ok, err := confirm(fmt.Sprintf("Permanently delete playbook %q (%s) and %d execution(s)?", pb.Name, pb.ID, len(execs)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	if err := mgr.Purge(ctx, pb.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted playbook %q (%s).\n", pb.Name, pb.ID)
+	fmt.Printf("Removed %d execution record(s).\n", len(execs))
+	return nil
+}
+
+// confirm asks a yes/no question on stdin and reports whether the answer was yes.
+func confirm(question string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("read confirmation: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// choose asks question on stdout until stdin answers with one of options
+// (case-insensitive; an answer starting with an option, like "merge" for "m",
+// also matches).
+func choose(question string, options ...string) (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("%s ", question)
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			return "", fmt.Errorf("read choice: %w", err)
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		for _, opt := range options {
+			if strings.HasPrefix(answer, opt) {
+				return opt, nil
+			}
+		}
+	}
+}
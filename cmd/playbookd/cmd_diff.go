@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 3 {
+		return fmt.Errorf("usage: playbookd diff [-json] ID FROM TO (e.g. playbookd diff ID v3 v5)")
+	}
+	ref := fs.Arg(0)
+
+	from, err := parseVersion(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	to, err := parseVersion(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	a, err := mgr.GetVersion(ctx, pb.ID, from)
+	if err != nil {
+		return fmt.Errorf("get version %d: %w", from, err)
+	}
+	b, err := mgr.GetVersion(ctx, pb.ID, to)
+	if err != nil {
+		return fmt.Errorf("get version %d: %w", to, err)
+	}
+
+	diff := playbookd.DiffPlaybooks(a, b)
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printDiff(pb.Name, diff)
+	return nil
+}
+
+// parseVersion accepts a version as "v3" or "3".
+func parseVersion(s string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(s), "v"))
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid version %q (expected e.g. v3)", s)
+	}
+	return v, nil
+}
+
+func printDiff(name string, d playbookd.PlaybookDiff) {
+	fmt.Printf("%s: v%d -> v%d\n", name, d.FromVersion, d.ToVersion)
+
+	if d.IsEmpty() {
+		fmt.Println("\nNo changes.")
+		return
+	}
+
+	for _, fc := range []struct {
+		label  string
+		change *playbookd.FieldChange
+	}{
+		{"Name", d.Name},
+		{"Description", d.Description},
+		{"Category", d.Category},
+	} {
+		if fc.change != nil {
+			fmt.Printf("\n%s:\n  - %s\n  + %s\n", fc.label, fc.change.From, fc.change.To)
+		}
+	}
+
+	if len(d.TagsAdded) > 0 || len(d.TagsRemoved) > 0 {
+		fmt.Println("\nTags:")
+		for _, t := range d.TagsRemoved {
+			fmt.Printf("  - %s\n", t)
+		}
+		for _, t := range d.TagsAdded {
+			fmt.Printf("  + %s\n", t)
+		}
+	}
+
+	if len(d.StepsAdded) > 0 || len(d.StepsRemoved) > 0 || len(d.StepsChanged) > 0 {
+		fmt.Println("\nSteps:")
+		for _, s := range d.StepsRemoved {
+			fmt.Printf("  - %d. %s\n", s.Order, s.Action)
+		}
+		for _, c := range d.StepsChanged {
+			fmt.Printf("  ~ %d. %s\n", c.Order, c.From.Action)
+			if c.To.Action != c.From.Action {
+				fmt.Printf("     -> %s\n", c.To.Action)
+			}
+		}
+		for _, s := range d.StepsAdded {
+			fmt.Printf("  + %d. %s\n", s.Order, s.Action)
+		}
+	}
+
+	if len(d.LessonsAdded) > 0 || len(d.LessonsRemoved) > 0 {
+		fmt.Println("\nLessons:")
+		for _, l := range d.LessonsRemoved {
+			fmt.Printf("  - %s\n", l.Content)
+		}
+		for _, l := range d.LessonsAdded {
+			fmt.Printf("  + %s\n", l.Content)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -17,15 +18,21 @@ import (
 func runEdit(args []string) error {
 	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
 	editorFlag := fs.String("editor", "", "editor command (default: $PLAYBOOKD_EDITOR, $EDITOR, code --wait, vi)")
+	formatFlag := fs.String("format", "json", "format to edit in: json or yaml")
+	jsonFlag := fs.Bool("json", false, "print the saved playbook as JSON")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
 	if fs.NArg() < 1 {
-		return fmt.Errorf("usage: playbookd edit ID [-editor CMD]")
+		return fmt.Errorf("usage: playbookd edit [-editor CMD] [-format json|yaml] [-json] ID|SLUG")
+	}
+	ref := fs.Arg(0)
+	format, err := formatByName(*formatFlag)
+	if err != nil {
+		return err
 	}
-	id := fs.Arg(0)
 
 	mgr, err := newManager()
 	if err != nil {
@@ -34,66 +41,205 @@ func runEdit(args []string) error {
 	defer mgr.Close()
 
 	ctx := context.Background()
-	original, err := mgr.Get(ctx, id)
+	original, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	editor := resolveEditor(*editorFlag)
+	session := &editSession{
+		mgr:    mgr,
+		edit:   func(pb *playbookd.Playbook) (*playbookd.Playbook, error) { return editInEditor(editor, format, pb) },
+		choose: choose,
+	}
+
+	edited, err := session.edit(original)
 	if err != nil {
-		return fmt.Errorf("get playbook %q: %w", id, err)
+		return err
+	}
+	var updated *playbookd.Playbook
+	if edited != nil {
+		if updated, err = session.save(ctx, original, edited); err != nil {
+			return err
+		}
 	}
 
+	if *jsonFlag {
+		// Print the playbook as stored, edited or not
+		if updated == nil {
+			fmt.Fprintln(os.Stderr, "No changes detected.")
+			updated = original
+		}
+		data, err := json.MarshalIndent(updated, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if updated == nil {
+		fmt.Println("No changes detected.")
+		return nil
+	}
+
+	fmt.Print("\nPlaybook updated successfully.\n\n")
+	printPlaybook(updated, mgr.ConfidenceInterval(updated))
+	return nil
+}
+
+// editSession saves an edited playbook, resolving conflicts with concurrent
+// updates interactively. The editor and prompt are pluggable so the conflict
+// flow can be exercised without a terminal.
+type editSession struct {
+	mgr    *playbookd.PlaybookManager
+	edit   func(pb *playbookd.Playbook) (*playbookd.Playbook, error) // nil result means no changes
+	choose func(question string, options ...string) (string, error)
+}
+
+// save applies edited onto base with Update. If the stored playbook changed
+// since base was loaded, it shows what changed and asks whether to merge the
+// edits onto the latest version, reopen the latest version in the editor, or
+// abort. It returns the saved playbook, or nil if nothing was left to save.
+func (s *editSession) save(ctx context.Context, base, edited *playbookd.Playbook) (*playbookd.Playbook, error) {
+	for {
+		merged := mergePlaybook(base, edited)
+		err := s.mgr.Update(ctx, merged)
+		if err == nil {
+			return merged, nil
+		}
+		if !errors.Is(err, playbookd.ErrVersionConflict) {
+			return nil, fmt.Errorf("update playbook: %w", err)
+		}
+
+		latest, err := s.mgr.Get(ctx, base.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get playbook %q: %w", base.ID, err)
+		}
+		fmt.Printf("\nThe playbook was changed since you opened it. Their changes:\n\n")
+		printDiff(latest.Name, playbookd.DiffPlaybooks(base, latest))
+		fmt.Println()
+
+		base, edited, err = s.resolve(base, edited, latest)
+		if err != nil || edited == nil {
+			return nil, err
+		}
+	}
+}
+
+// resolve asks how to handle a conflict between edited (based on base) and
+// latest, and returns the new base and edits to save. A nil edited means the
+// user reopened the playbook and made no changes.
+func (s *editSession) resolve(base, edited, latest *playbookd.Playbook) (*playbookd.Playbook, *playbookd.Playbook, error) {
+	for {
+		choice, err := s.choose("[m]erge your edits onto the latest version, [r]eopen the latest version, or [a]bort?", "m", "r", "a")
+		if err != nil {
+			return nil, nil, err
+		}
+		switch choice {
+		case "m":
+			resolved, conflicts := mergeEdits(base, edited, latest)
+			if len(conflicts) > 0 {
+				fmt.Printf("Cannot merge: both sides changed %s.\n", strings.Join(conflicts, ", "))
+				continue
+			}
+			return latest, resolved, nil
+		case "r":
+			reedited, err := s.edit(latest)
+			return latest, reedited, err
+		default:
+			return nil, nil, fmt.Errorf("edit aborted; your changes were not saved")
+		}
+	}
+}
+
+// mergeEdits three-way merges the editable fields of ours and theirs, both
+// derived from base. A field changed on only one side takes that side's value;
+// a field changed differently on both sides is reported as a conflict.
+func mergeEdits(base, ours, theirs *playbookd.Playbook) (*playbookd.Playbook, []string) {
+	resolved := *theirs
+	var conflicts []string
+
+	fields := []struct {
+		name               string
+		base, ours, theirs any
+		take               func()
+	}{
+		{"name", base.Name, ours.Name, theirs.Name, func() { resolved.Name = ours.Name }},
+		{"description", base.Description, ours.Description, theirs.Description, func() { resolved.Description = ours.Description }},
+		{"tags", base.Tags, ours.Tags, theirs.Tags, func() { resolved.Tags = ours.Tags }},
+		{"category", base.Category, ours.Category, theirs.Category, func() { resolved.Category = ours.Category }},
+		{"steps", base.Steps, ours.Steps, theirs.Steps, func() { resolved.Steps = ours.Steps }},
+		{"lessons", base.Lessons, ours.Lessons, theirs.Lessons, func() { resolved.Lessons = ours.Lessons }},
+		{"archived", base.Archived, ours.Archived, theirs.Archived, func() { resolved.Archived = ours.Archived }},
+	}
+	for _, f := range fields {
+		switch {
+		case sameJSON(f.base, f.ours), sameJSON(f.ours, f.theirs):
+			// Unchanged by us, or both made the same change
+		case sameJSON(f.base, f.theirs):
+			f.take()
+		default:
+			conflicts = append(conflicts, f.name)
+		}
+	}
+	return &resolved, conflicts
+}
+
+// sameJSON reports whether a and b encode to the same JSON.
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// editInEditor opens pb in the editor, written in format f, and returns the
+// parsed result, or nil if the file was saved unchanged.
+func editInEditor(editor []string, f fileFormat, pb *playbookd.Playbook) (*playbookd.Playbook, error) {
 	// Serialize for editing (without embedding)
-	data, err := marshalForEditor(original)
+	data, err := marshalForEditor(pb)
+	if err == nil {
+		data, err = f.fromJSON(data)
+	}
 	if err != nil {
-		return fmt.Errorf("marshal playbook: %w", err)
+		return nil, fmt.Errorf("marshal playbook: %w", err)
 	}
 
 	// Write to temp file
-	tmpFile, err := os.CreateTemp("", "playbookd-edit-*.json")
+	tmpFile, err := os.CreateTemp("", "playbookd-edit-*"+f.ext)
 	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
+		return nil, fmt.Errorf("create temp file: %w", err)
 	}
 	tmpPath := tmpFile.Name()
 	defer os.Remove(tmpPath)
 
 	if _, err := tmpFile.Write(data); err != nil {
 		tmpFile.Close()
-		return fmt.Errorf("write temp file: %w", err)
+		return nil, fmt.Errorf("write temp file: %w", err)
 	}
 	tmpFile.Close()
 
 	// Open editor
-	editor := resolveEditor(*editorFlag)
 	if err := openEditor(editor, tmpPath); err != nil {
-		return fmt.Errorf("editor: %w", err)
+		return nil, fmt.Errorf("editor: %w", err)
 	}
 
 	// Read back edited file
 	edited, err := os.ReadFile(tmpPath)
 	if err != nil {
-		return fmt.Errorf("read edited file: %w", err)
+		return nil, fmt.Errorf("read edited file: %w", err)
 	}
 
 	// Check for changes
 	if bytes.Equal(data, edited) {
-		fmt.Println("No changes detected.")
-		return nil
+		return nil, nil
 	}
 
 	// Parse and validate
-	editedPb, err := parseAndValidate(edited)
+	editedPb, err := parsePlaybook(edited, f)
 	if err != nil {
-		return fmt.Errorf("invalid playbook: %w", err)
+		return nil, err
 	}
-
-	// Merge editable fields onto original
-	merged := mergePlaybook(original, editedPb)
-
-	// Update via manager (increments version, re-embeds, re-indexes)
-	if err := mgr.Update(ctx, merged); err != nil {
-		return fmt.Errorf("update playbook: %w", err)
-	}
-
-	fmt.Print("\nPlaybook updated successfully.\n\n")
-	printPlaybook(merged)
-	return nil
+	return editedPb, nil
 }
 
 // resolveEditor determines which editor to use, in priority order:
@@ -126,24 +272,26 @@ func openEditor(editor []string, filePath string) error {
 
 // editorPlaybook mirrors Playbook but omits the embedding field.
 type editorPlaybook struct {
-	ID           string              `json:"id"`
-	Name         string              `json:"name"`
-	Slug         string              `json:"slug"`
-	Description  string              `json:"description"`
-	Tags         []string            `json:"tags"`
-	Category     string              `json:"category"`
-	Steps        []playbookd.Step    `json:"steps"`
-	Version      int                 `json:"version"`
-	SuccessCount int                 `json:"success_count"`
-	FailureCount int                 `json:"failure_count"`
-	SuccessRate  float64             `json:"success_rate"`
-	Confidence   float64             `json:"confidence"`
-	Archived     bool                `json:"archived,omitempty"`
-	Lessons      []playbookd.Lesson  `json:"lessons"`
-	CreatedAt    string              `json:"created_at"`
-	UpdatedAt    string              `json:"updated_at"`
-	LastUsedAt   string              `json:"last_used_at,omitempty"`
-	CreatedBy    string              `json:"created_by"`
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Slug         string             `json:"slug"`
+	Description  string             `json:"description"`
+	Tags         []string           `json:"tags"`
+	Category     string             `json:"category"`
+	Steps        []playbookd.Step   `json:"steps"`
+	Version      int                `json:"version"`
+	SuccessCount int                `json:"success_count"`
+	FailureCount int                `json:"failure_count"`
+	SuccessRate  float64            `json:"success_rate"`
+	Confidence   float64            `json:"confidence"`
+	Archived     bool               `json:"archived,omitempty"`
+	Lessons      []playbookd.Lesson `json:"lessons"`
+	CreatedAt    string             `json:"created_at"`
+	UpdatedAt    string             `json:"updated_at"`
+	LastUsedAt   string             `json:"last_used_at,omitempty"`
+	CreatedBy    string             `json:"created_by"`
+	UpdatedBy    string             `json:"updated_by,omitempty"`
+	LastUsedBy   string             `json:"last_used_by,omitempty"`
 }
 
 // marshalForEditor serializes a playbook as indented JSON, omitting the embedding field.
@@ -166,6 +314,8 @@ func marshalForEditor(pb *playbookd.Playbook) ([]byte, error) {
 		CreatedAt:    pb.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:    pb.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		CreatedBy:    pb.CreatedBy,
+		UpdatedBy:    pb.UpdatedBy,
+		LastUsedBy:   pb.LastUsedBy,
 	}
 	if !pb.LastUsedAt.IsZero() {
 		ep.LastUsedAt = pb.LastUsedAt.Format("2006-01-02T15:04:05Z07:00")
@@ -173,22 +323,14 @@ func marshalForEditor(pb *playbookd.Playbook) ([]byte, error) {
 	return json.MarshalIndent(ep, "", "  ")
 }
 
-// parseAndValidate parses edited JSON and validates required fields.
+// parseAndValidate parses edited JSON and validates it with ValidatePlaybook.
 func parseAndValidate(data []byte) (*playbookd.Playbook, error) {
 	var pb playbookd.Playbook
 	if err := json.Unmarshal(data, &pb); err != nil {
-		return nil, fmt.Errorf("invalid JSON: %w", err)
+		return nil, fmt.Errorf("%w: invalid JSON: %v", playbookd.ErrInvalidPlaybook, err)
 	}
-	if strings.TrimSpace(pb.Name) == "" {
-		return nil, fmt.Errorf("name is required")
-	}
-	if len(pb.Steps) == 0 {
-		return nil, fmt.Errorf("at least one step is required")
-	}
-	for i, s := range pb.Steps {
-		if strings.TrimSpace(s.Action) == "" {
-			return nil, fmt.Errorf("step %d: action is required", i+1)
-		}
+	if err := playbookd.ValidatePlaybook(&pb); err != nil {
+		return nil, err
 	}
 	return &pb, nil
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func newEditTestManager(t *testing.T) (*playbookd.PlaybookManager, *playbookd.Playbook) {
+	t.Helper()
+	mgr, err := playbookd.NewPlaybookManager(playbookd.ManagerConfig{
+		DataDir: t.TempDir(),
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { mgr.Close() })
+
+	pb := &playbookd.Playbook{
+		Name:        "Deploy App",
+		Description: "original",
+		Category:    "deployment",
+		Steps:       []playbookd.Step{{Order: 1, Action: "build"}},
+	}
+	if err := mgr.Create(context.Background(), pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return mgr, pb
+}
+
+// openAndChange simulates opening the playbook in the editor, then someone else
+// saving their change before ours is written.
+func openAndChange(t *testing.T, mgr *playbookd.PlaybookManager, id string, theirs func(pb *playbookd.Playbook)) *playbookd.Playbook {
+	t.Helper()
+	ctx := context.Background()
+	opened, err := mgr.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	other, err := mgr.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	theirs(other)
+	if err := mgr.Update(ctx, other); err != nil {
+		t.Fatalf("concurrent Update: %v", err)
+	}
+	return opened
+}
+
+func scriptedChoices(t *testing.T, answers ...string) func(string, ...string) (string, error) {
+	return func(string, ...string) (string, error) {
+		if len(answers) == 0 {
+			t.Fatal("unexpected prompt")
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+}
+
+func TestEditConflictMerge(t *testing.T) {
+	mgr, pb := newEditTestManager(t)
+	ctx := context.Background()
+
+	opened := openAndChange(t, mgr, pb.ID, func(p *playbookd.Playbook) { p.Description = "theirs" })
+	edited := *opened
+	edited.Steps = append(edited.Steps, playbookd.Step{Order: 2, Action: "deploy"})
+
+	session := &editSession{
+		mgr: mgr,
+		edit: func(*playbookd.Playbook) (*playbookd.Playbook, error) {
+			t.Fatal("editor should not reopen")
+			return nil, nil
+		},
+		choose: scriptedChoices(t, "m"),
+	}
+	if _, err := session.save(ctx, opened, &edited); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	got, err := mgr.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "theirs" {
+		t.Errorf("Description = %q, want their change kept", got.Description)
+	}
+	if len(got.Steps) != 2 {
+		t.Errorf("Steps = %d, want our added step", len(got.Steps))
+	}
+	if got.Version != 3 {
+		t.Errorf("Version = %d, want 3", got.Version)
+	}
+}
+
+func TestEditConflictSameFieldAbort(t *testing.T) {
+	mgr, pb := newEditTestManager(t)
+	ctx := context.Background()
+
+	opened := openAndChange(t, mgr, pb.ID, func(p *playbookd.Playbook) { p.Description = "theirs" })
+	edited := *opened
+	edited.Description = "ours"
+
+	session := &editSession{
+		mgr: mgr,
+		edit: func(*playbookd.Playbook) (*playbookd.Playbook, error) {
+			t.Fatal("editor should not reopen")
+			return nil, nil
+		},
+		choose: scriptedChoices(t, "m", "a"), // merge is refused, then abort
+	}
+	if _, err := session.save(ctx, opened, &edited); err == nil {
+		t.Fatal("expected abort error, got nil")
+	}
+
+	got, err := mgr.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "theirs" || got.Version != 2 {
+		t.Errorf("stored playbook changed after abort: %q v%d", got.Description, got.Version)
+	}
+}
+
+func TestEditConflictReopen(t *testing.T) {
+	mgr, pb := newEditTestManager(t)
+	ctx := context.Background()
+
+	opened := openAndChange(t, mgr, pb.ID, func(p *playbookd.Playbook) { p.Description = "theirs" })
+	edited := *opened
+	edited.Description = "ours"
+
+	var reopened *playbookd.Playbook
+	session := &editSession{
+		mgr: mgr,
+		edit: func(latest *playbookd.Playbook) (*playbookd.Playbook, error) {
+			reopened = latest
+			redone := *latest
+			redone.Description = "theirs and ours"
+			return &redone, nil
+		},
+		choose: scriptedChoices(t, "r"),
+	}
+	if _, err := session.save(ctx, opened, &edited); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if reopened == nil || reopened.Description != "theirs" {
+		t.Fatalf("editor reopened with %+v, want the latest version", reopened)
+	}
+	got, err := mgr.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "theirs and ours" {
+		t.Errorf("Description = %q, want %q", got.Description, "theirs and ours")
+	}
+}
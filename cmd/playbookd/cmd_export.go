@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatFlag := fs.String("format", "json", "output format: json (one array) or jsonl (one playbook per line, streamed)")
+	outFlag := fs.String("o", "", "write to FILE instead of stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *formatFlag != "json" && *formatFlag != "jsonl" {
+		return fmt.Errorf("unknown -format %q (want json or jsonl)", *formatFlag)
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	var w io.Writer = os.Stdout
+	if *outFlag != "" {
+		f, err := os.Create(*outFlag)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx := context.Background()
+	if *formatFlag == "jsonl" {
+		if err := mgr.ExportStream(ctx, w); err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+	} else {
+		playbooks, err := mgr.List(ctx, playbookd.ListFilter{IncludeArchived: true})
+		if err != nil {
+			return fmt.Errorf("list playbooks: %w", err)
+		}
+		if playbooks == nil {
+			playbooks = []*playbookd.Playbook{}
+		}
+		data, err := json.MarshalIndent(playbooks, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	}
+
+	if *outFlag != "" {
+		fmt.Fprintf(os.Stderr, "Exported playbooks to %s.\n", *outFlag)
+	}
+	return nil
+}
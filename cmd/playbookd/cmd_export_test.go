@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func TestRunExportImportJSONL(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	mgr, err := newManager()
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	pb := &playbookd.Playbook{Name: "Deploy Service", Steps: []playbookd.Step{{Order: 1, Action: "Ship"}}}
+	if err := mgr.Create(context.Background(), pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	mgr.Close()
+
+	file := filepath.Join(t.TempDir(), "backup.jsonl")
+	if err := runExport([]string{"-format", "jsonl", "-o", file}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], pb.ID) {
+		t.Fatalf("export = %q, want one line with the playbook", data)
+	}
+
+	// Import into an empty data directory picks the format from the extension.
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+	out := captureStdout(t, func() { err = runImport([]string{file}) })
+	if err != nil || !strings.Contains(out, "Imported 1 playbook(s)") {
+		t.Fatalf("import: output = %q, err = %v", out, err)
+	}
+	out = captureStdout(t, func() { err = runImport([]string{file}) })
+	if err != nil || !strings.Contains(out, "Skipped 1") {
+		t.Errorf("second import: output = %q, err = %v; want the playbook skipped", out, err)
+	}
+}
+
+func TestRunImportYAML(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	file := filepath.Join(t.TempDir(), "playbooks.yml")
+	yaml := `- id: yaml-one
+  name: Deploy Service
+  tags: [deploy]
+  steps:
+    - order: 1
+      action: Ship
+- name: Rollback Service
+  steps:
+    - order: 1
+      action: |
+        Revert the release
+        and page the owner
+- name: No Steps
+`
+	if err := os.WriteFile(file, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	var err error
+	out := captureStdout(t, func() { err = runImport([]string{file}) })
+	if err == nil || !strings.Contains(out, "Imported 2 playbook(s)") || !strings.Contains(out, "entry 2 (No Steps)") {
+		t.Fatalf("import: output = %q, err = %v; want two imported and the stepless entry reported", out, err)
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	got, err := mgr.Get(context.Background(), "yaml-one")
+	mgr.Close()
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "Deploy Service" || len(got.Steps) != 1 || got.Tags[0] != "deploy" {
+		t.Errorf("imported playbook = %+v, want the YAML entry", got)
+	}
+
+	if err := os.WriteFile(file, []byte("name: Not A List\n"), 0o644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := runImport([]string{file}); err == nil || !strings.Contains(err.Error(), "YAML sequence") {
+		t.Errorf("import of a YAML mapping: error = %v, want one asking for a sequence", err)
+	}
+}
@@ -12,6 +12,9 @@ import (
 func runGet(args []string) error {
 	fs := flag.NewFlagSet("get", flag.ContinueOnError)
 	executionsFlag := fs.Int("executions", 0, "also show last N executions")
+	outcomeFlag := fs.String("outcome", "", "only show executions with this outcome (with -executions)")
+	agentFlag := fs.String("agent", "", "only show executions by this agent (with -executions)")
+	durationsFlag := fs.Bool("durations", false, "also show run and per-step durations of recent executions")
 	jsonFlag := fs.Bool("json", false, "output as JSON")
 
 	if err := fs.Parse(args); err != nil {
@@ -19,9 +22,9 @@ func runGet(args []string) error {
 	}
 
 	if fs.NArg() < 1 {
-		return fmt.Errorf("usage: playbookd get ID [-executions N]")
+		return fmt.Errorf("usage: playbookd get [-executions N [-outcome O] [-agent ID]] [-durations] ID|SLUG")
 	}
-	id := fs.Arg(0)
+	ref := fs.Arg(0)
 
 	mgr, err := newManager()
 	if err != nil {
@@ -30,24 +33,39 @@ func runGet(args []string) error {
 	defer mgr.Close()
 
 	ctx := context.Background()
-	pb, err := mgr.Get(ctx, id)
+	pb, err := resolvePlaybook(ctx, mgr, ref)
 	if err != nil {
-		return fmt.Errorf("get playbook %q: %w", id, err)
+		return fmt.Errorf("get playbook %q: %w", ref, err)
 	}
 
 	var execs []*playbookd.ExecutionRecord
 	if *executionsFlag > 0 {
-		execs, err = mgr.ListExecutions(ctx, id, *executionsFlag)
+		execs, err = mgr.ListExecutionsFiltered(ctx, pb.ID, playbookd.ExecutionFilter{
+			Outcome: playbookd.Outcome(*outcomeFlag),
+			AgentID: *agentFlag,
+			Limit:   *executionsFlag,
+		})
 		if err != nil {
 			return fmt.Errorf("list executions: %w", err)
 		}
 	}
 
+	var durations *playbookd.DurationStats
+	if *durationsFlag {
+		durations, err = mgr.ExecutionDurations(ctx, pb.ID)
+		if err != nil {
+			return fmt.Errorf("execution durations: %w", err)
+		}
+	}
+
 	if *jsonFlag {
 		out := map[string]any{"playbook": pb}
 		if execs != nil {
 			out["executions"] = execs
 		}
+		if durations != nil {
+			out["durations"] = durations
+		}
 		data, err := json.MarshalIndent(out, "", "  ")
 		if err != nil {
 			return err
@@ -56,16 +74,31 @@ func runGet(args []string) error {
 		return nil
 	}
 
-	printPlaybook(pb)
+	printPlaybook(pb, mgr.ConfidenceInterval(pb))
+
+	if durations != nil {
+		fmt.Printf("\nDurations (last %d runs):\n", durations.Runs)
+		if durations.Runs > 0 {
+			fmt.Printf("  run      avg %-10s p95 %s\n", roundDuration(durations.Avg), roundDuration(durations.P95))
+		}
+		for _, sd := range durations.Steps {
+			fmt.Printf("  step %-3d avg %-10s p95 %-10s total %s (%d runs)\n",
+				sd.StepOrder, roundDuration(sd.Avg), roundDuration(sd.P95), roundDuration(sd.Total), sd.Runs)
+		}
+	}
 
 	if len(execs) > 0 {
 		fmt.Printf("\nRecent Executions (%d):\n", len(execs))
 		for _, e := range execs {
-			fmt.Printf("  [%s] %s  outcome=%s\n",
+			fmt.Printf("  [%s] %s  outcome=%s",
 				e.StartedAt.Format("2006-01-02 15:04"),
 				e.ID,
 				e.Outcome,
 			)
+			if e.AgentID != "" {
+				fmt.Printf("  agent=%s", e.AgentID)
+			}
+			fmt.Println()
 		}
 	}
 
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatFlag := fs.String("format", "", "input format: json (one array), yaml (one sequence), or jsonl (one playbook per line); default from the file extension")
+	jsonFlag := fs.Bool("json", false, "output the result as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd import [-format json|yaml|jsonl] [-json] FILE|-")
+	}
+	path := fs.Arg(0)
+
+	format := *formatFlag
+	if format == "" {
+		format = formatForPath(path).name
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".jsonl" || ext == ".ndjson" {
+			format = "jsonl"
+		}
+	}
+	var fileFmt fileFormat
+	if format != "jsonl" {
+		var err error
+		if fileFmt, err = formatByName(format); err != nil {
+			return fmt.Errorf("unknown -format %q (want json, yaml, or jsonl)", format)
+		}
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	var result *playbookd.ImportResult
+	if format == "jsonl" {
+		result, err = mgr.ImportStream(ctx, r)
+	} else {
+		playbooks, parseErr := parsePlaybookList(r, fileFmt)
+		if parseErr != nil {
+			return fmt.Errorf("%s: %v", path, parseErr)
+		}
+		result, err = mgr.Import(ctx, playbooks)
+	}
+	if err != nil && result == nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	if *jsonFlag {
+		data, jsonErr := json.MarshalIndent(result, "", "  ")
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("Imported %d playbook(s).\n", result.Imported)
+		if len(result.Skipped) > 0 {
+			fmt.Printf("Skipped %d playbook(s) already in the store.\n", len(result.Skipped))
+		}
+		for _, f := range result.Failed {
+			what := "line"
+			if format != "jsonl" {
+				what = "entry"
+			}
+			if f.Name != "" {
+				fmt.Printf("  - %s %d (%s): %s\n", what, f.Line, f.Name, f.Error)
+			} else {
+				fmt.Printf("  - %s %d: %s\n", what, f.Line, f.Error)
+			}
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("import: %d playbook(s) could not be imported", len(result.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+)
+
+func runIndexDrift(args []string) error {
+	fs := flag.NewFlagSet("index-drift", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	missing, extra, err := mgr.IndexDrift(context.Background())
+	if err != nil {
+		return fmt.Errorf("index drift: %w", err)
+	}
+
+	if *jsonFlag {
+		out := map[string][]string{
+			"missing_from_index": missing,
+			"extra_in_index":     extra,
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(missing) == 0 && len(extra) == 0 {
+		fmt.Println("Index is in sync with the store.")
+		return nil
+	}
+
+	if len(missing) > 0 {
+		fmt.Printf("In store but not indexed (%d):\n", len(missing))
+		for _, id := range missing {
+			fmt.Printf("  - %s\n", id)
+		}
+	}
+	if len(extra) > 0 {
+		fmt.Printf("Indexed but not in store (%d):\n", len(extra))
+		for _, id := range extra {
+			fmt.Printf("  - %s\n", id)
+		}
+	}
+
+	fmt.Println("\nRun \"playbookd repair\" to fix both.")
+	return nil
+}
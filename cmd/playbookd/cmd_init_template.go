@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func runInitTemplate(args []string) error {
+	fs := flag.NewFlagSet("init-template", flag.ContinueOnError)
+	fromFlag := fs.String("from", "", "playbook ID or slug to take the structure from (required)")
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 || *fromFlag == "" {
+		return fmt.Errorf("usage: playbookd init-template -from ID|SLUG NAME")
+	}
+	name := fs.Arg(0)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	src, err := resolvePlaybook(ctx, mgr, *fromFlag)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", *fromFlag, err)
+	}
+
+	tmpl, err := mgr.SaveTemplate(ctx, name, src)
+	if err != nil {
+		return err
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(tmpl, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Saved template %q from %q (%d steps", tmpl.Name, src.Name, len(tmpl.Steps))
+	if len(tmpl.Tags) > 0 {
+		fmt.Printf(", tags: %s", strings.Join(tmpl.Tags, ", "))
+	}
+	fmt.Printf(").\nCreate a playbook from it with: playbookd create -template %s -name \"New Name\"\n", tmpl.Name)
+	return nil
+}
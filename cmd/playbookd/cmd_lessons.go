@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runLessons(args []string) error {
+	fs := flag.NewFlagSet("lessons", flag.ContinueOnError)
+	categoryFlag := fs.String("category", "", "only lessons from playbooks in this category")
+	minConfFlag := fs.Float64("min-confidence", 0, "only lessons with at least this confidence")
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	lessons, err := mgr.AllLessons(context.Background(), playbookd.LessonFilter{
+		Category:      *categoryFlag,
+		MinConfidence: *minConfFlag,
+	})
+	if err != nil {
+		return fmt.Errorf("lessons: %w", err)
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(lessons, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printLessonsMarkdown(lessons, *categoryFlag)
+	return nil
+}
+
+// printLessonsMarkdown writes lessons as a Markdown document for a wiki.
+func printLessonsMarkdown(lessons []playbookd.LessonWithSource, category string) {
+	title := "Lessons learned"
+	if category != "" {
+		title += ": " + category
+	}
+	fmt.Printf("# %s\n\n", title)
+
+	if len(lessons) == 0 {
+		fmt.Println("No lessons recorded yet.")
+		return
+	}
+
+	sources := make(map[string]bool)
+	for _, l := range lessons {
+		sources[l.PlaybookID] = true
+	}
+	fmt.Printf("%d lesson(s) from %d playbook(s), most confident first.\n\n", len(lessons), len(sources))
+
+	for _, l := range lessons {
+		content := strings.Join(strings.Fields(l.Content), " ")
+		details := []string{fmt.Sprintf("confidence %.2f", l.Confidence)}
+		if l.Applies != "" {
+			details = append(details, "applies: "+l.Applies)
+		}
+		if !l.LearnedAt.IsZero() {
+			details = append(details, "learned "+l.LearnedAt.Format("2006-01-02"))
+		}
+		fmt.Printf("- %s\n  _From **%s** (`%s`) · %s_\n", content, l.PlaybookName, l.PlaybookSlug, strings.Join(details, " · "))
+	}
+}
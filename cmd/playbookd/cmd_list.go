@@ -5,19 +5,30 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/lucas-stellet/playbookd"
 )
 
 func runList(args []string) error {
 	fs := flag.NewFlagSet("list", flag.ContinueOnError)
-	archivedFlag := fs.Bool("archived", false, "include archived playbooks")
+	archivedFlag := fs.Bool("archived", false, "show only archived playbooks")
+	allFlag := fs.Bool("all", false, "include archived playbooks")
 	categoryFlag := fs.String("category", "", "filter by category")
+	tagFlag := fs.String("tag", "", "filter by tags (comma-separated, all must match)")
+	sortFlags := addSortFlags(fs, playbookd.SortByConfidence)
+	wideFlag := fs.Bool("wide", false, "also show tags and last update time")
 	jsonFlag := fs.Bool("json", false, "output as JSON")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	sortBy, ascending, err := sortFlags.parse()
+	if err != nil {
+		return err
+	}
 
 	mgr, err := newManager()
 	if err != nil {
@@ -26,8 +37,12 @@ func runList(args []string) error {
 	defer mgr.Close()
 
 	filter := playbookd.ListFilter{
-		IncludeArchived: *archivedFlag,
+		IncludeArchived: *allFlag,
+		OnlyArchived:    *archivedFlag,
 		Category:        *categoryFlag,
+		Tags:            splitList(*tagFlag),
+		SortBy:          sortBy,
+		Ascending:       ascending,
 	}
 
 	playbooks, err := mgr.List(context.Background(), filter)
@@ -49,16 +64,97 @@ func runList(args []string) error {
 		return nil
 	}
 
-	fmt.Printf("%-36s  %-30s  %-12s  %s\n", "ID", "Name", "Category", "Confidence")
-	fmt.Printf("%-36s  %-30s  %-12s  %s\n",
-		"------------------------------------",
-		"------------------------------",
-		"------------",
-		"----------",
+	printPlaybookTable(playbooks, *wideFlag, terminalWidth(), useColor())
+	return nil
+}
+
+// sortFlags are the -sort, -asc, and -desc flags of list and tree.
+type sortFlags struct {
+	sort      *string
+	asc, desc *bool
+}
+
+func addSortFlags(fs *flag.FlagSet, def playbookd.SortField) sortFlags {
+	return sortFlags{
+		sort: fs.String("sort", string(def), "sort by "+joinSortFields(", ")),
+		asc:  fs.Bool("asc", false, "sort smallest or A to Z first (default for name)"),
+		desc: fs.Bool("desc", false, "sort largest or Z to A first (default except for name)"),
+	}
+}
+
+// parse returns the sort field and direction the flags ask for. Names sort
+// A to Z by default and everything else largest first.
+func (f sortFlags) parse() (playbookd.SortField, bool, error) {
+	sortBy := playbookd.SortField(*f.sort)
+	if !slices.Contains(playbookd.SortFields, sortBy) {
+		return "", false, fmt.Errorf("unknown -sort %q (expected one of %s)", *f.sort, joinSortFields(", "))
+	}
+	if *f.asc && *f.desc {
+		return "", false, fmt.Errorf("-asc and -desc cannot be used together")
+	}
+	return sortBy, *f.asc || (sortBy == playbookd.SortByName && !*f.desc), nil
+}
+
+// joinSortFields returns the valid -sort values joined by sep.
+func joinSortFields(sep string) string {
+	names := make([]string, len(playbookd.SortFields))
+	for i, f := range playbookd.SortFields {
+		names[i] = string(f)
+	}
+	return strings.Join(names, sep)
+}
+
+// printPlaybookTable prints playbooks as a table. With a known terminal width,
+// the name (and, when wide, tags) columns shrink to fit and long values are
+// truncated; otherwise they grow to fit the longest value.
+func printPlaybookTable(playbooks []*playbookd.Playbook, wide bool, width int, color bool) {
+	const (
+		idW      = 36
+		catW     = 12
+		confW    = 10
+		updatedW = 16
+		maxByW   = 16
+		gap      = "  "
 	)
+
+	nameW, tagsW, byW := len("Name"), len("Tags"), len("By")
 	for _, pb := range playbooks {
-		fmt.Printf("%-36s  %-30s  %-12s  %.2f\n",
-			pb.ID, pb.Name, pb.Category, pb.Confidence)
+		nameW = max(nameW, utf8.RuneCountInString(pb.Name))
+		tagsW = max(tagsW, utf8.RuneCountInString(strings.Join(pb.Tags, ",")))
+		byW = max(byW, min(maxByW, utf8.RuneCountInString(changedBy(pb))))
+	}
+
+	if width > 0 {
+		avail := width - idW - catW - confW - 3*len(gap)
+		if wide {
+			avail -= updatedW + byW + tagsW + 3*len(gap)
+			if avail < 8 {
+				// Give tags up to a third of what is left, never less than their header
+				rest := avail + tagsW
+				tagsW = max(len("Tags"), min(tagsW, rest/3))
+				avail = rest - tagsW
+			}
+		}
+		nameW = max(8, min(nameW, avail))
+	} else {
+		nameW = max(nameW, 30)
+	}
+
+	header := []string{fit("ID", idW), fit("Name", nameW), fit("Category", catW), fit("Confidence", confW)}
+	rule := []string{strings.Repeat("-", idW), strings.Repeat("-", nameW), strings.Repeat("-", catW), strings.Repeat("-", confW)}
+	if wide {
+		header = append(header, fit("Tags", tagsW), fit("Updated", updatedW), fit("By", byW))
+		rule = append(rule, strings.Repeat("-", tagsW), strings.Repeat("-", updatedW), strings.Repeat("-", byW))
+	}
+	fmt.Println(strings.TrimRight(strings.Join(header, gap), " "))
+	fmt.Println(strings.Join(rule, gap))
+
+	for _, pb := range playbooks {
+		conf := colorize(fit(fmt.Sprintf("%.2f", pb.Confidence), confW), confidenceColor(pb.Confidence), color)
+		row := []string{fit(pb.ID, idW), fit(pb.Name, nameW), fit(pb.Category, catW), conf}
+		if wide {
+			row = append(row, fit(strings.Join(pb.Tags, ","), tagsW), pb.UpdatedAt.Format("2006-01-02 15:04"), fit(changedBy(pb), byW))
+		}
+		fmt.Println(strings.TrimRight(strings.Join(row, gap), " "))
 	}
-	return nil
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read stdout: %v", err)
+	}
+	return string(out)
+}
+
+func TestPrintPlaybookTableFitsWidth(t *testing.T) {
+	playbooks := []*playbookd.Playbook{{
+		ID:         "3f6c2a1e-0000-4000-8000-000000000001",
+		Name:       "A very long playbook name that would wrap in a narrow terminal",
+		Category:   "deployment",
+		Tags:       []string{"go", "kubernetes", "blue-green", "production"},
+		Confidence: 0.72,
+	}}
+
+	for _, wide := range []bool{false, true} {
+		width := 100
+		if wide {
+			width = 140
+		}
+		out := captureStdout(t, func() { printPlaybookTable(playbooks, wide, width, false) })
+		for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+			if n := utf8.RuneCountInString(line); n > width {
+				t.Errorf("wide=%v: line is %d columns, want <= %d: %q", wide, n, width, line)
+			}
+		}
+		if !strings.Contains(out, "…") {
+			t.Errorf("wide=%v: expected the long name to be truncated:\n%s", wide, out)
+		}
+		if strings.Contains(out, "\033[") {
+			t.Errorf("wide=%v: color codes in uncolored output", wide)
+		}
+	}
+
+	// Unknown width (piped): nothing is truncated.
+	out := captureStdout(t, func() { printPlaybookTable(playbooks, false, 0, false) })
+	if !strings.Contains(out, playbooks[0].Name) {
+		t.Errorf("piped output should not truncate names:\n%s", out)
+	}
+
+	out = captureStdout(t, func() { printPlaybookTable(playbooks, false, 0, true) })
+	if !strings.Contains(out, colorGreen) {
+		t.Errorf("expected green confidence for 0.72:\n%q", out)
+	}
+}
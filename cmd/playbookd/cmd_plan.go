@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd plan [-json] ID|SLUG")
+	}
+	ref := fs.Arg(0)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+	plan, err := mgr.ExecutionPlan(ctx, pb.ID)
+	if err != nil {
+		return fmt.Errorf("execution plan: %w", err)
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printPlan(plan)
+	return nil
+}
+
+// printPlan writes plan as a numbered checklist.
+func printPlan(plan *playbookd.Plan) {
+	fmt.Printf("Plan: %s (v%d)\n", plan.Name, plan.Version)
+	for _, s := range plan.Steps {
+		optional := ""
+		if s.Optional {
+			optional = " (optional)"
+		}
+		fmt.Printf("\n  %d. %s%s\n", s.Order, s.Action, optional)
+		if s.Tool != "" {
+			fmt.Printf("     tool:     %s", s.Tool)
+			if len(s.ToolArgs) > 0 {
+				if args, err := json.Marshal(s.ToolArgs); err == nil {
+					fmt.Printf(" %s", args)
+				}
+			}
+			fmt.Println()
+		}
+		if s.Expected != "" {
+			fmt.Printf("     expect:   %s\n", s.Expected)
+		}
+		if s.Fallback != "" {
+			fmt.Printf("     fallback: %s\n", s.Fallback)
+		}
+		if len(s.Refs) > 0 {
+			fmt.Printf("     see:      %s\n", strings.Join(s.Refs, ", "))
+		}
+	}
+	if len(plan.Lessons) > 0 {
+		fmt.Println("\nLessons:")
+		for _, l := range plan.Lessons {
+			fmt.Printf("  - [%.2f] %s\n", l.Confidence, l.Content)
+		}
+	}
+}
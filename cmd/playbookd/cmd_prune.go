@@ -5,35 +5,54 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"time"
+	"sort"
 
 	"github.com/lucas-stellet/playbookd"
 )
 
 func runPrune(args []string) error {
 	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
-	maxAgeFlag := fs.String("max-age", "90d", "maximum age before pruning (e.g. 30d, 90d)")
+	maxAgeFlag := fs.String("max-age", "90d", "maximum age before pruning (e.g. 30d, 2w, 12h)")
+	minAgeFlag := fs.String("min-age", "", "never prune playbooks created more recently than this (e.g. 30d)")
+	streakFlag := fs.Int("failure-streak", 0, "also archive playbooks whose last N executions all failed (0 = off)")
 	dryRunFlag := fs.Bool("dry-run", false, "show what would be pruned without making changes")
+	executionsFlag := fs.Bool("executions", false, "prune execution records instead of playbooks")
+	keepFlag := fs.Int("keep", 0, "with -executions, keep only the newest N records per playbook (0 = no limit)")
 	jsonFlag := fs.Bool("json", false, "output as JSON")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
-	maxAge, err := parseDuration(*maxAgeFlag)
+	maxAge, err := playbookd.ParseDuration(*maxAgeFlag)
 	if err != nil {
 		return fmt.Errorf("invalid -max-age %q: %w", *maxAgeFlag, err)
 	}
 
+	minAge, err := playbookd.ParseDuration(*minAgeFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -min-age %q: %w", *minAgeFlag, err)
+	}
+
 	mgr, err := newManager()
 	if err != nil {
 		return err
 	}
 	defer mgr.Close()
 
+	if *executionsFlag {
+		return pruneExecutions(mgr, playbookd.ExecutionPruneOptions{
+			MaxAge:         maxAge,
+			MaxPerPlaybook: *keepFlag,
+			DryRun:         *dryRunFlag,
+		}, *jsonFlag)
+	}
+
 	result, err := mgr.Prune(context.Background(), playbookd.PruneOptions{
-		MaxAge: maxAge,
-		DryRun: *dryRunFlag,
+		MaxAge:        maxAge,
+		MinAgeToPrune: minAge,
+		DryRun:        *dryRunFlag,
+		FailureStreak: *streakFlag,
 	})
 	if err != nil {
 		return fmt.Errorf("prune: %w", err)
@@ -54,22 +73,50 @@ func runPrune(args []string) error {
 		fmt.Printf("Archived %d playbook(s).\n", len(result.Archived))
 	}
 
-	for _, id := range result.Archived {
-		fmt.Printf("  - %s\n", id)
+	for _, item := range result.Archived {
+		fmt.Printf("  - %s  %s (%s)\n", item.ID, item.Name, item.Reason)
 	}
 
+	if len(result.Failed) > 0 {
+		fmt.Printf("\nFailed to archive %d playbook(s); they were left unchanged:\n", len(result.Failed))
+		for _, item := range result.Failed {
+			fmt.Printf("  - %s  %s: %s\n", item.ID, item.Name, item.Error)
+		}
+		return fmt.Errorf("prune: %d playbook(s) could not be archived", len(result.Failed))
+	}
 	return nil
 }
 
-// parseDuration parses a duration string like "90d" or standard Go durations.
-func parseDuration(s string) (time.Duration, error) {
-	// Support "Nd" shorthand for days
-	if len(s) > 1 && s[len(s)-1] == 'd' {
-		var days int
-		if _, err := fmt.Sscanf(s[:len(s)-1], "%d", &days); err != nil {
-			return 0, fmt.Errorf("cannot parse %q as days", s)
+// pruneExecutions deletes old execution records and reports how many were removed.
+func pruneExecutions(mgr *playbookd.PlaybookManager, opts playbookd.ExecutionPruneOptions, asJSON bool) error {
+	result, err := mgr.PruneExecutions(context.Background(), opts)
+	if err != nil {
+		return fmt.Errorf("prune executions: %w", err)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
 		}
-		return time.Duration(days) * 24 * time.Hour, nil
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if opts.DryRun {
+		fmt.Printf("Dry run: %d execution record(s) would be removed.\n", result.Removed)
+	} else {
+		fmt.Printf("Removed %d execution record(s).\n", result.Removed)
 	}
-	return time.ParseDuration(s)
+
+	ids := make([]string, 0, len(result.ByPlaybook))
+	for id := range result.ByPlaybook {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Printf("  - %s: %d\n", id, result.ByPlaybook[id])
+	}
+
+	return nil
 }
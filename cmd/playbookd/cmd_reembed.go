@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runReembed(args []string) error {
+	fs := flag.NewFlagSet("reembed", flag.ContinueOnError)
+	allFlag := fs.Bool("all", false, "re-embed every playbook")
+	concurrencyFlag := fs.Int("concurrency", playbookd.DefaultReindexConcurrency, "playbooks to re-embed at once (with -all)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *allFlag == (fs.NArg() > 0) {
+		return fmt.Errorf("usage: playbookd reembed [-concurrency N] -all | ID|SLUG")
+	}
+	if *concurrencyFlag < 1 {
+		return fmt.Errorf("-concurrency must be at least 1, got %d", *concurrencyFlag)
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	// Ctrl-C stops embedding; playbooks already re-embedded keep their new embedding
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var result *playbookd.ReembedResult
+	if *allFlag {
+		var reported bool
+		result, err = mgr.ReembedAll(ctx, playbookd.ReindexOptions{
+			Concurrency: *concurrencyFlag,
+			Progress: func(p playbookd.ReindexProgress) {
+				printReindexProgress(p)
+				reported = true
+			},
+		})
+		if reported {
+			fmt.Fprintln(os.Stderr)
+		}
+	} else {
+		ref := fs.Arg(0)
+		pb, resolveErr := resolvePlaybook(ctx, mgr, ref)
+		if resolveErr != nil {
+			return fmt.Errorf("get playbook %q: %w", ref, resolveErr)
+		}
+		result, err = mgr.Reembed(ctx, pb.ID)
+	}
+	if result != nil {
+		fmt.Printf("Re-embedded %d playbook(s)", result.Reembedded)
+		if result.EmbedDims > 0 {
+			fmt.Printf(" at %d dimensions", result.EmbedDims)
+		}
+		fmt.Println(".")
+		if result.IndexOutdated {
+			fmt.Fprintf(os.Stderr, "warning: the index's vector field has %d dimensions, but the new embeddings have %d; delete the index directory and run `playbookd reindex` to use them in vector search\n",
+				result.IndexDims, result.EmbedDims)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("reembed: %w", err)
+	}
+	return nil
+}
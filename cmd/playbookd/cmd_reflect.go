@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runReflect(args []string) error {
+	fs := flag.NewFlagSet("reflect", flag.ContinueOnError)
+	fileFlag := fs.String("file", "", "reflection JSON or YAML file (what_worked, what_failed, improvements, should_update)")
+	var worked, failed, improve stringList
+	fs.Var(&worked, "worked", "something that worked (repeatable)")
+	fs.Var(&failed, "failed", "something that failed (repeatable)")
+	fs.Var(&improve, "improve", "an improvement to add as a lesson (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd reflect [-file FILE] [-worked TEXT] [-failed TEXT] [-improve TEXT] ID|SLUG")
+	}
+	ref := fs.Arg(0)
+
+	// Without a file, asking for improvements is asking for the update.
+	reflection := &playbookd.Reflection{ShouldUpdate: true}
+	if *fileFlag != "" {
+		var err error
+		if reflection, err = readReflectionFile(*fileFlag); err != nil {
+			return err
+		}
+	}
+	reflection.WhatWorked = append(reflection.WhatWorked, worked...)
+	reflection.WhatFailed = append(reflection.WhatFailed, failed...)
+	reflection.Improvements = append(reflection.Improvements, improve...)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	if len(reflection.Improvements) == 0 {
+		fmt.Printf("No improvements to apply; %q is unchanged.\n", pb.Name)
+		return nil
+	}
+	if !reflection.ShouldUpdate {
+		fmt.Printf("Reflection has should_update set to false; %q is unchanged.\n", pb.Name)
+		return nil
+	}
+
+	if err := mgr.ApplyReflection(ctx, pb.ID, reflection); err != nil {
+		return fmt.Errorf("apply reflection: %w", err)
+	}
+	updated, err := mgr.Get(ctx, pb.ID)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", pb.ID, err)
+	}
+
+	before := make(map[string]float64, len(pb.Lessons))
+	for _, l := range pb.Lessons {
+		before[l.ID] = l.Confidence
+	}
+	fmt.Printf("Applied reflection to %q (version %d).\n", updated.Name, updated.Version)
+	for _, l := range updated.Lessons {
+		conf, existed := before[l.ID]
+		switch {
+		case !existed:
+			fmt.Printf("  + %s\n", l.Content)
+		case l.Confidence != conf:
+			fmt.Printf("  ^ %s (confidence %.2f -> %.2f)\n", l.Content, conf, l.Confidence)
+		}
+	}
+	return nil
+}
+
+// readReflectionFile parses a reflection JSON or YAML file, chosen by
+// extension.
+func readReflectionFile(path string) (*playbookd.Reflection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := formatForPath(path)
+	converted, err := f.toJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid %s: %v", path, strings.ToUpper(f.name), err)
+	}
+	var r playbookd.Reflection
+	if err := json.Unmarshal(converted, &r); err != nil {
+		return nil, fmt.Errorf("%s: invalid reflection: %v", path, err)
+	}
+	return &r, nil
+}
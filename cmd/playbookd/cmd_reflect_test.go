@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func TestRunReflect(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	mgr, err := newManager()
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	pb := &playbookd.Playbook{Name: "Deploy Service", Steps: []playbookd.Step{{Order: 1, Action: "Ship"}}}
+	if err := mgr.Create(context.Background(), pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	mgr.Close()
+
+	version := func() int {
+		t.Helper()
+		mgr, err := newManager()
+		if err != nil {
+			t.Fatalf("newManager: %v", err)
+		}
+		defer mgr.Close()
+		got, err := mgr.Get(context.Background(), pb.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		return got.Version
+	}
+
+	out := captureStdout(t, func() { err = runReflect([]string{"-improve", "add retry logic", "-improve", "raise the timeout", "deploy-service"}) })
+	if err != nil {
+		t.Fatalf("reflect: %v", err)
+	}
+	if !strings.Contains(out, "version 2") || !strings.Contains(out, "+ add retry logic") || !strings.Contains(out, "+ raise the timeout") {
+		t.Errorf("output = %q, want the new version and both lessons", out)
+	}
+
+	file := filepath.Join(t.TempDir(), "reflection.yaml")
+	if err := os.WriteFile(file, []byte("what_failed: [timeout]\nimprovements: [Add retry logic]\nshould_update: false\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out = captureStdout(t, func() { err = runReflect([]string{"-file", file, "deploy-service"}) })
+	if err != nil || !strings.Contains(out, "unchanged") {
+		t.Errorf("should_update false: output = %q, err = %v; want the playbook left unchanged", out, err)
+	}
+	out = captureStdout(t, func() { err = runReflect([]string{"-worked", "fast build", "deploy-service"}) })
+	if err != nil || !strings.Contains(out, "No improvements") {
+		t.Errorf("empty reflection: output = %q, err = %v; want a no-op", out, err)
+	}
+	if v := version(); v != 2 {
+		t.Errorf("Version = %d, want 2 after the no-op reflections", v)
+	}
+}
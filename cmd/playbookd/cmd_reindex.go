@@ -2,12 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lucas-stellet/playbookd"
 )
 
 func runReindex(args []string) error {
 	fs := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	concurrencyFlag := fs.Int("concurrency", playbookd.DefaultReindexConcurrency, "playbooks to re-embed at once")
+	dryRunFlag := fs.Bool("dry-run", false, "count playbooks and embedding calls without changing anything")
+	jsonFlag := fs.Bool("json", false, "output as JSON")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -19,11 +28,71 @@ func runReindex(args []string) error {
 	}
 	defer mgr.Close()
 
-	fmt.Println("Rebuilding search index...")
-	if err := mgr.Reindex(context.Background()); err != nil {
-		return fmt.Errorf("reindex: %w", err)
+	if *concurrencyFlag < 1 {
+		return fmt.Errorf("-concurrency must be at least 1, got %d", *concurrencyFlag)
 	}
 
-	fmt.Println("Reindex complete.")
+	// Ctrl-C stops embedding and leaves the index as it was
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *dryRunFlag {
+		est, err := mgr.EstimateReindex(ctx)
+		if err != nil {
+			return fmt.Errorf("reindex: %w", err)
+		}
+		if *jsonFlag {
+			data, err := json.MarshalIndent(est, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		fmt.Printf("Dry run: %d playbook(s) would be indexed, with %d embedding call(s).\n", est.Playbooks, est.EmbeddingCalls)
+		return nil
+	}
+
+	if !*jsonFlag {
+		fmt.Println("Rebuilding search index...")
+	}
+	var reported bool
+	result, err := mgr.Reindex(ctx, playbookd.ReindexOptions{
+		Concurrency: *concurrencyFlag,
+		Progress: func(p playbookd.ReindexProgress) {
+			printReindexProgress(p)
+			reported = true
+		},
+	})
+	if reported {
+		fmt.Fprintln(os.Stderr)
+	}
+	if result != nil {
+		if *jsonFlag {
+			data, jsonErr := json.MarshalIndent(result, "", "  ")
+			if jsonErr != nil {
+				return jsonErr
+			}
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("Reindex complete: %d indexed, %d removed, %d re-embedded", result.Indexed, result.Removed, result.Reembedded)
+			if result.EmbedErrors > 0 {
+				fmt.Printf(", %d failed to embed", result.EmbedErrors)
+			}
+			fmt.Println(".")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("reindex: %w", err)
+	}
 	return nil
 }
+
+// printReindexProgress rewrites a progress line on stderr.
+func printReindexProgress(p playbookd.ReindexProgress) {
+	line := fmt.Sprintf("%d of %d indexed", p.Indexed, p.Total)
+	if p.ToEmbed > 0 {
+		line += fmt.Sprintf(", %d of %d embedding calls", p.EmbeddingCalls, p.ToEmbed)
+	}
+	fmt.Fprintf(os.Stderr, "\r%s", line)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func runRename(args []string) error {
+	fs := flag.NewFlagSet("rename", flag.ContinueOnError)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage: playbookd rename ID|SLUG \"New Name\"")
+	}
+	ref, newName := fs.Arg(0), fs.Arg(1)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	if err := mgr.Rename(ctx, pb.ID, newName); err != nil {
+		return fmt.Errorf("rename playbook: %w", err)
+	}
+
+	renamed, err := mgr.Get(ctx, pb.ID)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", pb.ID, err)
+	}
+
+	fmt.Printf("Renamed %q to %q (slug: %s, version %d).\n", pb.Name, renamed.Name, renamed.Slug, renamed.Version)
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runRepair(args []string) error {
+	fs := flag.NewFlagSet("repair", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "show what would be repaired without changing the index")
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	var report *playbookd.DriftReport
+	if *dryRun {
+		report, err = mgr.Verify(ctx)
+	} else {
+		report, err = mgr.Repair(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("repair: %w", err)
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if report.InSync() {
+		fmt.Println("Index is in sync with the store; nothing to repair.")
+		return nil
+	}
+
+	indexVerb, removeVerb := "Indexed", "Removed"
+	if *dryRun {
+		indexVerb, removeVerb = "Dry run: would index", "Dry run: would remove"
+	}
+	if len(report.MissingFromIndex) > 0 {
+		fmt.Printf("%s %d playbook(s) missing from the index:\n", indexVerb, len(report.MissingFromIndex))
+		for _, id := range report.MissingFromIndex {
+			fmt.Printf("  - %s\n", id)
+		}
+	}
+	if len(report.OrphanedInIndex) > 0 {
+		fmt.Printf("%s %d orphaned index entr(ies):\n", removeVerb, len(report.OrphanedInIndex))
+		for _, id := range report.OrphanedInIndex {
+			fmt.Printf("  - %s\n", id)
+		}
+	}
+	return nil
+}
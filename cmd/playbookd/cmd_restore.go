@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd restore ID|SLUG")
+	}
+	ref := fs.Arg(0)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	if err := mgr.Restore(ctx, pb.ID); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+
+	fmt.Printf("Restored playbook %q (%s).\n", pb.Name, pb.ID)
+	return nil
+}
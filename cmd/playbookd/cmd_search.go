@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/lucas-stellet/playbookd"
 )
@@ -13,6 +15,9 @@ func runSearch(args []string) error {
 	fs := flag.NewFlagSet("search", flag.ContinueOnError)
 	modeFlag := fs.String("mode", "hybrid", "search mode: hybrid, bm25, or vector")
 	limitFlag := fs.Int("limit", playbookd.DefaultSearchLimit, "maximum number of results")
+	tagFlag := fs.String("tag", "", "only match playbooks with these tags (comma-separated, all must match)")
+	fieldsFlag := fs.String("fields", "", "comma-separated fields to search (default: "+strings.Join(playbookd.SearchFields, ",")+"; also "+playbookd.TaskContextField+")")
+	explainFlag := fs.Bool("explain", false, "show how each score was computed")
 	jsonFlag := fs.Bool("json", false, "output as JSON")
 
 	if err := fs.Parse(args); err != nil {
@@ -20,10 +25,15 @@ func runSearch(args []string) error {
 	}
 
 	if fs.NArg() < 1 {
-		return fmt.Errorf("usage: playbookd search \"query\" [-mode hybrid|bm25|vector] [-limit N]")
+		return fmt.Errorf("usage: playbookd search \"query\" [-mode hybrid|bm25|vector] [-limit N] [-tag T1,T2] [-fields name,tags] [-explain]")
 	}
 	query := fs.Arg(0)
 
+	// Flags may also follow the query
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+
 	mgr, err := newManager()
 	if err != nil {
 		return err
@@ -31,9 +41,12 @@ func runSearch(args []string) error {
 	defer mgr.Close()
 
 	results, err := mgr.Search(context.Background(), playbookd.SearchQuery{
-		Text:  query,
-		Mode:  playbookd.SearchMode(*modeFlag),
-		Limit: *limitFlag,
+		Text:    query,
+		Mode:    playbookd.SearchMode(*modeFlag),
+		Limit:   *limitFlag,
+		Tags:    splitList(*tagFlag),
+		Fields:  splitList(*fieldsFlag),
+		Explain: *explainFlag,
 	})
 	if err != nil {
 		return fmt.Errorf("search: %w", err)
@@ -53,6 +66,9 @@ func runSearch(args []string) error {
 		return nil
 	}
 
+	if r := results[0]; r.DegradedToBM25 {
+		fmt.Fprintf(os.Stderr, "warning: query could not be embedded (%s); showing BM25 results only\n", r.DegradedReason)
+	}
 	fmt.Printf("Found %d result(s) for %q:\n\n", len(results), query)
 	for i, r := range results {
 		fmt.Printf("%d. [%.3f] %s\n", i+1, r.Score, r.Playbook.Name)
@@ -60,7 +76,20 @@ func runSearch(args []string) error {
 		if r.Playbook.Description != "" {
 			fmt.Printf("   %s\n", r.Playbook.Description)
 		}
+		if r.Explanation != nil {
+			fmt.Println("   Score breakdown:")
+			printExplanation(r.Explanation, 2)
+		}
 		fmt.Println()
 	}
 	return nil
 }
+
+// printExplanation prints a score explanation as an indented tree, one
+// contribution per line.
+func printExplanation(e *playbookd.Explanation, depth int) {
+	fmt.Printf("%s%.4f  %s\n", strings.Repeat("  ", depth), e.Value, e.Message)
+	for _, child := range e.Children {
+		printExplanation(child, depth+1)
+	}
+}
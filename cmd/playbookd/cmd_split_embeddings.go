@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func runSplitEmbeddings(args []string) error {
+	fs := flag.NewFlagSet("split-embeddings", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	n, err := mgr.SplitEmbeddings(context.Background())
+	if err != nil {
+		return fmt.Errorf("split embeddings: %w", err)
+	}
+	fmt.Printf("Moved %d embedding(s) into sidecar files.\n", n)
+	return nil
+}
@@ -6,23 +6,36 @@ import (
 	"flag"
 	"fmt"
 	"sort"
+	"time"
+
+	"github.com/lucas-stellet/playbookd"
 )
 
 func runStats(args []string) error {
 	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
 	jsonFlag := fs.Bool("json", false, "output as JSON")
+	sinceFlag := fs.String("since", "", "also show executions in a recent window (e.g. 30d, 12h)")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
+	var opts playbookd.StatsOptions
+	if *sinceFlag != "" {
+		window, err := playbookd.ParseDuration(*sinceFlag)
+		if err != nil {
+			return fmt.Errorf("invalid -since %q: %w", *sinceFlag, err)
+		}
+		opts.Since = time.Now().Add(-window)
+	}
+
 	mgr, err := newManager()
 	if err != nil {
 		return err
 	}
 	defer mgr.Close()
 
-	stats, err := mgr.Stats(context.Background())
+	stats, err := mgr.Stats(context.Background(), opts)
 	if err != nil {
 		return fmt.Errorf("stats: %w", err)
 	}
@@ -40,6 +53,19 @@ func runStats(args []string) error {
 	fmt.Printf("Total Executions: %d\n", stats.TotalExecs)
 	fmt.Printf("Avg Confidence:   %.2f\n", stats.AvgConfidence)
 	fmt.Printf("Archived:         %d\n", stats.TotalArchived)
+	fmt.Printf("Indexed:          %d\n", stats.IndexDocCount)
+	if stats.IndexSizeBytes > 0 {
+		fmt.Printf("Index Size:       %s\n", formatBytes(stats.IndexSizeBytes))
+	}
+	if live := stats.TotalPlaybooks - stats.TotalArchived; stats.IndexDocCount != live {
+		fmt.Printf("\nWarning: the index has %d documents but there are %d active playbooks; run \"playbookd index-drift\" for details.\n", stats.IndexDocCount, live)
+	}
+
+	if !opts.Since.IsZero() {
+		fmt.Printf("\nSince %s (last %s):\n", stats.Since.Format("2006-01-02 15:04"), *sinceFlag)
+		fmt.Printf("  Executions:     %d\n", stats.RecentExecs)
+		fmt.Printf("  Success Rate:   %.2f\n", stats.RecentSuccessRate)
+	}
 
 	if len(stats.ByCategory) > 0 {
 		fmt.Println("\nBy Category:")
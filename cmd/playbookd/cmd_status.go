@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runPromote(args []string) error {
+	return runSetStatus("promote", playbookd.StatusActive, args)
+}
+
+func runDeprecate(args []string) error {
+	return runSetStatus("deprecate", playbookd.StatusDeprecated, args)
+}
+
+// runSetStatus implements promote and deprecate: it moves a playbook to status,
+// refusing transitions ValidateStatusTransition rejects unless -force is given.
+func runSetStatus(name string, status playbookd.Status, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	forceFlag := fs.Bool("force", false, "allow any status transition")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd %s [-force] ID|SLUG", name)
+	}
+	ref := fs.Arg(0)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolvePlaybook(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", ref, err)
+	}
+
+	if !*forceFlag {
+		if err := playbookd.ValidateStatusTransition(pb.Status, status); err != nil {
+			return fmt.Errorf("%w (use -force to override)", err)
+		}
+	}
+
+	if err := mgr.SetStatus(ctx, pb.ID, status); err != nil {
+		return fmt.Errorf("set status: %w", err)
+	}
+
+	fmt.Printf("Playbook %q is now %s.\n", pb.Name, status)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func runSuggest(args []string) error {
+	fs := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	limitFlag := fs.Int("limit", 10, "maximum number of suggestions")
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd suggest [-limit N] PREFIX")
+	}
+	prefix := strings.Join(fs.Args(), " ")
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	suggestions, err := mgr.Suggest(context.Background(), prefix, *limitFlag)
+	if err != nil {
+		return fmt.Errorf("suggest: %w", err)
+	}
+
+	if *jsonFlag {
+		if suggestions == nil {
+			suggestions = []string{}
+		}
+		data, err := json.MarshalIndent(suggestions, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for _, s := range suggestions {
+		fmt.Println(s)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+const trashUsage = "usage: playbookd trash [list [-json] | restore ID|SLUG | empty [-older-than AGE] [-force]]"
+
+func runTrash(args []string) error {
+	sub := "list"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "list":
+		return runTrashList(args)
+	case "restore":
+		return runTrashRestore(args)
+	case "empty":
+		return runTrashEmpty(args)
+	default:
+		return fmt.Errorf("unknown trash subcommand %q; %s", sub, trashUsage)
+	}
+}
+
+func runTrashList(args []string) error {
+	fs := flag.NewFlagSet("trash list", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	trash, err := mgr.ListTrash(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(trash, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(trash) == 0 {
+		fmt.Println("Trash is empty.")
+		return nil
+	}
+	fmt.Printf("%d playbook(s) in the trash:\n", len(trash))
+	for _, pb := range trash {
+		deleted := "unknown"
+		if pb.DeletedAt != nil {
+			deleted = pb.DeletedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  - %s  %s (deleted %s)\n", pb.ID, pb.Name, deleted)
+	}
+	return nil
+}
+
+func runTrashRestore(args []string) error {
+	fs := flag.NewFlagSet("trash restore", flag.ContinueOnError)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd trash restore ID|SLUG")
+	}
+	ref := fs.Arg(0)
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	pb, err := resolveTrashed(ctx, mgr, ref)
+	if err != nil {
+		return fmt.Errorf("get trashed playbook %q: %w", ref, err)
+	}
+
+	if err := mgr.RestoreFromTrash(ctx, pb.ID); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	restored, err := mgr.Get(ctx, pb.ID)
+	if err != nil {
+		return fmt.Errorf("get playbook %q: %w", pb.ID, err)
+	}
+
+	fmt.Printf("Restored playbook %q (%s) from the trash.\n", restored.Name, restored.ID)
+	if restored.Slug != pb.Slug {
+		fmt.Printf("Its slug %q was taken, so it is now %q.\n", pb.Slug, restored.Slug)
+	}
+	return nil
+}
+
+func runTrashEmpty(args []string) error {
+	fs := flag.NewFlagSet("trash empty", flag.ContinueOnError)
+	olderThanFlag := fs.String("older-than", "", "only purge playbooks deleted longer ago than this (e.g. 30d, 2w, 12h)")
+	forceFlag := fs.Bool("force", false, "empty without asking for confirmation")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	olderThan, err := playbookd.ParseDuration(*olderThanFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -older-than %q: %w", *olderThanFlag, err)
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	if !*forceFlag {
+		question := "Permanently delete every playbook in the trash?"
+		if olderThan > 0 {
+			question = fmt.Sprintf("Permanently delete playbooks in the trash deleted before %s?",
+				time.Now().Add(-olderThan).Format("2006-01-02 15:04"))
+		}
+		ok, err := confirm(question)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	purged, err := mgr.EmptyTrash(ctx, olderThan)
+	fmt.Printf("Purged %d playbook(s) from the trash.\n", purged)
+	if err != nil {
+		return fmt.Errorf("empty trash: %w", err)
+	}
+	return nil
+}
+
+// resolveTrashed finds a trashed playbook by ID, falling back to a slug
+// match.
+func resolveTrashed(ctx context.Context, mgr *playbookd.PlaybookManager, ref string) (*playbookd.Playbook, error) {
+	trash, err := mgr.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pb := range trash {
+		if pb.ID == ref {
+			return pb, nil
+		}
+	}
+	for _, pb := range trash {
+		if pb.Slug == ref {
+			return pb, nil
+		}
+	}
+	return nil, fmt.Errorf("playbook %s: %w", ref, playbookd.ErrNotFound)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func TestRunDeleteAndTrash(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	mgr, err := newManager()
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	pb := &playbookd.Playbook{Name: "Deploy Service", Steps: []playbookd.Step{{Order: 1, Action: "Ship"}}}
+	if err := mgr.Create(context.Background(), pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	mgr.Close()
+
+	out := captureStdout(t, func() { err = runDelete([]string{"-force", "deploy-service"}) })
+	if err != nil || !strings.Contains(out, "to the trash") {
+		t.Fatalf("delete: output = %q, err = %v; want the playbook moved to the trash", out, err)
+	}
+	out = captureStdout(t, func() { err = runTrash(nil) })
+	if err != nil || !strings.Contains(out, pb.ID) {
+		t.Errorf("trash: output = %q, err = %v; want the deleted playbook listed", out, err)
+	}
+	out = captureStdout(t, func() { err = runTrash([]string{"restore", "deploy-service"}) })
+	if err != nil || !strings.Contains(out, "Restored") {
+		t.Fatalf("trash restore: output = %q, err = %v", out, err)
+	}
+
+	if err := runDelete([]string{"-force", "deploy-service"}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	out = captureStdout(t, func() { err = runDelete([]string{"-hard", "-force", "deploy-service"}) })
+	if err != nil || !strings.Contains(out, "Deleted playbook") {
+		t.Fatalf("delete -hard of a trashed playbook: output = %q, err = %v", out, err)
+	}
+	out = captureStdout(t, func() { err = runTrash([]string{"list"}) })
+	if err != nil || !strings.Contains(out, "Trash is empty") {
+		t.Errorf("trash list after purge: output = %q, err = %v; want an empty trash", out, err)
+	}
+}
+
+func TestRunDeleteFlagsAfterID(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	mgr, err := newManager()
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	pb := &playbookd.Playbook{Name: "Deploy Service", Steps: []playbookd.Step{{Order: 1, Action: "Ship"}}}
+	if err := mgr.Create(context.Background(), pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	mgr.Close()
+
+	// Only one playbook is deleted at a time; the rejected calls leave it in place
+	for _, args := range [][]string{{pb.ID, "other"}, {pb.ID, "-force", "other"}} {
+		if err := runDelete(args); err == nil || !strings.Contains(err.Error(), "usage") {
+			t.Errorf("delete %v: error = %v, want a usage error", args, err)
+		}
+	}
+
+	// Without -force taking effect, the prompt would fail to read stdin
+	out := captureStdout(t, func() { err = runDelete([]string{pb.ID, "-force"}) })
+	if err != nil || !strings.Contains(out, "to the trash") {
+		t.Fatalf("delete ID -force: output = %q, err = %v; want the playbook trashed without a prompt", out, err)
+	}
+	out = captureStdout(t, func() { err = runDelete([]string{pb.ID, "-hard", "-force"}) })
+	if err != nil || !strings.Contains(out, "Deleted playbook") {
+		t.Fatalf("delete ID -hard -force: output = %q, err = %v; want the playbook deleted without a prompt", out, err)
+	}
+}
+
+func TestRunDeleteConfirms(t *testing.T) {
+	withCLIConfig(t, "[embedding]\nprovider = \"noop\"\n")
+
+	mgr, err := newManager()
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	pb := &playbookd.Playbook{Name: "Deploy Service", Steps: []playbookd.Step{{Order: 1, Action: "Ship"}}}
+	if err := mgr.Create(context.Background(), pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	mgr.Close()
+
+	for _, args := range [][]string{{"deploy-service"}, {"-hard", "deploy-service"}} {
+		withStdin(t, "n\n")
+		out := captureStdout(t, func() { err = runDelete(args) })
+		if err != nil || !strings.Contains(out, "[y/N]") || !strings.Contains(out, "Aborted") {
+			t.Errorf("delete %v answered no: output = %q, err = %v; want a prompt and no delete", args, out, err)
+		}
+	}
+
+	withStdin(t, "y\n")
+	out := captureStdout(t, func() { err = runDelete([]string{"deploy-service"}) })
+	if err != nil || !strings.Contains(out, "to the trash") {
+		t.Errorf("delete answered yes: output = %q, err = %v; want the playbook moved to the trash", out, err)
+	}
+}
+
+// withStdin replaces os.Stdin with input for the rest of the test.
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatalf("write stdin: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open stdin: %v", err)
+	}
+	orig := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = orig
+		f.Close()
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runTree(args []string) error {
+	fs := flag.NewFlagSet("tree", flag.ContinueOnError)
+	archivedFlag := fs.Bool("archived", false, "show only archived playbooks")
+	allFlag := fs.Bool("all", false, "include archived playbooks")
+	tagFlag := fs.String("tag", "", "filter by tags (comma-separated, all must match)")
+	sortFlags := addSortFlags(fs, playbookd.SortByName)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sortBy, ascending, err := sortFlags.parse()
+	if err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	groups, err := mgr.Catalog(context.Background(), playbookd.ListFilter{
+		IncludeArchived: *allFlag,
+		OnlyArchived:    *archivedFlag,
+		Tags:            splitList(*tagFlag),
+		SortBy:          sortBy,
+		Ascending:       ascending,
+	})
+	if err != nil {
+		return fmt.Errorf("tree: %w", err)
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(groups, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No playbooks found.")
+		return nil
+	}
+	printCatalog(groups, useColor())
+	return nil
+}
+
+// printCatalog prints each category with its count and average confidence,
+// and its playbooks beneath it.
+func printCatalog(groups []playbookd.CategoryGroup, color bool) {
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		name := g.Category
+		if name == "" {
+			name = "(uncategorized)"
+		}
+		noun := "playbooks"
+		if g.Count == 1 {
+			noun = "playbook"
+		}
+		fmt.Printf("%s  %d %s, avg confidence %s\n", name, g.Count, noun,
+			colorize(fmt.Sprintf("%.2f", g.AvgConfidence), confidenceColor(g.AvgConfidence), color))
+		for j, pb := range g.Playbooks {
+			branch := "├─"
+			if j == len(g.Playbooks)-1 {
+				branch = "└─"
+			}
+			conf := colorize(fmt.Sprintf("%.2f", pb.Confidence), confidenceColor(pb.Confidence), color)
+			fmt.Printf("  %s %s  %s  %s\n", branch, conf, pb.Name, pb.ID)
+		}
+	}
+}
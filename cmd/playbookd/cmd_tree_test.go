@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func TestPrintCatalog(t *testing.T) {
+	groups := []playbookd.CategoryGroup{
+		{Category: "deployment", Count: 2, AvgConfidence: 0.6, Playbooks: []*playbookd.Playbook{
+			{ID: "pb-1", Name: "Canary Release", Confidence: 0.4},
+			{ID: "pb-2", Name: "Deploy Service", Confidence: 0.8},
+		}},
+		{Count: 1, AvgConfidence: 0.1, Playbooks: []*playbookd.Playbook{{ID: "pb-3", Name: "Scratch Notes", Confidence: 0.1}}},
+	}
+	out := captureStdout(t, func() { printCatalog(groups, false) })
+
+	want := []string{
+		"deployment  2 playbooks, avg confidence 0.60",
+		"  ├─ 0.40  Canary Release  pb-1",
+		"  └─ 0.80  Deploy Service  pb-2",
+		"",
+		"(uncategorized)  1 playbook, avg confidence 0.10",
+		"  └─ 0.10  Scratch Notes  pb-3",
+	}
+	if got := strings.Split(strings.TrimRight(out, "\n"), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("printCatalog output:\n%s\nwant:\n%s", out, strings.Join(want, "\n"))
+	}
+}
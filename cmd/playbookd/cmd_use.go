@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runUse(args []string) error {
+	fs := flag.NewFlagSet("use", flag.ContinueOnError)
+	modeFlag := fs.String("mode", "hybrid", "search mode: hybrid, bm25, or vector")
+	outcomeFlag := fs.String("outcome", "success", "outcome to record: success, partial, or failure")
+	agentFlag := fs.String("agent", "", "agent ID to record")
+	contextFlag := fs.String("context", "", "task context to record")
+	yesFlag := fs.Bool("yes", false, "record without asking for confirmation")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd use [-outcome success|partial|failure] [-agent ID] [-yes] \"query\"")
+	}
+	query := fs.Arg(0)
+
+	outcome := playbookd.Outcome(*outcomeFlag)
+	switch outcome {
+	case playbookd.OutcomeSuccess, playbookd.OutcomePartial, playbookd.OutcomeFailure:
+	default:
+		return fmt.Errorf("invalid -outcome %q (expected success, partial, or failure)", *outcomeFlag)
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx := context.Background()
+	results, err := mgr.Search(ctx, playbookd.SearchQuery{
+		Text:  query,
+		Mode:  playbookd.SearchMode(*modeFlag),
+		Limit: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("No results found.")
+		return nil
+	}
+
+	top := results[0]
+	fmt.Printf("Top match for %q [%.3f]:\n\n", query, top.Score)
+	printPlaybook(top.Playbook, mgr.ConfidenceInterval(top.Playbook))
+	fmt.Println()
+
+	if !*yesFlag {
+		ok, err := confirm(fmt.Sprintf("Record a %s execution of %q?", outcome, top.Playbook.Name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	now := time.Now()
+	rec := &playbookd.ExecutionRecord{
+		PlaybookID:        top.Playbook.ID,
+		PlaybookVer:       top.Playbook.Version,
+		AgentID:           *agentFlag,
+		StartedAt:         now,
+		CompletedAt:       now,
+		Outcome:           outcome,
+		TaskContext:       *contextFlag,
+		SelectedFromQuery: query,
+	}
+	if err := mgr.RecordExecution(ctx, rec); err != nil {
+		return fmt.Errorf("record execution: %w", err)
+	}
+
+	fmt.Printf("Recorded execution %s (outcome=%s).\n", rec.ID, rec.Outcome)
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+// runValidate checks playbook JSON or YAML files without touching the store,
+// so they can be validated (e.g. in CI) before they are imported. The size
+// limits in the config file's [manager] section are checked too.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: playbookd validate FILE...")
+	}
+	opts, err := loadValidateOptions()
+	if err != nil {
+		return err
+	}
+
+	type fileResult struct {
+		File  string `json:"file"`
+		Valid bool   `json:"valid"`
+		Error string `json:"error,omitempty"`
+	}
+	var results []fileResult
+	failed := 0
+	for _, path := range fs.Args() {
+		r := fileResult{File: path, Valid: true}
+		if err := validatePlaybookFile(path, opts); err != nil {
+			r.Valid = false
+			r.Error = err.Error()
+			failed++
+		}
+		results = append(results, r)
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, r := range results {
+			if r.Valid {
+				fmt.Printf("%s: ok\n", r.File)
+			} else {
+				fmt.Printf("%s: %s\n", r.File, r.Error)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d file(s) failed validation", failed, len(results))
+	}
+	return nil
+}
+
+// validatePlaybookFile parses a playbook file, as YAML if it has a .yaml or
+// .yml extension and as JSON otherwise, and validates it against opts.
+func validatePlaybookFile(path string, opts playbookd.ValidateOptions) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	pb, err := parsePlaybook(data, formatForPath(path))
+	if err != nil {
+		return err
+	}
+	return playbookd.ValidatePlaybook(pb, opts)
+}
+
+// loadValidateOptions reads the size limits from the config file, without
+// building the manager and its store. With no config file there are none.
+func loadValidateOptions() (playbookd.ValidateOptions, error) {
+	cfg, err := playbookd.LoadConfig(configPath)
+	switch {
+	case err == nil:
+		return playbookd.ValidateOptions{
+			MaxSteps:           cfg.Manager.MaxSteps,
+			MaxStepActionChars: cfg.Manager.MaxStepActionChars,
+		}, nil
+	case errors.Is(err, os.ErrNotExist) && configPath == defaultConfigPath:
+		return playbookd.ValidateOptions{}, nil
+	default:
+		return playbookd.ValidateOptions{}, fmt.Errorf("load config: %w", err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.json":      `{"name": "Deploy", "steps": [{"order": 1, "action": "Build"}, {"order": 2, "action": "Ship"}]}`,
+		"duplicate.json": `{"name": "Deploy", "steps": [{"order": 1, "action": "Build"}, {"order": 1, "action": "Ship"}]}`,
+		"broken.json":    `{"name": `,
+		"good.yaml":      "name: Deploy\nsteps:\n  - order: 1\n    action: |\n      Build\n      the binary\n",
+	}
+	paths := make(map[string]string)
+	for name, content := range files {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	var err error
+	out := captureStdout(t, func() { err = runValidate([]string{paths["good.json"], paths["good.yaml"]}) })
+	if err != nil {
+		t.Errorf("valid files: unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "good.json: ok") || !strings.Contains(out, "good.yaml: ok") {
+		t.Errorf("output = %q, want good.json: ok and good.yaml: ok", out)
+	}
+
+	out = captureStdout(t, func() {
+		err = runValidate([]string{paths["good.json"], paths["duplicate.json"], paths["broken.json"], filepath.Join(dir, "missing.json")})
+	})
+	if err == nil || !strings.Contains(err.Error(), "3 of 4") {
+		t.Errorf("error = %v, want 3 of 4 files failed", err)
+	}
+	for _, want := range []string{"good.json: ok", "duplicate.json: invalid playbook: step 2: order 1 duplicates step 1", "broken.json: invalid playbook: invalid JSON", "missing.json: "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunValidateConfigLimits(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "playbookd.toml")
+	if err := os.WriteFile(cfgPath, []byte("[manager]\nmax_steps = 1\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	origConfig := configPath
+	configPath = cfgPath
+	t.Cleanup(func() { configPath = origConfig })
+
+	path := filepath.Join(dir, "two.json")
+	if err := os.WriteFile(path, []byte(`{"name": "Deploy", "steps": [{"order": 1, "action": "Build"}, {"order": 2, "action": "Ship"}]}`), 0644); err != nil {
+		t.Fatalf("write playbook: %v", err)
+	}
+	var err error
+	out := captureStdout(t, func() { err = runValidate([]string{path}) })
+	if err == nil || !strings.Contains(out, "2 steps exceeds the maximum of 1") {
+		t.Errorf("runValidate = %v, output %q; want the max_steps limit reported", err, out)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+// buildInfo describes the running binary.
+type buildInfo struct {
+	Version      string `json:"version"`
+	Revision     string `json:"revision,omitempty"`
+	GoVersion    string `json:"go_version"`
+	VectorSearch bool   `json:"vector_search"`
+}
+
+func readBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:      "(devel)",
+		GoVersion:    runtime.Version(),
+		VectorSearch: playbookd.VectorSearchEnabled,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				info.Revision = s.Value
+			}
+		}
+	}
+	return info
+}
+
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	info := readBuildInfo()
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printVersion(info)
+	return nil
+}
+
+func printVersion(info buildInfo) {
+	fmt.Printf("playbookd %s\n", info.Version)
+	if info.Revision != "" {
+		fmt.Printf("  Revision:      %s\n", info.Revision)
+	}
+	fmt.Printf("  Go:            %s\n", info.GoVersion)
+	if info.VectorSearch {
+		fmt.Println("  Vector search: enabled")
+	} else {
+		fmt.Println("  Vector search: disabled (built without -tags vectors; hybrid and vector search use BM25 only)")
+	}
+}
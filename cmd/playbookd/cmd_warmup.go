@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+)
+
+func runWarmup(args []string) error {
+	fs := flag.NewFlagSet("warmup", flag.ContinueOnError)
+	jsonFlag := fs.Bool("json", false, "output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	cold, err := mgr.ColdStartCandidates(context.Background())
+	if err != nil {
+		return fmt.Errorf("cold start candidates: %w", err)
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(cold, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(cold) == 0 {
+		fmt.Println("No cold playbooks; every active playbook has enough executions.")
+		return nil
+	}
+
+	fmt.Printf("Cold playbooks that need test runs (%d):\n\n", len(cold))
+	fmt.Printf("%-36s  %-30s  %-12s  %s\n", "ID", "Name", "Category", "Executions")
+	fmt.Printf("%-36s  %-30s  %-12s  %s\n",
+		"------------------------------------",
+		"------------------------------",
+		"------------",
+		"----------",
+	)
+	for _, pb := range cold {
+		fmt.Printf("%-36s  %-30s  %-12s  %d\n",
+			pb.ID, pb.Name, pb.Category, pb.SuccessCount+pb.FailureCount)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	debounceFlag := fs.Duration("debounce", playbookd.DefaultWatchDebounce, "wait this long after the last change to a file before re-indexing it")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Println("Watching playbook files; press Ctrl-C to stop.")
+	err = mgr.Watch(ctx, playbookd.WatchOptions{
+		Debounce: *debounceFlag,
+		OnSync: func(id string, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "sync %s: %v\n", id, err)
+				return
+			}
+			fmt.Printf("Synced %s\n", id)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	fmt.Println("Stopped.")
+	return nil
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lucas-stellet/playbookd"
 )
 
-func printPlaybook(pb *playbookd.Playbook) {
+// printPlaybook prints pb's details, with ci, its confidence interval at the
+// manager's ConfidenceZ.
+func printPlaybook(pb *playbookd.Playbook, ci playbookd.ConfidenceInterval) {
 	fmt.Printf("Name:       %s\n", pb.Name)
 	fmt.Printf("ID:         %s\n", pb.ID)
 	fmt.Printf("Slug:       %s\n", pb.Slug)
@@ -15,16 +18,26 @@ func printPlaybook(pb *playbookd.Playbook) {
 	if pb.Archived {
 		fmt.Println("** ARCHIVED **")
 	}
+	if pb.Status != "" && pb.Status != playbookd.StatusActive {
+		fmt.Printf("Status:     %s\n", pb.Status)
+	}
 	fmt.Printf("Version:    %d\n", pb.Version)
-	fmt.Printf("Confidence: %.2f\n", pb.Confidence)
+	if pb.SuccessCount+pb.FailureCount > 0 {
+		fmt.Printf("Confidence: %.2f (%.2f–%.2f)\n", ci.Estimate, ci.Lower, ci.Upper)
+	} else {
+		fmt.Printf("Confidence: %.2f\n", pb.Confidence)
+	}
 	fmt.Printf("Success:    %d  Failure: %d\n", pb.SuccessCount, pb.FailureCount)
+	if pb.AvgDuration > 0 {
+		fmt.Printf("Duration:   avg %s  p95 %s\n", roundDuration(pb.AvgDuration), roundDuration(pb.P95Duration))
+	}
 	if len(pb.Tags) > 0 {
 		fmt.Printf("Tags:       %s\n", strings.Join(pb.Tags, ", "))
 	}
-	fmt.Printf("Created:    %s\n", pb.CreatedAt.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Updated:    %s\n", pb.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Created:    %s%s\n", pb.CreatedAt.Format("2006-01-02 15:04:05"), byline(pb.CreatedBy))
+	fmt.Printf("Updated:    %s%s\n", pb.UpdatedAt.Format("2006-01-02 15:04:05"), byline(pb.UpdatedBy))
 	if !pb.LastUsedAt.IsZero() {
-		fmt.Printf("Last Used:  %s\n", pb.LastUsedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Last Used:  %s%s\n", pb.LastUsedAt.Format("2006-01-02 15:04:05"), byline(pb.LastUsedBy))
 	}
 
 	if pb.Description != "" {
@@ -48,3 +61,43 @@ func printPlaybook(pb *playbookd.Playbook) {
 		}
 	}
 }
+
+// byline returns " by who", or "" when who is unknown.
+func byline(who string) string {
+	if who == "" {
+		return ""
+	}
+	return " by " + who
+}
+
+// changedBy returns who last updated pb, or who created it if it was never
+// updated by a known actor.
+func changedBy(pb *playbookd.Playbook) string {
+	if pb.UpdatedBy != "" {
+		return pb.UpdatedBy
+	}
+	return pb.CreatedBy
+}
+
+// roundDuration rounds d for display: to the second from a minute up, to the
+// millisecond below.
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Minute {
+		return d.Round(time.Second)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// formatBytes renders n bytes with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
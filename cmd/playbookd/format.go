@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas-stellet/playbookd"
+	"gopkg.in/yaml.v3"
+)
+
+// fileFormat is an authoring format for playbook files. The CLI works with
+// playbooks as JSON; a format only converts that JSON to and from its own
+// encoding, so every format accepts exactly the fields the JSON does.
+type fileFormat struct {
+	name     string // "json" or "yaml"
+	ext      string // extension for files in this format, such as temp files
+	fromJSON func([]byte) ([]byte, error)
+	toJSON   func([]byte) ([]byte, error)
+}
+
+var (
+	formatJSON = fileFormat{name: "json", ext: ".json", fromJSON: identity, toJSON: identity}
+	formatYAML = fileFormat{name: "yaml", ext: ".yaml", fromJSON: jsonToYAML, toJSON: yamlToJSON}
+)
+
+func identity(data []byte) ([]byte, error) { return data, nil }
+
+// formatForPath returns YAML for .yaml and .yml files and JSON otherwise.
+func formatForPath(path string) fileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return formatJSON
+	}
+}
+
+// formatByName returns the format named by a -format flag.
+func formatByName(name string) (fileFormat, error) {
+	switch name {
+	case "", "json":
+		return formatJSON, nil
+	case "yaml", "yml":
+		return formatYAML, nil
+	default:
+		return fileFormat{}, fmt.Errorf("unknown format %q (expected json or yaml)", name)
+	}
+}
+
+// parsePlaybook decodes a playbook in format f and validates it with
+// ValidatePlaybook. Decoding errors wrap ErrInvalidPlaybook.
+func parsePlaybook(data []byte, f fileFormat) (*playbookd.Playbook, error) {
+	converted, err := f.toJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s: %v", playbookd.ErrInvalidPlaybook, strings.ToUpper(f.name), err)
+	}
+	return parseAndValidate(converted)
+}
+
+// parsePlaybookList decodes a list of playbooks in format f, a JSON array or
+// a YAML sequence, as written for import. Entries are not validated, so the
+// importer can report each invalid one.
+func parsePlaybookList(r io.Reader, f fileFormat) ([]*playbookd.Playbook, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := f.toJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", strings.ToUpper(f.name), err)
+	}
+	var playbooks []*playbookd.Playbook
+	if err := json.Unmarshal(converted, &playbooks); err != nil {
+		list := "array"
+		if f.name == "yaml" {
+			list = "sequence"
+		}
+		return nil, fmt.Errorf("invalid %s %s of playbooks: %v", strings.ToUpper(f.name), list, err)
+	}
+	return playbooks, nil
+}
+
+// yamlToJSON converts a YAML document to JSON. Mapping keys must be strings.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// jsonToYAML converts JSON to YAML, keeping the key order. Multi-line strings,
+// such as long step actions, are written as literal block scalars.
+func jsonToYAML(data []byte) ([]byte, error) {
+	// JSON is valid YAML, so parsing it gives a node tree in the original order.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	restyleYAML(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// restyleYAML replaces the flow style and quoting a JSON document parses with
+// by block style, letting the encoder quote only strings that need it.
+func restyleYAML(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && strings.Contains(n.Value, "\n") {
+		n.Style = yaml.LiteralStyle
+	}
+	for _, c := range n.Content {
+		restyleYAML(c)
+	}
+}
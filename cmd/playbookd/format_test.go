@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+	src := `name: Deploy service
+description: Ship a Go service
+tags: [go, deploy]
+category: deployment
+steps:
+  - order: 1
+    action: |
+      Build the binary:
+      go build ./...
+    notes: Run from the repo root
+  - order: 2
+    action: Ship it
+`
+	pb, err := parsePlaybook([]byte(src), formatYAML)
+	if err != nil {
+		t.Fatalf("parsePlaybook: %v", err)
+	}
+	if want := "Build the binary:\ngo build ./...\n"; pb.Steps[0].Action != want {
+		t.Errorf("block scalar action = %q, want %q", pb.Steps[0].Action, want)
+	}
+
+	// The same playbook written as JSON parses to the same struct.
+	fromJSON, err := parsePlaybook([]byte(`{
+		"name": "Deploy service", "description": "Ship a Go service",
+		"tags": ["go", "deploy"], "category": "deployment",
+		"steps": [
+			{"order": 1, "action": "Build the binary:\ngo build ./...\n", "notes": "Run from the repo root"},
+			{"order": 2, "action": "Ship it"}
+		]}`), formatJSON)
+	if err != nil {
+		t.Fatalf("parsePlaybook JSON: %v", err)
+	}
+	if !sameJSON(pb, fromJSON) {
+		t.Errorf("YAML and JSON parse differently:\n%+v\n%+v", pb, fromJSON)
+	}
+
+	// struct -> JSON -> YAML -> struct is lossless and uses block scalars.
+	data, err := json.Marshal(pb)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	out, err := formatYAML.fromJSON(data)
+	if err != nil {
+		t.Fatalf("jsonToYAML: %v", err)
+	}
+	if !strings.Contains(string(out), "action: |") {
+		t.Errorf("multi-line action not written as a block scalar:\n%s", out)
+	}
+	back, err := parsePlaybook(out, formatYAML)
+	if err != nil {
+		t.Fatalf("parsePlaybook(round trip): %v", err)
+	}
+	if !sameJSON(pb, back) {
+		t.Errorf("round trip changed the playbook:\n%+v\n%+v", pb, back)
+	}
+}
+
+func TestParsePlaybookInvalidYAML(t *testing.T) {
+	_, err := parsePlaybook([]byte("name: [unclosed"), formatYAML)
+	if !errors.Is(err, playbookd.ErrInvalidPlaybook) || !strings.Contains(err.Error(), "invalid YAML") {
+		t.Errorf("error = %v, want invalid YAML", err)
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	for path, want := range map[string]string{"a.yaml": "yaml", "b.YML": "yaml", "c.json": "json", "d": "json"} {
+		if got := formatForPath(path).name; got != want {
+			t.Errorf("formatForPath(%q) = %s, want %s", path, got, want)
+		}
+	}
+	if _, err := formatByName("toml"); err == nil {
+		t.Error("formatByName(toml) should fail")
+	}
+}
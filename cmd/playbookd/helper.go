@@ -1,47 +1,100 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/lucas-stellet/playbookd"
 )
 
+// newManager builds a manager from the config file (--config, default
+// .playbookd.toml). The data directory is taken from, in order: --data-dir,
+// $PLAYBOOKD_DATA, the config file, and "./playbooks".
 func newManager() (*playbookd.PlaybookManager, error) {
-	cfg, err := playbookd.LoadConfig(".playbookd.toml")
-	if err == nil {
+	mgrCfg, err := loadManagerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	mgr, err := playbookd.NewPlaybookManager(mgrCfg)
+	if err != nil {
+		return nil, fmt.Errorf("init manager: %w", err)
+	}
+	return mgr, nil
+}
+
+// loadManagerConfig resolves the manager configuration used by newManager.
+func loadManagerConfig() (playbookd.ManagerConfig, error) {
+	var mgrCfg playbookd.ManagerConfig
+
+	cfg, err := playbookd.LoadConfig(configPath)
+	switch {
+	case err == nil:
 		// TOML config found — use it
-		mgrCfg, err := cfg.BuildManagerConfig()
+		mgrCfg, err = cfg.BuildManagerConfig()
 		if err != nil {
-			return nil, fmt.Errorf("build config: %w", err)
-		}
-		// Env var overrides TOML data dir
-		if envDir := os.Getenv("PLAYBOOKD_DATA"); envDir != "" {
-			mgrCfg.DataDir = envDir
+			return mgrCfg, fmt.Errorf("%s: %w", configPath, err)
 		}
-		mgr, err := playbookd.NewPlaybookManager(mgrCfg)
-		if err != nil {
-			return nil, fmt.Errorf("init manager: %w", err)
-		}
-		return mgr, nil
+	case errors.Is(err, os.ErrNotExist) && configPath == defaultConfigPath:
+		// No config file — fall back to env var and defaults
+	default:
+		// An explicit --config must exist
+		return mgrCfg, fmt.Errorf("load config: %w", err)
+	}
+
+	if dataDirFlag != "" {
+		mgrCfg.DataDir = dataDirFlag
+	} else if envDir := os.Getenv("PLAYBOOKD_DATA"); envDir != "" {
+		mgrCfg.DataDir = envDir
+	} else if mgrCfg.DataDir == "" {
+		mgrCfg.DataDir = "./playbooks"
 	}
+	return mgrCfg, nil
+}
 
-	// No config file — fall back to env var
-	if !errors.Is(err, os.ErrNotExist) {
-		return nil, fmt.Errorf("load config: %w", err)
+// resolvePlaybook loads a playbook by ID, falling back to a slug match
+// (including archived playbooks) when no playbook has that ID.
+func resolvePlaybook(ctx context.Context, mgr *playbookd.PlaybookManager, ref string) (*playbookd.Playbook, error) {
+	pb, err := mgr.Get(ctx, ref)
+	if err == nil {
+		return pb, nil
+	}
+	if !errors.Is(err, playbookd.ErrNotFound) {
+		return nil, err
 	}
 
-	dataDir := os.Getenv("PLAYBOOKD_DATA")
-	if dataDir == "" {
-		dataDir = "./playbooks"
+	playbooks, listErr := mgr.List(ctx, playbookd.ListFilter{IncludeArchived: true})
+	if listErr != nil {
+		return nil, fmt.Errorf("list playbooks: %w", listErr)
 	}
+	for _, p := range playbooks {
+		if p.Slug == ref {
+			return p, nil
+		}
+	}
+	return nil, err
+}
 
-	mgr, err := playbookd.NewPlaybookManager(playbookd.ManagerConfig{
-		DataDir: dataDir,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("init manager: %w", err)
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
 	}
-	return mgr, nil
+	return out
+}
+
+// stringList is a repeatable string flag: each occurrence appends a value.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 )
@@ -8,47 +9,167 @@ import (
 const usage = `playbookd - Procedural memory manager for AI agents
 
 Usage:
-  playbookd <command> [options]
+  playbookd [--config PATH] [--data-dir DIR] <command> [options]
+
+Global options:
+  --config PATH   Configuration file (default: .playbookd.toml)
+  --data-dir DIR  Data directory; overrides $PLAYBOOKD_DATA and the config file
+  --version, -v   Print version and build information
 
 Commands:
-  init      Generate a .playbookd.toml configuration file
-  list      List playbooks
-  search    Search for playbooks
-  get       Get a specific playbook
-  edit      Edit a playbook in an external editor
-  stats     Show aggregate statistics
-  prune     Archive stale playbooks
-  reindex   Rebuild the search index
+  init           Generate a .playbookd.toml configuration file
+  init-template  Save a playbook's structure as a reusable template
+  list           List playbooks
+  count          Count playbooks matching the list filters
+  tree           List playbooks grouped by category
+  search         Search for playbooks
+  suggest        Complete a playbook name or tag from its first letters
+  use            Search, pick the top match, and record an execution of it
+  get            Get a specific playbook
+  plan           Print a playbook's steps and top lessons for an agent to run
+  create         Create a playbook from a template or a JSON/YAML file
+  edit           Edit a playbook in an external editor
+  rename         Rename a playbook and regenerate its slug
+  clone          Create a new playbook from a copy of an existing one
+  delete         Move a playbook to the trash, or delete it permanently with -hard
+  trash          List, restore, or empty deleted playbooks
+  promote        Mark a draft playbook as active
+  deprecate      Mark a playbook as deprecated
+  diff           Show changes between two versions of a playbook
+  validate       Check playbook JSON or YAML files before importing them
+  export         Export all playbooks as JSON or streamed JSON Lines
+  import         Import playbooks from an export, keeping their IDs and stats
+  stats          Show aggregate statistics
+  reflect        Apply an execution reflection to a playbook as lessons
+  lessons        Export lessons from all playbooks as Markdown
+  warmup         List cold playbooks that need more executions
+  prune          Archive stale playbooks
+  restore        Unarchive a pruned playbook
+  reindex        Rebuild the search index
+  reembed        Regenerate embeddings with the current provider and re-index
+  index-drift    Compare the search index against the store
+  repair         Index missing playbooks and remove orphaned index entries
+  split-embeddings  Move stored embeddings out of playbook files into sidecar files
+  watch          Keep the search index in sync with hand-edited playbook files
+  check          Verify the data directory, index, search, and embedding provider
+  completion     Print a shell completion script (bash, zsh, fish)
+  version        Print version and build information
 
 Use "playbookd <command> -help" for more information about a command.`
 
+// Global options, set from flags given before the command.
+var (
+	configPath  = defaultConfigPath
+	dataDirFlag string
+)
+
+const defaultConfigPath = ".playbookd.toml"
+
 func main() {
-	if len(os.Args) < 2 {
+	global := flag.NewFlagSet("playbookd", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	global.StringVar(&configPath, "config", defaultConfigPath, "configuration file")
+	global.StringVar(&dataDirFlag, "data-dir", "", "data directory")
+	showVersion := global.Bool("version", false, "print version and build information")
+	global.BoolVar(showVersion, "v", false, "print version and build information")
+	if err := global.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	if *showVersion {
+		printVersion(readBuildInfo())
+		return
+	}
+
+	if global.NArg() < 1 {
 		fmt.Fprintln(os.Stderr, usage)
 		os.Exit(1)
 	}
 
-	cmd := os.Args[1]
-	args := os.Args[2:]
+	cmd := global.Arg(0)
+	args := global.Args()[1:]
 
 	var err error
 	switch cmd {
 	case "init":
 		err = runInit(args)
+	case "init-template":
+		err = runInitTemplate(args)
 	case "list":
 		err = runList(args)
+	case "count":
+		err = runCount(args)
+	case "tree":
+		err = runTree(args)
 	case "search":
 		err = runSearch(args)
+	case "suggest":
+		err = runSuggest(args)
+	case "use":
+		err = runUse(args)
 	case "get":
 		err = runGet(args)
+	case "plan":
+		err = runPlan(args)
+	case "create":
+		err = runCreate(args)
 	case "edit":
 		err = runEdit(args)
+	case "rename":
+		err = runRename(args)
+	case "clone":
+		err = runClone(args)
+	case "delete":
+		err = runDelete(args)
+	case "trash":
+		err = runTrash(args)
+	case "promote":
+		err = runPromote(args)
+	case "deprecate":
+		err = runDeprecate(args)
+	case "diff":
+		err = runDiff(args)
+	case "validate":
+		err = runValidate(args)
+	case "export":
+		err = runExport(args)
+	case "import":
+		err = runImport(args)
 	case "stats":
 		err = runStats(args)
+	case "reflect":
+		err = runReflect(args)
+	case "lessons":
+		err = runLessons(args)
+	case "warmup":
+		err = runWarmup(args)
 	case "prune":
 		err = runPrune(args)
+	case "restore":
+		err = runRestore(args)
 	case "reindex":
 		err = runReindex(args)
+	case "reembed":
+		err = runReembed(args)
+	case "index-drift":
+		err = runIndexDrift(args)
+	case "repair":
+		err = runRepair(args)
+	case "split-embeddings":
+		err = runSplitEmbeddings(args)
+	case "watch":
+		err = runWatch(args)
+	case "check":
+		err = runCheck(args)
+	case "completion":
+		err = runCompletion(args)
+	case "version":
+		err = runVersion(args)
+	case "__complete-playbooks":
+		err = runCompletePlaybooks(args)
 	case "-h", "-help", "--help", "help":
 		fmt.Println(usage)
 		return
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI color codes used for terminal output.
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// stdoutIsTerminal reports whether stdout is attached to a terminal.
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether output should be colored: stdout is a terminal and
+// NO_COLOR (https://no-color.org) is not set.
+func useColor() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return stdoutIsTerminal()
+}
+
+// terminalWidth returns the width of the terminal on stdout, preferring
+// $COLUMNS, or 0 if stdout is not a terminal or the width is unknown.
+func terminalWidth() int {
+	if !stdoutIsTerminal() {
+		return 0
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return ttyWidth(os.Stdout)
+}
+
+// colorize wraps s in the given color code when enabled.
+func colorize(s, color string, enabled bool) string {
+	if !enabled || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// confidenceColor returns the color for a confidence score: green when proven,
+// yellow when middling, red when low.
+func confidenceColor(c float64) string {
+	switch {
+	case c >= 0.6:
+		return colorGreen
+	case c >= 0.3:
+		return colorYellow
+	default:
+		return colorRed
+	}
+}
+
+// fit truncates s to width characters, marking the cut with "…", and pads it
+// with spaces to exactly width.
+func fit(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		if width <= 1 {
+			return string([]rune(s)[:width])
+		}
+		return string([]rune(s)[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-n)
+}
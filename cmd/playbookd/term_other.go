@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// ttyWidth returns 0: terminal size detection is only implemented on Unix.
+// Set $COLUMNS to enable width-aware output elsewhere.
+func ttyWidth(f *os.File) int {
+	return 0
+}
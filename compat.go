@@ -0,0 +1,321 @@
+package playbookd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Forward compatibility: Playbook and ExecutionRecord keep any JSON keys they
+// do not recognize in RawExtra and write them back out on marshal, so a
+// load→save round-trip in an older binary does not drop fields added by a
+// newer one.
+//
+// Backward compatibility: StepResult.Duration used to be a free-form string.
+// It is now a time.Duration, still written as a string such as "1.5s", and
+// read from either a string or a number of nanoseconds. Playbook.AvgDuration
+// and P95Duration, and the durations in DurationStats and StepDuration, use
+// the same encoding; files written while they were stored as nanoseconds
+// still load.
+
+type playbookJSON Playbook
+type executionRecordJSON ExecutionRecord
+type stepResultJSON StepResult
+type durationStatsJSON DurationStats
+type stepDurationJSON StepDuration
+
+// UnmarshalJSON decodes a playbook, keeping unknown keys in RawExtra.
+func (pb *Playbook) UnmarshalJSON(data []byte) error {
+	var pj playbookJSON
+	v := struct {
+		*playbookJSON
+		AvgDuration json.RawMessage `json:"avg_duration,omitempty"`
+		P95Duration json.RawMessage `json:"p95_duration,omitempty"`
+	}{playbookJSON: &pj}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var err error
+	if pj.AvgDuration, err = parseJSONDuration(v.AvgDuration); err != nil {
+		return fmt.Errorf("avg_duration: %w", err)
+	}
+	if pj.P95Duration, err = parseJSONDuration(v.P95Duration); err != nil {
+		return fmt.Errorf("p95_duration: %w", err)
+	}
+	extra, err := unknownFields(data, reflect.TypeOf(pj))
+	if err != nil {
+		return err
+	}
+	pj.RawExtra = extra
+	*pb = Playbook(pj)
+	return nil
+}
+
+// MarshalJSON encodes a playbook with its durations as strings, e.g. "1.5s",
+// including any unknown keys from RawExtra.
+func (pb Playbook) MarshalJSON() ([]byte, error) {
+	v := struct {
+		playbookJSON
+		AvgDuration string `json:"avg_duration,omitempty"`
+		P95Duration string `json:"p95_duration,omitempty"`
+	}{playbookJSON: playbookJSON(pb)}
+	if pb.AvgDuration != 0 {
+		v.AvgDuration = pb.AvgDuration.String()
+	}
+	if pb.P95Duration != 0 {
+		v.P95Duration = pb.P95Duration.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendUnknownFields(data, pb.RawExtra)
+}
+
+// UnmarshalJSON decodes an execution record, keeping unknown keys in RawExtra.
+func (rec *ExecutionRecord) UnmarshalJSON(data []byte) error {
+	var v executionRecordJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	v.RawExtra = extra
+	*rec = ExecutionRecord(v)
+	return nil
+}
+
+// MarshalJSON encodes an execution record, including any unknown keys from RawExtra.
+func (rec ExecutionRecord) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(executionRecordJSON(rec))
+	if err != nil {
+		return nil, err
+	}
+	return appendUnknownFields(data, rec.RawExtra)
+}
+
+// MarshalJSON encodes a step result with its duration as a string, e.g. "1.5s".
+func (sr StepResult) MarshalJSON() ([]byte, error) {
+	v := struct {
+		stepResultJSON
+		Duration string `json:"duration,omitempty"`
+	}{stepResultJSON: stepResultJSON(sr)}
+	if sr.Duration != 0 {
+		v.Duration = sr.Duration.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a step result whose duration is a string accepted by
+// ParseDuration, as older files and most callers write it, or a number of
+// nanoseconds. An unparseable or negative duration is an error.
+func (sr *StepResult) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*stepResultJSON
+		Duration json.RawMessage `json:"duration,omitempty"`
+	}{stepResultJSON: (*stepResultJSON)(sr)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	d, err := parseJSONDuration(v.Duration)
+	if err != nil {
+		return fmt.Errorf("step %d: %w", sr.StepOrder, err)
+	}
+	sr.Duration = d
+	return nil
+}
+
+// MarshalJSON encodes duration stats with durations as strings, e.g. "1.5s".
+func (ds DurationStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		durationStatsJSON
+		Avg string `json:"avg"`
+		P95 string `json:"p95"`
+	}{durationStatsJSON(ds), ds.Avg.String(), ds.P95.String()})
+}
+
+// UnmarshalJSON decodes duration stats whose durations are strings accepted
+// by ParseDuration or numbers of nanoseconds.
+func (ds *DurationStats) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*durationStatsJSON
+		Avg json.RawMessage `json:"avg"`
+		P95 json.RawMessage `json:"p95"`
+	}{durationStatsJSON: (*durationStatsJSON)(ds)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var err error
+	if ds.Avg, err = parseJSONDuration(v.Avg); err != nil {
+		return fmt.Errorf("avg: %w", err)
+	}
+	if ds.P95, err = parseJSONDuration(v.P95); err != nil {
+		return fmt.Errorf("p95: %w", err)
+	}
+	return nil
+}
+
+// MarshalJSON encodes a step's duration stats with durations as strings.
+func (sd StepDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		stepDurationJSON
+		Total string `json:"total"`
+		Avg   string `json:"avg"`
+		P95   string `json:"p95"`
+	}{stepDurationJSON(sd), sd.Total.String(), sd.Avg.String(), sd.P95.String()})
+}
+
+// UnmarshalJSON decodes a step's duration stats whose durations are strings
+// accepted by ParseDuration or numbers of nanoseconds.
+func (sd *StepDuration) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*stepDurationJSON
+		Total json.RawMessage `json:"total"`
+		Avg   json.RawMessage `json:"avg"`
+		P95   json.RawMessage `json:"p95"`
+	}{stepDurationJSON: (*stepDurationJSON)(sd)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var err error
+	if sd.Total, err = parseJSONDuration(v.Total); err != nil {
+		return fmt.Errorf("step %d total: %w", sd.StepOrder, err)
+	}
+	if sd.Avg, err = parseJSONDuration(v.Avg); err != nil {
+		return fmt.Errorf("step %d avg: %w", sd.StepOrder, err)
+	}
+	if sd.P95, err = parseJSONDuration(v.P95); err != nil {
+		return fmt.Errorf("step %d p95: %w", sd.StepOrder, err)
+	}
+	return nil
+}
+
+// parseJSONDuration reads a duration from a JSON string, number of
+// nanoseconds, or null.
+func parseJSONDuration(raw json.RawMessage) (time.Duration, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return 0, nil
+	}
+	var d time.Duration
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, err
+		}
+		parsed, err := ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		d = parsed
+	} else if err := json.Unmarshal(raw, &d); err != nil {
+		return 0, fmt.Errorf("invalid duration %s (expected a string such as \"1.5s\" or a number of nanoseconds)", raw)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %s: must not be negative", raw)
+	}
+	return d, nil
+}
+
+var knownFieldsCache sync.Map // reflect.Type -> map[string]bool
+
+// knownFields returns the JSON keys declared by the struct type t.
+func knownFields(t reflect.Type) map[string]bool {
+	if cached, ok := knownFieldsCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+	known := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		known[name] = true
+	}
+	knownFieldsCache.Store(t, known)
+	return known
+}
+
+// unknownFields returns the keys of the JSON object in data that t does not
+// declare, as a JSON object, or nil if there are none.
+func unknownFields(data []byte, t reflect.Type) (json.RawMessage, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	known := knownFields(t)
+	for key := range all {
+		// encoding/json matches keys case-insensitively, so do the same here
+		if known[key] || hasFoldedKey(known, key) {
+			delete(all, key)
+		}
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(all)
+}
+
+func hasFoldedKey(known map[string]bool, key string) bool {
+	for k := range known {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendUnknownFields merges the keys of the JSON object extra into the JSON
+// object data. Keys already present in data win.
+func appendUnknownFields(data []byte, extra json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(extra, &fields); err != nil {
+		return nil, fmt.Errorf("raw extra fields: %w", err)
+	}
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if _, ok := present[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return data, nil
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(bytes.TrimSpace(data), []byte("}")))
+	for i, k := range keys {
+		if len(present) > 0 || i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(fields[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,159 @@
+package playbookd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepResultDurationJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want time.Duration
+	}{
+		{"old string form", `{"step_order":1,"outcome":"success","duration":"1.5s"}`, 1500 * time.Millisecond},
+		{"string in days", `{"step_order":1,"duration":"2d"}`, 48 * time.Hour},
+		{"numeric nanoseconds", `{"step_order":1,"duration":1500000000}`, 1500 * time.Millisecond},
+		{"missing", `{"step_order":1}`, 0},
+		{"null", `{"step_order":1,"duration":null}`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sr StepResult
+			if err := json.Unmarshal([]byte(tt.json), &sr); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if sr.Duration != tt.want || sr.StepOrder != 1 {
+				t.Fatalf("got step %d duration %v, want step 1 duration %v", sr.StepOrder, sr.Duration, tt.want)
+			}
+
+			data, err := json.Marshal(sr)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if tt.want == 0 && strings.Contains(string(data), "duration") {
+				t.Errorf("Marshal = %s, want the zero duration omitted", data)
+			}
+			if tt.want != 0 && !strings.Contains(string(data), `"duration":"`+tt.want.String()+`"`) {
+				t.Errorf("Marshal = %s, want the duration written as %q", data, tt.want.String())
+			}
+
+			var again StepResult
+			if err := json.Unmarshal(data, &again); err != nil {
+				t.Fatalf("Unmarshal round-trip: %v", err)
+			}
+			if again != sr {
+				t.Errorf("round-trip = %+v, want %+v", again, sr)
+			}
+		})
+	}
+}
+
+func TestStepResultDurationJSONInvalid(t *testing.T) {
+	for _, in := range []string{
+		`{"step_order":2,"duration":"a while"}`,
+		`{"step_order":2,"duration":"-3s"}`,
+		`{"step_order":2,"duration":1.5}`,
+		`{"step_order":2,"duration":true}`,
+	} {
+		var sr StepResult
+		err := json.Unmarshal([]byte(in), &sr)
+		if err == nil || !strings.Contains(err.Error(), "step 2") {
+			t.Errorf("Unmarshal(%s) error = %v, want an error naming step 2", in, err)
+		}
+	}
+}
+
+func TestPlaybookDurationJSON(t *testing.T) {
+	for _, in := range []string{
+		`{"id":"pb1","avg_duration":"1m30s","p95_duration":"2m0s","custom":1}`,
+		`{"id":"pb1","avg_duration":90000000000,"p95_duration":120000000000,"custom":1}`,
+	} {
+		var pb Playbook
+		if err := json.Unmarshal([]byte(in), &pb); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", in, err)
+		}
+		if pb.AvgDuration != 90*time.Second || pb.P95Duration != 2*time.Minute {
+			t.Fatalf("Unmarshal(%s) = avg %v, p95 %v; want 1m30s, 2m0s", in, pb.AvgDuration, pb.P95Duration)
+		}
+		if string(pb.RawExtra) != `{"custom":1}` {
+			t.Errorf("Unmarshal(%s) RawExtra = %s, want only the unknown key", in, pb.RawExtra)
+		}
+
+		data, err := json.Marshal(pb)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		for _, want := range []string{`"avg_duration":"1m30s"`, `"p95_duration":"2m0s"`, `"custom":1`} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("Marshal = %s, want it to contain %s", data, want)
+			}
+		}
+	}
+
+	data, err := json.Marshal(Playbook{ID: "pb1"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(data), "duration") {
+		t.Errorf("Marshal = %s, want zero durations omitted", data)
+	}
+
+	var pb Playbook
+	if err := json.Unmarshal([]byte(`{"id":"pb1","p95_duration":"soon"}`), &pb); err == nil || !strings.Contains(err.Error(), "p95_duration") {
+		t.Errorf("Unmarshal of an invalid duration: error = %v, want one naming p95_duration", err)
+	}
+}
+
+func TestDurationStatsJSON(t *testing.T) {
+	stats := DurationStats{
+		Runs: 3,
+		Avg:  90 * time.Second,
+		P95:  2 * time.Minute,
+		Steps: []StepDuration{
+			{StepOrder: 1, Runs: 3, Total: 3 * time.Second, Avg: time.Second, P95: 1500 * time.Millisecond},
+		},
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"runs":3,"steps":[{"step_order":1,"runs":3,"total":"3s","avg":"1s","p95":"1.5s"}],"avg":"1m30s","p95":"2m0s"}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	for _, in := range []string{
+		want,
+		`{"runs":3,"avg":90000000000,"p95":120000000000,"steps":[{"step_order":1,"runs":3,"total":3000000000,"avg":1000000000,"p95":1500000000}]}`,
+	} {
+		var got DurationStats
+		if err := json.Unmarshal([]byte(in), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", in, err)
+		}
+		if got.Runs != 3 || got.Avg != stats.Avg || got.P95 != stats.P95 || len(got.Steps) != 1 || got.Steps[0] != stats.Steps[0] {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", in, got, stats)
+		}
+	}
+
+	var got DurationStats
+	if err := json.Unmarshal([]byte(`{"steps":[{"step_order":2,"avg":"soon"}]}`), &got); err == nil || !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("Unmarshal of an invalid step duration: error = %v, want one naming step 2", err)
+	}
+}
+
+func TestExecutionRecordStepDurations(t *testing.T) {
+	old := `{"id":"e1","playbook_id":"p1","outcome":"success","step_results":[{"step_order":1,"outcome":"success","duration":"45s"}]}`
+	var rec ExecutionRecord
+	if err := json.Unmarshal([]byte(old), &rec); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(rec.StepResults) != 1 || rec.StepResults[0].Duration != 45*time.Second {
+		t.Fatalf("StepResults = %+v, want one step of 45s", rec.StepResults)
+	}
+	if rec.RawExtra != nil {
+		t.Errorf("RawExtra = %s, want nil", rec.RawExtra)
+	}
+}
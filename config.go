@@ -2,7 +2,10 @@ package playbookd
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,35 +16,86 @@ import (
 
 // Config holds the playbookd configuration loaded from a TOML file.
 type Config struct {
-	Embedding EmbeddingConfig `toml:"embedding"`
-	Data      DataConfig      `toml:"data"`
-	Manager   ManagerCfg      `toml:"manager"`
+	Embedding EmbeddingConfig        `toml:"embedding"`
+	Data      DataConfig             `toml:"data"`
+	Index     IndexCfg               `toml:"index"`
+	Manager   ManagerCfg             `toml:"manager"`
+	Templates map[string]TemplateCfg `toml:"templates"` // keyed by category
+	Category  []CategoryCfg          `toml:"category"`  // [[category]] confidence threshold overrides
+}
+
+// CategoryCfg overrides confidence thresholds for one category. Zero or
+// omitted thresholds fall back to the global defaults.
+type CategoryCfg struct {
+	Name                  string  `toml:"name"`
+	PositiveMinConfidence float64 `toml:"positive_min_confidence"` // proven in contrastive search
+	NegativeMaxConfidence float64 `toml:"negative_max_confidence"` // failed in contrastive search
+	PruneMinConfidence    float64 `toml:"prune_min_confidence"`    // prunable below this
+}
+
+// TemplateCfg configures the required steps for one category.
+type TemplateCfg struct {
+	Mode          string   `toml:"mode"` // "validate" (default) or "scaffold"
+	RequiredSteps []string `toml:"required_steps"`
 }
 
 // EmbeddingConfig configures the embedding provider.
 type EmbeddingConfig struct {
-	Provider   string `toml:"provider"` // "google", "openai", "ollama", "noop"
-	Mode       string `toml:"mode"`     // "api" or "local"
-	Model      string `toml:"model"`
-	APIKey     string `toml:"api_key"` // supports ${ENV_VAR} expansion
-	URL        string `toml:"url"`
-	Dimensions int    `toml:"dimensions"`
+	Provider    string `toml:"provider"` // "google", "openai", "ollama", "local", "noop"
+	Mode        string `toml:"mode"`     // "api" or "local"
+	Model       string `toml:"model"`    // for "local", the path to the .onnx model
+	APIKey      string `toml:"api_key"`  // supports ${ENV_VAR} expansion
+	URL         string `toml:"url"`
+	Dimensions  int    `toml:"dimensions"`
+	Timeout     string `toml:"timeout"`      // HTTP request timeout, e.g. "2m" (default: 30s)
+	VocabPath   string `toml:"vocab_path"`   // "local" only; default: vocab.txt next to the model
+	LibraryPath string `toml:"library_path"` // "local" only; onnxruntime shared library
+
+	// Path and Headers adapt the "openai" provider to OpenAI-compatible
+	// servers and gateways ([embedding.headers]; values support ${ENV_VAR}).
+	Path    string            `toml:"path"` // default: "/embeddings"
+	Headers map[string]string `toml:"headers"`
+
+	// Fallback is used when this provider returns an error ([embedding.fallback]).
+	Fallback *EmbeddingConfig `toml:"fallback"`
 }
 
 // DataConfig configures data storage.
 type DataConfig struct {
-	Dir string `toml:"dir"` // default: "./playbooks"
+	Dir     string `toml:"dir"`     // default: "./playbooks"; with backend "postgres", holds only the index
+	Backend string `toml:"backend"` // "file" (default) or "postgres"
+	DSN     string `toml:"dsn"`     // "postgres" only; supports ${ENV_VAR} expansion
+
+	SeparateEmbeddings bool `toml:"separate_embeddings"` // "file" only: store embeddings in sidecar files, out of the playbook JSON
+}
+
+// IndexCfg configures the search index.
+type IndexCfg struct {
+	Analyzer  string              `toml:"analyzer"`   // Bleve analyzer for text fields, e.g. "de" (default: "en")
+	StopWords []string            `toml:"stop_words"` // Extra words to ignore
+	Synonyms  map[string][]string `toml:"synonyms"`   // [index.synonyms] word = ["equivalent", ...]
 }
 
 // ManagerCfg configures the PlaybookManager behavior.
 type ManagerCfg struct {
-	AutoReflect   bool    `toml:"auto_reflect"`
-	MaxAge        string  `toml:"max_age"` // duration string like "90d"
-	MinConfidence float64 `toml:"min_confidence"`
+	AutoReflect            bool    `toml:"auto_reflect"`
+	AutoHealthTags         bool    `toml:"auto_health_tags"`
+	MaxTags                int     `toml:"max_tags"`              // 0 = unbounded
+	MaxDescriptionChars    int     `toml:"max_description_chars"` // 0 = unbounded
+	MaxSteps               int     `toml:"max_steps"`             // 0 = unbounded
+	MaxStepActionChars     int     `toml:"max_step_action_chars"` // 0 = unbounded
+	MaxLessons             int     `toml:"max_lessons"`           // 0 = unbounded
+	MaxAge                 string  `toml:"max_age"`               // duration string like "90d"
+	MinConfidence          float64 `toml:"min_confidence"`
+	ConfidenceZ            float64 `toml:"confidence_z"`             // 0 = default (1.96, a 95% interval)
+	ColdExecutionThreshold int     `toml:"cold_execution_threshold"` // 0 = default (5)
+	Actor                  string  `toml:"actor"`                    // recorded as created_by/updated_by; supports ${ENV_VAR} expansion
+	SearchCacheSize        int     `toml:"search_cache_size"`        // 0 = no search cache
+	MaxSearchResults       int     `toml:"max_search_results"`       // 0 = default (100)
 }
 
 // LoadConfig reads a TOML file at path and returns a parsed Config.
-// Environment variables referenced as ${VAR_NAME} in the api_key field are expanded.
+// Environment variables referenced as ${VAR_NAME} in the api_key, headers, dsn, and actor fields are expanded.
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -53,62 +107,314 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
 
-	cfg.Embedding.APIKey = expandEnvVars(cfg.Embedding.APIKey)
+	for e := &cfg.Embedding; e != nil; e = e.Fallback {
+		e.APIKey = expandEnvVars(e.APIKey)
+		for k, v := range e.Headers {
+			e.Headers[k] = expandEnvVars(v)
+		}
+	}
+	cfg.Data.DSN = expandEnvVars(cfg.Data.DSN)
+	cfg.Manager.Actor = expandEnvVars(cfg.Manager.Actor)
 
 	return &cfg, nil
 }
 
 // BuildEmbedFunc constructs an EmbeddingFunc from the embedding configuration.
+// If [embedding.fallback] is set, its provider is used when the primary fails.
 func (c *Config) BuildEmbedFunc() (embed.EmbeddingFunc, error) {
-	switch c.Embedding.Provider {
+	fn, err := c.Embedding.build("embedding")
+	if err != nil {
+		return nil, err
+	}
+	key := "embedding"
+	for fb := c.Embedding.Fallback; fb != nil; fb = fb.Fallback {
+		key += ".fallback"
+		secondary, err := fb.build(key)
+		if err != nil {
+			return nil, err
+		}
+		fn = embed.Fallback(fn, secondary)
+	}
+	return fn, nil
+}
+
+// build constructs the EmbeddingFunc for this provider alone, ignoring any
+// fallback. key names the table in error messages.
+func (e *EmbeddingConfig) build(key string) (embed.EmbeddingFunc, error) {
+	timeout, err := ParseDuration(e.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%s.timeout: %w", key, err)
+	}
+
+	switch e.Provider {
 	case "noop", "":
 		return embed.Noop(), nil
 	case "openai":
 		return embed.OpenAI(embed.OpenAIConfig{
-			URL:    c.Embedding.URL,
-			APIKey: c.Embedding.APIKey,
-			Model:  c.Embedding.Model,
+			URL:     e.URL,
+			APIKey:  e.APIKey,
+			Model:   e.Model,
+			Path:    e.Path,
+			Headers: e.Headers,
+			Timeout: timeout,
 		}), nil
 	case "ollama":
 		return embed.Ollama(embed.OllamaConfig{
-			URL:   c.Embedding.URL,
-			Model: c.Embedding.Model,
+			URL:     e.URL,
+			Model:   e.Model,
+			Timeout: timeout,
 		}), nil
 	case "google":
 		return embed.Google(embed.GoogleConfig{
-			URL:    c.Embedding.URL,
-			APIKey: c.Embedding.APIKey,
-			Model:  c.Embedding.Model,
+			URL:     e.URL,
+			APIKey:  e.APIKey,
+			Model:   e.Model,
+			Timeout: timeout,
 		}), nil
+	case "local":
+		fn, err := embed.Local(embed.LocalConfig{
+			ModelPath:   e.Model,
+			VocabPath:   e.VocabPath,
+			Dimensions:  e.Dimensions,
+			LibraryPath: e.LibraryPath,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: provider \"local\": %w", key, err)
+		}
+		return fn, nil
 	default:
-		return nil, fmt.Errorf("unknown embedding provider: %q", c.Embedding.Provider)
+		return nil, fmt.Errorf("unknown embedding provider: %q", e.Provider)
 	}
 }
 
-// BuildManagerConfig constructs a ManagerConfig from the loaded configuration.
+// EmbedModelName returns a "provider/model" identifier for the configured
+// embedding model, resolving an empty model to the provider's default.
+// It returns an empty string for the noop provider.
+func (c *Config) EmbedModelName() string {
+	model := c.Embedding.Model
+	switch c.Embedding.Provider {
+	case "noop", "":
+		return ""
+	case "openai":
+		if model == "" {
+			model = embed.DefaultOpenAIModel
+		}
+	case "ollama":
+		if model == "" {
+			model = embed.DefaultOllamaModel
+		}
+	case "google":
+		if model == "" {
+			model = embed.DefaultGoogleModel
+		}
+	case "local":
+		model = strings.TrimSuffix(filepath.Base(model), filepath.Ext(model))
+	}
+	return c.Embedding.Provider + "/" + model
+}
+
+// Validate checks the configuration for values that would otherwise fail later,
+// such as at the first embedding call, and returns the first problem found.
+func (c *Config) Validate() error {
+	if err := c.Embedding.validate("embedding"); err != nil {
+		return err
+	}
+	key, dims := "embedding", c.Embedding.Dimensions
+	for fb := c.Embedding.Fallback; fb != nil; fb = fb.Fallback {
+		key += ".fallback"
+		if err := fb.validate(key); err != nil {
+			return err
+		}
+		// The index holds vectors of one length, so every provider in the chain must match.
+		if fb.Dimensions != dims {
+			return fmt.Errorf("%s.dimensions (%d) must match embedding.dimensions (%d)", key, fb.Dimensions, dims)
+		}
+	}
+
+	switch c.Data.Backend {
+	case "", "file":
+	case "postgres":
+		if c.Data.DSN == "" {
+			return fmt.Errorf("data.dsn is required for backend \"postgres\"")
+		}
+		if c.Data.SeparateEmbeddings {
+			return fmt.Errorf("data.separate_embeddings applies only to backend \"file\"")
+		}
+	default:
+		return fmt.Errorf("data.backend must be \"file\" or \"postgres\", got %q", c.Data.Backend)
+	}
+
+	if c.Index.Analyzer != "" {
+		if err := ValidateAnalyzer(c.Index.Analyzer); err != nil {
+			return fmt.Errorf("index.analyzer: %w", err)
+		}
+	}
+
+	m := c.Manager
+	if m.MinConfidence < 0 || m.MinConfidence > 1 {
+		return fmt.Errorf("manager.min_confidence must be between 0 and 1, got %g", m.MinConfidence)
+	}
+	if m.ConfidenceZ < 0 {
+		return fmt.Errorf("manager.confidence_z must not be negative, got %g", m.ConfidenceZ)
+	}
+	if _, err := ParseDuration(m.MaxAge); err != nil {
+		return fmt.Errorf("manager.max_age: %w", err)
+	}
+
+	seen := make(map[string]bool, len(c.Category))
+	for i, cat := range c.Category {
+		if cat.Name == "" {
+			return fmt.Errorf("category[%d].name is empty", i)
+		}
+		if seen[cat.Name] {
+			return fmt.Errorf("category %q is configured more than once", cat.Name)
+		}
+		seen[cat.Name] = true
+		for _, v := range []struct {
+			key   string
+			value float64
+		}{
+			{"positive_min_confidence", cat.PositiveMinConfidence},
+			{"negative_max_confidence", cat.NegativeMaxConfidence},
+			{"prune_min_confidence", cat.PruneMinConfidence},
+		} {
+			if v.value < 0 || v.value > 1 {
+				return fmt.Errorf("category %q: %s must be between 0 and 1, got %g", cat.Name, v.key, v.value)
+			}
+		}
+	}
+
+	for _, category := range slices.Sorted(maps.Keys(c.Templates)) {
+		t := c.Templates[category]
+		switch TemplateMode(t.Mode) {
+		case "", TemplateModeValidate, TemplateModeScaffold:
+		default:
+			return fmt.Errorf("templates.%s.mode %q is not supported (expected \"validate\" or \"scaffold\")", category, t.Mode)
+		}
+		if len(t.RequiredSteps) == 0 {
+			return fmt.Errorf("templates.%s.required_steps is empty", category)
+		}
+	}
+	return nil
+}
+
+// validate checks a single provider's settings; key names the table in error
+// messages.
+func (e *EmbeddingConfig) validate(key string) error {
+	switch e.Provider {
+	case "", "noop", "ollama":
+	case "openai", "google":
+		if e.APIKey == "" {
+			return fmt.Errorf("%s.api_key is required for provider %q (if it references ${VAR}, check that the variable is set)", key, e.Provider)
+		}
+		// Google falls back to its public endpoint; OpenAI-compatible APIs have no default.
+		if e.Provider == "openai" && e.URL == "" {
+			return fmt.Errorf("%s.url is required for provider %q (e.g. \"https://api.openai.com/v1\")", key, e.Provider)
+		}
+	case "local":
+		if e.Model == "" {
+			return fmt.Errorf("%s.model is required for provider \"local\" (path to the .onnx model)", key)
+		}
+		if e.Dimensions <= 0 {
+			return fmt.Errorf("%s.dimensions is required for provider \"local\"", key)
+		}
+	default:
+		return fmt.Errorf("%s.provider %q is not supported (expected \"noop\", \"openai\", \"ollama\", \"google\", or \"local\")", key, e.Provider)
+	}
+	if e.Mode != "" && e.Mode != "api" && e.Mode != "local" {
+		return fmt.Errorf("%s.mode %q is not supported (expected \"api\" or \"local\")", key, e.Mode)
+	}
+	if e.Dimensions < 0 {
+		return fmt.Errorf("%s.dimensions must be non-negative, got %d", key, e.Dimensions)
+	}
+	if _, err := ParseDuration(e.Timeout); err != nil {
+		return fmt.Errorf("%s.timeout: %w", key, err)
+	}
+	return nil
+}
+
+// BuildManagerConfig validates the loaded configuration and constructs a
+// ManagerConfig from it. With [data] backend = "postgres" it connects to the
+// database; the store is closed by the manager's Close.
 func (c *Config) BuildManagerConfig() (ManagerConfig, error) {
+	if err := c.Validate(); err != nil {
+		return ManagerConfig{}, fmt.Errorf("invalid config: %w", err)
+	}
+
 	embedFunc, err := c.BuildEmbedFunc()
 	if err != nil {
 		return ManagerConfig{}, fmt.Errorf("build embed func: %w", err)
 	}
 
-	maxAge, err := parseMaxAge(c.Manager.MaxAge)
+	maxAge, err := ParseDuration(c.Manager.MaxAge)
 	if err != nil {
 		return ManagerConfig{}, fmt.Errorf("parse max_age: %w", err)
 	}
 
+	var templates map[string]CategoryTemplate
+	if len(c.Templates) > 0 {
+		templates = make(map[string]CategoryTemplate, len(c.Templates))
+		for category, t := range c.Templates {
+			mode := TemplateMode(t.Mode)
+			if mode == "" {
+				mode = TemplateModeValidate
+			}
+			templates[category] = CategoryTemplate{Mode: mode, RequiredSteps: t.RequiredSteps}
+		}
+	}
+
+	var thresholds map[string]ThresholdSet
+	if len(c.Category) > 0 {
+		thresholds = make(map[string]ThresholdSet, len(c.Category))
+		for _, cat := range c.Category {
+			thresholds[cat.Name] = ThresholdSet{
+				PositiveMinConfidence: cat.PositiveMinConfidence,
+				NegativeMaxConfidence: cat.NegativeMaxConfidence,
+				PruneMinConfidence:    cat.PruneMinConfidence,
+			}
+		}
+	}
+
 	dataDir := c.Data.Dir
 	if dataDir == "" {
 		dataDir = "./playbooks"
 	}
 
+	var store Store
+	if c.Data.Backend == "postgres" {
+		ps, err := NewPostgresStore(c.Data.DSN)
+		if err != nil {
+			return ManagerConfig{}, err
+		}
+		store = ps
+	}
+
 	return ManagerConfig{
-		DataDir:       dataDir,
-		EmbedFunc:     embedFunc,
-		EmbedDims:     c.Embedding.Dimensions,
-		AutoReflect:   c.Manager.AutoReflect,
-		MaxAge:        maxAge,
-		MinConfidence: c.Manager.MinConfidence,
+		DataDir:                dataDir,
+		Store:                  store,
+		SeparateEmbeddings:     c.Data.SeparateEmbeddings,
+		EmbedFunc:              embedFunc,
+		EmbedDims:              c.Embedding.Dimensions,
+		EmbedModel:             c.EmbedModelName(),
+		IndexAnalyzer:          c.Index.Analyzer,
+		IndexStopWords:         c.Index.StopWords,
+		IndexSynonyms:          c.Index.Synonyms,
+		AutoReflect:            c.Manager.AutoReflect,
+		AutoHealthTags:         c.Manager.AutoHealthTags,
+		MaxTags:                c.Manager.MaxTags,
+		MaxDescriptionChars:    c.Manager.MaxDescriptionChars,
+		MaxSteps:               c.Manager.MaxSteps,
+		MaxStepActionChars:     c.Manager.MaxStepActionChars,
+		MaxLessons:             c.Manager.MaxLessons,
+		MaxAge:                 maxAge,
+		MinConfidence:          c.Manager.MinConfidence,
+		ConfidenceZ:            c.Manager.ConfidenceZ,
+		ColdExecutionThreshold: c.Manager.ColdExecutionThreshold,
+		SearchCacheSize:        c.Manager.SearchCacheSize,
+		MaxSearchResults:       c.Manager.MaxSearchResults,
+		Actor:                  c.Manager.Actor,
+		CategoryTemplates:      templates,
+		CategoryThresholds:     thresholds,
 	}, nil
 }
 
@@ -120,23 +426,30 @@ func expandEnvVars(s string) string {
 	})
 }
 
-// parseMaxAge parses a duration string in "Nd" format (e.g. "90d") into a time.Duration.
-// An empty string returns zero.
-func parseMaxAge(s string) (time.Duration, error) {
+// ParseDuration parses a duration such as max_age. It accepts a whole number
+// of days ("90d"), weeks ("2w"), or hours ("12h"), as well as anything
+// time.ParseDuration accepts ("720h", "1h30m"). An empty string returns zero.
+func ParseDuration(s string) (time.Duration, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, nil
 	}
 
-	if !strings.HasSuffix(s, "d") {
-		return 0, fmt.Errorf("unsupported max_age format %q (expected Nd, e.g. \"90d\")", s)
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if unit, ok := units[s[len(s)-1]]; ok {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q (expected e.g. \"90d\", \"2w\", or \"12h\")", s)
+		}
+		return time.Duration(n) * unit, nil
 	}
 
-	numStr := strings.TrimSuffix(s, "d")
-	days, err := strconv.Atoi(numStr)
+	d, err := time.ParseDuration(s)
 	if err != nil {
-		return 0, fmt.Errorf("invalid number of days in max_age %q: %w", s, err)
+		return 0, fmt.Errorf("invalid duration %q (expected e.g. \"90d\", \"2w\", or \"12h\")", s)
 	}
-
-	return time.Duration(days) * 24 * time.Hour, nil
+	return d, nil
 }
@@ -4,17 +4,20 @@ import "context"
 
 // Default thresholds for contrastive search.
 const (
-	DefaultPositiveMinConfidence = 0.5
-	DefaultNegativeMaxConfidence = 0.3
+	DefaultPositiveMinConfidence    = 0.5
+	DefaultNegativeMaxConfidence    = 0.3
+	DefaultContrastiveMinExecutions = 3
 )
 
 // ContrastiveQuery extends SearchQuery with confidence thresholds for splitting
 // results into positive (proven) and negative (failed) groups.
 type ContrastiveQuery struct {
 	SearchQuery
-	PositiveMinConfidence float64 // Minimum confidence for positive group (default 0.5)
-	NegativeMaxConfidence float64 // Maximum confidence for negative group (default 0.3)
+	PositiveMinConfidence float64 // Minimum confidence for positive group (default: the category's threshold, else 0.5)
+	NegativeMaxConfidence float64 // Maximum confidence for negative group (default: the category's threshold, else 0.3)
 	IncludeNeutral        bool    // Whether to include neutral results
+	MinExecutions         int     // Executions needed for positive/negative; fewer are neutral (default 3, negative = no minimum)
+	ResolveRefs           bool    // Fill in one level of Step.Subplaybooks for positive results, so FormatForContext shows them
 }
 
 // ContrastiveResults holds search results split by confidence into positive,
@@ -30,12 +33,10 @@ type ContrastiveResults struct {
 // positive (high confidence), negative (low confidence), and optionally
 // neutral groups based on the playbook's Wilson confidence score.
 func (pm *PlaybookManager) SearchWithContext(ctx context.Context, cq ContrastiveQuery) (*ContrastiveResults, error) {
-	// Apply defaults
-	if cq.PositiveMinConfidence == 0 {
-		cq.PositiveMinConfidence = DefaultPositiveMinConfidence
-	}
-	if cq.NegativeMaxConfidence == 0 {
-		cq.NegativeMaxConfidence = DefaultNegativeMaxConfidence
+	// Apply defaults; the confidence thresholds also depend on each result's
+	// category, so they are resolved per result below
+	if cq.MinExecutions == 0 {
+		cq.MinExecutions = DefaultContrastiveMinExecutions
 	}
 
 	// Save original limit and search with expanded limit to capture more candidates
@@ -43,8 +44,10 @@ func (pm *PlaybookManager) SearchWithContext(ctx context.Context, cq Contrastive
 	if originalLimit == 0 {
 		originalLimit = DefaultSearchLimit
 	}
-	cq.SearchQuery.Limit = originalLimit * 3
-	cq.SearchQuery.MinScore = 0 // Capture low-quality matches too
+	originalLimit = pm.clampLimit(originalLimit)
+	cq.SearchQuery.Limit = min(originalLimit*3, pm.cfg.MaxSearchResults)
+	cq.SearchQuery.MinScore = NoMinScore // Capture low-quality matches too
+	cq.SearchQuery.SkipHydration = false // Splitting needs each playbook's confidence
 
 	results, err := pm.Search(ctx, cq.SearchQuery)
 	if err != nil {
@@ -55,12 +58,18 @@ func (pm *PlaybookManager) SearchWithContext(ctx context.Context, cq Contrastive
 		Query: cq.Text,
 	}
 
-	// Split by Wilson confidence (real confidence, not blended score)
+	// Split by Wilson confidence (real confidence, not blended score). Playbooks
+	// with too few executions are neither proven nor failed examples.
 	for _, r := range results {
+		execs := r.Playbook.SuccessCount + r.Playbook.FailureCount
 		switch {
-		case r.Playbook.Confidence >= cq.PositiveMinConfidence:
+		case execs < cq.MinExecutions:
+			if cq.IncludeNeutral {
+				cr.Neutral = append(cr.Neutral, r)
+			}
+		case r.Playbook.Confidence >= pm.positiveMinConfidence(r.Playbook, cq.PositiveMinConfidence):
 			cr.Positive = append(cr.Positive, r)
-		case r.Playbook.Confidence <= cq.NegativeMaxConfidence:
+		case r.Playbook.Confidence <= pm.negativeMaxConfidence(r.Playbook, cq.NegativeMaxConfidence):
 			cr.Negative = append(cr.Negative, r)
 		default:
 			if cq.IncludeNeutral {
@@ -80,5 +89,13 @@ func (pm *PlaybookManager) SearchWithContext(ctx context.Context, cq Contrastive
 		cr.Neutral = cr.Neutral[:originalLimit]
 	}
 
+	if cq.ResolveRefs {
+		for _, r := range cr.Positive {
+			if err := pm.resolveRefs(ctx, r.Playbook, 1, []string{r.Playbook.ID}); err != nil {
+				pm.log.Warn("resolve sub-playbooks", "playbook_id", r.Playbook.ID, "error", err)
+			}
+		}
+	}
+
 	return cr, nil
 }
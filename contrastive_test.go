@@ -195,3 +195,63 @@ func TestSearchWithContextLimitsCapping(t *testing.T) {
 		t.Errorf("expected at most 3 positive results, got %d", len(cr.Positive))
 	}
 }
+
+func TestSearchWithContextMinExecutions(t *testing.T) {
+	pm := setupContrastivePlaybooks(t)
+	ctx := context.Background()
+
+	// 1/1 and 0/1 are too few runs to advise on either way.
+	for _, s := range []struct {
+		name                string
+		successes, failures int
+	}{
+		{"Lucky Deployment", 1, 0},
+		{"Unlucky Deployment", 0, 1},
+	} {
+		pb := samplePlaybook(s.name)
+		pb.Description = "A deployment procedure for testing contrastive search"
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("Create %s: %v", s.name, err)
+		}
+		recordOutcomes(t, pm, pb.ID, OutcomeSuccess, s.successes)
+		recordOutcomes(t, pm, pb.ID, OutcomeFailure, s.failures)
+	}
+
+	names := func(results []SearchResult) map[string]bool {
+		m := make(map[string]bool)
+		for _, r := range results {
+			m[r.Playbook.Name] = true
+		}
+		return m
+	}
+
+	cr, err := pm.SearchWithContext(ctx, ContrastiveQuery{
+		SearchQuery:    SearchQuery{Text: "deployment", Mode: SearchModeBM25, Limit: 10},
+		IncludeNeutral: true,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithContext: %v", err)
+	}
+	pos, neg, neutral := names(cr.Positive), names(cr.Negative), names(cr.Neutral)
+	if pos["Lucky Deployment"] || neg["Lucky Deployment"] || neg["Unlucky Deployment"] {
+		t.Errorf("under-sampled playbooks in positive/negative: positive=%v negative=%v", pos, neg)
+	}
+	if !neutral["Lucky Deployment"] || !neutral["Unlucky Deployment"] {
+		t.Errorf("under-sampled playbooks should be neutral, got %v", neutral)
+	}
+	if !pos["Proven Deployment"] || !neg["Failed Deployment"] {
+		t.Errorf("well-sampled playbooks misclassified: positive=%v negative=%v", pos, neg)
+	}
+
+	// A negative minimum disables the filter.
+	cr, err = pm.SearchWithContext(ctx, ContrastiveQuery{
+		SearchQuery:   SearchQuery{Text: "deployment", Mode: SearchModeBM25, Limit: 10},
+		MinExecutions: -1,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithContext: %v", err)
+	}
+	if !names(cr.Negative)["Unlucky Deployment"] {
+		t.Errorf("with no minimum, 0/1 should be negative, got %v", names(cr.Negative))
+	}
+}
@@ -0,0 +1,146 @@
+package playbookd
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FieldChange describes a scalar field that differs between two playbooks.
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StepChange describes a step whose content changed between two playbooks.
+// Steps are matched by Order, not by their position in the slice.
+type StepChange struct {
+	Order int  `json:"order"`
+	From  Step `json:"from"`
+	To    Step `json:"to"`
+}
+
+// PlaybookDiff holds the field-level differences between two playbooks.
+type PlaybookDiff struct {
+	FromVersion    int          `json:"from_version"`
+	ToVersion      int          `json:"to_version"`
+	Name           *FieldChange `json:"name,omitempty"`
+	Description    *FieldChange `json:"description,omitempty"`
+	Category       *FieldChange `json:"category,omitempty"`
+	TagsAdded      []string     `json:"tags_added,omitempty"`
+	TagsRemoved    []string     `json:"tags_removed,omitempty"`
+	StepsAdded     []Step       `json:"steps_added,omitempty"`
+	StepsRemoved   []Step       `json:"steps_removed,omitempty"`
+	StepsChanged   []StepChange `json:"steps_changed,omitempty"`
+	LessonsAdded   []Lesson     `json:"lessons_added,omitempty"`
+	LessonsRemoved []Lesson     `json:"lessons_removed,omitempty"`
+}
+
+// IsEmpty reports whether the diff contains no changes.
+func (d PlaybookDiff) IsEmpty() bool {
+	return d.Name == nil && d.Description == nil && d.Category == nil &&
+		len(d.TagsAdded) == 0 && len(d.TagsRemoved) == 0 &&
+		len(d.StepsAdded) == 0 && len(d.StepsRemoved) == 0 && len(d.StepsChanged) == 0 &&
+		len(d.LessonsAdded) == 0 && len(d.LessonsRemoved) == 0
+}
+
+// DiffPlaybooks returns the changes needed to go from playbook a to playbook b.
+// Steps are matched by Order and lessons by ID (or content when the ID is empty).
+func DiffPlaybooks(a, b *Playbook) PlaybookDiff {
+	d := PlaybookDiff{
+		FromVersion: a.Version,
+		ToVersion:   b.Version,
+		Name:        diffField(a.Name, b.Name),
+		Description: diffField(a.Description, b.Description),
+		Category:    diffField(a.Category, b.Category),
+	}
+
+	d.TagsAdded, d.TagsRemoved = diffStrings(a.Tags, b.Tags)
+
+	fromSteps := make(map[int]Step, len(a.Steps))
+	for _, s := range a.Steps {
+		fromSteps[s.Order] = s
+	}
+	toSteps := make(map[int]Step, len(b.Steps))
+	for _, s := range b.Steps {
+		toSteps[s.Order] = s
+	}
+	for _, s := range b.Steps {
+		old, ok := fromSteps[s.Order]
+		switch {
+		case !ok:
+			d.StepsAdded = append(d.StepsAdded, s)
+		case !reflect.DeepEqual(old, s):
+			d.StepsChanged = append(d.StepsChanged, StepChange{Order: s.Order, From: old, To: s})
+		}
+	}
+	for _, s := range a.Steps {
+		if _, ok := toSteps[s.Order]; !ok {
+			d.StepsRemoved = append(d.StepsRemoved, s)
+		}
+	}
+	sortSteps(d.StepsAdded)
+	sortSteps(d.StepsRemoved)
+	sort.Slice(d.StepsChanged, func(i, j int) bool {
+		return d.StepsChanged[i].Order < d.StepsChanged[j].Order
+	})
+
+	fromLessons := make(map[string]bool, len(a.Lessons))
+	for _, l := range a.Lessons {
+		fromLessons[lessonKey(l)] = true
+	}
+	toLessons := make(map[string]bool, len(b.Lessons))
+	for _, l := range b.Lessons {
+		toLessons[lessonKey(l)] = true
+		if !fromLessons[lessonKey(l)] {
+			d.LessonsAdded = append(d.LessonsAdded, l)
+		}
+	}
+	for _, l := range a.Lessons {
+		if !toLessons[lessonKey(l)] {
+			d.LessonsRemoved = append(d.LessonsRemoved, l)
+		}
+	}
+
+	return d
+}
+
+func diffField(from, to string) *FieldChange {
+	if from == to {
+		return nil
+	}
+	return &FieldChange{From: from, To: to}
+}
+
+// diffStrings returns the values present only in b (added) and only in a (removed).
+func diffStrings(a, b []string) (added, removed []string) {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+		if !inA[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+func sortSteps(steps []Step) {
+	sort.Slice(steps, func(i, j int) bool {
+		return steps[i].Order < steps[j].Order
+	})
+}
+
+func lessonKey(l Lesson) string {
+	if l.ID != "" {
+		return l.ID
+	}
+	return l.Content
+}
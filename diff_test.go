@@ -0,0 +1,127 @@
+package playbookd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiffPlaybooks(t *testing.T) {
+	a := &Playbook{
+		Version:     3,
+		Name:        "Deploy",
+		Description: "Deploy the service",
+		Category:    "ops",
+		Tags:        []string{"deploy", "staging"},
+		Steps: []Step{
+			{Order: 1, Action: "Build"},
+			{Order: 2, Action: "Push"},
+			{Order: 3, Action: "Notify"},
+		},
+		Lessons: []Lesson{{ID: "l1", Content: "cache layers"}},
+	}
+	b := &Playbook{
+		Version:     5,
+		Name:        "Deploy Service",
+		Description: "Deploy the service",
+		Category:    "ops",
+		Tags:        []string{"deploy", "production"},
+		Steps: []Step{
+			{Order: 2, Action: "Push image"},
+			{Order: 1, Action: "Build"},
+			{Order: 4, Action: "Smoke test"},
+		},
+		Lessons: []Lesson{
+			{ID: "l1", Content: "cache layers"},
+			{ID: "l2", Content: "run smoke tests"},
+		},
+	}
+
+	d := DiffPlaybooks(a, b)
+
+	if d.FromVersion != 3 || d.ToVersion != 5 {
+		t.Errorf("versions = %d→%d, want 3→5", d.FromVersion, d.ToVersion)
+	}
+	if d.Name == nil || d.Name.From != "Deploy" || d.Name.To != "Deploy Service" {
+		t.Errorf("Name change = %+v, want Deploy → Deploy Service", d.Name)
+	}
+	if d.Description != nil {
+		t.Errorf("Description change = %+v, want nil", d.Description)
+	}
+	if d.Category != nil {
+		t.Errorf("Category change = %+v, want nil", d.Category)
+	}
+	if len(d.TagsAdded) != 1 || d.TagsAdded[0] != "production" {
+		t.Errorf("TagsAdded = %v, want [production]", d.TagsAdded)
+	}
+	if len(d.TagsRemoved) != 1 || d.TagsRemoved[0] != "staging" {
+		t.Errorf("TagsRemoved = %v, want [staging]", d.TagsRemoved)
+	}
+	if len(d.StepsAdded) != 1 || d.StepsAdded[0].Order != 4 {
+		t.Errorf("StepsAdded = %v, want step 4", d.StepsAdded)
+	}
+	if len(d.StepsRemoved) != 1 || d.StepsRemoved[0].Order != 3 {
+		t.Errorf("StepsRemoved = %v, want step 3", d.StepsRemoved)
+	}
+	// Step 1 moved position but is unchanged; only step 2 changed.
+	if len(d.StepsChanged) != 1 || d.StepsChanged[0].Order != 2 {
+		t.Fatalf("StepsChanged = %v, want step 2", d.StepsChanged)
+	}
+	if d.StepsChanged[0].From.Action != "Push" || d.StepsChanged[0].To.Action != "Push image" {
+		t.Errorf("StepsChanged[0] = %+v", d.StepsChanged[0])
+	}
+	if len(d.LessonsAdded) != 1 || d.LessonsAdded[0].ID != "l2" {
+		t.Errorf("LessonsAdded = %v, want l2", d.LessonsAdded)
+	}
+	if len(d.LessonsRemoved) != 0 {
+		t.Errorf("LessonsRemoved = %v, want none", d.LessonsRemoved)
+	}
+	if d.IsEmpty() {
+		t.Error("IsEmpty() = true, want false")
+	}
+}
+
+func TestDiffPlaybooksIdentical(t *testing.T) {
+	pb := samplePlaybook("Same")
+	if d := DiffPlaybooks(pb, pb); !d.IsEmpty() {
+		t.Errorf("expected empty diff, got %+v", d)
+	}
+}
+
+func TestManagerGetVersion(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Versioned")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	pb.Description = "second revision"
+	if err := pm.Update(ctx, pb); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	v1, err := pm.GetVersion(ctx, pb.ID, 1)
+	if err != nil {
+		t.Fatalf("GetVersion(1): %v", err)
+	}
+	if v1.Description != "A playbook for testing: Versioned" {
+		t.Errorf("v1 Description = %q", v1.Description)
+	}
+
+	v2, err := pm.GetVersion(ctx, pb.ID, 2)
+	if err != nil {
+		t.Fatalf("GetVersion(2): %v", err)
+	}
+	if v2.Description != "second revision" {
+		t.Errorf("v2 Description = %q, want %q", v2.Description, "second revision")
+	}
+
+	d := DiffPlaybooks(v1, v2)
+	if d.Description == nil {
+		t.Error("expected description change between v1 and v2")
+	}
+
+	if _, err := pm.GetVersion(ctx, pb.ID, 7); err == nil {
+		t.Error("expected error for unknown version")
+	}
+}
@@ -0,0 +1,89 @@
+package playbookd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// DurationSampleSize is how many of a playbook's most recent executions its
+// duration stats are computed from.
+const DurationSampleSize = 100
+
+// DurationStats summarizes how long a playbook's executions take.
+type DurationStats struct {
+	Runs  int            `json:"runs"` // Executions with a known duration
+	Avg   time.Duration  `json:"avg"`
+	P95   time.Duration  `json:"p95"`
+	Steps []StepDuration `json:"steps,omitempty"` // By step order, for steps that reported a Duration
+}
+
+// StepDuration summarizes how long one step takes across executions.
+type StepDuration struct {
+	StepOrder int           `json:"step_order"`
+	Runs      int           `json:"runs"`
+	Total     time.Duration `json:"total"`
+	Avg       time.Duration `json:"avg"`
+	P95       time.Duration `json:"p95"`
+}
+
+// ExecutionDurations computes duration stats from a playbook's last
+// DurationSampleSize executions, with a breakdown by step. A run lasts from
+// StartedAt to CompletedAt, or, when either is missing, the sum of its step
+// durations. Steps without a Duration are left out of the breakdown.
+func (pm *PlaybookManager) ExecutionDurations(ctx context.Context, id string) (*DurationStats, error) {
+	execs, err := pm.store.ListExecutions(ctx, id, DurationSampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("list executions: %w", err)
+	}
+	return durationStats(execs), nil
+}
+
+// durationStats aggregates the run and step durations of execs.
+func durationStats(execs []*ExecutionRecord) *DurationStats {
+	var runs []time.Duration
+	steps := make(map[int][]time.Duration)
+	for _, rec := range execs {
+		var stepSum time.Duration
+		var timedSteps int
+		for _, sr := range rec.StepResults {
+			if sr.Duration <= 0 {
+				continue
+			}
+			steps[sr.StepOrder] = append(steps[sr.StepOrder], sr.Duration)
+			stepSum += sr.Duration
+			timedSteps++
+		}
+		switch {
+		case !rec.StartedAt.IsZero() && !rec.CompletedAt.IsZero() && !rec.CompletedAt.Before(rec.StartedAt):
+			runs = append(runs, rec.CompletedAt.Sub(rec.StartedAt))
+		case timedSteps > 0:
+			runs = append(runs, stepSum)
+		}
+	}
+
+	stats := &DurationStats{Runs: len(runs)}
+	_, stats.Avg, stats.P95 = summarizeDurations(runs)
+	for _, order := range slices.Sorted(maps.Keys(steps)) {
+		sd := StepDuration{StepOrder: order, Runs: len(steps[order])}
+		sd.Total, sd.Avg, sd.P95 = summarizeDurations(steps[order])
+		stats.Steps = append(stats.Steps, sd)
+	}
+	return stats
+}
+
+// summarizeDurations returns the total, mean, and 95th percentile (nearest
+// rank) of ds, which it sorts. All are zero for no durations.
+func summarizeDurations(ds []time.Duration) (total, avg, p95 time.Duration) {
+	if len(ds) == 0 {
+		return 0, 0, 0
+	}
+	slices.Sort(ds)
+	for _, d := range ds {
+		total += d
+	}
+	rank := (len(ds)*95 + 99) / 100 // ceil(0.95 * n)
+	return total, total / time.Duration(len(ds)), ds[rank-1]
+}
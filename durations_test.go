@@ -0,0 +1,87 @@
+package playbookd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExecutionDurations(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Timed Deploy")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	runs := []struct {
+		took  time.Duration
+		steps []StepResult
+	}{
+		{10 * time.Second, []StepResult{{StepOrder: 1, Duration: 4 * time.Second}, {StepOrder: 2, Duration: 6 * time.Second}}},
+		{20 * time.Second, []StepResult{{StepOrder: 1, Duration: 8 * time.Second}, {StepOrder: 2}}},
+		{30 * time.Second, nil},
+	}
+	for i, r := range runs {
+		began := start.Add(time.Duration(i) * time.Minute)
+		rec := &ExecutionRecord{
+			PlaybookID:  pb.ID,
+			Outcome:     OutcomeSuccess,
+			StartedAt:   began,
+			CompletedAt: began.Add(r.took),
+			StepResults: r.steps,
+		}
+		if err := pm.RecordExecution(ctx, rec); err != nil {
+			t.Fatalf("RecordExecution: %v", err)
+		}
+	}
+	// Without timestamps, a run lasts as long as its steps.
+	if err := pm.RecordExecution(ctx, &ExecutionRecord{
+		PlaybookID:  pb.ID,
+		Outcome:     OutcomeSuccess,
+		StepResults: []StepResult{{StepOrder: 1, Duration: 40 * time.Second}},
+	}); err != nil {
+		t.Fatalf("RecordExecution: %v", err)
+	}
+
+	got, err := pm.ExecutionDurations(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("ExecutionDurations: %v", err)
+	}
+	if got.Runs != 4 || got.Avg != 25*time.Second || got.P95 != 40*time.Second {
+		t.Errorf("runs = %d, avg %v, p95 %v; want 4, 25s, 40s", got.Runs, got.Avg, got.P95)
+	}
+	if len(got.Steps) != 2 {
+		t.Fatalf("Steps = %+v, want 2 steps", got.Steps)
+	}
+	if s := got.Steps[0]; s.StepOrder != 1 || s.Runs != 3 || s.Total != 52*time.Second || s.P95 != 40*time.Second {
+		t.Errorf("step 1 = %+v, want 3 runs totalling 52s with p95 40s", s)
+	}
+	if s := got.Steps[1]; s.StepOrder != 2 || s.Runs != 1 || s.Avg != 6*time.Second {
+		t.Errorf("step 2 = %+v, want 1 run of 6s (the untimed step is ignored)", s)
+	}
+
+	stored, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.AvgDuration != got.Avg || stored.P95Duration != got.P95 {
+		t.Errorf("playbook durations = avg %v, p95 %v; want %v, %v", stored.AvgDuration, stored.P95Duration, got.Avg, got.P95)
+	}
+}
+
+func TestSummarizeDurations(t *testing.T) {
+	var ds []time.Duration
+	for i := 20; i >= 1; i-- {
+		ds = append(ds, time.Duration(i)*time.Second)
+	}
+	total, avg, p95 := summarizeDurations(ds)
+	if total != 210*time.Second || avg != 10500*time.Millisecond || p95 != 19*time.Second {
+		t.Errorf("summarizeDurations = %v, %v, %v; want 3m30s, 10.5s, 19s", total, avg, p95)
+	}
+	if total, avg, p95 := summarizeDurations(nil); total != 0 || avg != 0 || p95 != 0 {
+		t.Errorf("summarizeDurations(nil) = %v, %v, %v; want zeros", total, avg, p95)
+	}
+}
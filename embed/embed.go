@@ -5,12 +5,38 @@ package embed
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
 	"strings"
+	"time"
 )
 
 // EmbeddingFunc generates a vector embedding from text.
 type EmbeddingFunc func(ctx context.Context, text string) ([]float32, error)
 
+// DefaultTimeout is the request timeout used by the HTTP providers when their
+// Timeout is zero.
+const DefaultTimeout = 30 * time.Second
+
+// newHTTPClient returns the client an HTTP provider sends requests with: a
+// copy of client (or a new one if nil) whose timeout is set to timeout. A zero
+// timeout keeps a provided client's own timeout, or uses DefaultTimeout for a
+// new client.
+func newHTTPClient(client *http.Client, timeout time.Duration) *http.Client {
+	if client == nil {
+		if timeout == 0 {
+			timeout = DefaultTimeout
+		}
+		return &http.Client{Timeout: timeout}
+	}
+	c := *client
+	if timeout != 0 {
+		c.Timeout = timeout
+	}
+	return &c
+}
+
 // Noop returns an EmbeddingFunc that always returns nil (BM25-only mode).
 func Noop() EmbeddingFunc {
 	return func(_ context.Context, _ string) ([]float32, error) {
@@ -18,6 +44,44 @@ func Noop() EmbeddingFunc {
 	}
 }
 
+// Fallback returns an EmbeddingFunc that tries primary and, if it fails, logs
+// the error and returns secondary's result instead. Both should produce
+// vectors of the same length; PlaybookManager rejects embeddings whose length
+// differs from ManagerConfig.EmbedDims.
+func Fallback(primary, secondary EmbeddingFunc) EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		emb, err := primary(ctx, text)
+		if err == nil || ctx.Err() != nil {
+			return emb, err
+		}
+		slog.Warn("embedding provider failed, using fallback", "error", err)
+		emb, fbErr := secondary(ctx, text)
+		if fbErr != nil {
+			return nil, fmt.Errorf("%w (fallback: %w)", err, fbErr)
+		}
+		return emb, nil
+	}
+}
+
+// Normalize returns a copy of v scaled to unit L2 length, so that vectors
+// from providers with different magnitudes compare consistently under cosine
+// similarity. A nil or all-zero vector is returned unchanged.
+func Normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := math.Sqrt(sum)
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
 // TextForPlaybook concatenates playbook fields into a single string for embedding.
 // This ensures consistent text representation across indexing and search.
 func TextForPlaybook(name, description string, tags []string, steps []string) string {
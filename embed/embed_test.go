@@ -2,8 +2,13 @@ package embed
 
 import (
 	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNoop(t *testing.T) {
@@ -155,3 +160,111 @@ func TestStepActions(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	in := []float32{3, 4, 0}
+	got := Normalize(in)
+
+	var sum float64
+	for _, x := range got {
+		sum += float64(x) * float64(x)
+	}
+	if math.Abs(math.Sqrt(sum)-1) > 1e-6 {
+		t.Errorf("Normalize(%v) length = %f, want 1", in, math.Sqrt(sum))
+	}
+	if math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("Normalize(%v) = %v, want [0.6 0.8 0]", in, got)
+	}
+	if in[0] != 3 {
+		t.Errorf("Normalize modified its input: %v", in)
+	}
+}
+
+func TestNormalizeZeroVector(t *testing.T) {
+	if got := Normalize(nil); got != nil {
+		t.Errorf("Normalize(nil) = %v, want nil", got)
+	}
+	got := Normalize([]float32{0, 0})
+	if len(got) != 2 || got[0] != 0 || got[1] != 0 {
+		t.Errorf("Normalize([0 0]) = %v, want [0 0]", got)
+	}
+}
+
+func TestHTTPProvidersTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	timeout := 50 * time.Millisecond
+	providers := map[string]EmbeddingFunc{
+		"openai": OpenAI(OpenAIConfig{URL: srv.URL, APIKey: "k", Timeout: timeout}),
+		"google": Google(GoogleConfig{URL: srv.URL, APIKey: "k", Timeout: timeout}),
+		"ollama": Ollama(OllamaConfig{URL: srv.URL, Timeout: timeout}),
+	}
+	for name, fn := range providers {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			_, err := fn(context.Background(), "hello")
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("request took %v, want about %v", elapsed, timeout)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	if c := newHTTPClient(nil, 0); c.Timeout != DefaultTimeout {
+		t.Errorf("default timeout = %v, want %v", c.Timeout, DefaultTimeout)
+	}
+
+	transport := &http.Transport{}
+	custom := &http.Client{Transport: transport, Timeout: time.Minute}
+	if c := newHTTPClient(custom, 0); c.Transport != transport || c.Timeout != time.Minute {
+		t.Errorf("custom client: got transport %v timeout %v, want the client's own", c.Transport, c.Timeout)
+	}
+	c := newHTTPClient(custom, time.Second)
+	if c.Transport != transport || c.Timeout != time.Second {
+		t.Errorf("custom client with timeout: got transport %v timeout %v", c.Transport, c.Timeout)
+	}
+	if custom.Timeout != time.Minute {
+		t.Errorf("newHTTPClient modified the provided client: timeout %v", custom.Timeout)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	ok := func(v float32) EmbeddingFunc {
+		return func(context.Context, string) ([]float32, error) { return []float32{v}, nil }
+	}
+	fail := func(msg string) EmbeddingFunc {
+		return func(context.Context, string) ([]float32, error) { return nil, errors.New(msg) }
+	}
+	ctx := context.Background()
+
+	if got, err := Fallback(ok(1), ok(2))(ctx, "x"); err != nil || got[0] != 1 {
+		t.Errorf("primary succeeds: got %v, %v, want [1]", got, err)
+	}
+	if got, err := Fallback(fail("primary down"), ok(2))(ctx, "x"); err != nil || got[0] != 2 {
+		t.Errorf("primary fails: got %v, %v, want [2]", got, err)
+	}
+	_, err := Fallback(fail("primary down"), fail("fallback down"))(ctx, "x")
+	if err == nil || !strings.Contains(err.Error(), "primary down") || !strings.Contains(err.Error(), "fallback down") {
+		t.Errorf("both fail: error = %v, want both causes", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	called := false
+	secondary := func(context.Context, string) ([]float32, error) { called = true; return nil, nil }
+	if _, err := Fallback(fail("cancelled"), secondary)(cancelled, "x"); err == nil || called {
+		t.Errorf("cancelled context: err = %v, fallback called = %v; want error without fallback", err, called)
+	}
+}
@@ -10,11 +10,16 @@ import (
 	"time"
 )
 
+// DefaultGoogleModel is the model used when GoogleConfig.Model is empty.
+const DefaultGoogleModel = "gemini-embedding-001"
+
 // GoogleConfig configures the Google Gemini embedding provider.
 type GoogleConfig struct {
-	URL    string // Base URL (default: https://generativelanguage.googleapis.com/v1beta)
-	APIKey string // API key
-	Model  string // Model name (default: gemini-embedding-001)
+	URL        string        // Base URL (default: https://generativelanguage.googleapis.com/v1beta)
+	APIKey     string        // API key
+	Model      string        // Model name (default: gemini-embedding-001)
+	Timeout    time.Duration // Request timeout (default: HTTPClient's timeout, or 30s)
+	HTTPClient *http.Client  // Client to send requests with, e.g. for a proxy (default: a new client)
 }
 
 type googleRequestPart struct {
@@ -43,10 +48,10 @@ func Google(cfg GoogleConfig) EmbeddingFunc {
 		cfg.URL = "https://generativelanguage.googleapis.com/v1beta"
 	}
 	if cfg.Model == "" {
-		cfg.Model = "gemini-embedding-001"
+		cfg.Model = DefaultGoogleModel
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(cfg.HTTPClient, cfg.Timeout)
 
 	return func(ctx context.Context, text string) ([]float32, error) {
 		reqBody, err := json.Marshal(googleRequest{
@@ -0,0 +1,38 @@
+package embed
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// LocalConfig configures the in-process ONNX embedding provider.
+type LocalConfig struct {
+	ModelPath   string // Path to the .onnx sentence-embedding model (required)
+	VocabPath   string // Path to the WordPiece vocab.txt (default: vocab.txt next to the model)
+	Dimensions  int    // Embedding dimensions produced by the model (required)
+	LibraryPath string // Path to the onnxruntime shared library (default: platform lookup)
+	MaxTokens   int    // Max tokens per input, including [CLS] and [SEP] (default: 256)
+}
+
+// DefaultLocalMaxTokens is the input length used when LocalConfig.MaxTokens is zero.
+const DefaultLocalMaxTokens = 256
+
+// ErrLocalUnavailable is returned by Local when the binary was built without
+// the onnx build tag.
+var ErrLocalUnavailable = errors.New("local embedding provider is not available: rebuild with -tags onnx")
+
+func (cfg *LocalConfig) applyDefaults() error {
+	if cfg.ModelPath == "" {
+		return errors.New("local embedder: model path is required")
+	}
+	if cfg.Dimensions <= 0 {
+		return errors.New("local embedder: dimensions must be positive")
+	}
+	if cfg.VocabPath == "" {
+		cfg.VocabPath = filepath.Join(filepath.Dir(cfg.ModelPath), "vocab.txt")
+	}
+	if cfg.MaxTokens == 0 {
+		cfg.MaxTokens = DefaultLocalMaxTokens
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+//go:build onnx
+
+package embed
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// onnxInitMu guards the process-wide onnxruntime environment.
+var onnxInitMu sync.Mutex
+
+// Local returns an EmbeddingFunc that runs a sentence-embedding ONNX model in
+// process, so no embedding endpoint needs to be reachable. The model must take
+// input_ids and attention_mask (and optionally token_type_ids) and produce
+// either a pooled "sentence_embedding" output or per-token hidden states,
+// which are mean-pooled.
+func Local(cfg LocalConfig) (EmbeddingFunc, error) {
+	if err := cfg.applyDefaults(); err != nil {
+		return nil, err
+	}
+
+	tokenizer, err := loadWordPiece(cfg.VocabPath)
+	if err != nil {
+		return nil, fmt.Errorf("local embedder: %w", err)
+	}
+
+	onnxInitMu.Lock()
+	if !ort.IsInitialized() {
+		if cfg.LibraryPath != "" {
+			ort.SetSharedLibraryPath(cfg.LibraryPath)
+		}
+		err = ort.InitializeEnvironment()
+	}
+	onnxInitMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("local embedder: initialize onnxruntime: %w", err)
+	}
+
+	inputs, outputs, err := ort.GetInputOutputInfo(cfg.ModelPath)
+	if err != nil {
+		return nil, fmt.Errorf("local embedder: inspect model: %w", err)
+	}
+	inputNames := []string{"input_ids", "attention_mask"}
+	withTypeIDs := slices.ContainsFunc(inputs, func(i ort.InputOutputInfo) bool { return i.Name == "token_type_ids" })
+	if withTypeIDs {
+		inputNames = append(inputNames, "token_type_ids")
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("local embedder: model has no outputs")
+	}
+	outputName := outputs[0].Name
+	for _, o := range outputs {
+		if o.Name == "sentence_embedding" {
+			outputName = o.Name
+		}
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(cfg.ModelPath, inputNames, []string{outputName}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("local embedder: load model: %w", err)
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		ids := tokenizer.encode(text, cfg.MaxTokens)
+		shape := ort.NewShape(1, int64(len(ids)))
+		mask := make([]int64, len(ids))
+		for i := range mask {
+			mask[i] = 1
+		}
+
+		values := make([]ort.Value, 0, len(inputNames))
+		defer func() {
+			for _, v := range values {
+				v.Destroy()
+			}
+		}()
+		for _, data := range [][]int64{ids, mask, make([]int64, len(ids))}[:len(inputNames)] {
+			t, err := ort.NewTensor(shape, data)
+			if err != nil {
+				return nil, fmt.Errorf("create input tensor: %w", err)
+			}
+			values = append(values, t)
+		}
+
+		out := []ort.Value{nil}
+		if err := session.Run(values, out); err != nil {
+			return nil, fmt.Errorf("run model: %w", err)
+		}
+		defer out[0].Destroy()
+
+		tensor, ok := out[0].(*ort.Tensor[float32])
+		if !ok {
+			return nil, fmt.Errorf("unexpected output type %T", out[0])
+		}
+		embedding, err := poolOutput(tensor.GetData(), tensor.GetShape())
+		if err != nil {
+			return nil, err
+		}
+		if len(embedding) != cfg.Dimensions {
+			return nil, fmt.Errorf("model produced %d dimensions, configured %d", len(embedding), cfg.Dimensions)
+		}
+		return embedding, nil
+	}, nil
+}
+
+// poolOutput turns a model output into a single vector: a [1, dims] output is
+// returned as is, and [1, tokens, dims] hidden states are averaged over tokens.
+func poolOutput(data []float32, shape ort.Shape) ([]float32, error) {
+	switch len(shape) {
+	case 2:
+		return slices.Clone(data[:shape[1]]), nil
+	case 3:
+		tokens, dims := int(shape[1]), int(shape[2])
+		pooled := make([]float32, dims)
+		for t := 0; t < tokens; t++ {
+			for d := 0; d < dims; d++ {
+				pooled[d] += data[t*dims+d]
+			}
+		}
+		for d := range pooled {
+			pooled[d] /= float32(tokens)
+		}
+		return pooled, nil
+	default:
+		return nil, fmt.Errorf("unexpected output shape %v", shape)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !onnx
+
+package embed
+
+// Local returns ErrLocalUnavailable; the in-process embedder is only compiled
+// in with -tags onnx.
+func Local(cfg LocalConfig) (EmbeddingFunc, error) {
+	return nil, ErrLocalUnavailable
+}
@@ -0,0 +1,18 @@
+//go:build !onnx
+
+package embed
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLocalWithoutBuildTag(t *testing.T) {
+	fn, err := Local(LocalConfig{ModelPath: "model.onnx", Dimensions: 384})
+	if !errors.Is(err, ErrLocalUnavailable) {
+		t.Errorf("Local() error = %v, want ErrLocalUnavailable", err)
+	}
+	if fn != nil {
+		t.Error("Local() returned a non-nil EmbeddingFunc")
+	}
+}
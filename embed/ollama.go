@@ -10,10 +10,15 @@ import (
 	"time"
 )
 
+// DefaultOllamaModel is the model used when OllamaConfig.Model is empty.
+const DefaultOllamaModel = "nomic-embed-text-v2-moe"
+
 // OllamaConfig configures the Ollama embedding provider.
 type OllamaConfig struct {
-	URL   string // Base URL (default: http://localhost:11434)
-	Model string // Model name (default: nomic-embed-text-v2-moe)
+	URL        string        // Base URL (default: http://localhost:11434)
+	Model      string        // Model name (default: nomic-embed-text-v2-moe)
+	Timeout    time.Duration // Request timeout (default: HTTPClient's timeout, or 30s)
+	HTTPClient *http.Client  // Client to send requests with, e.g. for a proxy (default: a new client)
 }
 
 type ollamaRequest struct {
@@ -31,10 +36,10 @@ func Ollama(cfg OllamaConfig) EmbeddingFunc {
 		cfg.URL = "http://localhost:11434"
 	}
 	if cfg.Model == "" {
-		cfg.Model = "nomic-embed-text-v2-moe"
+		cfg.Model = DefaultOllamaModel
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(cfg.HTTPClient, cfg.Timeout)
 
 	return func(ctx context.Context, text string) ([]float32, error) {
 		reqBody, err := json.Marshal(ollamaRequest{
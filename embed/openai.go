@@ -7,14 +7,25 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// DefaultOpenAIModel is the model used when OpenAIConfig.Model is empty.
+const DefaultOpenAIModel = "text-embedding-3-small"
+
+// DefaultOpenAIPath is the endpoint path used when OpenAIConfig.Path is empty.
+const DefaultOpenAIPath = "/embeddings"
+
 // OpenAIConfig configures an OpenAI-compatible embedding provider.
 type OpenAIConfig struct {
-	URL    string // Base URL (e.g., https://api.openai.com/v1)
-	APIKey string
-	Model  string // Model name (default: text-embedding-3-small)
+	URL        string // Base URL (e.g., https://api.openai.com/v1)
+	APIKey     string
+	Model      string            // Model name (default: text-embedding-3-small)
+	Path       string            // Endpoint path appended to URL (default: /embeddings)
+	Headers    map[string]string // Extra request headers, e.g. for a gateway; they override the defaults
+	Timeout    time.Duration     // Request timeout (default: HTTPClient's timeout, or 30s)
+	HTTPClient *http.Client      // Client to send requests with, e.g. for a proxy (default: a new client)
 }
 
 type openaiRequest struct {
@@ -28,13 +39,21 @@ type openaiResponse struct {
 	} `json:"data"`
 }
 
-// OpenAI returns an EmbeddingFunc that calls an OpenAI-compatible API.
+// OpenAI returns an EmbeddingFunc that calls an OpenAI-compatible API, such
+// as OpenAI itself or a self-hosted server like vLLM, LocalAI, or TEI. Path
+// and Headers adapt it to servers and gateways that expect a different
+// endpoint or extra headers.
 func OpenAI(cfg OpenAIConfig) EmbeddingFunc {
 	if cfg.Model == "" {
-		cfg.Model = "text-embedding-3-small"
+		cfg.Model = DefaultOpenAIModel
+	}
+	if cfg.Path == "" {
+		cfg.Path = DefaultOpenAIPath
+	} else if !strings.HasPrefix(cfg.Path, "/") {
+		cfg.Path = "/" + cfg.Path
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient(cfg.HTTPClient, cfg.Timeout)
 
 	return func(ctx context.Context, text string) ([]float32, error) {
 		reqBody, err := json.Marshal(openaiRequest{
@@ -45,7 +64,7 @@ func OpenAI(cfg OpenAIConfig) EmbeddingFunc {
 			return nil, fmt.Errorf("marshal request: %w", err)
 		}
 
-		url := cfg.URL + "/embeddings"
+		url := cfg.URL + cfg.Path
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
 		if err != nil {
 			return nil, fmt.Errorf("create request: %w", err)
@@ -54,6 +73,9 @@ func OpenAI(cfg OpenAIConfig) EmbeddingFunc {
 		if cfg.APIKey != "" {
 			req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
 		}
+		for k, v := range cfg.Headers {
+			req.Header.Set(k, v)
+		}
 
 		resp, err := client.Do(req)
 		if err != nil {
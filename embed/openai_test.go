@@ -0,0 +1,81 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAISuccess(t *testing.T) {
+	want := []float64{0.25, -0.5, 0.75}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %s, want /v1/embeddings", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want Bearer test-key", got)
+		}
+		var req openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Model != DefaultOpenAIModel || req.Input != "hello world" {
+			t.Errorf("request = %+v, want the default model and the input text", req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{{"embedding": want}},
+		})
+	}))
+	defer srv.Close()
+
+	fn := OpenAI(OpenAIConfig{URL: srv.URL + "/v1", APIKey: "test-key"})
+
+	got, err := fn(context.Background(), "hello world")
+	if err != nil {
+		t.Fatalf("OpenAI() returned error: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("embedding length = %d, want %d", len(got), len(want))
+	}
+	for i, v := range want {
+		if got[i] != float32(v) {
+			t.Errorf("embedding[%d] = %v, want %v", i, got[i], float32(v))
+		}
+	}
+}
+
+func TestOpenAICustomPathAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gateway/v2/embed" {
+			t.Errorf("path = %s, want /gateway/v2/embed", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Tenant"); got != "acme" {
+			t.Errorf("X-Tenant = %q, want acme", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Token gateway-secret" {
+			t.Errorf("Authorization = %q, want the custom header to replace the default", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{{"embedding": []float64{1}}},
+		})
+	}))
+	defer srv.Close()
+
+	fn := OpenAI(OpenAIConfig{
+		URL:    srv.URL + "/gateway",
+		APIKey: "ignored",
+		Path:   "v2/embed",
+		Headers: map[string]string{
+			"X-Tenant":      "acme",
+			"Authorization": "Token gateway-secret",
+		},
+	})
+	if _, err := fn(context.Background(), "hello"); err != nil {
+		t.Fatalf("OpenAI() returned error: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package embed
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// wordPiece is a minimal BERT WordPiece tokenizer: lowercase, split on
+// whitespace and punctuation, then greedily match the longest vocabulary
+// entries, marking continuations with "##". It is enough for the uncased
+// sentence-transformer models commonly exported to ONNX.
+type wordPiece struct {
+	vocab                map[string]int64
+	cls, sep, unk        int64
+	maxInputCharsPerWord int
+}
+
+// loadWordPiece reads a vocab.txt file with one token per line; a token's ID
+// is its line number.
+func loadWordPiece(path string) (*wordPiece, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open vocab: %w", err)
+	}
+	defer f.Close()
+
+	vocab := make(map[string]int64)
+	scanner := bufio.NewScanner(f)
+	for id := int64(0); scanner.Scan(); id++ {
+		vocab[strings.TrimRight(scanner.Text(), "\r")] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read vocab: %w", err)
+	}
+	return newWordPiece(vocab)
+}
+
+func newWordPiece(vocab map[string]int64) (*wordPiece, error) {
+	wp := &wordPiece{vocab: vocab, maxInputCharsPerWord: 100}
+	for token, dst := range map[string]*int64{"[CLS]": &wp.cls, "[SEP]": &wp.sep, "[UNK]": &wp.unk} {
+		id, ok := vocab[token]
+		if !ok {
+			return nil, fmt.Errorf("vocab is missing special token %s", token)
+		}
+		*dst = id
+	}
+	return wp, nil
+}
+
+// encode returns the token IDs for text wrapped in [CLS] ... [SEP], truncated
+// to at most maxTokens IDs (0 = no limit).
+func (wp *wordPiece) encode(text string, maxTokens int) []int64 {
+	ids := []int64{wp.cls}
+	for _, word := range splitWords(strings.ToLower(text)) {
+		ids = append(ids, wp.wordIDs(word)...)
+	}
+	if maxTokens > 0 && len(ids) > maxTokens-1 {
+		ids = ids[:maxTokens-1]
+	}
+	return append(ids, wp.sep)
+}
+
+// wordIDs splits a single word into the longest matching vocabulary pieces,
+// or [UNK] if the word cannot be fully covered.
+func (wp *wordPiece) wordIDs(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > wp.maxInputCharsPerWord {
+		return []int64{wp.unk}
+	}
+
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		var id int64
+		found := false
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, found = wp.vocab[piece]; found {
+				break
+			}
+		}
+		if !found {
+			return []int64{wp.unk}
+		}
+		ids = append(ids, id)
+		start = end
+	}
+	return ids
+}
+
+// splitWords splits text on whitespace and makes each punctuation character a
+// word of its own.
+func splitWords(text string) []string {
+	var words []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			flush()
+			words = append(words, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
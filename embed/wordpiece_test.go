@@ -0,0 +1,49 @@
+package embed
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testVocab(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	tokens := []string{"[PAD]", "[UNK]", "[CLS]", "[SEP]", "deploy", "##ment", "the", "app", ",", "roll", "##back"}
+	if err := os.WriteFile(path, []byte(strings.Join(tokens, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("write vocab: %v", err)
+	}
+	return path
+}
+
+func TestWordPieceEncode(t *testing.T) {
+	wp, err := loadWordPiece(testVocab(t))
+	if err != nil {
+		t.Fatalf("loadWordPiece: %v", err)
+	}
+
+	tests := []struct {
+		text      string
+		maxTokens int
+		want      []int64
+	}{
+		{"Deploy the app", 0, []int64{2, 4, 6, 7, 3}},
+		{"Deployment, rollback", 0, []int64{2, 4, 5, 8, 9, 10, 3}},
+		{"deploy xyz", 0, []int64{2, 4, 1, 3}},
+		{"deploy the app", 3, []int64{2, 4, 3}},
+		{"", 0, []int64{2, 3}},
+	}
+	for _, tt := range tests {
+		if got := wp.encode(tt.text, tt.maxTokens); !slices.Equal(got, tt.want) {
+			t.Errorf("encode(%q, %d) = %v, want %v", tt.text, tt.maxTokens, got, tt.want)
+		}
+	}
+}
+
+func TestWordPieceMissingSpecialTokens(t *testing.T) {
+	if _, err := newWordPiece(map[string]int64{"deploy": 0}); err == nil {
+		t.Error("newWordPiece without [CLS]/[SEP]/[UNK]: expected error")
+	}
+}
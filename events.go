@@ -0,0 +1,70 @@
+package playbookd
+
+import (
+	"time"
+)
+
+// EventType identifies a playbook lifecycle change reported to ManagerConfig.EventHook.
+type EventType string
+
+const (
+	EventCreated       EventType = "created"        // Create, CreateBatch, or Import saved a new playbook
+	EventUpdated       EventType = "updated"        // Update saved a new version
+	EventPromoted      EventType = "promoted"       // SetStatus moved the playbook to active
+	EventDeprecated    EventType = "deprecated"     // SetStatus moved the playbook to deprecated
+	EventStatusChanged EventType = "status_changed" // SetStatus made any other change, such as back to draft
+	EventHealthChanged EventType = "health_changed" // RecordExecution changed the reserved health tag
+	EventArchived      EventType = "archived"       // Prune archived the playbook
+	EventRestored      EventType = "restored"       // Restore un-archived the playbook
+	EventDeleted       EventType = "deleted"        // Delete moved the playbook to the trash
+	EventUntrashed     EventType = "untrashed"      // RestoreFromTrash brought the playbook back
+	EventPurged        EventType = "purged"         // Purge or EmptyTrash deleted the playbook permanently
+)
+
+// Event describes a playbook lifecycle change.
+type Event struct {
+	Type       EventType
+	PlaybookID string
+	Time       time.Time
+	// Details holds type-specific context: "name" for created; "version" for
+	// updated; "from" and "to" for status and health changes (health is
+	// "proven", "experimental", or empty); "reason" for archived.
+	Details map[string]string
+}
+
+// emit calls the configured EventHook, if any. A panicking hook is logged and
+// otherwise ignored so it cannot fail the operation that triggered it.
+func (pm *PlaybookManager) emit(typ EventType, id string, details map[string]string) {
+	if pm.cfg.EventHook == nil {
+		return
+	}
+	ev := Event{Type: typ, PlaybookID: id, Time: time.Now(), Details: details}
+	defer func() {
+		if r := recover(); r != nil {
+			pm.log.Error("event hook panicked", "event", typ, "playbook_id", id, "panic", r)
+		}
+	}()
+	pm.cfg.EventHook(ev)
+}
+
+// statusEventType returns the event reported when SetStatus moves a playbook to s.
+func statusEventType(s Status) EventType {
+	switch s {
+	case StatusActive:
+		return EventPromoted
+	case StatusDeprecated:
+		return EventDeprecated
+	default:
+		return EventStatusChanged
+	}
+}
+
+// healthTag returns the reserved health tag in tags, or "" if there is none.
+func healthTag(tags []string) string {
+	for _, t := range tags {
+		if isHealthTag(t) {
+			return t
+		}
+	}
+	return ""
+}
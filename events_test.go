@@ -0,0 +1,83 @@
+package playbookd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newEventManager(t *testing.T, hook func(Event)) *PlaybookManager {
+	t.Helper()
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:        t.TempDir(),
+		AutoHealthTags: true,
+		EventHook:      hook,
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	return pm
+}
+
+func TestManagerEventHook(t *testing.T) {
+	var events []Event
+	pm := newEventManager(t, func(ev Event) { events = append(events, ev) })
+	ctx := context.Background()
+
+	pb := samplePlaybook("Evented")
+	pb.Status = StatusDraft
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := pm.SetStatus(ctx, pb.ID, StatusActive); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	// Five successes leave "experimental" before confidence reaches "proven" at six.
+	for range ProvenMinExecutions + 1 {
+		rec := &ExecutionRecord{PlaybookID: pb.ID, Outcome: OutcomeSuccess, StartedAt: time.Now(), CompletedAt: time.Now()}
+		if err := pm.RecordExecution(ctx, rec); err != nil {
+			t.Fatalf("RecordExecution: %v", err)
+		}
+	}
+	if err := pm.SetStatus(ctx, pb.ID, StatusDeprecated); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+
+	want := []struct {
+		typ      EventType
+		from, to string
+	}{
+		{EventCreated, "", ""},
+		{EventPromoted, "draft", "active"},
+		{EventHealthChanged, TagExperimental, ""},
+		{EventHealthChanged, "", TagProven},
+		{EventDeprecated, "active", "deprecated"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events %+v, want %d", len(events), events, len(want))
+	}
+	for i, w := range want {
+		ev := events[i]
+		if ev.Type != w.typ || ev.PlaybookID != pb.ID || ev.Time.IsZero() {
+			t.Errorf("event %d = %+v, want type %s for %s", i, ev, w.typ, pb.ID)
+		}
+		if ev.Details["from"] != w.from || ev.Details["to"] != w.to {
+			t.Errorf("event %d details = %v, want from %q to %q", i, ev.Details, w.from, w.to)
+		}
+	}
+	if events[0].Details["name"] != pb.Name {
+		t.Errorf("created name = %q, want %q", events[0].Details["name"], pb.Name)
+	}
+}
+
+func TestManagerEventHookPanic(t *testing.T) {
+	pm := newEventManager(t, func(Event) { panic("hook failed") })
+
+	if err := pm.Create(context.Background(), samplePlaybook("Survivor")); err != nil {
+		t.Fatalf("Create with a panicking hook: %v", err)
+	}
+}
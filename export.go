@@ -0,0 +1,199 @@
+package playbookd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ImportBatchSize is how many imported playbooks are indexed together.
+const ImportBatchSize = 100
+
+// ImportResult reports what an import did.
+type ImportResult struct {
+	Imported int
+	Skipped  []string        `json:",omitempty"` // IDs already in the store, left untouched
+	Failed   []ImportFailure `json:",omitempty"` // Entries that could not be imported
+}
+
+// ImportFailure identifies an entry that could not be imported.
+type ImportFailure struct {
+	Line  int    // 1-based line for ImportStream, position in the slice for Import
+	Name  string `json:",omitempty"`
+	Error string
+}
+
+// ExportStream writes every playbook, archived ones included, to w as JSON
+// Lines: one playbook per line, embeddings and stats included. Playbooks are
+// loaded one at a time, so memory use does not grow with the collection.
+func (pm *PlaybookManager) ExportStream(ctx context.Context, w io.Writer) error {
+	ids, err := pm.store.ListPlaybookIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("list playbooks: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pb, err := pm.store.GetPlaybook(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue // deleted since it was listed
+		}
+		if err != nil {
+			return fmt.Errorf("get playbook %s: %w", id, err)
+		}
+		if err := enc.Encode(pb); err != nil {
+			return fmt.Errorf("write playbook %s: %w", id, err)
+		}
+	}
+	return bw.Flush()
+}
+
+// ImportStream reads playbooks written by ExportStream from r, one JSON
+// object per line, and imports them as Import does. Blank lines are ignored.
+// Lines are processed as they are read and indexed in batches of
+// ImportBatchSize, so memory use does not grow with the input. A line that
+// does not parse is reported in Failed; only an error reading r or from the
+// index stops the import.
+func (pm *PlaybookManager) ImportStream(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	imp, err := pm.newImporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return imp.result, err
+		}
+		data, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return imp.result, fmt.Errorf("read line %d: %w", line, readErr)
+		}
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			var pb Playbook
+			if err := json.Unmarshal(data, &pb); err != nil {
+				imp.fail(line, "", err)
+			} else if err := imp.add(ctx, line, &pb); err != nil {
+				return imp.result, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+	return imp.result, imp.flush(ctx)
+}
+
+// Import restores exported playbooks. Each is prepared as Create prepares a
+// new one, category template and validation included, but keeps its ID,
+// version, stats, lessons, and timestamps, and reuses its embedding when it
+// was generated from the same text by the configured model. Playbooks
+// whose ID is already in the store are skipped; a slug that is already taken
+// gets a number appended. Invalid playbooks are reported in Failed and the
+// rest are still imported.
+func (pm *PlaybookManager) Import(ctx context.Context, pbs []*Playbook) (*ImportResult, error) {
+	imp, err := pm.newImporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, pb := range pbs {
+		if err := ctx.Err(); err != nil {
+			return imp.result, err
+		}
+		if err := imp.add(ctx, i, pb); err != nil {
+			return imp.result, err
+		}
+	}
+	return imp.result, imp.flush(ctx)
+}
+
+// importer carries the state of one Import or ImportStream call.
+type importer struct {
+	pm      *PlaybookManager
+	ids     map[string]bool
+	slugs   map[string]bool
+	pending []*Playbook // saved, awaiting indexing
+	result  *ImportResult
+}
+
+func (pm *PlaybookManager) newImporter(ctx context.Context) (*importer, error) {
+	existing, err := pm.store.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
+	if err != nil {
+		return nil, fmt.Errorf("list playbooks: %w", err)
+	}
+	imp := &importer{
+		pm:     pm,
+		ids:    make(map[string]bool, len(existing)),
+		slugs:  make(map[string]bool, len(existing)),
+		result: &ImportResult{},
+	}
+	for _, pb := range existing {
+		imp.ids[pb.ID] = true
+		imp.slugs[pb.Slug] = true
+	}
+	return imp, nil
+}
+
+func (imp *importer) fail(pos int, name string, err error) {
+	imp.result.Failed = append(imp.result.Failed, ImportFailure{Line: pos, Name: name, Error: err.Error()})
+}
+
+// add saves one playbook, as Create does but keeping its timestamps and a
+// still valid embedding, and queues it for indexing. Problems with the
+// playbook itself are recorded as failures; the returned error is only for
+// failures that should stop the import.
+func (imp *importer) add(ctx context.Context, pos int, pb *Playbook) error {
+	pm := imp.pm
+	if imp.ids[pb.ID] {
+		imp.result.Skipped = append(imp.result.Skipped, pb.ID)
+		return nil
+	}
+
+	base := pb.Slug
+	if base == "" {
+		base = slugify(pb.Name)
+	}
+	pb.Slug = base
+	for n := 2; imp.slugs[pb.Slug]; n++ {
+		pb.Slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	pb.DeletedAt = nil
+	if err := pm.prepareAndSave(ctx, pb, CreateOptions{PreserveTimestamps: true, reuseEmbedding: true}); err != nil {
+		imp.fail(pos, pb.Name, err)
+		return nil
+	}
+
+	imp.ids[pb.ID] = true
+	imp.slugs[pb.Slug] = true
+	imp.result.Imported++
+	pm.metrics.PlaybookCreated()
+	pm.emit(EventCreated, pb.ID, map[string]string{"name": pb.Name})
+	if !pb.Archived {
+		imp.pending = append(imp.pending, pb)
+	}
+	if len(imp.pending) >= ImportBatchSize {
+		return imp.flush(ctx)
+	}
+	return nil
+}
+
+// flush indexes the playbooks saved since the last flush.
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+	if err := imp.pm.indexer.Reindex(ctx, imp.pending); err != nil {
+		return fmt.Errorf("index playbooks: %w", err)
+	}
+	imp.pending = imp.pending[:0]
+	return nil
+}
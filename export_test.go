@@ -0,0 +1,151 @@
+package playbookd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportStream(t *testing.T) {
+	src := newTestManager(t)
+	ctx := context.Background()
+
+	deploy := samplePlaybook("Deploy Service")
+	rollback := samplePlaybook("Rollback Release")
+	if err := src.CreateBatch(ctx, []*Playbook{deploy, rollback}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	recordOutcomes(t, src, deploy.ID, OutcomeSuccess, 3)
+	archived, _ := src.Get(ctx, rollback.ID)
+	archived.Archived = true
+	if err := src.store.SavePlaybook(ctx, archived); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportStream(ctx, &buf); err != nil {
+		t.Fatalf("ExportStream: %v", err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("export has %d lines, want 2:\n%s", n, buf.String())
+	}
+
+	dst := newTestManager(t)
+	result, err := dst.ImportStream(ctx, strings.NewReader(buf.String()+"\n{not json}\n"))
+	if err != nil {
+		t.Fatalf("ImportStream: %v", err)
+	}
+	if result.Imported != 2 || len(result.Failed) != 1 || result.Failed[0].Line != 4 {
+		t.Fatalf("result = %+v, want 2 imported and line 4 failed", result)
+	}
+
+	want, _ := src.Get(ctx, deploy.ID)
+	got, err := dst.Get(ctx, deploy.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Slug != want.Slug || got.SuccessCount != 3 || got.Version != want.Version || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("imported = slug %q, %d successes, v%d, created %v; want %q, 3, v%d, %v",
+			got.Slug, got.SuccessCount, got.Version, got.CreatedAt, want.Slug, want.Version, want.CreatedAt)
+	}
+	if n := searchCount(t, dst, "deploy"); n != 1 {
+		t.Errorf("search for the imported playbook found %d results, want 1", n)
+	}
+	if n := searchCount(t, dst, "rollback"); n != 0 {
+		t.Errorf("archived playbook was indexed: %d results, want 0", n)
+	}
+
+	again, err := dst.ImportStream(ctx, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("second ImportStream: %v", err)
+	}
+	if again.Imported != 0 || len(again.Skipped) != 2 {
+		t.Errorf("second import = %+v, want both playbooks skipped", again)
+	}
+}
+
+func TestImportRejectsMalformed(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	valid := samplePlaybook("Valid Import")
+	valid.ID = "x0"
+	result, err := pm.Import(ctx, []*Playbook{
+		valid,
+		{ID: "x1"},
+		{ID: "x2", Name: "Stepless"},
+		{ID: "x3", Steps: []Step{{Order: 1, Action: "Ship"}}},
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Imported != 1 || len(result.Failed) != 3 {
+		t.Fatalf("result = %+v, want 1 imported and 3 failed", result)
+	}
+	for i, f := range result.Failed {
+		if f.Line != i+1 || !strings.Contains(f.Error, ErrInvalidPlaybook.Error()) {
+			t.Errorf("Failed[%d] = %+v, want entry %d rejected as invalid", i, f, i+1)
+		}
+	}
+	for _, id := range []string{"x1", "x2", "x3"} {
+		if _, err := pm.Get(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%s): error = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestImportRenamesTakenSlugs(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	if err := pm.Create(ctx, samplePlaybook("Deploy Service")); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	incoming := samplePlaybook("Deploy Service")
+	incoming.ID = "imported"
+	incoming.Slug = "deploy-service"
+	result, err := pm.Import(ctx, []*Playbook{incoming})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Imported != 1 {
+		t.Fatalf("result = %+v, want 1 imported", result)
+	}
+	got, err := pm.Get(ctx, "imported")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Slug != "deploy-service-2" {
+		t.Errorf("Slug = %q, want deploy-service-2", got.Slug)
+	}
+}
+
+func TestImportPreservesTimestamps(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	created := time.Date(2020, 1, 15, 9, 30, 0, 0, time.UTC)
+	updated := time.Date(2023, 11, 2, 17, 0, 0, 0, time.UTC)
+	backup := samplePlaybook("Restored From Backup")
+	backup.ID = "from-backup"
+	backup.CreatedAt, backup.UpdatedAt = created, updated
+	data, err := json.Marshal(backup)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	if _, err := pm.ImportStream(ctx, bytes.NewReader(data)); err != nil {
+		t.Fatalf("ImportStream: %v", err)
+	}
+	got, err := pm.Get(ctx, "from-backup")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("timestamps = %v/%v, want %v/%v from the backup", got.CreatedAt, got.UpdatedAt, created, updated)
+	}
+}
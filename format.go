@@ -2,13 +2,30 @@ package playbookd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
+// HighLessonConfidence is the confidence at or above which FormatForContext
+// emphasizes a lesson.
+const HighLessonConfidence = 0.8
+
+// FormatOptions configures FormatForContext.
+type FormatOptions struct {
+	MinLessonConfidence float64 // Leave out lessons below this confidence (default 0: keep all)
+}
+
 // FormatForContext formats contrastive search results as a structured Markdown
 // string suitable for injection into an LLM's context window (in-context learning).
 // Returns an empty string for nil input and a "no results" message for empty results.
-func FormatForContext(cr *ContrastiveResults) string {
+// Sub-playbooks filled in on a step (see ContrastiveQuery.ResolveRefs) are
+// listed under it, one level deep. Lessons are listed most confident first,
+// with those at HighLessonConfidence or above in bold.
+func FormatForContext(cr *ContrastiveResults, opts ...FormatOptions) string {
+	var o FormatOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
 	if cr == nil {
 		return ""
 	}
@@ -24,14 +41,14 @@ func FormatForContext(cr *ContrastiveResults) string {
 	if len(cr.Positive) > 0 {
 		b.WriteString("### Proven Approaches (Follow These)\n\n")
 		for i, r := range cr.Positive {
-			writePositiveEntry(&b, i+1, r)
+			writePositiveEntry(&b, i+1, r, o)
 		}
 	}
 
 	if len(cr.Negative) > 0 {
 		b.WriteString("### Failed Approaches (Avoid These)\n\n")
 		for i, r := range cr.Negative {
-			writeNegativeEntry(&b, i+1, r)
+			writeNegativeEntry(&b, i+1, r, o)
 		}
 	}
 
@@ -43,7 +60,7 @@ func FormatForContext(cr *ContrastiveResults) string {
 	return b.String()
 }
 
-func writePositiveEntry(b *strings.Builder, num int, r SearchResult) {
+func writePositiveEntry(b *strings.Builder, num int, r SearchResult, o FormatOptions) {
 	pb := r.Playbook
 	total := pb.SuccessCount + pb.FailureCount
 	b.WriteString(fmt.Sprintf("**%d. %s** (confidence: %.0f%%, executions: %d)\n\n",
@@ -53,20 +70,20 @@ func writePositiveEntry(b *strings.Builder, num int, r SearchResult) {
 		b.WriteString("Steps:\n")
 		for _, s := range pb.Steps {
 			b.WriteString(fmt.Sprintf("  %d. %s\n", s.Order, s.Action))
+			for _, sub := range s.Subplaybooks {
+				b.WriteString(fmt.Sprintf("     Run sub-playbook %q:\n", sub.Name))
+				for _, ss := range sub.Steps {
+					b.WriteString(fmt.Sprintf("       %d. %s\n", ss.Order, ss.Action))
+				}
+			}
 		}
 		b.WriteString("\n")
 	}
 
-	if len(pb.Lessons) > 0 {
-		b.WriteString("Lessons learned:\n")
-		for _, l := range pb.Lessons {
-			b.WriteString(fmt.Sprintf("  - %s\n", l.Content))
-		}
-		b.WriteString("\n")
-	}
+	writeLessons(b, "Lessons learned:", pb.Lessons, o)
 }
 
-func writeNegativeEntry(b *strings.Builder, num int, r SearchResult) {
+func writeNegativeEntry(b *strings.Builder, num int, r SearchResult, o FormatOptions) {
 	pb := r.Playbook
 	total := pb.SuccessCount + pb.FailureCount
 	failureRate := 0.0
@@ -76,11 +93,32 @@ func writeNegativeEntry(b *strings.Builder, num int, r SearchResult) {
 	b.WriteString(fmt.Sprintf("**%d. %s** (confidence: %.0f%%, failure rate: %.0f%%)\n\n",
 		num, pb.Name, pb.Confidence*100, failureRate))
 
-	if len(pb.Lessons) > 0 {
-		b.WriteString("What failed:\n")
-		for _, l := range pb.Lessons {
+	writeLessons(b, "What failed:", pb.Lessons, o)
+}
+
+// writeLessons lists the lessons at or above o.MinLessonConfidence under
+// header, most confident first. It writes nothing if none qualify.
+func writeLessons(b *strings.Builder, header string, lessons []Lesson, o FormatOptions) {
+	var kept []Lesson
+	for _, l := range lessons {
+		if l.Confidence >= o.MinLessonConfidence {
+			kept = append(kept, l)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Confidence > kept[j].Confidence
+	})
+
+	b.WriteString(header + "\n")
+	for _, l := range kept {
+		if l.Confidence >= HighLessonConfidence {
+			b.WriteString(fmt.Sprintf("  - **%s**\n", l.Content))
+		} else {
 			b.WriteString(fmt.Sprintf("  - %s\n", l.Content))
 		}
-		b.WriteString("\n")
 	}
+	b.WriteString("\n")
 }
@@ -128,3 +128,54 @@ func TestFormatForContextNil(t *testing.T) {
 		t.Errorf("expected empty string for nil input, got: %q", out)
 	}
 }
+
+func TestFormatForContextSubPlaybooks(t *testing.T) {
+	rollback := &Playbook{Name: "Rollback", Steps: []Step{{Order: 1, Action: "Restore previous release"}}}
+	cr := &ContrastiveResults{
+		Query: "deploy app",
+		Positive: []SearchResult{{Playbook: &Playbook{
+			Name: "Safe Deploy",
+			Steps: []Step{
+				{Order: 1, Action: "Deploy"},
+				{Order: 2, Action: "Roll back on failure", Refs: []string{"rollback"}, Subplaybooks: []*Playbook{rollback}},
+			},
+		}}},
+	}
+
+	out := FormatForContext(cr)
+	if !strings.Contains(out, `Run sub-playbook "Rollback"`) || !strings.Contains(out, "1. Restore previous release") {
+		t.Errorf("sub-playbook steps missing from output:\n%s", out)
+	}
+}
+
+func TestFormatForContextLessonConfidence(t *testing.T) {
+	cr := &ContrastiveResults{
+		Query: "deploy app",
+		Positive: []SearchResult{{Playbook: &Playbook{
+			Name: "Safe Deploy",
+			Lessons: []Lesson{
+				{Content: "Tag the release", Confidence: 0.5},
+				{Content: "Check the error budget", Confidence: 0.2},
+				{Content: "Run smoke tests", Confidence: 0.9},
+			},
+		}}},
+	}
+
+	out := FormatForContext(cr)
+	smoke, tag := strings.Index(out, "Run smoke tests"), strings.Index(out, "Tag the release")
+	if smoke < 0 || tag < 0 || smoke > tag {
+		t.Errorf("expected lessons most confident first:\n%s", out)
+	}
+	if !strings.Contains(out, "- **Run smoke tests**") || strings.Contains(out, "**Tag the release**") {
+		t.Errorf("expected only the high-confidence lesson in bold:\n%s", out)
+	}
+
+	out = FormatForContext(cr, FormatOptions{MinLessonConfidence: 0.4})
+	if strings.Contains(out, "error budget") || !strings.Contains(out, "Tag the release") {
+		t.Errorf("expected lessons below 0.4 dropped:\n%s", out)
+	}
+	out = FormatForContext(cr, FormatOptions{MinLessonConfidence: 0.95})
+	if strings.Contains(out, "Lessons learned") {
+		t.Errorf("expected no lessons section when none qualify:\n%s", out)
+	}
+}
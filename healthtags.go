@@ -0,0 +1,44 @@
+package playbookd
+
+// Reserved tags maintained by the manager when ManagerConfig.AutoHealthTags is set.
+const (
+	TagProven       = "proven"
+	TagExperimental = "experimental"
+)
+
+// Thresholds for the reserved health tags.
+const (
+	ProvenMinConfidence       = 0.6 // Minimum Wilson confidence for "proven"
+	ProvenMinExecutions       = 5   // Minimum executions for "proven"
+	ExperimentalMaxExecutions = 5   // Playbooks with fewer executions are "experimental"
+)
+
+// isHealthTag reports whether tag is one of the reserved health tags.
+func isHealthTag(tag string) bool {
+	return tag == TagProven || tag == TagExperimental
+}
+
+// withoutHealthTags returns tags with the reserved health tags removed.
+func withoutHealthTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if !isHealthTag(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// applyHealthTags replaces any reserved health tags on pb with the ones implied
+// by its current stats. UpdateStats must have been called first.
+func applyHealthTags(pb *Playbook) {
+	tags := withoutHealthTags(pb.Tags)
+	total := pb.SuccessCount + pb.FailureCount
+	if total < ExperimentalMaxExecutions {
+		tags = append(tags, TagExperimental)
+	}
+	if total >= ProvenMinExecutions && pb.Confidence >= ProvenMinConfidence {
+		tags = append(tags, TagProven)
+	}
+	pb.Tags = tags
+}
@@ -0,0 +1,108 @@
+package playbookd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd/embed"
+)
+
+func newHealthTagManager(t *testing.T) *PlaybookManager {
+	t.Helper()
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:        t.TempDir(),
+		EmbedFunc:      embed.Noop(),
+		AutoHealthTags: true,
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	return pm
+}
+
+func TestAutoHealthTags(t *testing.T) {
+	pm := newHealthTagManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Health Tagged")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !slices.Contains(pb.Tags, TagExperimental) {
+		t.Errorf("new playbook Tags = %v, want %q", pb.Tags, TagExperimental)
+	}
+
+	// Enough successes to become proven and no longer experimental.
+	recordOutcomes(t, pm, pb.ID, OutcomeSuccess, 10)
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !slices.Contains(got.Tags, TagProven) {
+		t.Errorf("after successes Tags = %v, want %q", got.Tags, TagProven)
+	}
+	if slices.Contains(got.Tags, TagExperimental) {
+		t.Errorf("after successes Tags = %v, want no %q", got.Tags, TagExperimental)
+	}
+
+	// Failures drop confidence below the threshold.
+	recordOutcomes(t, pm, pb.ID, OutcomeFailure, 10)
+	got, err = pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if slices.Contains(got.Tags, TagProven) {
+		t.Errorf("after failures Tags = %v (confidence %.2f), want no %q", got.Tags, got.Confidence, TagProven)
+	}
+	if !slices.Contains(got.Tags, "test") {
+		t.Errorf("user tag lost: Tags = %v", got.Tags)
+	}
+
+	proven, err := pm.List(ctx, ListFilter{Tags: []string{TagProven}})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(proven) != 0 {
+		t.Errorf("List(proven) = %d playbooks, want 0", len(proven))
+	}
+}
+
+func TestAutoHealthTagsIgnoreUserEdits(t *testing.T) {
+	pm := newHealthTagManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("User Edited")
+	pb.Tags = append(pb.Tags, TagProven)
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if slices.Contains(pb.Tags, TagProven) {
+		t.Errorf("Tags = %v, user-supplied %q should be removed", pb.Tags, TagProven)
+	}
+
+	pb.Tags = []string{"test"}
+	if err := pm.Update(ctx, pb); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !slices.Contains(pb.Tags, TagExperimental) {
+		t.Errorf("Tags = %v, %q should be restored on update", pb.Tags, TagExperimental)
+	}
+}
+
+func TestHealthTagsDisabledByDefault(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Plain")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if slices.Contains(pb.Tags, TagExperimental) {
+		t.Errorf("Tags = %v, health tags should be off by default", pb.Tags)
+	}
+}
@@ -2,12 +2,21 @@ package playbookd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
 	blevequery "github.com/blevesearch/bleve/v2/search/query"
 )
 
@@ -17,46 +26,120 @@ type Indexer interface {
 	Remove(ctx context.Context, id string) error
 	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
 	Reindex(ctx context.Context, playbooks []*Playbook) error
+	DocIDs(ctx context.Context) ([]string, error)
 	Close() error
 }
 
 // BleveIndexer implements Indexer using Bleve with optional FAISS vector support.
 type BleveIndexer struct {
-	index     bleve.Index
-	indexPath string
-	dims      int // embedding dimensions, 0 means no vector support
+	index      bleve.Index
+	indexPath  string
+	dims       int // embedding dimensions, 0 means no vector support
+	maxResults int
 }
 
 var _ Indexer = (*BleveIndexer)(nil)
 
 // bleveDoc is the document structure indexed by Bleve.
 type bleveDoc struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Tags        string    `json:"tags"`
-	Category    string    `json:"category"`
-	Steps       string    `json:"steps"`
-	Lessons     string    `json:"lessons"`
-	Confidence  float64   `json:"confidence"`
-	SuccessRate float64   `json:"success_rate"`
-	Embedding   []float32 `json:"embedding,omitempty"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Tags         string    `json:"tags"`
+	TagKeywords  []string  `json:"tag_keywords"` // unanalyzed, for exact tag filters and boosts
+	Category     string    `json:"category"`
+	Steps        string    `json:"steps"`
+	Lessons      string    `json:"lessons"`
+	TaskContexts string    `json:"task_contexts"`
+	SuggestWords string    `json:"suggest_words"` // name and tags, lowercased but not stemmed, for prefix suggestions
+	Confidence   float64   `json:"confidence"`
+	SuccessRate  float64   `json:"success_rate"`
+	Embedding    []float32 `json:"embedding,omitempty"`
 }
 
 // IndexerConfig configures the Bleve indexer.
 type IndexerConfig struct {
-	Path string // Directory for the Bleve index
-	Dims int    // Embedding dimensions (0 = BM25 only, no vector field)
+	Path       string // Directory for the Bleve index
+	Dims       int    // Embedding dimensions (0 = BM25 only, no vector field)
+	Analyzer   string // Bleve analyzer for text fields (default DefaultAnalyzer); changing it requires a reindex
+	MaxResults int    // Cap on SearchQuery.Limit; higher limits are clamped (default DefaultMaxSearchResults)
+
+	// StopWords are extra words dropped from text fields and queries, on top
+	// of the analyzer's own. Synonyms makes each listed word equivalent to its
+	// key, e.g. {"kubernetes": {"k8s"}}; every entry must be a single word.
+	// Both are applied at index time, so changing them requires a reindex.
+	StopWords []string
+	Synonyms  map[string][]string
 }
 
-// NewBleveIndexer creates or opens a Bleve index at the given path.
+// TagMatchBoost weights the extra match a BM25 search scores for a playbook
+// with a tag exactly equal to the query text (case-sensitive).
+const TagMatchBoost = 2.0
+
+// DefaultAnalyzer is the Bleve analyzer applied to text fields when none is
+// configured: English tokenization, stop words, and stemming.
+const DefaultAnalyzer = "en"
+
+// ErrAnalyzerMismatch is returned by NewBleveIndexer when an existing index
+// was built with a different analyzer than the one configured.
+var ErrAnalyzerMismatch = errors.New("index analyzer mismatch")
+
+// ErrIndexOutdated is returned by NewBleveIndexer when an existing index was
+// created before fields that searches now rely on were added to the mapping.
+var ErrIndexOutdated = errors.New("index outdated")
+
+// indexedFields lists the mapped fields added after the index format was
+// first released. An index created without them must be rebuilt, since Bleve
+// keeps the mapping an index was created with.
+var indexedFields = []string{"tag_keywords", TaskContextField, "suggest_words"}
+
+// ValidateAnalyzer reports an error if name is not a registered Bleve
+// analyzer. The built-in ones include "standard", "simple", "keyword", and
+// the language analyzers named by ISO 639-1 code, such as "en", "de", "fr",
+// "es", "pt", "ru", and "cjk".
+func ValidateAnalyzer(name string) error {
+	if bleve.NewIndexMapping().AnalyzerNamed(name) == nil {
+		return fmt.Errorf("unknown analyzer %q", name)
+	}
+	return nil
+}
+
+// NewBleveIndexer creates or opens a Bleve index at the given path. Opening an
+// index built with a different analyzer fails with ErrAnalyzerMismatch, and one
+// missing newer fields fails with ErrIndexOutdated.
 func NewBleveIndexer(cfg IndexerConfig) (*BleveIndexer, error) {
+	if cfg.Analyzer == "" {
+		cfg.Analyzer = DefaultAnalyzer
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = DefaultMaxSearchResults
+	}
+	if err := ValidateAnalyzer(cfg.Analyzer); err != nil {
+		return nil, err
+	}
+
+	want := textAnalyzerConfig{Base: cfg.Analyzer, Synonyms: cfg.Synonyms}
+	if len(cfg.StopWords) > 0 {
+		want.StopWords = slices.Sorted(slices.Values(cfg.StopWords))
+	}
+
 	// Try to open existing index first
 	idx, err := bleve.Open(cfg.Path)
 	if err == nil {
+		if built := storedTextAnalyzer(idx.Mapping()); !built.equal(want) {
+			idx.Close()
+			return nil, fmt.Errorf("%w: index at %s was built with %s but %s is configured; delete the index directory and run `playbookd reindex` to rebuild it",
+				ErrAnalyzerMismatch, cfg.Path, built, want)
+		}
+		if missing := missingIndexFields(idx.Mapping()); len(missing) > 0 {
+			idx.Close()
+			return nil, fmt.Errorf("%w: index at %s has no %s field(s); delete the index directory and run `playbookd reindex` to rebuild it",
+				ErrIndexOutdated, cfg.Path, strings.Join(missing, ", "))
+		}
 		return &BleveIndexer{
-			index:     idx,
-			indexPath: cfg.Path,
-			dims:      cfg.Dims,
+			index:      idx,
+			indexPath:  cfg.Path,
+			dims:       cfg.Dims,
+			maxResults: cfg.MaxResults,
 		}, nil
 	}
 
@@ -66,7 +149,10 @@ func NewBleveIndexer(cfg IndexerConfig) (*BleveIndexer, error) {
 	}
 
 	// Create new index with mapping
-	indexMapping := buildBaseIndexMapping()
+	indexMapping, err := buildBaseIndexMapping(want)
+	if err != nil {
+		return nil, err
+	}
 	addVectorMapping(indexMapping, cfg.Dims)
 
 	idx, err = bleve.New(cfg.Path, indexMapping)
@@ -75,20 +161,45 @@ func NewBleveIndexer(cfg IndexerConfig) (*BleveIndexer, error) {
 	}
 
 	return &BleveIndexer{
-		index:     idx,
-		indexPath: cfg.Path,
-		dims:      cfg.Dims,
+		index:      idx,
+		indexPath:  cfg.Path,
+		dims:       cfg.Dims,
+		maxResults: cfg.MaxResults,
 	}, nil
 }
 
-// buildBaseIndexMapping creates the Bleve index mapping with text fields for BM25.
-func buildBaseIndexMapping() *mapping.IndexMappingImpl {
+// customTextAnalyzer is the name the text fields' analyzer is registered
+// under in an index mapping with custom stop words or synonyms.
+const customTextAnalyzer = "text"
+
+// wordsAnalyzer is the name of the analyzer for suggest_words: words split and
+// lowercased, without stemming or stop words, so a prefix of a whole word
+// such as "deploy" or "running" matches it.
+const wordsAnalyzer = "words"
+
+// buildBaseIndexMapping creates the Bleve index mapping with text fields for
+// BM25, analyzed with the base analyzer plus any custom stop words and synonyms.
+func buildBaseIndexMapping(text textAnalyzerConfig) (*mapping.IndexMappingImpl, error) {
 	indexMapping := bleve.NewIndexMapping()
 	docMapping := bleve.NewDocumentMapping()
 
+	analyzer := text.Base
+	if len(text.StopWords) > 0 || len(text.Synonyms) > 0 {
+		err := indexMapping.AddCustomAnalyzer(customTextAnalyzer, map[string]interface{}{
+			"type":       textAnalyzerType,
+			"base":       text.Base,
+			"stop_words": text.StopWords,
+			"synonyms":   text.Synonyms,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("index analyzer: %w", err)
+		}
+		analyzer = customTextAnalyzer
+	}
+
 	// Text fields for BM25 search
 	textField := bleve.NewTextFieldMapping()
-	textField.Analyzer = "en"
+	textField.Analyzer = analyzer
 	textField.Store = false
 
 	docMapping.AddFieldMappingsAt("name", textField)
@@ -96,11 +207,28 @@ func buildBaseIndexMapping() *mapping.IndexMappingImpl {
 	docMapping.AddFieldMappingsAt("tags", textField)
 	docMapping.AddFieldMappingsAt("steps", textField)
 	docMapping.AddFieldMappingsAt("lessons", textField)
+	docMapping.AddFieldMappingsAt(TaskContextField, textField)
+
+	// Unstemmed name and tag words for prefix suggestions
+	err := indexMapping.AddCustomAnalyzer(wordsAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("words analyzer: %w", err)
+	}
+	wordsField := bleve.NewTextFieldMapping()
+	wordsField.Analyzer = wordsAnalyzer
+	wordsField.Store = false
+	wordsField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("suggest_words", wordsField)
 
 	// Keyword fields for filtering
 	keywordField := bleve.NewKeywordFieldMapping()
 	keywordField.Store = false
 	docMapping.AddFieldMappingsAt("category", keywordField)
+	docMapping.AddFieldMappingsAt("tag_keywords", keywordField)
 
 	// Numeric fields
 	numericField := bleve.NewNumericFieldMapping()
@@ -109,7 +237,42 @@ func buildBaseIndexMapping() *mapping.IndexMappingImpl {
 	docMapping.AddFieldMappingsAt("success_rate", numericField)
 
 	indexMapping.DefaultMapping = docMapping
-	return indexMapping
+	return indexMapping, nil
+}
+
+// storedTextAnalyzer returns the analyzer configuration an existing index's
+// text fields were built with.
+func storedTextAnalyzer(m mapping.IndexMapping) textAnalyzerConfig {
+	name := m.AnalyzerNameForPath("name")
+	impl, ok := m.(*mapping.IndexMappingImpl)
+	if !ok || impl.CustomAnalysis == nil {
+		return textAnalyzerConfig{Base: name}
+	}
+	custom, ok := impl.CustomAnalysis.Analyzers[name]
+	if !ok || custom["type"] != textAnalyzerType {
+		return textAnalyzerConfig{Base: name}
+	}
+	var cfg textAnalyzerConfig
+	if data, err := json.Marshal(custom); err == nil {
+		json.Unmarshal(data, &cfg)
+	}
+	return cfg
+}
+
+// missingIndexFields returns the entries of indexedFields that an existing
+// index's mapping does not declare.
+func missingIndexFields(m mapping.IndexMapping) []string {
+	impl, ok := m.(*mapping.IndexMappingImpl)
+	if !ok || impl.DefaultMapping == nil {
+		return nil
+	}
+	var missing []string
+	for _, field := range indexedFields {
+		if _, ok := impl.DefaultMapping.Properties[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	return missing
 }
 
 // Index adds or updates a playbook in the search index.
@@ -135,12 +298,18 @@ func (bi *BleveIndexer) Search(_ context.Context, query SearchQuery) ([]SearchRe
 	if limit <= 0 {
 		limit = DefaultSearchLimit
 	}
+	limit = min(limit, bi.maxResults)
+	query = query.withTermOperators()
 
 	mode := query.Mode
 	if mode == "" {
 		mode = SearchModeHybrid
 	}
 
+	if err := checkSearchFields(query.Fields); err != nil {
+		return nil, err
+	}
+
 	var searchReq *bleve.SearchRequest
 
 	switch mode {
@@ -154,6 +323,10 @@ func (bi *BleveIndexer) Search(_ context.Context, query SearchQuery) ([]SearchRe
 		return nil, fmt.Errorf("unsupported search mode: %s", mode)
 	}
 
+	if len(query.MustTerms) > 0 || len(query.MustNotTerms) > 0 {
+		searchReq.Query = applyTermOperators(searchReq.Query, query)
+	}
+
 	// Apply category filter if specified
 	if query.Category != "" {
 		filterQuery := bleve.NewTermQuery(query.Category)
@@ -162,25 +335,65 @@ func (bi *BleveIndexer) Search(_ context.Context, query SearchQuery) ([]SearchRe
 		searchReq.Query = conjQuery
 	}
 
+	// Every requested tag must be present
+	if len(query.Tags) > 0 {
+		conjuncts := []blevequery.Query{searchReq.Query}
+		for _, tag := range query.Tags {
+			tagQuery := bleve.NewTermQuery(tag)
+			tagQuery.SetField("tag_keywords")
+			conjuncts = append(conjuncts, tagQuery)
+		}
+		searchReq.Query = bleve.NewConjunctionQuery(conjuncts...)
+	}
+
+	searchReq.Explain = query.Explain
+
 	results, err := bi.index.Search(searchReq)
 	if err != nil {
 		return nil, fmt.Errorf("bleve search: %w", err)
 	}
 
+	minScore := query.MinScore
+	if minScore == 0 {
+		minScore = DefaultMinScore
+		if mode != SearchModeVector {
+			// BM25 scores grow with the size of the index, so a real match in
+			// a small one can score below any fixed threshold. The default is
+			// relative to the best hit instead, dropping incidental matches.
+			minScore *= results.MaxScore
+		}
+	}
+
 	searchResults := make([]SearchResult, 0, len(results.Hits))
 	for _, hit := range results.Hits {
-		if query.MinScore > 0 && hit.Score < query.MinScore {
+		if minScore > 0 && hit.Score < minScore {
 			continue
 		}
 		searchResults = append(searchResults, SearchResult{
-			Playbook: &Playbook{ID: hit.ID},
-			Score:    hit.Score,
+			Playbook:    &Playbook{ID: hit.ID},
+			Score:       hit.Score,
+			Explanation: convertExplanation(hit.Expl),
 		})
 	}
 
 	return searchResults, nil
 }
 
+// convertExplanation copies a Bleve score explanation into an Explanation, so
+// callers do not depend on Bleve types.
+func convertExplanation(expl *search.Explanation) *Explanation {
+	if expl == nil {
+		return nil
+	}
+	e := &Explanation{Value: expl.Value, Message: expl.Message}
+	for _, child := range expl.Children {
+		if c := convertExplanation(child); c != nil {
+			e.Children = append(e.Children, c)
+		}
+	}
+	return e
+}
+
 // Reindex indexes all provided playbooks in a single batch. It does not remove
 // stale entries for playbooks not present in the list; callers that need a full
 // rebuild should delete the index directory and create a new BleveIndexer.
@@ -195,26 +408,180 @@ func (bi *BleveIndexer) Reindex(_ context.Context, playbooks []*Playbook) error
 	return bi.index.Batch(batch)
 }
 
+// DocCount returns the number of indexed documents.
+func (bi *BleveIndexer) DocCount() (uint64, error) {
+	return bi.index.DocCount()
+}
+
+// SizeBytes returns the total size of the index files on disk.
+func (bi *BleveIndexer) SizeBytes() (int64, error) {
+	var size int64
+	err := filepath.WalkDir(bi.indexPath, func(_ string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // removed by a segment merge during the walk
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// VectorDims returns the dimensions of the index's embedding field, fixed
+// when the index was created, or 0 if it has none.
+func (bi *BleveIndexer) VectorDims() int {
+	impl, ok := bi.index.Mapping().(*mapping.IndexMappingImpl)
+	if !ok || impl.DefaultMapping == nil {
+		return 0
+	}
+	field := impl.DefaultMapping.Properties["embedding"]
+	if field == nil {
+		return 0
+	}
+	for _, f := range field.Fields {
+		if f.Dims > 0 {
+			return f.Dims
+		}
+	}
+	return 0
+}
+
+// indexDocCount counts idx's documents with DocCount when it has it, as
+// BleveIndexer does, and by listing IDs otherwise.
+func indexDocCount(ctx context.Context, idx Indexer) (int, error) {
+	if c, ok := idx.(interface{ DocCount() (uint64, error) }); ok {
+		n, err := c.DocCount()
+		return int(n), err
+	}
+	ids, err := idx.DocIDs(ctx)
+	return len(ids), err
+}
+
+// DocIDs returns the IDs of all documents currently in the index.
+func (bi *BleveIndexer) DocIDs(_ context.Context) ([]string, error) {
+	count, err := bi.index.DocCount()
+	if err != nil {
+		return nil, fmt.Errorf("doc count: %w", err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	req := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	req.Size = int(count)
+	results, err := bi.index.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("list doc ids: %w", err)
+	}
+
+	ids := make([]string, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids, nil
+}
+
+// SuggestIDs returns the IDs of up to limit playbooks with a word in their
+// name or tags that starts with the last word of prefix, or a tag that starts
+// with prefix, most confident first. Words are matched lowercased but not
+// stemmed, so a complete word matches as well as a partial one.
+func (bi *BleveIndexer) SuggestIDs(_ context.Context, prefix string, limit int) ([]string, error) {
+	words := strings.Fields(prefix)
+	if len(words) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	last := strings.ToLower(words[len(words)-1])
+	tag := strings.Join(words, " ")
+
+	wordQuery := bleve.NewPrefixQuery(last)
+	wordQuery.SetField("suggest_words")
+	queries := []blevequery.Query{wordQuery}
+	for _, t := range slices.Compact([]string{tag, strings.ToLower(tag)}) {
+		q := bleve.NewPrefixQuery(t)
+		q.SetField("tag_keywords")
+		queries = append(queries, q)
+	}
+	req := bleve.NewSearchRequest(bleve.NewDisjunctionQuery(queries...))
+	req.Size = limit
+	req.SortBy([]string{"-confidence", "_id"})
+	results, err := bi.index.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("suggest: %w", err)
+	}
+
+	ids := make([]string, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids, nil
+}
+
 // Close closes the Bleve index.
 func (bi *BleveIndexer) Close() error {
 	return bi.index.Close()
 }
 
 func (bi *BleveIndexer) buildBM25Request(query SearchQuery, limit int) *bleve.SearchRequest {
-	// Search across each indexed text field individually, then combine with OR.
-	// NewMatchQuery against the _all composite field does not work correctly when
-	// individual fields use the "en" analyzer, because _all uses a different analyzer.
-	fields := []string{"name", "description", "tags", "steps", "lessons"}
+	req := bleve.NewSearchRequest(buildTextQuery(query))
+	req.Size = limit
+	return req
+}
+
+// buildTextQuery matches the query text against each requested field (all of
+// SearchFields by default) individually, then combines them with OR.
+// NewMatchQuery against the _all composite field does not work correctly when
+// individual fields use the "en" analyzer, because _all uses a different analyzer.
+//
+// When tags are searched, a playbook with a tag exactly equal to the query
+// text scores an extra TagMatchBoost-weighted match on tag_keywords, so an
+// exact tag outranks a passing mention of the word. Other scores are unchanged.
+func buildTextQuery(query SearchQuery) blevequery.Query {
+	fields := query.Fields
+	if len(fields) == 0 {
+		fields = SearchFields
+	}
 	fieldQueries := make([]blevequery.Query, 0, len(fields))
 	for _, field := range fields {
 		q := bleve.NewMatchQuery(query.Text)
 		q.SetField(field)
 		fieldQueries = append(fieldQueries, q)
 	}
-	disjQ := bleve.NewDisjunctionQuery(fieldQueries...)
-	req := bleve.NewSearchRequest(disjQ)
-	req.Size = limit
-	return req
+	text := bleve.NewDisjunctionQuery(fieldQueries...)
+
+	tag := strings.TrimSpace(query.Text)
+	if tag == "" || !slices.Contains(fields, "tags") {
+		return text
+	}
+	tagQuery := bleve.NewTermQuery(tag)
+	tagQuery.SetField("tag_keywords")
+	tagQuery.SetBoost(TagMatchBoost)
+	return blevequery.NewBooleanQuery([]blevequery.Query{text}, []blevequery.Query{tagQuery}, nil)
+}
+
+// applyTermOperators combines the text query base with query's required and
+// excluded terms. Each term is matched against the same fields as the text.
+// Without free text, the required terms alone select the results.
+func applyTermOperators(base blevequery.Query, query SearchQuery) blevequery.Query {
+	var must, mustNot []blevequery.Query
+	if strings.TrimSpace(query.Text) != "" || len(query.MustTerms) == 0 {
+		must = append(must, base)
+	}
+	for _, term := range query.MustTerms {
+		must = append(must, buildTextQuery(SearchQuery{Text: term, Fields: query.Fields}))
+	}
+	for _, term := range query.MustNotTerms {
+		mustNot = append(mustNot, buildTextQuery(SearchQuery{Text: term, Fields: query.Fields}))
+	}
+	return blevequery.NewBooleanQuery(must, nil, mustNot)
 }
 
 // playbookToDoc converts a Playbook to the indexed document format.
@@ -230,14 +597,17 @@ func playbookToDoc(pb *Playbook) bleveDoc {
 	}
 
 	return bleveDoc{
-		Name:        pb.Name,
-		Description: pb.Description,
-		Tags:        strings.Join(pb.Tags, " "),
-		Category:    pb.Category,
-		Steps:       strings.Join(stepActions, " "),
-		Lessons:     strings.Join(lessonContents, " "),
-		Confidence:  pb.Confidence,
-		SuccessRate: pb.SuccessRate,
-		Embedding:   pb.Embedding,
+		Name:         pb.Name,
+		Description:  pb.Description,
+		Tags:         strings.Join(pb.Tags, " "),
+		TagKeywords:  pb.Tags,
+		Category:     pb.Category,
+		Steps:        strings.Join(stepActions, " "),
+		Lessons:      strings.Join(lessonContents, " "),
+		TaskContexts: strings.Join(pb.TaskContexts, "\n"),
+		SuggestWords: strings.Join(append([]string{pb.Name}, pb.Tags...), " "),
+		Confidence:   pb.Confidence,
+		SuccessRate:  pb.SuccessRate,
+		Embedding:    pb.Embedding,
 	}
 }
@@ -7,6 +7,10 @@ import (
 	"github.com/blevesearch/bleve/v2/mapping"
 )
 
+// VectorSearchEnabled reports whether this build includes FAISS vector search
+// (-tags vectors). Without it, vector and hybrid searches fall back to BM25.
+const VectorSearchEnabled = false
+
 // addVectorMapping is a no-op when built without -tags vectors.
 func addVectorMapping(_ *mapping.IndexMappingImpl, _ int) {}
 
@@ -3,12 +3,14 @@
 package playbookd
 
 import (
-	blevequery "github.com/blevesearch/bleve/v2/search/query"
-
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/mapping"
 )
 
+// VectorSearchEnabled reports whether this build includes FAISS vector search
+// (-tags vectors).
+const VectorSearchEnabled = true
+
 // addVectorMapping adds a vector field to the index mapping when built with -tags vectors.
 func addVectorMapping(indexMapping *mapping.IndexMappingImpl, dims int) {
 	if dims > 0 {
@@ -24,24 +26,18 @@ func (bi *BleveIndexer) buildVectorRequest(query SearchQuery, limit int) *bleve.
 		return bi.buildBM25Request(query, limit)
 	}
 	req := bleve.NewSearchRequest(bleve.NewMatchNoneQuery())
-	req.AddKNN("embedding", query.Embedding, int64(limit), 1.0)
+	k, boost := query.knnParams(limit)
+	req.AddKNN("embedding", query.Embedding, k, boost)
 	req.Size = limit
 	return req
 }
 
 func (bi *BleveIndexer) buildHybridRequest(query SearchQuery, limit int) *bleve.SearchRequest {
-	fields := []string{"name", "description", "tags", "steps", "lessons"}
-	fieldQueries := make([]blevequery.Query, 0, len(fields))
-	for _, field := range fields {
-		q := bleve.NewMatchQuery(query.Text)
-		q.SetField(field)
-		fieldQueries = append(fieldQueries, q)
-	}
-	disjQ := bleve.NewDisjunctionQuery(fieldQueries...)
-	req := bleve.NewSearchRequest(disjQ)
+	req := bleve.NewSearchRequest(buildTextQuery(query))
 
 	if bi.dims > 0 && len(query.Embedding) > 0 {
-		req.AddKNN("embedding", query.Embedding, int64(limit), 1.0)
+		k, boost := query.knnParams(limit)
+		req.AddKNN("embedding", query.Embedding, k, boost)
 	}
 
 	req.Size = limit
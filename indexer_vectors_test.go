@@ -0,0 +1,48 @@
+//go:build vectors
+
+package playbookd
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func TestBleveIndexerKNNParams(t *testing.T) {
+	bi := &BleveIndexer{dims: 3}
+	emb := []float32{1, 0, 0}
+
+	tests := []struct {
+		name      string
+		query     SearchQuery
+		wantK     int64
+		wantBoost float64
+	}{
+		{"defaults", SearchQuery{Embedding: emb}, 5, DefaultVectorBoost},
+		{"wider k", SearchQuery{Embedding: emb, VectorK: 50}, 50, DefaultVectorBoost},
+		{"boost", SearchQuery{Embedding: emb, VectorBoost: 2.5}, 5, 2.5},
+		{"negative is default", SearchQuery{Embedding: emb, VectorK: -1, VectorBoost: -1}, 5, DefaultVectorBoost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for mode, req := range map[SearchMode]*bleve.SearchRequest{
+				SearchModeVector: bi.buildVectorRequest(tt.query, 5),
+				SearchModeHybrid: bi.buildHybridRequest(tt.query, 5),
+			} {
+				if len(req.KNN) != 1 {
+					t.Fatalf("%s request has %d KNN parts, want 1", mode, len(req.KNN))
+				}
+				knn := req.KNN[0]
+				if knn.Boost == nil {
+					t.Fatalf("%s KNN has no boost", mode)
+				}
+				if knn.K != tt.wantK || knn.Boost.Value() != tt.wantBoost {
+					t.Errorf("%s KNN k = %d, boost = %g; want %d, %g", mode, knn.K, knn.Boost.Value(), tt.wantK, tt.wantBoost)
+				}
+				if req.Size != 5 {
+					t.Errorf("%s request size = %d, want the limit 5", mode, req.Size)
+				}
+			}
+		})
+	}
+}
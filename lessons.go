@@ -0,0 +1,133 @@
+package playbookd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LessonReinforcement is how much a lesson's confidence grows each time a
+// duplicate of it is learned again, up to 1.
+const LessonReinforcement = 0.1
+
+// LessonSimilarityThreshold is the cosine similarity between two lessons'
+// embeddings at or above which ConsolidateLessons treats them as the same
+// lesson.
+const LessonSimilarityThreshold = 0.9
+
+// normalizeLesson returns the text lessons are compared by: lowercased, with
+// runs of whitespace collapsed and trailing punctuation removed.
+func normalizeLesson(content string) string {
+	s := strings.ToLower(strings.Join(strings.Fields(content), " "))
+	return strings.TrimRight(s, ".!;:, ")
+}
+
+// reinforceLesson folds dup into kept: kept stays, with its confidence raised
+// to the higher of the two plus LessonReinforcement.
+func reinforceLesson(kept *Lesson, dup Lesson) {
+	kept.Confidence = math.Min(math.Max(kept.Confidence, dup.Confidence)+LessonReinforcement, 1)
+}
+
+// addLesson appends l to lessons, or reinforces the existing lesson with the
+// same normalized text instead.
+func addLesson(lessons []Lesson, l Lesson) []Lesson {
+	key := normalizeLesson(l.Content)
+	for i := range lessons {
+		if normalizeLesson(lessons[i].Content) == key {
+			reinforceLesson(&lessons[i], l)
+			return lessons
+		}
+	}
+	return append(lessons, l)
+}
+
+// ConsolidateLessons merges duplicate lessons of a playbook: lessons with the
+// same normalized text and, when an embedding provider is configured, lessons
+// whose embeddings are at least LessonSimilarityThreshold similar. The oldest
+// lesson of each set is kept and reinforced once per duplicate it absorbs. It
+// returns the number of lessons removed; if none are, the playbook is left
+// untouched.
+func (pm *PlaybookManager) ConsolidateLessons(ctx context.Context, id string) (int, error) {
+	pb, err := pm.store.GetPlaybook(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("get playbook: %w", err)
+	}
+	lessons, removed := pm.consolidateLessons(ctx, pb.Lessons)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	version := pb.Version
+	_, err = pm.UpdateWithRetry(ctx, id, func(latest *Playbook) error {
+		if latest.Version != version {
+			lessons, removed = pm.consolidateLessons(ctx, latest.Lessons)
+			version = latest.Version
+		}
+		latest.Lessons = lessons
+		return nil
+	}, defaultUpdateRetries)
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// consolidateLessons returns lessons with duplicates merged, in their original
+// order, and the number of lessons removed.
+func (pm *PlaybookManager) consolidateLessons(ctx context.Context, lessons []Lesson) ([]Lesson, int) {
+	var merged []Lesson
+	for _, l := range lessons {
+		merged = addLesson(merged, l)
+	}
+
+	embeddings := make([][]float32, len(merged))
+	for i, l := range merged {
+		emb, err := pm.embedFn(ctx, l.Content)
+		if err != nil {
+			pm.log.Warn("embedding lesson failed, merging exact duplicates only", "error", err)
+			return merged, len(lessons) - len(merged)
+		}
+		if len(emb) == 0 {
+			return merged, len(lessons) - len(merged)
+		}
+		embeddings[i] = emb
+	}
+
+	var kept []Lesson
+	var keptEmb [][]float32
+	for i, l := range merged {
+		similar := -1
+		for j := range kept {
+			if cosineSimilarity(embeddings[i], keptEmb[j]) >= LessonSimilarityThreshold {
+				similar = j
+				break
+			}
+		}
+		if similar >= 0 {
+			reinforceLesson(&kept[similar], l)
+			continue
+		}
+		kept = append(kept, l)
+		keptEmb = append(keptEmb, embeddings[i])
+	}
+	return kept, len(lessons) - len(kept)
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 if
+// they differ in length or either is zero.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,92 @@
+package playbookd
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestApplyReflectionReinforcesDuplicateLessons(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Flaky Deploy")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	for _, improvements := range [][]string{
+		{"Add retry logic", "increase timeout"},
+		{"add  retry logic."},
+	} {
+		if err := pm.ApplyReflection(ctx, pb.ID, &Reflection{Improvements: improvements}); err != nil {
+			t.Fatalf("ApplyReflection: %v", err)
+		}
+	}
+
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Lessons) != 2 {
+		t.Fatalf("Lessons = %+v, want the repeated improvement merged", got.Lessons)
+	}
+	if got.Lessons[0].Content != "Add retry logic" || math.Abs(got.Lessons[0].Confidence-0.6) > 1e-9 {
+		t.Errorf("Lessons[0] = %+v, want the original reinforced to 0.6", got.Lessons[0])
+	}
+}
+
+func TestConsolidateLessons(t *testing.T) {
+	// Lessons mentioning "retry" embed to the same direction.
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(_ context.Context, text string) ([]float32, error) {
+			if strings.Contains(strings.ToLower(text), "retry") {
+				return []float32{1, 0}, nil
+			}
+			return []float32{0, 1}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	pb := samplePlaybook("Cluttered Lessons")
+	pb.Lessons = []Lesson{
+		{ID: "a", Content: "Add retry logic", Confidence: 0.5},
+		{ID: "b", Content: "increase timeout", Confidence: 0.5},
+		{ID: "c", Content: "add retry logic!", Confidence: 0.7},
+		{ID: "d", Content: "Retry failed requests with backoff", Confidence: 0.5},
+	}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	removed, err := pm.ConsolidateLessons(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("ConsolidateLessons: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Lessons) != 2 || got.Lessons[0].ID != "a" || got.Lessons[1].ID != "b" {
+		t.Fatalf("Lessons = %+v, want a and b", got.Lessons)
+	}
+	if math.Abs(got.Lessons[0].Confidence-0.9) > 1e-9 {
+		t.Errorf("Confidence = %v, want 0.9 after absorbing two duplicates", got.Lessons[0].Confidence)
+	}
+
+	version := got.Version
+	if removed, err := pm.ConsolidateLessons(ctx, pb.ID); err != nil || removed != 0 {
+		t.Errorf("second ConsolidateLessons = %d, %v; want 0, nil", removed, err)
+	}
+	if got, _ := pm.Get(ctx, pb.ID); got.Version != version {
+		t.Errorf("Version = %d, want %d when nothing was merged", got.Version, version)
+	}
+}
@@ -2,49 +2,157 @@ package playbookd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/lucas-stellet/playbookd/embed"
 
 	"github.com/google/uuid"
 )
 
+// ErrInvalidPlaybook is returned by Create and Update when a playbook fails validation.
+var ErrInvalidPlaybook = errors.New("invalid playbook")
+
+// ErrInvalidStatusTransition is returned by ValidateStatusTransition for a
+// lifecycle change that is not allowed, such as promoting a deprecated playbook.
+var ErrInvalidStatusTransition = errors.New("invalid status transition")
+
+// ErrVersionConflict is returned by Update when the stored playbook has a
+// different version than the one being updated, meaning it was modified
+// concurrently since it was loaded.
+var ErrVersionConflict = errors.New("version conflict")
+
 // ManagerConfig configures the PlaybookManager.
 type ManagerConfig struct {
-	DataDir       string              // Root directory for all data
-	EmbedFunc     embed.EmbeddingFunc // Embedding function (nil = BM25 only)
-	EmbedDims     int                 // Embedding dimensions (0 = BM25 only)
-	AutoReflect   bool                // Automatically trigger reflection after recording
-	MaxAge        time.Duration       // Max age before a playbook is prunable (default 90 days)
-	MinConfidence float64             // Min confidence for pruning (default 0.3)
-	Logger        *slog.Logger        // Logger (nil = slog.Default())
+	DataDir                string                      // Root directory for all data (optional when Store and Indexer are both set)
+	Store                  Store                       // Playbook and execution storage (nil = FileStore under DataDir); closed by Close if it is an io.Closer
+	SeparateEmbeddings     bool                        // Default FileStore only: keep embeddings in sidecar files, out of the playbook JSON
+	Indexer                Indexer                     // Search index (nil = BleveIndexer under DataDir); closed by Close; Index* settings apply only to the default
+	EmbedFunc              embed.EmbeddingFunc         // Embedding function (nil = BM25 only)
+	EmbedDims              int                         // Embedding dimensions (0 = BM25 only)
+	EmbedModel             string                      // Identifier of the embedding model, recorded on each playbook
+	DisableNormalize       bool                        // Keep raw provider vectors instead of L2-normalizing them
+	IndexAnalyzer          string                      // Bleve analyzer for text fields (default "en"); changing it requires a reindex
+	IndexStopWords         []string                    // Extra stop words for text fields; changing them requires a reindex
+	IndexSynonyms          map[string][]string         // Words treated as equivalent to their key; changing them requires a reindex
+	AutoReflect            bool                        // Automatically trigger reflection after recording
+	AutoHealthTags         bool                        // Maintain reserved "proven"/"experimental" tags from stats
+	MaxTags                int                         // Max tags per playbook (0 = unbounded)
+	MaxDescriptionChars    int                         // Max description length in characters (0 = unbounded)
+	MaxSteps               int                         // Max steps per playbook (0 = unbounded)
+	MaxStepActionChars     int                         // Max step action length in characters (0 = unbounded)
+	MaxLessons             int                         // Lessons kept per playbook, most confident first (0 = unbounded)
+	MaxAge                 time.Duration               // Max age before a playbook is prunable (default 90 days)
+	MinConfidence          float64                     // Min confidence for pruning (default 0.3)
+	ConfidenceZ            float64                     // z-score of the Wilson interval whose lower bound is Playbook.Confidence; higher is more conservative (default DefaultConfidenceZ, 95%)
+	CategoryThresholds     map[string]ThresholdSet     // Per-category confidence thresholds for SearchWithContext and Prune (nil = defaults everywhere)
+	ColdExecutionThreshold int                         // Executions below which a playbook is cold (default 5)
+	SearchCacheSize        int                         // Max queries whose index hits are cached for repeated searches (0 = no cache)
+	MaxSearchResults       int                         // Cap on SearchQuery.Limit; higher limits are clamped with a warning (default DefaultMaxSearchResults)
+	IDGenerator            func() string               // Generates playbook, execution, and lesson IDs (default: UUID v4)
+	Actor                  string                      // Who is making changes, stamped as CreatedBy on Create and UpdatedBy on Update (empty = not recorded)
+	CategoryTemplates      map[string]CategoryTemplate // Required steps per category, enforced on Create and Update
+	Metrics                Metrics                     // Instrumentation, e.g. prommetrics.New (nil = none)
+	EventHook              func(Event)                 // Called synchronously on lifecycle changes; must not block or call back into write methods (nil = none)
+	Logger                 *slog.Logger                // Logger (nil = slog.Default())
 }
 
 // PlaybookManager is the main entry point for the playbookd library.
 type PlaybookManager struct {
-	store   Store
-	indexer Indexer
-	embedFn embed.EmbeddingFunc
-	cfg     ManagerConfig
-	log     *slog.Logger
+	store       Store
+	indexer     Indexer
+	baseIndexer Indexer // indexer without the metrics and cache wrappers, for optional methods like DocCount
+	embedFn     embed.EmbeddingFunc
+	metrics     Metrics
+	cfg         ManagerConfig
+
+	searchCache *searchCache // nil unless SearchCacheSize is set
+	log         *slog.Logger
+	mu          sync.Mutex // serializes the version check and save in Update
+
+	vectorWarnOnce sync.Once // warns once that vector search degrades to BM25
 }
 
+// defaultUpdateRetries is the number of retries used by internal callers of
+// UpdateWithRetry.
+const defaultUpdateRetries = 3
+
+// DefaultColdExecutionThreshold is the default number of executions a playbook
+// needs before its confidence is considered stable.
+const DefaultColdExecutionThreshold = 5
+
 // PruneOptions configures the prune operation.
 type PruneOptions struct {
 	MaxAge        time.Duration
-	MinConfidence float64
+	MinConfidence float64       // Overrides ManagerConfig.MinConfidence and CategoryThresholds for every playbook (0 = use those)
+	MinAgeToPrune time.Duration // Grace period: playbooks created more recently are never pruned (0 = none)
 	DryRun        bool
+
+	// FailureStreak archives playbooks whose last FailureStreak executions
+	// all failed, whatever their age or confidence. Checking it reads each
+	// playbook's recent execution records, so it is off by default (0).
+	FailureStreak int
+}
+
+// PruneReason identifies the rule that selected a playbook for pruning.
+type PruneReason string
+
+const (
+	PruneReasonLowConfidenceAndOld    PruneReason = "low_confidence_and_old"    // Low confidence and not updated within MaxAge
+	PruneReasonStale                  PruneReason = "stale"                     // Not used within MaxAge
+	PruneReasonNeverUsedLowConfidence PruneReason = "never_used_low_confidence" // Never used, low confidence, and older than MaxAge
+	PruneReasonFailureStreak          PruneReason = "failure_streak"            // Last FailureStreak executions all failed
+)
+
+// PrunedItem is a playbook selected by Prune and why.
+type PrunedItem struct {
+	ID     string      `json:"id"`
+	Name   string      `json:"name"`
+	Reason PruneReason `json:"reason"`
+	Error  string      `json:"error,omitempty"` // Why archiving failed, for items in PruneResult.Failed
 }
 
 // PruneResult reports what was pruned.
 type PruneResult struct {
-	Archived []string // IDs of archived playbooks
+	Archived []PrunedItem // Archived playbooks, in store order
+	Failed   []PrunedItem `json:",omitempty"` // Selected playbooks that could not be archived and were left as they were
+}
+
+// IDs returns the IDs of the archived playbooks.
+func (r *PruneResult) IDs() []string {
+	ids := make([]string, len(r.Archived))
+	for i, item := range r.Archived {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+// ExecutionPruneOptions configures the execution prune operation. A record is
+// removed if it is older than MaxAge or falls outside the newest MaxPerPlaybook
+// records of its playbook. Zero values disable the corresponding rule.
+type ExecutionPruneOptions struct {
+	MaxAge         time.Duration
+	MaxPerPlaybook int
+	DryRun         bool
+}
+
+// ExecutionPruneResult reports how many execution records were pruned.
+type ExecutionPruneResult struct {
+	Removed    int
+	ByPlaybook map[string]int // playbook ID -> records removed
 }
 
 // Stats holds aggregate statistics.
@@ -54,18 +162,77 @@ type Stats struct {
 	ByCategory     map[string]int
 	TotalExecs     int
 	AvgConfidence  float64
+	IndexDocCount  int   // Documents in the search index; archived playbooks are not indexed
+	IndexSizeBytes int64 // On-disk size of the index (0 if the indexer does not report it)
+
+	// Windowed numbers, computed from execution records when StatsOptions.Since is set.
+	Since             time.Time
+	RecentExecs       int
+	RecentSuccessRate float64
+}
+
+// ReindexOptions configures the Reindex operation.
+type ReindexOptions struct {
+	Concurrency int                   // Playbooks embedded at once (default DefaultReindexConcurrency)
+	Progress    func(ReindexProgress) // Called after each embedding call and each indexed batch, one call at a time (nil = none)
+}
+
+// ReindexProgress reports how far a Reindex has got.
+type ReindexProgress struct {
+	Total          int // Playbooks to index
+	Indexed        int // Playbooks indexed so far
+	ToEmbed        int // Playbooks whose embedding is regenerated first
+	EmbeddingCalls int // Embedding calls made so far
+}
+
+// ReindexResult reports what Reindex did.
+type ReindexResult struct {
+	Indexed     int `json:"indexed"`      // Playbooks written to the index
+	Removed     int `json:"removed"`      // Index entries with no matching non-archived playbook, removed
+	Reembedded  int `json:"reembedded"`   // Playbooks given a new embedding before indexing
+	EmbedErrors int `json:"embed_errors"` // Playbooks that failed to embed and were indexed with their old embedding
+}
+
+// ReindexEstimate is the work a Reindex would do, from EstimateReindex.
+type ReindexEstimate struct {
+	Playbooks      int `json:"playbooks"`       // Playbooks that would be indexed
+	EmbeddingCalls int `json:"embedding_calls"` // Embedding calls that would be made (0 without an embedding provider)
+}
+
+// CreateOptions configures Create and CreateBatch.
+type CreateOptions struct {
+	// PreserveTimestamps keeps a non-zero CreatedAt and UpdatedAt from the
+	// playbook instead of stamping the current time, as when restoring a
+	// backup. Zero timestamps are still set to now.
+	PreserveTimestamps bool
+
+	// reuseEmbedding keeps the playbook's own embedding when it was generated
+	// from the same text by the configured model, as Import does.
+	reuseEmbedding bool
+}
+
+// StatsOptions configures the Stats operation.
+type StatsOptions struct {
+	// Since, when non-zero, adds execution counts and success rate for records
+	// started after this time. This reads every execution record of every
+	// playbook, so it is proportional to the size of the execution history.
+	Since time.Time
 }
 
 // NewPlaybookManager initializes a PlaybookManager with store, indexer, and embedding.
 func NewPlaybookManager(cfg ManagerConfig) (*PlaybookManager, error) {
-	if cfg.DataDir == "" {
+	if cfg.DataDir == "" && (cfg.Store == nil || cfg.Indexer == nil) {
 		return nil, fmt.Errorf("data_dir is required")
 	}
 
 	// Initialize store
-	store, err := NewFileStore(cfg.DataDir)
-	if err != nil {
-		return nil, fmt.Errorf("create store: %w", err)
+	store := cfg.Store
+	if store == nil {
+		fs, err := NewFileStore(cfg.DataDir, FileStoreOptions{SeparateEmbeddings: cfg.SeparateEmbeddings})
+		if err != nil {
+			return nil, fmt.Errorf("create store: %w", err)
+		}
+		store = fs
 	}
 
 	// Initialize embedding function
@@ -75,13 +242,37 @@ func NewPlaybookManager(cfg ManagerConfig) (*PlaybookManager, error) {
 	}
 
 	// Initialize indexer
-	indexPath := filepath.Join(cfg.DataDir, "index")
-	indexer, err := NewBleveIndexer(IndexerConfig{
-		Path: indexPath,
-		Dims: cfg.EmbedDims,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("create indexer: %w", err)
+	indexer := cfg.Indexer
+	if indexer == nil {
+		bi, err := NewBleveIndexer(IndexerConfig{
+			Path:       filepath.Join(cfg.DataDir, "index"),
+			Dims:       cfg.EmbedDims,
+			Analyzer:   cfg.IndexAnalyzer,
+			MaxResults: cfg.MaxSearchResults,
+			StopWords:  cfg.IndexStopWords,
+			Synonyms:   cfg.IndexSynonyms,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create indexer: %w", err)
+		}
+		indexer = bi
+	}
+
+	baseIndexer := indexer
+
+	// Instrument the embedder and indexer
+	metrics := cfg.Metrics
+	if metrics == nil {
+		metrics = noopMetrics{}
+	} else {
+		if cfg.EmbedFunc != nil {
+			embedFn = instrumentEmbed(embedFn, metrics)
+		}
+		indexer = &instrumentedIndexer{Indexer: indexer, metrics: metrics}
+	}
+	cache := newSearchCache(cfg.SearchCacheSize)
+	if cache != nil {
+		indexer = &invalidatingIndexer{Indexer: indexer, cache: cache}
 	}
 
 	// Set defaults
@@ -91,28 +282,229 @@ func NewPlaybookManager(cfg ManagerConfig) (*PlaybookManager, error) {
 	if cfg.MinConfidence == 0 {
 		cfg.MinConfidence = 0.3
 	}
+	if cfg.ConfidenceZ <= 0 {
+		cfg.ConfidenceZ = DefaultConfidenceZ
+	}
+	if cfg.IDGenerator == nil {
+		cfg.IDGenerator = func() string { return uuid.New().String() }
+	}
+	if cfg.ColdExecutionThreshold == 0 {
+		cfg.ColdExecutionThreshold = DefaultColdExecutionThreshold
+	}
+	if cfg.MaxSearchResults <= 0 {
+		cfg.MaxSearchResults = DefaultMaxSearchResults
+	}
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
 
 	return &PlaybookManager{
-		store:   store,
-		indexer: indexer,
-		embedFn: embedFn,
-		cfg:     cfg,
-		log:     cfg.Logger,
+		store:       store,
+		indexer:     indexer,
+		baseIndexer: baseIndexer,
+		embedFn:     embedFn,
+		metrics:     metrics,
+		searchCache: cache,
+		cfg:         cfg,
+		log:         cfg.Logger,
 	}, nil
 }
 
 // Close shuts down the manager and its resources.
 func (pm *PlaybookManager) Close() error {
-	return pm.indexer.Close()
+	err := pm.indexer.Close()
+	if c, ok := pm.store.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// Validate checks a playbook against ValidatePlaybook's structural rules and
+// the limits and category templates configured on the manager. Errors wrap
+// ErrInvalidPlaybook.
+func (pm *PlaybookManager) Validate(pb *Playbook) error {
+	if err := ValidatePlaybook(pb, pm.validateOptions()); err != nil {
+		return err
+	}
+	if pm.cfg.MaxTags > 0 {
+		tags := pb.Tags
+		if pm.cfg.AutoHealthTags {
+			tags = withoutHealthTags(tags)
+		}
+		if len(tags) > pm.cfg.MaxTags {
+			return fmt.Errorf("%w: %d tags exceeds the maximum of %d", ErrInvalidPlaybook, len(tags), pm.cfg.MaxTags)
+		}
+	}
+	if pm.cfg.MaxDescriptionChars > 0 {
+		if n := utf8.RuneCountInString(pb.Description); n > pm.cfg.MaxDescriptionChars {
+			return fmt.Errorf("%w: description is %d characters, exceeds the maximum of %d",
+				ErrInvalidPlaybook, n, pm.cfg.MaxDescriptionChars)
+		}
+	}
+	if tmpl, ok := pm.cfg.CategoryTemplates[pb.Category]; ok {
+		if err := tmpl.validate(pb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOptions returns the size limits configured on the manager.
+func (pm *PlaybookManager) validateOptions() ValidateOptions {
+	return ValidateOptions{MaxSteps: pm.cfg.MaxSteps, MaxStepActionChars: pm.cfg.MaxStepActionChars}
+}
+
+// capLessons drops pb's least confident lessons beyond MaxLessons, keeping the
+// rest in their order, and logs a warning when it does. Of lessons with equal
+// confidence, the earlier ones are kept.
+func (pm *PlaybookManager) capLessons(pb *Playbook) {
+	limit := pm.cfg.MaxLessons
+	if limit <= 0 || len(pb.Lessons) <= limit {
+		return
+	}
+	idx := make([]int, len(pb.Lessons))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return pb.Lessons[idx[a]].Confidence > pb.Lessons[idx[b]].Confidence
+	})
+	keep := idx[:limit]
+	sort.Ints(keep)
+	kept := make([]Lesson, 0, limit)
+	for _, i := range keep {
+		kept = append(kept, pb.Lessons[i])
+	}
+	pm.log.Warn("dropped least confident lessons", "id", pb.ID, "name", pb.Name, "dropped", len(pb.Lessons)-limit, "max", limit)
+	pb.Lessons = kept
+}
+
+// normalizeSteps renumbers pb's steps 1..N in Order if they have duplicate,
+// missing, or out-of-sequence orders, logging a warning when it does.
+func (pm *PlaybookManager) normalizeSteps(pb *Playbook) {
+	before := make([]int, len(pb.Steps))
+	for i, s := range pb.Steps {
+		before[i] = s.Order
+	}
+	if normalizeStepOrder(pb.Steps) {
+		pm.log.Warn("renumbered playbook steps", "id", pb.ID, "name", pb.Name, "orders", before)
+	}
 }
 
 // Create creates a new playbook, generates its embedding, and indexes it.
-func (pm *PlaybookManager) Create(ctx context.Context, pb *Playbook) error {
+// If the playbook's category has a scaffold template, missing required steps
+// are added as placeholders first. Steps are renumbered 1..N by Order if their
+// orders have duplicates or gaps. Step references to playbooks that do not exist
+// are logged as warnings.
+func (pm *PlaybookManager) Create(ctx context.Context, pb *Playbook, opts ...CreateOptions) error {
+	var o CreateOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if err := pm.prepareAndSave(ctx, pb, o); err != nil {
+		return err
+	}
+
+	// Index for search
+	if err := pm.indexer.Index(ctx, pb); err != nil {
+		return fmt.Errorf("index playbook: %w", err)
+	}
+
+	pm.metrics.PlaybookCreated()
+	pm.emit(EventCreated, pb.ID, map[string]string{"name": pb.Name})
+	return nil
+}
+
+// BatchItemError is the failure of one playbook in a CreateBatch call.
+type BatchItemError struct {
+	Index int    // Position in the slice passed to CreateBatch
+	Name  string // Playbook name, for reporting
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("playbook %d (%q): %v", e.Index, e.Name, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error { return e.Err }
+
+// BatchError is returned by CreateBatch when some playbooks could not be
+// created. The others were saved and indexed.
+type BatchError struct {
+	Failed []*BatchItemError // In input order
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%d playbook(s) failed: %s", len(e.Failed), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual failures, so errors.Is and errors.As see
+// through to them (e.g. ErrInvalidPlaybook).
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+// CreateBatch creates many playbooks at once, as when importing or seeding a
+// fresh install. Each playbook is prepared, embedded, and saved as by Create;
+// the ones that succeed are then indexed together in a single batch instead of
+// one index operation each. If some playbooks fail, the rest are still created
+// and a *BatchError identifies the failures.
+func (pm *PlaybookManager) CreateBatch(ctx context.Context, pbs []*Playbook, opts ...CreateOptions) error {
+	var o CreateOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	saved := make([]*Playbook, 0, len(pbs))
+	var batchErr BatchError
+	for i, pb := range pbs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := pm.prepareAndSave(ctx, pb, o); err != nil {
+			batchErr.Failed = append(batchErr.Failed, &BatchItemError{Index: i, Name: pb.Name, Err: err})
+			continue
+		}
+		saved = append(saved, pb)
+	}
+
+	if len(saved) > 0 {
+		if err := pm.indexer.Reindex(ctx, saved); err != nil {
+			return fmt.Errorf("index playbooks: %w", err)
+		}
+	}
+	for _, pb := range saved {
+		pm.metrics.PlaybookCreated()
+		pm.emit(EventCreated, pb.ID, map[string]string{"name": pb.Name})
+	}
+	if len(batchErr.Failed) > 0 {
+		return &batchErr
+	}
+	return nil
+}
+
+// prepareAndSave does the work of Create short of indexing: it applies the
+// category scaffold, normalizes and validates steps, fills in ID, slug,
+// version and timestamps, generates the embedding, and saves the playbook.
+func (pm *PlaybookManager) prepareAndSave(ctx context.Context, pb *Playbook, opts CreateOptions) error {
+	if tmpl, ok := pm.cfg.CategoryTemplates[pb.Category]; ok {
+		tmpl.scaffold(pb)
+	}
+	pm.normalizeSteps(pb)
+	pm.capLessons(pb)
+	if err := pm.Validate(pb); err != nil {
+		return err
+	}
+
 	if pb.ID == "" {
-		pb.ID = uuid.New().String()
+		pb.ID = pm.cfg.IDGenerator()
 	}
 	if pb.Slug == "" {
 		pb.Slug = slugify(pb.Name)
@@ -120,28 +512,38 @@ func (pm *PlaybookManager) Create(ctx context.Context, pb *Playbook) error {
 	if pb.Version == 0 {
 		pb.Version = 1
 	}
+	pm.warnDanglingRefs(ctx, pb)
 
-	now := time.Now()
-	pb.CreatedAt = now
-	pb.UpdatedAt = now
-	pb.UpdateStats()
+	if pb.CreatedBy == "" {
+		pb.CreatedBy = pm.cfg.Actor
+	}
+	stampCreated(pb, opts.PreserveTimestamps)
+	pm.updateStats(pb)
 
 	// Generate embedding
-	if err := pm.generateEmbedding(ctx, pb); err != nil {
-		return fmt.Errorf("generate embedding: %w", err)
+	if !opts.reuseEmbedding || !pm.reuseEmbedding(pb, pb) {
+		if err := pm.generateEmbedding(ctx, pb); err != nil {
+			return fmt.Errorf("generate embedding: %w", err)
+		}
 	}
 
 	// Save to store
 	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
 		return fmt.Errorf("save playbook: %w", err)
 	}
+	return nil
+}
 
-	// Index for search
-	if err := pm.indexer.Index(ctx, pb); err != nil {
-		return fmt.Errorf("index playbook: %w", err)
+// stampCreated sets a new playbook's CreatedAt and UpdatedAt to now, or, with
+// preserve, only those that are zero.
+func stampCreated(pb *Playbook, preserve bool) {
+	now := time.Now()
+	if !preserve || pb.CreatedAt.IsZero() {
+		pb.CreatedAt = now
+	}
+	if !preserve || pb.UpdatedAt.IsZero() {
+		pb.UpdatedAt = now
 	}
-
-	return nil
 }
 
 // Get retrieves a playbook by ID.
@@ -149,21 +551,82 @@ func (pm *PlaybookManager) Get(ctx context.Context, id string) (*Playbook, error
 	return pm.store.GetPlaybook(ctx, id)
 }
 
+// GetVersion retrieves a specific version of a playbook. The current version is
+// read from the playbook itself; earlier versions come from the version history
+// saved by Update.
+func (pm *PlaybookManager) GetVersion(ctx context.Context, id string, version int) (*Playbook, error) {
+	pb, err := pm.store.GetPlaybook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pb.Version == version {
+		return pb, nil
+	}
+	return pm.store.GetPlaybookVersion(ctx, id, version)
+}
+
 // List returns playbooks matching the filter.
 func (pm *PlaybookManager) List(ctx context.Context, filter ListFilter) ([]*Playbook, error) {
 	return pm.store.ListPlaybooks(ctx, filter)
 }
 
+// Count returns how many playbooks List would return for filter, ignoring its
+// Limit, without loading them.
+func (pm *PlaybookManager) Count(ctx context.Context, filter ListFilter) (int, error) {
+	return pm.store.CountPlaybooks(ctx, filter)
+}
+
+// Exists reports whether a playbook with the given ID is in the store,
+// archived or not. Playbooks in the trash do not exist.
+func (pm *PlaybookManager) Exists(ctx context.Context, id string) (bool, error) {
+	return pm.store.PlaybookExists(ctx, id)
+}
+
 // Update modifies a playbook, re-generates embedding, re-indexes, and increments version.
+// It returns ErrVersionConflict if the stored playbook's version differs from pb.Version.
+// Like Create, it renumbers steps whose orders have duplicates or gaps and
+// logs a warning for step references to playbooks that do not exist.
 func (pm *PlaybookManager) Update(ctx context.Context, pb *Playbook) error {
+	pm.normalizeSteps(pb)
+	pm.capLessons(pb)
+	if err := pm.Validate(pb); err != nil {
+		return err
+	}
+	pm.warnDanglingRefs(ctx, pb)
+
+	if err := pm.embedUnlocked(ctx, pb); err != nil {
+		return err
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	current, err := pm.store.GetPlaybook(ctx, pb.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("get current playbook: %w", err)
+	}
+	if current != nil && current.Version != pb.Version {
+		return fmt.Errorf("playbook %s at version %d, stored version is %d: %w",
+			pb.ID, pb.Version, current.Version, ErrVersionConflict)
+	}
+
+	// Keep the outgoing version (without its embedding) so it can be diffed later.
+	// Status is not content: it only changes through SetStatus.
+	if current != nil {
+		pb.Status = current.Status
+		outgoing := *current
+		outgoing.Embedding = nil
+		if err := pm.store.SavePlaybookVersion(ctx, &outgoing); err != nil {
+			return fmt.Errorf("save playbook version: %w", err)
+		}
+	}
+
 	pb.Version++
 	pb.UpdatedAt = time.Now()
-	pb.UpdateStats()
-
-	// Re-generate embedding
-	if err := pm.generateEmbedding(ctx, pb); err != nil {
-		return fmt.Errorf("generate embedding: %w", err)
+	if pm.cfg.Actor != "" {
+		pb.UpdatedBy = pm.cfg.Actor
 	}
+	pm.updateStats(pb)
 
 	// Save to store
 	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
@@ -175,82 +638,550 @@ func (pm *PlaybookManager) Update(ctx context.Context, pb *Playbook) error {
 		return fmt.Errorf("re-index playbook: %w", err)
 	}
 
+	pm.metrics.PlaybookUpdated()
+	pm.emit(EventUpdated, pb.ID, map[string]string{"version": strconv.Itoa(pb.Version)})
 	return nil
 }
 
-// Delete removes a playbook from store and index.
+// SetStatus changes a playbook's lifecycle status. It re-saves and re-indexes
+// the playbook without bumping its version, since a status change is not a
+// content change. It does not check transitions; see ValidateStatusTransition.
+func (pm *PlaybookManager) SetStatus(ctx context.Context, id string, s Status) error {
+	switch s {
+	case StatusDraft, StatusActive, StatusDeprecated:
+	default:
+		return fmt.Errorf("unknown status %q", s)
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	current, err := pm.store.GetPlaybook(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get playbook: %w", err)
+	}
+	pb := *current
+	pb.Status = s
+	pb.UpdatedAt = time.Now()
+	if err := pm.saveMetadataLocked(ctx, current, &pb); err != nil {
+		return err
+	}
+	pm.metrics.PlaybookUpdated()
+	from := current.Status
+	if from == "" {
+		from = StatusActive
+	}
+	pm.emit(statusEventType(s), id, map[string]string{"from": string(from), "to": string(s)})
+	return nil
+}
+
+// ValidateStatusTransition reports whether a playbook may move from one status
+// to another: drafts can be promoted to active, drafts and active playbooks can
+// be deprecated. An empty status is treated as active. Errors wrap
+// ErrInvalidStatusTransition.
+func ValidateStatusTransition(from, to Status) error {
+	if from == "" {
+		from = StatusActive
+	}
+	switch {
+	case from == to:
+		return fmt.Errorf("%w: playbook is already %s", ErrInvalidStatusTransition, to)
+	case to == StatusActive && from == StatusDraft,
+		to == StatusDeprecated && (from == StatusDraft || from == StatusActive):
+		return nil
+	default:
+		return fmt.Errorf("%w: cannot go from %s to %s", ErrInvalidStatusTransition, from, to)
+	}
+}
+
+// UpdateWithRetry loads the playbook, applies mutate, and attempts Update. On
+// ErrVersionConflict it reloads the latest version and re-applies mutate, up to
+// maxRetries additional attempts. It returns the updated playbook.
+func (pm *PlaybookManager) UpdateWithRetry(ctx context.Context, id string, mutate func(*Playbook) error, maxRetries int) (*Playbook, error) {
+	for attempt := 0; ; attempt++ {
+		pb, err := pm.store.GetPlaybook(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("get playbook: %w", err)
+		}
+		if err := mutate(pb); err != nil {
+			return nil, err
+		}
+
+		err = pm.Update(ctx, pb)
+		if err == nil {
+			return pb, nil
+		}
+		if !errors.Is(err, ErrVersionConflict) || attempt >= maxRetries {
+			return nil, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		pm.log.Debug("version conflict, retrying update", "playbook_id", id, "attempt", attempt+1)
+	}
+}
+
+// UpdateMetadata saves changes to a playbook's non-content fields, such as
+// stats, status, or timestamps, and re-indexes it without bumping its version.
+// The stored embedding is reused unless the name, description, tags, or step
+// actions changed, in which case it is regenerated before the manager's lock is
+// taken. pb is checked with Validate first. Like Update, it returns
+// ErrVersionConflict if the stored playbook's version differs from pb.Version.
+func (pm *PlaybookManager) UpdateMetadata(ctx context.Context, pb *Playbook) error {
+	if err := pm.Validate(pb); err != nil {
+		return err
+	}
+	if err := pm.embedUnlocked(ctx, pb); err != nil {
+		return err
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	current, err := pm.store.GetPlaybook(ctx, pb.ID)
+	if err != nil {
+		return fmt.Errorf("get current playbook: %w", err)
+	}
+	if current.Version != pb.Version {
+		return fmt.Errorf("playbook %s at version %d, stored version is %d: %w",
+			pb.ID, pb.Version, current.Version, ErrVersionConflict)
+	}
+	if err := pm.saveMetadataLocked(ctx, current, pb); err != nil {
+		return err
+	}
+	pm.metrics.PlaybookUpdated()
+	return nil
+}
+
+// embedUnlocked gives pb an embedding for its text, reusing the stored one
+// when it is still valid. It runs before pm.mu is taken, so a slow provider
+// does not hold up other writes. The embedding depends only on pb's own text,
+// so a concurrent write cannot make it stale; the caller's version check under
+// the lock still rejects conflicting updates.
+func (pm *PlaybookManager) embedUnlocked(ctx context.Context, pb *Playbook) error {
+	prev, err := pm.store.GetPlaybook(ctx, pb.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("get current playbook: %w", err)
+	}
+	if prev != nil && pm.reuseEmbedding(prev, pb) {
+		return nil
+	}
+	if err := pm.generateEmbedding(ctx, pb); err != nil {
+		return fmt.Errorf("generate embedding: %w", err)
+	}
+	return nil
+}
+
+// saveMetadataLocked saves pb over current, its stored version, keeping pb's
+// embedding if it matches pb's text (as after embedUnlocked) or reusing the
+// stored one when the embedded text is unchanged. The caller holds pm.mu.
+func (pm *PlaybookManager) saveMetadataLocked(ctx context.Context, current, pb *Playbook) error {
+	if !pm.reuseEmbedding(pb, pb) && !pm.reuseEmbedding(current, pb) {
+		if err := pm.generateEmbedding(ctx, pb); err != nil {
+			return fmt.Errorf("generate embedding: %w", err)
+		}
+	}
+
+	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+		return fmt.Errorf("save playbook: %w", err)
+	}
+	if pb.Archived {
+		return nil // archived playbooks are not indexed
+	}
+	if err := pm.indexer.Index(ctx, pb); err != nil {
+		return fmt.Errorf("re-index playbook: %w", err)
+	}
+	return nil
+}
+
+// Clone creates a new playbook from a copy of an existing one. Steps, tags,
+// category, description, and lessons are preserved; the clone gets a fresh ID,
+// fresh lesson IDs, a slug derived from newName, version 1, and no execution
+// history or stats. It is credited to the manager's Actor, not the source's author.
+func (pm *PlaybookManager) Clone(ctx context.Context, id string, newName string) (*Playbook, error) {
+	if strings.TrimSpace(newName) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	src, err := pm.store.GetPlaybook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get playbook: %w", err)
+	}
+
+	clone := &Playbook{
+		Name:        newName,
+		Description: src.Description,
+		Tags:        slices.Clone(src.Tags),
+		Category:    src.Category,
+		Steps:       cloneSteps(src.Steps),
+		Lessons:     slices.Clone(src.Lessons),
+		ForkedFrom:  src.ID,
+	}
+	for i := range clone.Lessons {
+		clone.Lessons[i].ID = pm.cfg.IDGenerator()
+	}
+
+	if err := pm.Create(ctx, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// Rename changes a playbook's name and regenerates its slug, appending a numeric
+// suffix if another playbook already uses it. Like any Update, it bumps the
+// version, re-embeds, and re-indexes.
+func (pm *PlaybookManager) Rename(ctx context.Context, id, newName string) error {
+	if strings.TrimSpace(newName) == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	slug, err := pm.uniqueSlug(ctx, slugify(newName), id)
+	if err != nil {
+		return err
+	}
+
+	_, err = pm.UpdateWithRetry(ctx, id, func(pb *Playbook) error {
+		pb.Name = newName
+		pb.Slug = slug
+		return nil
+	}, defaultUpdateRetries)
+	return err
+}
+
+// uniqueSlug returns base, or base with a "-N" suffix, such that no playbook
+// other than excludeID uses it.
+func (pm *PlaybookManager) uniqueSlug(ctx context.Context, base, excludeID string) (string, error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
+	if err != nil {
+		return "", fmt.Errorf("list playbooks: %w", err)
+	}
+
+	taken := make(map[string]bool, len(playbooks))
+	for _, pb := range playbooks {
+		if pb.ID != excludeID {
+			taken[pb.Slug] = true
+		}
+	}
+
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+// Delete moves a playbook to the trash and removes it from the index. Its
+// executions and versions are kept, and RestoreFromTrash brings it back; use
+// Purge to delete it permanently.
 func (pm *PlaybookManager) Delete(ctx context.Context, id string) error {
-	if err := pm.store.DeletePlaybook(ctx, id); err != nil {
+	if err := pm.store.TrashPlaybook(ctx, id, time.Now()); err != nil {
 		return fmt.Errorf("delete playbook: %w", err)
 	}
 	if err := pm.indexer.Remove(ctx, id); err != nil {
-		return fmt.Errorf("remove from index: %w", err)
+		return fmt.Errorf("playbook %s was moved to the trash but not removed from the index: %w", id, err)
 	}
+	pm.metrics.PlaybookDeleted()
+	pm.emit(EventDeleted, id, nil)
 	return nil
 }
 
 // Search performs hybrid BM25 + vector search and hydrates results with full playbook data.
+//
+// With query.SkipHydration set, Search does not read the playbooks: each
+// result's Playbook holds only its ID, Score is its TextScore, and a playbook
+// deleted since it was indexed is still returned. Weights, ConfidenceWeight,
+// and RecencyBoost need the playbooks, so they are rejected.
 func (pm *PlaybookManager) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	if err := query.checkSkipHydration(); err != nil {
+		return nil, err
+	}
+	mode := query.Mode
+	if mode == "" {
+		mode = SearchModeHybrid
+	}
+	start := time.Now()
+	results, err := pm.search(ctx, query)
+	pm.metrics.SearchCompleted(mode, time.Since(start), err)
+	return results, err
+}
+
+func (pm *PlaybookManager) search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	query, results, err := pm.indexHits(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.SkipHydration {
+		return unhydrated(results), nil
+	}
+
+	// Hydrate results with full playbook data
+	hydrated := make([]SearchResult, 0, len(results))
+	var mixed int
+	for _, r := range results {
+		h, ok := pm.hydrate(ctx, r)
+		if !ok {
+			continue // Skip if playbook was deleted between search and fetch
+		}
+		if pm.hasStaleEmbedding(h.Playbook) {
+			mixed++
+		}
+		hydrated = append(hydrated, h)
+	}
+	pm.warnMixedModels(query, mixed, len(hydrated))
+
+	// Composite score blending
+	if w, ok := query.effectiveWeights(); ok {
+		blendScores(hydrated, w)
+	}
+	if query.RecencyBoost > 0 {
+		applyRecencyBoost(hydrated, query.RecencyBoost, time.Now())
+	}
+
+	return hydrated, nil
+}
+
+// SearchStream runs a search like Search but hydrates the results one at a
+// time, calling fn with each in index order, so only one playbook is in
+// memory at once however large Limit is. It stops at the first error from fn
+// and returns it. Weights, ConfidenceWeight, and RecencyBoost re-rank across
+// the whole result set, so SearchStream rejects them; every result's Score is
+// its TextScore.
+func (pm *PlaybookManager) SearchStream(ctx context.Context, query SearchQuery, fn func(SearchResult) error) error {
+	if _, ok := query.effectiveWeights(); ok || query.RecencyBoost > 0 {
+		return fmt.Errorf("search stream: Weights, ConfidenceWeight, and RecencyBoost are not supported")
+	}
+	mode := query.Mode
+	if mode == "" {
+		mode = SearchModeHybrid
+	}
+	start := time.Now()
+	err := pm.searchStream(ctx, query, fn)
+	pm.metrics.SearchCompleted(mode, time.Since(start), err)
+	return err
+}
+
+func (pm *PlaybookManager) searchStream(ctx context.Context, query SearchQuery, fn func(SearchResult) error) error {
+	query, results, err := pm.indexHits(ctx, query)
+	if err != nil {
+		return err
+	}
+	if query.SkipHydration {
+		for _, r := range unhydrated(results) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var mixed, yielded int
+	defer func() { pm.warnMixedModels(query, mixed, yielded) }()
+	for _, r := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h, ok := pm.hydrate(ctx, r)
+		if !ok {
+			continue
+		}
+		if pm.hasStaleEmbedding(h.Playbook) {
+			mixed++
+		}
+		yielded++
+		if err := fn(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexHits returns the unhydrated index hits for query, with its Limit
+// clamped to MaxSearchResults, and the query as searched, reusing those of an
+// identical earlier query if caching is on.
+func (pm *PlaybookManager) indexHits(ctx context.Context, query SearchQuery) (SearchQuery, []SearchResult, error) {
+	query.Limit = pm.clampLimit(query.Limit)
+
+	var key string
+	var cached *searchCacheEntry
+	var gen uint64
+	if pm.searchCache != nil {
+		key = searchCacheKey(query)
+		cached, gen = pm.searchCache.get(key)
+	}
+	if cached == nil {
+		q, hits, degraded, err := pm.searchIndex(ctx, query)
+		if err != nil {
+			return query, nil, err
+		}
+		cached = &searchCacheEntry{key: key, query: q, hits: hits}
+		// A degraded search is not cached, so the next one tries to embed again
+		if key != "" && degraded == "" {
+			pm.searchCache.put(gen, cached)
+		}
+	}
+	return cached.query, cached.hits, nil
+}
+
+// hydrate loads the playbook of an index hit, reporting false if it no longer
+// exists.
+func (pm *PlaybookManager) hydrate(ctx context.Context, hit SearchResult) (SearchResult, bool) {
+	pb, err := pm.store.GetPlaybook(ctx, hit.Playbook.ID)
+	if err != nil {
+		return SearchResult{}, false
+	}
+	return SearchResult{
+		Playbook:       pb,
+		Score:          hit.Score,
+		TextScore:      hit.Score,
+		Explanation:    hit.Explanation,
+		DegradedToBM25: hit.DegradedToBM25,
+		DegradedReason: hit.DegradedReason,
+	}, true
+}
+
+// unhydrated returns copies of index hits, for a search with SkipHydration,
+// so callers cannot modify cached hits.
+func unhydrated(hits []SearchResult) []SearchResult {
+	results := make([]SearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = hit
+		results[i].Playbook = &Playbook{ID: hit.Playbook.ID}
+		results[i].TextScore = hit.Score
+	}
+	return results
+}
+
+// warnMixedModels logs when a vector search returned playbooks embedded with
+// another model, since vector similarity is meaningless across models.
+func (pm *PlaybookManager) warnMixedModels(query SearchQuery, mixed, results int) {
+	if mixed > 0 && query.Mode != SearchModeBM25 && len(query.Embedding) > 0 {
+		pm.log.Warn("search results include playbooks embedded with a different model",
+			"current_model", pm.cfg.EmbedModel, "mismatched", mixed, "results", results)
+	}
+}
+
+// clampLimit caps a search limit at MaxSearchResults, logging when it does.
+func (pm *PlaybookManager) clampLimit(limit int) int {
+	if limit > pm.cfg.MaxSearchResults {
+		pm.log.Warn("search limit above the maximum, clamping", "limit", limit, "max", pm.cfg.MaxSearchResults)
+		return pm.cfg.MaxSearchResults
+	}
+	return limit
+}
+
+// searchIndex embeds the query text, unless an embedding was given, and runs
+// the query against the index. It returns the query as searched, with the
+// embedding and any fallback to BM25, along with the unhydrated hits. If a
+// hybrid or vector search fell back to BM25, degraded says why and every hit
+// is marked with it.
+func (pm *PlaybookManager) searchIndex(ctx context.Context, query SearchQuery) (_ SearchQuery, _ []SearchResult, degraded DegradeReason, _ error) {
+	// Embed the free text only, without the +term and -term operators
+	query = query.withTermOperators()
+
 	// Generate query embedding if not provided and we have an embed function
 	if len(query.Embedding) == 0 && query.Text != "" {
 		emb, err := pm.embedFn(ctx, query.Text)
 		if err != nil {
 			// Non-fatal: fall back to BM25 only
 			pm.log.Warn("embedding failed, falling back to BM25", "error", err)
-			query.Mode = SearchModeBM25
+			degraded = DegradeEmbeddingFailed
+		} else if err := pm.checkEmbeddingDims(emb); err != nil {
+			pm.log.Warn("query embedding rejected, falling back to BM25", "error", err)
+			degraded = DegradeDimensionMismatch
 		} else {
 			query.Embedding = emb
 		}
 	}
+	if degraded != "" {
+		if query.Mode == SearchModeBM25 {
+			degraded = "" // nothing was lost
+		} else {
+			pm.metrics.SearchDegraded(degraded)
+		}
+		query.Mode = SearchModeBM25
+	}
+	if !pm.cfg.DisableNormalize {
+		query.Embedding = embed.Normalize(query.Embedding)
+	}
+	if query.Mode != SearchModeBM25 && len(query.Embedding) > 0 {
+		pm.warnIfNoVectorSearch(query.Mode)
+	}
 
 	results, err := pm.indexer.Search(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("search: %w", err)
+		return query, nil, degraded, fmt.Errorf("search: %w", err)
 	}
+	if degraded != "" {
+		for i := range results {
+			results[i].DegradedToBM25, results[i].DegradedReason = true, degraded
+		}
+	}
+	return query, results, degraded, nil
+}
 
-	// Hydrate results with full playbook data
-	hydrated := make([]SearchResult, 0, len(results))
-	for _, r := range results {
-		pb, err := pm.store.GetPlaybook(ctx, r.Playbook.ID)
-		if err != nil {
-			continue // Skip if playbook was deleted between search and fetch
+// SearchGrouped runs Search and returns the hits as groups. With
+// query.GroupByLineage set, hits whose ForkedFrom chains lead to the same root
+// playbook are grouped together, best hit first; otherwise every hit is its
+// own group. Groups are ordered by their best hit's score. Grouping by lineage
+// needs each playbook's ForkedFrom, so it cannot skip hydration.
+func (pm *PlaybookManager) SearchGrouped(ctx context.Context, query SearchQuery) ([]SearchGroup, error) {
+	if query.GroupByLineage && query.SkipHydration {
+		return nil, fmt.Errorf("search: GroupByLineage needs hydrated results; unset SkipHydration")
+	}
+	results, err := pm.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]SearchGroup, 0, len(results))
+	if !query.GroupByLineage {
+		for _, r := range results {
+			groups = append(groups, SearchGroup{RootID: r.Playbook.ID, Best: r})
 		}
-		hydrated = append(hydrated, SearchResult{
-			Playbook: pb,
-			Score:    r.Score,
-		})
+		return groups, nil
+	}
+
+	roots := make(map[string]string) // playbook ID -> lineage root ID
+	for _, r := range results {
+		roots[r.Playbook.ID] = r.Playbook.ForkedFrom
 	}
 
-	// Composite score blending
-	if query.ConfidenceWeight > 0 && len(hydrated) > 0 {
-		w := query.ConfidenceWeight
-		if w > 1 {
-			w = 1
+	byRoot := make(map[string]int)
+	for _, r := range results {
+		root := pm.lineageRoot(ctx, r.Playbook, roots)
+		if i, ok := byRoot[root]; ok {
+			groups[i].Variants = append(groups[i].Variants, r)
+			continue
 		}
+		byRoot[root] = len(groups)
+		groups = append(groups, SearchGroup{RootID: root, Best: r})
+	}
+	return groups, nil
+}
 
-		// Find min/max text scores for normalization
-		minScore, maxScore := hydrated[0].Score, hydrated[0].Score
-		for _, r := range hydrated[1:] {
-			if r.Score < minScore {
-				minScore = r.Score
+// lineageRoot follows pb's ForkedFrom chain to its first ancestor. parents
+// caches ID -> ForkedFrom and is filled in from the store as needed. A deleted
+// ancestor ends the chain at its child.
+func (pm *PlaybookManager) lineageRoot(ctx context.Context, pb *Playbook, parents map[string]string) string {
+	id, parent := pb.ID, pb.ForkedFrom
+	seen := map[string]bool{id: true}
+	for parent != "" && !seen[parent] {
+		grandparent, ok := parents[parent]
+		if !ok {
+			p, err := pm.store.GetPlaybook(ctx, parent)
+			if err != nil {
+				break
 			}
-			if r.Score > maxScore {
-				maxScore = r.Score
-			}
-		}
-
-		// Blend and re-sort
-		for i := range hydrated {
-			norm := normalizeScore(hydrated[i].Score, minScore, maxScore)
-			hydrated[i].Score = (1-w)*norm + w*hydrated[i].Playbook.Confidence
+			grandparent = p.ForkedFrom
+			parents[parent] = grandparent
 		}
-
-		sort.Slice(hydrated, func(i, j int) bool {
-			return hydrated[i].Score > hydrated[j].Score
-		})
+		seen[parent] = true
+		id, parent = parent, grandparent
 	}
-
-	return hydrated, nil
+	return id
 }
 
 // normalizeScore applies min-max normalization to [0,1].
@@ -265,7 +1196,7 @@ func normalizeScore(score, min, max float64) float64 {
 // RecordExecution saves an execution record and updates the playbook stats.
 func (pm *PlaybookManager) RecordExecution(ctx context.Context, rec *ExecutionRecord) error {
 	if rec.ID == "" {
-		rec.ID = uuid.New().String()
+		rec.ID = pm.cfg.IDGenerator()
 	}
 
 	// Save execution
@@ -274,10 +1205,13 @@ func (pm *PlaybookManager) RecordExecution(ctx context.Context, rec *ExecutionRe
 	}
 
 	// Update playbook stats
-	pb, err := pm.store.GetPlaybook(ctx, rec.PlaybookID)
+	pm.mu.Lock()
+	current, err := pm.store.GetPlaybook(ctx, rec.PlaybookID)
 	if err != nil {
+		pm.mu.Unlock()
 		return fmt.Errorf("get playbook for stats update: %w", err)
 	}
+	pb := *current
 
 	switch rec.Outcome {
 	case OutcomeSuccess:
@@ -290,15 +1224,26 @@ func (pm *PlaybookManager) RecordExecution(ctx context.Context, rec *ExecutionRe
 	}
 
 	pb.LastUsedAt = rec.CompletedAt
-	pb.UpdateStats()
+	pb.LastUsedBy = rec.AgentID
+	pm.updateStats(&pb)
 
-	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
-		return fmt.Errorf("save updated playbook: %w", err)
+	execs, err := pm.store.ListExecutions(ctx, rec.PlaybookID, DurationSampleSize)
+	if err != nil {
+		pm.mu.Unlock()
+		return fmt.Errorf("list executions for duration stats: %w", err)
 	}
+	durations := durationStats(execs)
+	pb.AvgDuration, pb.P95Duration = durations.Avg, durations.P95
+	pb.TaskContexts = recentTaskContexts(execs)
 
-	// Re-index with updated stats
-	if err := pm.indexer.Index(ctx, pb); err != nil {
-		return fmt.Errorf("re-index playbook: %w", err)
+	// Stats are not content: save and re-index without re-embedding
+	err = pm.saveMetadataLocked(ctx, current, &pb)
+	pm.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("update playbook stats: %w", err)
+	}
+	if from, to := healthTag(current.Tags), healthTag(pb.Tags); from != to {
+		pm.emit(EventHealthChanged, pb.ID, map[string]string{"from": from, "to": to})
 	}
 
 	// Auto-reflect if enabled
@@ -317,38 +1262,94 @@ func (pm *PlaybookManager) ListExecutions(ctx context.Context, playbookID string
 	return pm.store.ListExecutions(ctx, playbookID, limit)
 }
 
-// ApplyReflection applies improvements from a reflection to a playbook.
-func (pm *PlaybookManager) ApplyReflection(ctx context.Context, playbookID string, ref *Reflection) error {
-	pb, err := pm.store.GetPlaybook(ctx, playbookID)
+// ListExecutionsFiltered returns executions for a playbook matching the filter, newest first.
+func (pm *PlaybookManager) ListExecutionsFiltered(ctx context.Context, playbookID string, filter ExecutionFilter) ([]*ExecutionRecord, error) {
+	return pm.store.ListExecutionsFiltered(ctx, playbookID, filter)
+}
+
+// QueryFailureStat summarizes the executions recorded for one originating search query.
+type QueryFailureStat struct {
+	Query      string `json:"query"`
+	Failures   int    `json:"failures"`
+	Executions int    `json:"executions"`
+}
+
+// QueriesLeadingToFailures aggregates a playbook's executions by
+// SelectedFromQuery and returns the queries that led to at least one failure,
+// most failures first. Executions without a query are ignored.
+func (pm *PlaybookManager) QueriesLeadingToFailures(ctx context.Context, id string) ([]QueryFailureStat, error) {
+	execs, err := pm.store.ListExecutions(ctx, id, 0)
 	if err != nil {
-		return fmt.Errorf("get playbook: %w", err)
+		return nil, fmt.Errorf("list executions: %w", err)
+	}
+
+	byQuery := make(map[string]*QueryFailureStat)
+	for _, e := range execs {
+		if e.SelectedFromQuery == "" {
+			continue
+		}
+		st, ok := byQuery[e.SelectedFromQuery]
+		if !ok {
+			st = &QueryFailureStat{Query: e.SelectedFromQuery}
+			byQuery[e.SelectedFromQuery] = st
+		}
+		st.Executions++
+		if e.Outcome == OutcomeFailure {
+			st.Failures++
+		}
 	}
 
-	// Add lessons from improvements
-	for _, improvement := range ref.Improvements {
-		lesson := Lesson{
-			ID:          uuid.New().String(),
-			Content:     improvement,
-			LearnedFrom: "reflection",
-			LearnedAt:   time.Now(),
-			Applies:     "general",
-			Confidence:  0.5,
+	var stats []QueryFailureStat
+	for _, st := range byQuery {
+		if st.Failures > 0 {
+			stats = append(stats, *st)
 		}
-		pb.Lessons = append(pb.Lessons, lesson)
 	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Failures != stats[j].Failures {
+			return stats[i].Failures > stats[j].Failures
+		}
+		return stats[i].Query < stats[j].Query
+	})
+	return stats, nil
+}
 
-	// Update the playbook (increments version, re-embeds, re-indexes)
-	return pm.Update(ctx, pb)
+// ApplyReflection applies improvements from a reflection to a playbook. An
+// improvement that repeats an existing lesson reinforces that lesson instead of
+// adding a new one; see ConsolidateLessons for a fuller cleanup. A reflection
+// without improvements leaves the playbook unchanged.
+func (pm *PlaybookManager) ApplyReflection(ctx context.Context, playbookID string, ref *Reflection) error {
+	if ref == nil || len(ref.Improvements) == 0 {
+		return nil
+	}
+	// Update the playbook (increments version, re-embeds, re-indexes),
+	// re-applying the lessons on a fresh copy if it changed concurrently.
+	_, err := pm.UpdateWithRetry(ctx, playbookID, func(pb *Playbook) error {
+		// Add lessons from improvements
+		for _, improvement := range ref.Improvements {
+			lesson := Lesson{
+				ID:          pm.cfg.IDGenerator(),
+				Content:     improvement,
+				LearnedFrom: "reflection",
+				LearnedAt:   time.Now(),
+				Applies:     "general",
+				Confidence:  0.5,
+			}
+			pb.Lessons = addLesson(pb.Lessons, lesson)
+		}
+		return nil
+	}, defaultUpdateRetries)
+	return err
 }
 
-// Prune archives playbooks that are stale or have low confidence.
+// Prune archives playbooks that are stale, have low confidence, or, with
+// PruneOptions.FailureStreak, keep failing. A playbook that cannot be
+// archived is reported in PruneResult.Failed, unchanged, and the rest are
+// still pruned; Prune returns an error only if it cannot list the playbooks.
 func (pm *PlaybookManager) Prune(ctx context.Context, opts PruneOptions) (*PruneResult, error) {
 	if opts.MaxAge == 0 {
 		opts.MaxAge = pm.cfg.MaxAge
 	}
-	if opts.MinConfidence == 0 {
-		opts.MinConfidence = pm.cfg.MinConfidence
-	}
 
 	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
 	if err != nil {
@@ -357,58 +1358,343 @@ func (pm *PlaybookManager) Prune(ctx context.Context, opts PruneOptions) (*Prune
 
 	result := &PruneResult{}
 	cutoff := time.Now().Add(-opts.MaxAge)
+	graceCutoff := time.Now().Add(-opts.MinAgeToPrune)
 
 	for _, pb := range playbooks {
 		if pb.Archived {
 			continue
 		}
 
-		shouldPrune := false
+		// Too new to judge, e.g. just added during a bulk import
+		if opts.MinAgeToPrune > 0 && pb.CreatedAt.After(graceCutoff) {
+			continue
+		}
 
+		minConfidence := pm.pruneMinConfidence(pb, opts.MinConfidence)
+		var reason PruneReason
+		switch {
+		// Stale (unused too long)
+		case !pb.LastUsedAt.IsZero() && pb.LastUsedAt.Before(cutoff):
+			reason = PruneReasonStale
+		// Never used + old + low confidence
+		case pb.LastUsedAt.IsZero() && pb.CreatedAt.Before(cutoff) && pb.Confidence < minConfidence:
+			reason = PruneReasonNeverUsedLowConfidence
 		// Low confidence + old age
-		if pb.Confidence < opts.MinConfidence && pb.UpdatedAt.Before(cutoff) {
-			shouldPrune = true
+		case pb.Confidence < minConfidence && pb.UpdatedAt.Before(cutoff):
+			reason = PruneReasonLowConfidenceAndOld
 		}
 
-		// Stale (unused too long)
-		if !pb.LastUsedAt.IsZero() && pb.LastUsedAt.Before(cutoff) {
-			shouldPrune = true
+		if reason == "" && opts.FailureStreak > 0 {
+			failing, err := pm.failureStreak(ctx, pb.ID, opts.FailureStreak)
+			if err != nil {
+				pm.log.Warn("prune: listing executions failed", "playbook_id", pb.ID, "error", err)
+				continue
+			}
+			if failing {
+				reason = PruneReasonFailureStreak
+			}
 		}
 
-		// Never used + old + low confidence
-		if pb.LastUsedAt.IsZero() && pb.CreatedAt.Before(cutoff) && pb.Confidence < opts.MinConfidence {
-			shouldPrune = true
+		if reason == "" {
+			continue
+		}
+		item := PrunedItem{ID: pb.ID, Name: pb.Name, Reason: reason}
+		if !opts.DryRun {
+			if err := pm.archive(ctx, pb.ID); err != nil {
+				item.Error = err.Error()
+				result.Failed = append(result.Failed, item)
+				pm.log.Warn("prune: archiving failed", "playbook_id", pb.ID, "error", err)
+				continue
+			}
+			pm.emit(EventArchived, pb.ID, map[string]string{"reason": string(reason)})
+		}
+		result.Archived = append(result.Archived, item)
+	}
+
+	return result, nil
+}
+
+// failureStreak reports whether the playbook's last n executions all failed.
+// A playbook with fewer than n executions has no streak.
+func (pm *PlaybookManager) failureStreak(ctx context.Context, id string, n int) (bool, error) {
+	execs, err := pm.store.ListExecutions(ctx, id, n)
+	if err != nil {
+		return false, err
+	}
+	if len(execs) < n {
+		return false, nil
+	}
+	for _, rec := range execs {
+		if rec.Outcome != OutcomeFailure {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// archive marks the playbook archived in the store and removes it from the
+// index. It re-reads the playbook under pm.mu, so a write made since Prune
+// listed it is kept. If the index removal fails, the store change is rolled
+// back so the playbook stays active and searchable rather than archived but
+// still in the index.
+func (pm *PlaybookManager) archive(ctx context.Context, id string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pb, err := pm.store.GetPlaybook(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get playbook: %w", err)
+	}
+	archived := *pb
+	archived.Archived = true
+	archived.UpdatedAt = time.Now()
+	if err := pm.store.SavePlaybook(ctx, &archived); err != nil {
+		return fmt.Errorf("archive playbook: %w", err)
+	}
+	if err := pm.indexer.Remove(ctx, id); err != nil {
+		if rbErr := pm.store.SavePlaybook(ctx, pb); rbErr != nil {
+			return fmt.Errorf("remove from index: %w; restoring the unarchived playbook also failed, so it is archived but still indexed: %v", err, rbErr)
+		}
+		return fmt.Errorf("remove from index: %w", err)
+	}
+	return nil
+}
+
+// Restore brings back a playbook archived by Prune: it clears Archived, saves
+// the playbook, and re-indexes it so it is searchable again. Archiving never
+// changes Status, so the playbook keeps the status it had before. Restoring a
+// playbook that is not archived is an error.
+func (pm *PlaybookManager) Restore(ctx context.Context, id string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pb, err := pm.store.GetPlaybook(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get playbook: %w", err)
+	}
+	if !pb.Archived {
+		return fmt.Errorf("playbook %s is not archived", id)
+	}
+	pb.Archived = false
+	pb.UpdatedAt = time.Now()
+
+	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+		return fmt.Errorf("save playbook: %w", err)
+	}
+	if err := pm.indexer.Index(ctx, pb); err != nil {
+		return fmt.Errorf("re-index playbook: %w", err)
+	}
+	pm.emit(EventRestored, pb.ID, nil)
+	return nil
+}
+
+// PruneExecutions deletes old execution records. Only the raw records are
+// removed; the success and failure counts on each playbook are left untouched.
+func (pm *PlaybookManager) PruneExecutions(ctx context.Context, opts ExecutionPruneOptions) (*ExecutionPruneResult, error) {
+	result := &ExecutionPruneResult{ByPlaybook: make(map[string]int)}
+	if opts.MaxAge <= 0 && opts.MaxPerPlaybook <= 0 {
+		return result, nil
+	}
+
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var cutoff time.Time
+	if opts.MaxAge > 0 {
+		cutoff = time.Now().Add(-opts.MaxAge)
+	}
+
+	for _, pb := range playbooks {
+		// Newest first, so everything past MaxPerPlaybook is the oldest
+		execs, err := pm.store.ListExecutions(ctx, pb.ID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("list executions for %s: %w", pb.ID, err)
 		}
 
-		if shouldPrune {
-			result.Archived = append(result.Archived, pb.ID)
+		for i, rec := range execs {
+			tooMany := opts.MaxPerPlaybook > 0 && i >= opts.MaxPerPlaybook
+			tooOld := !cutoff.IsZero() && rec.StartedAt.Before(cutoff)
+			if !tooMany && !tooOld {
+				continue
+			}
+
 			if !opts.DryRun {
-				pb.Archived = true
-				pb.UpdatedAt = time.Now()
-				if err := pm.store.SavePlaybook(ctx, pb); err != nil {
-					return nil, fmt.Errorf("archive playbook %s: %w", pb.ID, err)
-				}
-				if err := pm.indexer.Remove(ctx, pb.ID); err != nil {
-					return nil, fmt.Errorf("remove archived playbook from index %s: %w", pb.ID, err)
+				if err := pm.store.DeleteExecution(ctx, pb.ID, rec.ID); err != nil {
+					return nil, fmt.Errorf("prune execution %s: %w", rec.ID, err)
 				}
 			}
+			result.Removed++
+			result.ByPlaybook[pb.ID]++
 		}
 	}
 
 	return result, nil
 }
 
-// Reindex rebuilds the entire search index from stored playbooks.
-func (pm *PlaybookManager) Reindex(ctx context.Context) error {
+// Reindex rebuilds the entire search index from stored playbooks. When an
+// embedding provider is configured, it first re-embeds the playbooks whose
+// stored embedding is missing or out of date, as after switching models, with
+// at most ReindexOptions.Concurrency embedding calls in flight. Once every
+// playbook is indexed, index entries with no matching non-archived playbook
+// are removed. A playbook that fails to embed is indexed with the embedding it
+// had, and the failures are returned together, with the result, once the index
+// is rebuilt. Cancelling ctx stops the embedding and leaves the index as it
+// was. EstimateReindex reports the work without doing it.
+func (pm *PlaybookManager) Reindex(ctx context.Context, opts ...ReindexOptions) (*ReindexResult, error) {
+	var o ReindexOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
 	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	result := &ReindexResult{Reembedded: pm.countStaleEmbeddings(playbooks)}
+	progress := newReindexProgress(o.Progress, len(playbooks), result.Reembedded)
+	var embedErr error
+	if pm.cfg.EmbedFunc != nil {
+		embedErr = pm.refreshEmbeddings(ctx, playbooks, pm.needsEmbedding, o.Concurrency, progress)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if joined, ok := embedErr.(interface{ Unwrap() []error }); ok {
+		result.EmbedErrors = len(joined.Unwrap())
+		result.Reembedded -= result.EmbedErrors
+	}
+	for start := 0; start < len(playbooks); start += ReindexBatchSize {
+		batch := playbooks[start:min(start+ReindexBatchSize, len(playbooks))]
+		if err := pm.indexer.Reindex(ctx, batch); err != nil {
+			return nil, err
+		}
+		result.Indexed += len(batch)
+		progress.indexed(len(batch))
+	}
+
+	docIDs, err := pm.indexer.DocIDs(ctx)
+	if err != nil {
+		return result, fmt.Errorf("list index documents: %w", err)
+	}
+	active := make(map[string]bool, len(playbooks))
+	for _, pb := range playbooks {
+		active[pb.ID] = true
+	}
+	for _, id := range docIDs {
+		if active[id] {
+			continue
+		}
+		if err := pm.indexer.Remove(ctx, id); err != nil {
+			return result, fmt.Errorf("remove orphaned index entry %s: %w", id, err)
+		}
+		result.Removed++
+	}
+
+	if embedErr != nil {
+		return result, fmt.Errorf("index rebuilt, but some playbooks kept their old embedding: %w", embedErr)
+	}
+	return result, nil
+}
+
+// IndexDrift compares the stored playbooks against the search index. It returns
+// the IDs of non-archived playbooks that are missing from the index, and the IDs
+// of index entries with no matching non-archived playbook in the store. Both
+// slices are sorted.
+func (pm *PlaybookManager) IndexDrift(ctx context.Context) (missingFromIndex, extraInIndex []string, err error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("list playbooks: %w", err)
+	}
+	docIDs, err := pm.indexer.DocIDs(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list index documents: %w", err)
+	}
+
+	stored := make(map[string]bool, len(playbooks))
+	for _, pb := range playbooks {
+		stored[pb.ID] = true
+	}
+	indexed := make(map[string]bool, len(docIDs))
+	for _, id := range docIDs {
+		indexed[id] = true
+		if !stored[id] {
+			extraInIndex = append(extraInIndex, id)
+		}
+	}
+	for _, pb := range playbooks {
+		if !indexed[pb.ID] {
+			missingFromIndex = append(missingFromIndex, pb.ID)
+		}
+	}
+
+	sort.Strings(missingFromIndex)
+	sort.Strings(extraInIndex)
+	return missingFromIndex, extraInIndex, nil
+}
+
+// DriftReport lists the differences between the store and the search index.
+type DriftReport struct {
+	MissingFromIndex []string `json:"missing_from_index"` // Non-archived playbooks with no index entry
+	OrphanedInIndex  []string `json:"orphaned_in_index"`  // Index entries with no matching non-archived playbook
+}
+
+// InSync reports whether the report found no drift.
+func (r *DriftReport) InSync() bool {
+	return len(r.MissingFromIndex) == 0 && len(r.OrphanedInIndex) == 0
+}
+
+// Verify compares the stored playbooks against the search index, as
+// IndexDrift does, and reports the drift in both directions.
+func (pm *PlaybookManager) Verify(ctx context.Context) (*DriftReport, error) {
+	missing, extra, err := pm.IndexDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &DriftReport{MissingFromIndex: missing, OrphanedInIndex: extra}, nil
+}
+
+// Repair fixes the drift found by Verify: it indexes the playbooks missing
+// from the index and removes orphaned index entries, leaving the rest of the
+// index untouched. This is cheaper than Reindex on a large store. It returns
+// the drift it repaired.
+func (pm *PlaybookManager) Repair(ctx context.Context) (*DriftReport, error) {
+	report, err := pm.Verify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]*Playbook, 0, len(report.MissingFromIndex))
+	for _, id := range report.MissingFromIndex {
+		pb, err := pm.store.GetPlaybook(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("load playbook %s: %w", id, err)
+		}
+		missing = append(missing, pb)
+	}
+	if len(missing) > 0 {
+		if err := pm.indexer.Reindex(ctx, missing); err != nil {
+			return nil, fmt.Errorf("index missing playbooks: %w", err)
+		}
 	}
-	return pm.indexer.Reindex(ctx, playbooks)
+	for _, id := range report.OrphanedInIndex {
+		if err := pm.indexer.Remove(ctx, id); err != nil {
+			return nil, fmt.Errorf("remove orphaned entry: %w", err)
+		}
+	}
+	return report, nil
 }
 
-// Stats returns aggregate statistics across all playbooks.
-func (pm *PlaybookManager) Stats(ctx context.Context) (*Stats, error) {
+// Stats returns aggregate statistics across all playbooks. All-time numbers come
+// from each playbook's cumulative counters; pass StatsOptions with Since set to
+// also get numbers for a recent window.
+func (pm *PlaybookManager) Stats(ctx context.Context, opts ...StatsOptions) (*Stats, error) {
+	var o StatsOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
 	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
 	if err != nil {
 		return nil, err
@@ -424,9 +1710,6 @@ func (pm *PlaybookManager) Stats(ctx context.Context) (*Stats, error) {
 		if pb.Archived {
 			stats.TotalArchived++
 		}
-		if pb.Category != "" {
-			stats.ByCategory[pb.Category]++
-		}
 		totalConfidence += pb.Confidence
 		stats.TotalExecs += pb.SuccessCount + pb.FailureCount
 	}
@@ -434,26 +1717,249 @@ func (pm *PlaybookManager) Stats(ctx context.Context) (*Stats, error) {
 	if len(playbooks) > 0 {
 		stats.AvgConfidence = totalConfidence / float64(len(playbooks))
 	}
+	for _, g := range groupByCategory(playbooks) {
+		if g.Category != "" {
+			stats.ByCategory[g.Category] = g.Count
+		}
+	}
+
+	if stats.IndexDocCount, err = indexDocCount(ctx, pm.baseIndexer); err != nil {
+		return nil, fmt.Errorf("index doc count: %w", err)
+	}
+	if s, ok := pm.baseIndexer.(interface{ SizeBytes() (int64, error) }); ok {
+		if stats.IndexSizeBytes, err = s.SizeBytes(); err != nil {
+			return nil, fmt.Errorf("index size: %w", err)
+		}
+	}
+
+	if !o.Since.IsZero() {
+		stats.Since = o.Since
+		var successes int
+		for _, pb := range playbooks {
+			execs, err := pm.store.ListExecutionsFiltered(ctx, pb.ID, ExecutionFilter{StartedAfter: o.Since})
+			if err != nil {
+				return nil, fmt.Errorf("list executions for %s: %w", pb.ID, err)
+			}
+			for _, e := range execs {
+				stats.RecentExecs++
+				// Partial counts as a success, matching RecordExecution
+				if e.Outcome == OutcomeSuccess || e.Outcome == OutcomePartial {
+					successes++
+				}
+			}
+		}
+		if stats.RecentExecs > 0 {
+			stats.RecentSuccessRate = float64(successes) / float64(stats.RecentExecs)
+		}
+	}
 
 	return stats, nil
 }
 
-// generateEmbedding creates an embedding for the playbook's text content.
-func (pm *PlaybookManager) generateEmbedding(ctx context.Context, pb *Playbook) error {
+// updateStats recalculates the playbook's stats and, when AutoHealthTags is
+// enabled, brings the reserved health tags in line with them.
+func (pm *PlaybookManager) updateStats(pb *Playbook) {
+	pb.UpdateStatsZ(pm.cfg.ConfidenceZ)
+	if pm.cfg.AutoHealthTags {
+		applyHealthTags(pb)
+	}
+}
+
+// ConfidenceInterval returns the Wilson score interval of pb's success and
+// failure counts at the manager's ConfidenceZ, whose lower bound is pb's
+// Confidence.
+func (pm *PlaybookManager) ConfidenceInterval(pb *Playbook) ConfidenceInterval {
+	return WilsonIntervalZ(pb.SuccessCount, pb.FailureCount, pm.cfg.ConfidenceZ)
+}
+
+// embedText returns the text a playbook's embedding is generated from.
+func (pm *PlaybookManager) embedText(pb *Playbook) string {
 	var stepActions []string
 	for _, s := range pb.Steps {
 		stepActions = append(stepActions, s.Action)
 	}
 
-	text := embed.TextForPlaybook(pb.Name, pb.Description, pb.Tags, stepActions)
+	// Health tags are derived from stats, not content, so keep them out of the embedding
+	tags := pb.Tags
+	if pm.cfg.AutoHealthTags {
+		tags = withoutHealthTags(tags)
+	}
+
+	return embed.TextForPlaybook(pb.Name, pb.Description, tags, stepActions)
+}
+
+// embedTextHash returns a hash of embedText, recorded as Playbook.EmbedHash to
+// tell whether a change affects the embedding.
+func (pm *PlaybookManager) embedTextHash(pb *Playbook) string {
+	sum := sha256.Sum256([]byte(pm.embedText(pb)))
+	return hex.EncodeToString(sum[:])
+}
+
+// reuseEmbedding copies current's embedding onto pb if it is still valid for
+// pb: generated from the same text by the configured model. Playbooks saved
+// before EmbedHash existed have no hash and are always re-embedded once.
+func (pm *PlaybookManager) reuseEmbedding(current, pb *Playbook) bool {
+	if current.EmbedHash == "" || current.EmbedHash != pm.embedTextHash(pb) ||
+		current.EmbedModel != pm.cfg.EmbedModel || pm.hasStaleEmbedding(current) {
+		return false
+	}
+	pb.Embedding, pb.EmbedModel, pb.EmbedDims, pb.EmbedHash = current.Embedding, current.EmbedModel, current.EmbedDims, current.EmbedHash
+	return true
+}
+
+// generateEmbedding creates an embedding for the playbook's text content.
+func (pm *PlaybookManager) generateEmbedding(ctx context.Context, pb *Playbook) error {
+	text := pm.embedText(pb)
 	emb, err := pm.embedFn(ctx, text)
 	if err != nil {
 		return err
 	}
+	if err := pm.checkEmbeddingDims(emb); err != nil {
+		return err
+	}
+	if !pm.cfg.DisableNormalize {
+		emb = embed.Normalize(emb)
+	}
 	pb.Embedding = emb
+	pb.EmbedHash = pm.embedTextHash(pb)
+	if len(emb) > 0 {
+		pb.EmbedModel = pm.cfg.EmbedModel
+		pb.EmbedDims = len(emb)
+	} else {
+		pb.EmbedModel = ""
+		pb.EmbedDims = 0
+	}
+	return nil
+}
+
+// warnIfNoVectorSearch logs, once per manager, that a vector or hybrid search
+// with an embedding will run as BM25 only because this build or configuration
+// cannot search vectors.
+func (pm *PlaybookManager) warnIfNoVectorSearch(mode SearchMode) {
+	var reason string
+	switch {
+	case !VectorSearchEnabled:
+		reason = "binary built without -tags vectors"
+	case pm.cfg.EmbedDims == 0:
+		reason = "EmbedDims is 0, so the index has no vector field"
+	default:
+		return
+	}
+	if mode == "" {
+		mode = SearchModeHybrid
+	}
+	pm.vectorWarnOnce.Do(func() {
+		pm.log.Warn("vector search unavailable, using BM25 only", "mode", mode, "reason", reason)
+	})
+}
+
+// checkEmbeddingDims rejects a non-empty embedding whose length differs from
+// the configured EmbedDims, such as one from a fallback provider with a
+// different model, so it cannot be indexed alongside incompatible vectors.
+func (pm *PlaybookManager) checkEmbeddingDims(emb []float32) error {
+	if pm.cfg.EmbedDims > 0 && len(emb) > 0 && len(emb) != pm.cfg.EmbedDims {
+		return fmt.Errorf("embedding has %d dimensions, expected %d", len(emb), pm.cfg.EmbedDims)
+	}
 	return nil
 }
 
+// hasStaleEmbedding reports whether pb carries an embedding that was not
+// produced by the currently configured model and dimensions.
+func (pm *PlaybookManager) hasStaleEmbedding(pb *Playbook) bool {
+	if len(pb.Embedding) == 0 {
+		return false
+	}
+	if pb.EmbedModel != pm.cfg.EmbedModel {
+		return true
+	}
+	return pm.cfg.EmbedDims > 0 && len(pb.Embedding) != pm.cfg.EmbedDims
+}
+
+// StaleEmbeddings returns non-archived playbooks whose stored embedding was
+// generated by a different model or with different dimensions than the manager
+// is currently configured for. Updating these playbooks re-embeds them.
+func (pm *PlaybookManager) StaleEmbeddings(ctx context.Context) ([]*Playbook, error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []*Playbook
+	for _, pb := range playbooks {
+		if pm.hasStaleEmbedding(pb) {
+			stale = append(stale, pb)
+		}
+	}
+	return stale, nil
+}
+
+// AllLessons collects the lessons of all non-archived playbooks matching
+// filter, each annotated with its source playbook, sorted by descending
+// confidence and then by most recently learned.
+func (pm *PlaybookManager) AllLessons(ctx context.Context, filter LessonFilter) ([]LessonWithSource, error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{Category: filter.Category})
+	if err != nil {
+		return nil, err
+	}
+
+	var lessons []LessonWithSource
+	for _, pb := range playbooks {
+		for _, l := range pb.Lessons {
+			if l.Confidence < filter.MinConfidence {
+				continue
+			}
+			lessons = append(lessons, LessonWithSource{
+				Lesson:       l,
+				PlaybookID:   pb.ID,
+				PlaybookName: pb.Name,
+				PlaybookSlug: pb.Slug,
+				Category:     pb.Category,
+			})
+		}
+	}
+
+	sort.SliceStable(lessons, func(i, j int) bool {
+		if lessons[i].Confidence != lessons[j].Confidence {
+			return lessons[i].Confidence > lessons[j].Confidence
+		}
+		return lessons[i].LearnedAt.After(lessons[j].LearnedAt)
+	})
+	return lessons, nil
+}
+
+// ColdStartCandidates returns non-archived playbooks with fewer executions than
+// ColdExecutionThreshold, so test runs can be routed to them deliberately while
+// search ranking favors established playbooks. Playbooks that have failed every
+// run so far are left out. Results are ordered by fewest executions, then
+// newest first.
+func (pm *PlaybookManager) ColdStartCandidates(ctx context.Context) ([]*Playbook, error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var cold []*Playbook
+	for _, pb := range playbooks {
+		if pb.SuccessCount+pb.FailureCount >= pm.cfg.ColdExecutionThreshold {
+			continue
+		}
+		if pb.FailureCount > 0 && pb.SuccessCount == 0 {
+			continue
+		}
+		cold = append(cold, pb)
+	}
+
+	sort.Slice(cold, func(i, j int) bool {
+		ni := cold[i].SuccessCount + cold[i].FailureCount
+		nj := cold[j].SuccessCount + cold[j].FailureCount
+		if ni != nj {
+			return ni < nj
+		}
+		return cold[i].CreatedAt.After(cold[j].CreatedAt)
+	})
+	return cold, nil
+}
+
 var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
 
 // slugify converts a name to a URL-safe slug.
@@ -1,12 +1,23 @@
 package playbookd
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/blevesearch/bleve/v2"
 	"github.com/lucas-stellet/playbookd/embed"
 )
 
@@ -41,6 +52,22 @@ func samplePlaybook(name string) *Playbook {
 	}
 }
 
+// recordOutcomes records n executions with the given outcome for a playbook.
+func recordOutcomes(t *testing.T, pm *PlaybookManager, id string, outcome Outcome, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		rec := &ExecutionRecord{
+			PlaybookID:  id,
+			Outcome:     outcome,
+			StartedAt:   time.Now(),
+			CompletedAt: time.Now(),
+		}
+		if err := pm.RecordExecution(context.Background(), rec); err != nil {
+			t.Fatalf("RecordExecution: %v", err)
+		}
+	}
+}
+
 func TestManagerCreate(t *testing.T) {
 	pm := newTestManager(t)
 	ctx := context.Background()
@@ -61,6 +88,117 @@ func TestManagerCreate(t *testing.T) {
 	}
 }
 
+func TestManagerCreatePreserveTimestamps(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	updated := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	fresh := samplePlaybook("Stamped Now")
+	fresh.CreatedAt, fresh.UpdatedAt = created, updated
+	if err := pm.Create(ctx, fresh); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if fresh.CreatedAt.Equal(created) || fresh.UpdatedAt.Equal(updated) {
+		t.Errorf("Create kept the timestamps %v/%v, want them stamped now", fresh.CreatedAt, fresh.UpdatedAt)
+	}
+
+	kept := samplePlaybook("Kept Timestamps")
+	kept.CreatedAt, kept.UpdatedAt = created, updated
+	partial := samplePlaybook("Only Created")
+	partial.CreatedAt = created
+	if err := pm.CreateBatch(ctx, []*Playbook{kept, partial}, CreateOptions{PreserveTimestamps: true}); err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	got, err := pm.Get(ctx, kept.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("timestamps = %v/%v, want %v/%v", got.CreatedAt, got.UpdatedAt, created, updated)
+	}
+	if !partial.CreatedAt.Equal(created) || time.Since(partial.UpdatedAt) > time.Minute {
+		t.Errorf("timestamps = %v/%v, want the given CreatedAt and UpdatedAt stamped now", partial.CreatedAt, partial.UpdatedAt)
+	}
+}
+
+func TestManagerCreateRejectsMalformed(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name string
+		pb   *Playbook
+	}{
+		{"empty", &Playbook{}},
+		{"no name", &Playbook{Steps: []Step{{Order: 1, Action: "Ship"}}}},
+		{"no steps", &Playbook{Name: "Stepless"}},
+		{"empty action", &Playbook{Name: "Blank Step", Steps: []Step{{Order: 1, Action: " "}}}},
+	} {
+		if err := pm.Create(ctx, tt.pb); !errors.Is(err, ErrInvalidPlaybook) {
+			t.Errorf("Create(%s): error = %v, want ErrInvalidPlaybook", tt.name, err)
+		}
+	}
+	if n, err := pm.Count(ctx, ListFilter{}); err != nil || n != 0 {
+		t.Errorf("Count = %d, %v; want nothing created", n, err)
+	}
+}
+
+func TestManagerCreateBatch(t *testing.T) {
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		MaxTags: 1,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	defer pm.Close()
+	ctx := context.Background()
+
+	invalid := samplePlaybook("Invalid")
+	invalid.Tags = []string{"one", "two"}
+	pbs := []*Playbook{samplePlaybook("Batch Alpha"), invalid, samplePlaybook("Batch Beta")}
+
+	err = pm.CreateBatch(ctx, pbs)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("CreateBatch error = %v, want *BatchError", err)
+	}
+	if len(batchErr.Failed) != 1 || batchErr.Failed[0].Index != 1 || batchErr.Failed[0].Name != "Invalid" {
+		t.Errorf("Failed = %+v, want only index 1 (Invalid)", batchErr.Failed)
+	}
+	if !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("errors.Is(err, ErrInvalidPlaybook) = false for %v", err)
+	}
+
+	for _, pb := range []*Playbook{pbs[0], pbs[2]} {
+		if pb.ID == "" || pb.Slug == "" || pb.Version != 1 || pb.CreatedAt.IsZero() {
+			t.Errorf("%s not prepared: %+v", pb.Name, pb)
+		}
+		if _, err := pm.Get(ctx, pb.ID); err != nil {
+			t.Errorf("Get(%s): %v", pb.Name, err)
+		}
+	}
+	if invalid.ID != "" {
+		if _, err := pm.Get(ctx, invalid.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("invalid playbook was saved: Get error = %v", err)
+		}
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{Text: "Batch", Mode: SearchModeBM25})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Search returned %d results, want both batch playbooks indexed", len(results))
+	}
+
+	if err := pm.CreateBatch(ctx, []*Playbook{samplePlaybook("Batch Gamma")}); err != nil {
+		t.Errorf("CreateBatch of valid playbooks: %v", err)
+	}
+}
+
 func TestManagerGet(t *testing.T) {
 	pm := newTestManager(t)
 	ctx := context.Background()
@@ -152,6 +290,219 @@ func TestManagerSearch(t *testing.T) {
 	}
 }
 
+func TestManagerSearchTermOperators(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	for name, desc := range map[string]string{
+		"Deploy Staging":      "deployment to the staging cluster",
+		"Deploy Production":   "deployment to the production cluster",
+		"Rollback Staging":    "rollback of a staging release",
+		"Rollback Production": "rollback of a production release",
+	} {
+		pb := samplePlaybook(name)
+		pb.Description = desc
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	search := func(q SearchQuery) []string {
+		t.Helper()
+		q.Mode, q.Limit, q.MinScore = SearchModeBM25, 10, NoMinScore
+		results, err := pm.Search(ctx, q)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", q.Text, err)
+		}
+		var names []string
+		for _, r := range results {
+			names = append(names, r.Playbook.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got := search(SearchQuery{Text: "deployment"}); !slices.Equal(got, []string{"Deploy Production", "Deploy Staging"}) {
+		t.Errorf("deployment = %v, want both deployments", got)
+	}
+	if got := search(SearchQuery{Text: "deployment -staging"}); !slices.Equal(got, []string{"Deploy Production"}) {
+		t.Errorf("deployment -staging = %v, want the staging playbook excluded", got)
+	}
+	if got := search(SearchQuery{Text: "+production rollback"}); !slices.Equal(got, []string{"Rollback Production"}) {
+		t.Errorf("+production rollback = %v, want only the production rollback", got)
+	}
+	if got := search(SearchQuery{MustTerms: []string{"production"}}); !slices.Equal(got, []string{"Deploy Production", "Rollback Production"}) {
+		t.Errorf("MustTerms production = %v, want every production playbook", got)
+	}
+	if got := search(SearchQuery{Text: "cluster", MustNotTerms: []string{"production"}}); !slices.Equal(got, []string{"Deploy Staging"}) {
+		t.Errorf("cluster without production = %v, want only staging", got)
+	}
+	if got := search(SearchQuery{Text: "blue-green - deployment"}); !slices.Equal(got, []string{"Deploy Production", "Deploy Staging"}) {
+		t.Errorf("text without operators = %v, want plain free-text matching", got)
+	}
+}
+
+func TestManagerSearchDefaultMinScore(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Memory Resident", "Deploy API", "Rotate Keys"} {
+		if err := pm.Create(ctx, samplePlaybook(name)); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	count := func(text string, minScore float64) int {
+		t.Helper()
+		results, err := pm.Search(ctx, SearchQuery{Text: text, Mode: SearchModeBM25, MinScore: minScore})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", text, err)
+		}
+		return len(results)
+	}
+
+	// A stop word matches nothing, and "playbook", which every description
+	// mentions, does not drag in playbooks far below the real match.
+	if n := count("the", 0); n != 0 {
+		t.Errorf("unset MinScore: got %d results for a stop word, want 0", n)
+	}
+	if n := count("the resident playbook", 0); n != 1 {
+		t.Errorf("unset MinScore: got %d results, want only the real match", n)
+	}
+	if n := count("the resident playbook", NoMinScore); n != 3 {
+		t.Errorf("NoMinScore: got %d results, want all 3", n)
+	}
+	// Matching equally well, every playbook is kept however low BM25 scores them.
+	if n := count("playbook", 0); n != 3 {
+		t.Errorf("unset MinScore: got %d results for a term in every playbook, want 3", n)
+	}
+}
+
+func TestManagerSearchFields(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Kubernetes Rollout")
+	pb.Lessons = []Lesson{{ID: "l1", Content: "drain the zanzibar node pool first"}}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	query := SearchQuery{Text: "zanzibar", Mode: SearchModeBM25}
+	results, err := pm.Search(ctx, query)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("all fields: got %d results, want 1", len(results))
+	}
+
+	query.Fields = []string{"name"}
+	results, err = pm.Search(ctx, query)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("name only: got %d results, want 0 for a lessons-only term", len(results))
+	}
+
+	query.Text = "kubernetes"
+	results, err = pm.Search(ctx, query)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("name only: got %d results for a name term, want 1", len(results))
+	}
+
+	query.Fields = []string{"name", "body"}
+	if _, err := pm.Search(ctx, query); err == nil {
+		t.Error("expected error for unknown field, got nil")
+	}
+}
+
+func TestManagerSearchExactTagBoost(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	tagged := samplePlaybook("Provision Cloud Network")
+	tagged.Description = "create the VPC and subnets"
+	tagged.Tags = []string{"terraform"}
+	mentioned := samplePlaybook("Fix Terraform Drift")
+	mentioned.Description = "reconcile drift found by terraform plan"
+	mentioned.Tags = []string{"infra"}
+	if err := pm.CreateBatch(ctx, []*Playbook{tagged, mentioned}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{Text: "terraform", Mode: SearchModeBM25, MinScore: NoMinScore})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 || results[0].Playbook.ID != tagged.ID {
+		t.Fatalf("results = %v, want %s (exact tag) first of 2", resultIDs(results), tagged.ID)
+	}
+
+	// Without the tags field, the exact tag no longer counts.
+	results, err = pm.Search(ctx, SearchQuery{Text: "terraform", Mode: SearchModeBM25, MinScore: NoMinScore, Fields: []string{"description"}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Playbook.ID != mentioned.ID {
+		t.Errorf("description only = %v, want [%s]", resultIDs(results), mentioned.ID)
+	}
+}
+
+func TestManagerSearchTags(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	tags := map[string][]string{
+		"Deploy API":        {"production", "go"},
+		"Deploy Worker":     {"production", "python"},
+		"Deploy Preview":    {"staging", "go"},
+		"Deploy Blue-Green": {"production", "blue-green"},
+	}
+	for name, tt := range tags {
+		pb := samplePlaybook(name)
+		pb.Description = "deploy a service"
+		pb.Tags = tt
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	search := func(tags ...string) []string {
+		t.Helper()
+		results, err := pm.Search(ctx, SearchQuery{Text: "deploy", Mode: SearchModeBM25, Limit: 10, Tags: tags})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		var names []string
+		for _, r := range results {
+			names = append(names, r.Playbook.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got := search(); len(got) != 4 {
+		t.Errorf("no tag filter: got %v, want all 4", got)
+	}
+	if got := search("production"); !slices.Equal(got, []string{"Deploy API", "Deploy Blue-Green", "Deploy Worker"}) {
+		t.Errorf("production: got %v", got)
+	}
+	if got := search("production", "go"); !slices.Equal(got, []string{"Deploy API"}) {
+		t.Errorf("production+go: got %v", got)
+	}
+	if got := search("blue-green"); !slices.Equal(got, []string{"Deploy Blue-Green"}) {
+		t.Errorf("blue-green: got %v, want exact tag match", got)
+	}
+	if got := search("prod"); len(got) != 0 {
+		t.Errorf("partial tag: got %v, want none", got)
+	}
+}
+
 func TestManagerRecordExecution(t *testing.T) {
 	pm := newTestManager(t)
 	ctx := context.Background()
@@ -282,6 +633,12 @@ func TestManagerStats(t *testing.T) {
 	if stats.ByCategory["infra"] != 2 {
 		t.Errorf("ByCategory[infra] = %d, want 2", stats.ByCategory["infra"])
 	}
+	if stats.IndexDocCount != 2 {
+		t.Errorf("IndexDocCount = %d, want 2", stats.IndexDocCount)
+	}
+	if stats.IndexSizeBytes <= 0 {
+		t.Errorf("IndexSizeBytes = %d, want the size of the index directory", stats.IndexSizeBytes)
+	}
 }
 
 func TestManagerPrune(t *testing.T) {
@@ -334,8 +691,8 @@ func TestManagerPrune(t *testing.T) {
 	if len(result.Archived) != 1 {
 		t.Errorf("Archived count = %d, want 1", len(result.Archived))
 	}
-	if result.Archived[0] != stale.ID {
-		t.Errorf("Archived[0] = %q, want %q", result.Archived[0], stale.ID)
+	if got := result.Archived[0]; got.ID != stale.ID || got.Name != "Old Stale" || got.Reason != PruneReasonNeverUsedLowConfidence {
+		t.Errorf("Archived[0] = %+v, want %s (Old Stale) for %s", got, stale.ID, PruneReasonNeverUsedLowConfidence)
 	}
 
 	// Stale playbook should now be archived.
@@ -348,6 +705,57 @@ func TestManagerPrune(t *testing.T) {
 	}
 }
 
+func TestManagerActorAttribution(t *testing.T) {
+	dir := t.TempDir()
+	newManager := func(actor string) *PlaybookManager {
+		pm, err := NewPlaybookManager(ManagerConfig{
+			DataDir:   dir,
+			EmbedFunc: embed.Noop(),
+			Actor:     actor,
+			Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		if err != nil {
+			t.Fatalf("NewPlaybookManager: %v", err)
+		}
+		return pm
+	}
+	ctx := context.Background()
+
+	alice := newManager("alice")
+	pb := samplePlaybook("Attributed")
+	if err := alice.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if pb.CreatedBy != "alice" || pb.UpdatedBy != "" {
+		t.Errorf("after Create: CreatedBy = %q, UpdatedBy = %q; want alice and none", pb.CreatedBy, pb.UpdatedBy)
+	}
+	alice.Close()
+
+	bob := newManager("bob")
+	defer bob.Close()
+	if err := bob.Rename(ctx, pb.ID, "Attributed Renamed"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if err := bob.RecordExecution(ctx, &ExecutionRecord{PlaybookID: pb.ID, AgentID: "agent-7", Outcome: OutcomeSuccess, CompletedAt: time.Now()}); err != nil {
+		t.Fatalf("RecordExecution: %v", err)
+	}
+	got, err := bob.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.CreatedBy != "alice" || got.UpdatedBy != "bob" || got.LastUsedBy != "agent-7" {
+		t.Errorf("CreatedBy, UpdatedBy, LastUsedBy = %q, %q, %q; want alice, bob, agent-7", got.CreatedBy, got.UpdatedBy, got.LastUsedBy)
+	}
+
+	clone, err := bob.Clone(ctx, pb.ID, "Attributed Copy")
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	if clone.CreatedBy != "bob" {
+		t.Errorf("clone CreatedBy = %q, want bob", clone.CreatedBy)
+	}
+}
+
 func TestManagerPruneDryRun(t *testing.T) {
 	pm := newTestManager(t)
 	ctx := context.Background()
@@ -391,258 +799,2405 @@ func TestManagerPruneDryRun(t *testing.T) {
 	}
 }
 
-// TestManagerIntegrationWorkflow is a full end-to-end integration test that mirrors
-// the lifecycle: Create -> Search -> RecordExecution -> ApplyReflection -> Search again.
-func TestManagerIntegrationWorkflow(t *testing.T) {
-	pm := newTestManager(t)
-	ctx := context.Background()
+// failingRemoveIndexer is a BleveIndexer whose Remove fails for one ID.
+type failingRemoveIndexer struct {
+	*BleveIndexer
+	failID string
+}
 
-	// 1. Create a playbook.
-	pb := &Playbook{
-		Name:        "Incident Response",
-		Description: "Handle production incidents and outages",
-		Tags:        []string{"incident", "ops", "production"},
-		Category:    "operations",
-		Steps: []Step{
-			{Order: 1, Action: "Alert on-call engineer"},
-			{Order: 2, Action: "Assess incident severity"},
-			{Order: 3, Action: "Apply mitigation"},
-			{Order: 4, Action: "Post-mortem"},
-		},
-	}
-	if err := pm.Create(ctx, pb); err != nil {
-		t.Fatalf("Create: %v", err)
+func (f *failingRemoveIndexer) Remove(ctx context.Context, id string) error {
+	if id == f.failID {
+		return errors.New("index unavailable")
 	}
+	return f.BleveIndexer.Remove(ctx, id)
+}
 
-	// 2. Search should find it.
-	results, err := pm.Search(ctx, SearchQuery{
-		Text:  "incident",
-		Mode:  SearchModeBM25,
-		Limit: 5,
-	})
+func TestManagerPrunePartialIndexFailure(t *testing.T) {
+	dir := t.TempDir()
+	bi, err := NewBleveIndexer(IndexerConfig{Path: filepath.Join(dir, "index")})
 	if err != nil {
-		t.Fatalf("Search: %v", err)
-	}
-	if len(results) == 0 {
-		t.Fatal("expected search to find the created playbook")
+		t.Fatalf("NewBleveIndexer: %v", err)
 	}
-
-	foundID := results[0].Playbook.ID
-	if foundID != pb.ID {
-		t.Errorf("found playbook ID = %q, want %q", foundID, pb.ID)
+	idx := &failingRemoveIndexer{BleveIndexer: bi}
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:   dir,
+		Indexer:   idx,
+		EmbedFunc: embed.Noop(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
 	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
 
-	// 3. Record 3 successful executions.
-	for i := 0; i < 3; i++ {
-		rec := &ExecutionRecord{
-			PlaybookID:  pb.ID,
-			PlaybookVer: 1,
-			AgentID:     "test-agent",
-			Outcome:     OutcomeSuccess,
-			StartedAt:   time.Now(),
-			CompletedAt: time.Now().Add(5 * time.Minute),
-			TaskContext: "production incident drill",
+	var ids []string
+	for _, name := range []string{"Stale One", "Stale Two", "Stale Three"} {
+		pb := samplePlaybook(name)
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
 		}
-		if err := pm.RecordExecution(ctx, rec); err != nil {
-			t.Fatalf("RecordExecution %d: %v", i+1, err)
+		pb.CreatedAt = time.Now().Add(-180 * 24 * time.Hour)
+		pb.UpdatedAt = pb.CreatedAt
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
 		}
+		ids = append(ids, pb.ID)
 	}
+	idx.failID = ids[1]
 
-	afterExec, err := pm.Get(ctx, pb.ID)
+	result, err := pm.Prune(ctx, PruneOptions{MaxAge: 90 * 24 * time.Hour, MinConfidence: 0.3})
 	if err != nil {
-		t.Fatalf("Get after executions: %v", err)
-	}
-	if afterExec.SuccessCount != 3 {
-		t.Errorf("SuccessCount = %d, want 3", afterExec.SuccessCount)
+		t.Fatalf("Prune: %v", err)
 	}
-
-	// 4. Apply a reflection.
-	ref := &Reflection{
-		WhatWorked:   []string{"alerting was fast", "mitigation worked"},
-		WhatFailed:   []string{"post-mortem took too long"},
-		Improvements: []string{"automate post-mortem template"},
-		ShouldUpdate: true,
+	if got := result.IDs(); !slices.Equal(got, []string{ids[0], ids[2]}) && !slices.Equal(got, []string{ids[2], ids[0]}) {
+		t.Errorf("Archived = %v, want the two playbooks whose removal succeeded", got)
 	}
-	if err := pm.ApplyReflection(ctx, pb.ID, ref); err != nil {
-		t.Fatalf("ApplyReflection: %v", err)
+	if len(result.Failed) != 1 || result.Failed[0].ID != ids[1] || !strings.Contains(result.Failed[0].Error, "index unavailable") {
+		t.Errorf("Failed = %+v, want %s with the index error", result.Failed, ids[1])
 	}
 
-	afterReflect, err := pm.Get(ctx, pb.ID)
+	// The failed playbook was rolled back: not archived, still indexed.
+	failed, err := pm.Get(ctx, ids[1])
 	if err != nil {
-		t.Fatalf("Get after reflection: %v", err)
+		t.Fatalf("Get: %v", err)
+	}
+	if failed.Archived {
+		t.Error("playbook whose index removal failed should not stay archived")
+	}
+	if report, err := pm.Verify(ctx); err != nil || !report.InSync() {
+		t.Errorf("Verify = %+v, %v; want store and index in sync", report, err)
+	}
+}
+
+// listHookStore is a Store that runs afterList once, after the first
+// ListPlaybooks call returns.
+type listHookStore struct {
+	Store
+	afterList func()
+}
+
+func (s *listHookStore) ListPlaybooks(ctx context.Context, filter ListFilter) ([]*Playbook, error) {
+	playbooks, err := s.Store.ListPlaybooks(ctx, filter)
+	if hook := s.afterList; hook != nil {
+		s.afterList = nil
+		hook()
+	}
+	return playbooks, err
+}
+
+func TestManagerPruneKeepsConcurrentWrites(t *testing.T) {
+	bi, err := NewBleveIndexer(IndexerConfig{Path: filepath.Join(t.TempDir(), "index")})
+	if err != nil {
+		t.Fatalf("NewBleveIndexer: %v", err)
+	}
+	idx := &failingRemoveIndexer{BleveIndexer: bi}
+	store := &listHookStore{Store: NewMemStore()}
+	pm, err := NewPlaybookManager(ManagerConfig{
+		Store:     store,
+		Indexer:   idx,
+		EmbedFunc: embed.Noop(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	var ids []string
+	for _, name := range []string{"Stale Archived", "Stale Rolled Back"} {
+		pb := samplePlaybook(name)
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		pb.CreatedAt = time.Now().Add(-180 * 24 * time.Hour)
+		pb.UpdatedAt = pb.CreatedAt
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		ids = append(ids, pb.ID)
+	}
+	idx.failID = ids[1]
+
+	// Executions recorded after Prune lists the playbooks must survive both
+	// the archive and its rollback.
+	store.afterList = func() {
+		for _, id := range ids {
+			recordOutcomes(t, pm, id, OutcomeSuccess, 1)
+		}
+	}
+	if _, err := pm.Prune(ctx, PruneOptions{MaxAge: 90 * 24 * time.Hour, MinConfidence: 0.3}); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	for i, id := range ids {
+		got, err := pm.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.SuccessCount != 1 {
+			t.Errorf("%s: SuccessCount = %d, want the concurrently recorded execution kept", got.Name, got.SuccessCount)
+		}
+		if wantArchived := i == 0; got.Archived != wantArchived {
+			t.Errorf("%s: Archived = %v, want %v", got.Name, got.Archived, wantArchived)
+		}
+	}
+}
+
+func TestManagerPruneMinAgeToPrune(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	// Both are never used and low confidence; only their creation time differs.
+	ages := map[string]time.Duration{
+		"Old Import":    180 * 24 * time.Hour,
+		"Recent Import": 45 * 24 * time.Hour,
+	}
+	ids := make(map[string]string)
+	for name, age := range ages {
+		pb := samplePlaybook(name)
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		pb.CreatedAt = time.Now().Add(-age)
+		pb.UpdatedAt = pb.CreatedAt
+		pb.Confidence = 0.1
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		ids[name] = pb.ID
+	}
+
+	result, err := pm.Prune(ctx, PruneOptions{
+		MaxAge:        30 * 24 * time.Hour,
+		MinConfidence: 0.3,
+		MinAgeToPrune: 60 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	if got := result.IDs(); len(got) != 1 || got[0] != ids["Old Import"] {
+		t.Errorf("Archived = %v, want only %s (Old Import)", got, ids["Old Import"])
+	}
+	recent, err := pm.Get(ctx, ids["Recent Import"])
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if recent.Archived {
+		t.Error("playbook inside the grace period should not be archived")
+	}
+}
+
+func TestManagerRestore(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Forgotten Runbook")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := pm.SetStatus(ctx, pb.ID, StatusDeprecated); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	pb, _ = pm.Get(ctx, pb.ID)
+	old := time.Now().Add(-180 * 24 * time.Hour)
+	pb.CreatedAt, pb.UpdatedAt, pb.Confidence = old, old, 0.1
+	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := pm.Prune(ctx, PruneOptions{MaxAge: 90 * 24 * time.Hour, MinConfidence: 0.3}); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	if err := pm.Restore(ctx, pb.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Archived {
+		t.Error("restored playbook should not be archived")
+	}
+	if got.Status != StatusDeprecated {
+		t.Errorf("Status = %q, want the pre-archive status %q", got.Status, StatusDeprecated)
+	}
+	if !got.UpdatedAt.After(old) {
+		t.Errorf("UpdatedAt = %v, want it refreshed", got.UpdatedAt)
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{Text: "Forgotten Runbook", Mode: SearchModeBM25})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 || results[0].Playbook.ID != pb.ID {
+		t.Errorf("restored playbook should be searchable, got %d results", len(results))
+	}
+
+	if err := pm.Restore(ctx, pb.ID); err == nil {
+		t.Error("Restore of a non-archived playbook: expected error")
+	}
+	if err := pm.Restore(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore of missing playbook: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestManagerPruneReasons(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+	old := time.Now().Add(-180 * 24 * time.Hour)
+
+	setups := map[string]func(pb *Playbook){
+		// Used long ago, confidence is fine
+		"Stale": func(pb *Playbook) {
+			pb.LastUsedAt = old
+			pb.Confidence = 0.9
+		},
+		// Never used, created long ago, low confidence
+		"Never Used": func(pb *Playbook) {
+			pb.CreatedAt = old
+			pb.UpdatedAt = old
+			pb.Confidence = 0.1
+		},
+		// Used recently but not updated in a long time, low confidence
+		"Low Confidence": func(pb *Playbook) {
+			pb.LastUsedAt = time.Now()
+			pb.UpdatedAt = old
+			pb.Confidence = 0.1
+		},
+	}
+	want := map[string]PruneReason{
+		"Stale":          PruneReasonStale,
+		"Never Used":     PruneReasonNeverUsedLowConfidence,
+		"Low Confidence": PruneReasonLowConfidenceAndOld,
+	}
+	for name, setup := range setups {
+		pb := samplePlaybook(name)
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		setup(pb)
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	result, err := pm.Prune(ctx, PruneOptions{MaxAge: 90 * 24 * time.Hour, MinConfidence: 0.3, DryRun: true})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(result.Archived) != len(want) {
+		t.Fatalf("Archived = %+v, want %d items", result.Archived, len(want))
+	}
+	for _, item := range result.Archived {
+		if item.Reason != want[item.Name] {
+			t.Errorf("%s: Reason = %q, want %q", item.Name, item.Reason, want[item.Name])
+		}
+	}
+}
+
+func TestManagerPruneFailureStreak(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	failing := samplePlaybook("Failing Deploy")
+	recovered := samplePlaybook("Recovered Deploy")
+	fresh := samplePlaybook("Fresh Deploy")
+	if err := pm.CreateBatch(ctx, []*Playbook{failing, recovered, fresh}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	recordOutcomes(t, pm, failing.ID, OutcomeSuccess, 2)
+	recordOutcomes(t, pm, failing.ID, OutcomeFailure, 3)
+	recordOutcomes(t, pm, recovered.ID, OutcomeFailure, 3)
+	recordOutcomes(t, pm, recovered.ID, OutcomeSuccess, 1)
+	recordOutcomes(t, pm, fresh.ID, OutcomeFailure, 2)
+
+	// Off by default: none of them is old or low-confidence enough.
+	result, err := pm.Prune(ctx, PruneOptions{MaxAge: 90 * 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(result.Archived) != 0 {
+		t.Fatalf("without FailureStreak: Archived = %+v, want none", result.Archived)
+	}
+
+	result, err = pm.Prune(ctx, PruneOptions{MaxAge: 90 * 24 * time.Hour, FailureStreak: 3})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(result.Archived) != 1 || result.Archived[0].ID != failing.ID || result.Archived[0].Reason != PruneReasonFailureStreak {
+		t.Fatalf("Archived = %+v, want only %s for its failure streak", result.Archived, failing.ID)
+	}
+	got, err := pm.Get(ctx, failing.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.Archived {
+		t.Error("failing playbook was not archived")
+	}
+}
+
+// TestManagerIntegrationWorkflow is a full end-to-end integration test that mirrors
+// the lifecycle: Create -> Search -> RecordExecution -> ApplyReflection -> Search again.
+func TestManagerIntegrationWorkflow(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	// 1. Create a playbook.
+	pb := &Playbook{
+		Name:        "Incident Response",
+		Description: "Handle production incidents and outages",
+		Tags:        []string{"incident", "ops", "production"},
+		Category:    "operations",
+		Steps: []Step{
+			{Order: 1, Action: "Alert on-call engineer"},
+			{Order: 2, Action: "Assess incident severity"},
+			{Order: 3, Action: "Apply mitigation"},
+			{Order: 4, Action: "Post-mortem"},
+		},
+	}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// 2. Search should find it.
+	results, err := pm.Search(ctx, SearchQuery{
+		Text:  "incident",
+		Mode:  SearchModeBM25,
+		Limit: 5,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("expected search to find the created playbook")
+	}
+
+	foundID := results[0].Playbook.ID
+	if foundID != pb.ID {
+		t.Errorf("found playbook ID = %q, want %q", foundID, pb.ID)
+	}
+
+	// 3. Record 3 successful executions.
+	for i := 0; i < 3; i++ {
+		rec := &ExecutionRecord{
+			PlaybookID:  pb.ID,
+			PlaybookVer: 1,
+			AgentID:     "test-agent",
+			Outcome:     OutcomeSuccess,
+			StartedAt:   time.Now(),
+			CompletedAt: time.Now().Add(5 * time.Minute),
+			TaskContext: "production incident drill",
+		}
+		if err := pm.RecordExecution(ctx, rec); err != nil {
+			t.Fatalf("RecordExecution %d: %v", i+1, err)
+		}
+	}
+
+	afterExec, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get after executions: %v", err)
+	}
+	if afterExec.SuccessCount != 3 {
+		t.Errorf("SuccessCount = %d, want 3", afterExec.SuccessCount)
+	}
+
+	// 4. Apply a reflection.
+	ref := &Reflection{
+		WhatWorked:   []string{"alerting was fast", "mitigation worked"},
+		WhatFailed:   []string{"post-mortem took too long"},
+		Improvements: []string{"automate post-mortem template"},
+		ShouldUpdate: true,
+	}
+	if err := pm.ApplyReflection(ctx, pb.ID, ref); err != nil {
+		t.Fatalf("ApplyReflection: %v", err)
+	}
+
+	afterReflect, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get after reflection: %v", err)
+	}
+	if len(afterReflect.Lessons) == 0 {
+		t.Error("expected lessons to be added after reflection")
+	}
+	if afterReflect.Version <= 1 {
+		t.Errorf("Version = %d, want > 1 after update", afterReflect.Version)
+	}
+
+	// 5. Search again — playbook should still be findable.
+	results2, err := pm.Search(ctx, SearchQuery{
+		Text:  "incident response",
+		Mode:  SearchModeBM25,
+		Limit: 5,
+	})
+	if err != nil {
+		t.Fatalf("Search after reflection: %v", err)
+	}
+	if len(results2) == 0 {
+		t.Error("expected search to find playbook after reflection and re-index")
+	}
+
+	// 6. Stats should reflect one playbook.
+	stats, err := pm.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.TotalPlaybooks != 1 {
+		t.Errorf("TotalPlaybooks = %d, want 1", stats.TotalPlaybooks)
+	}
+	if stats.TotalExecs != 3 {
+		t.Errorf("TotalExecs = %d, want 3", stats.TotalExecs)
+	}
+}
+
+func TestNewPlaybookManagerRequiresDataDir(t *testing.T) {
+	_, err := NewPlaybookManager(ManagerConfig{})
+	if err == nil {
+		t.Error("expected error when DataDir is empty")
+	}
+	// A store alone still needs DataDir for the default index.
+	if _, err := NewPlaybookManager(ManagerConfig{Store: NewMemStore()}); err == nil {
+		t.Error("expected error when DataDir is empty and only Store is set")
+	}
+}
+
+// stubIndexer is an Indexer test double that records which playbooks are indexed.
+type stubIndexer struct {
+	indexed map[string]bool
+	closed  bool
+}
+
+func (s *stubIndexer) Index(_ context.Context, pb *Playbook) error {
+	s.indexed[pb.ID] = true
+	return nil
+}
+
+func (s *stubIndexer) Remove(_ context.Context, id string) error {
+	delete(s.indexed, id)
+	return nil
+}
+
+func (s *stubIndexer) Search(context.Context, SearchQuery) ([]SearchResult, error) { return nil, nil }
+
+func (s *stubIndexer) Reindex(ctx context.Context, playbooks []*Playbook) error {
+	for _, pb := range playbooks {
+		s.indexed[pb.ID] = true
+	}
+	return nil
+}
+
+func (s *stubIndexer) DocIDs(context.Context) ([]string, error) {
+	return slices.Sorted(maps.Keys(s.indexed)), nil
+}
+
+func (s *stubIndexer) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestNewPlaybookManagerInjectedStoreAndIndexer(t *testing.T) {
+	idx := &stubIndexer{indexed: make(map[string]bool)}
+	pm, err := NewPlaybookManager(ManagerConfig{
+		Store:   NewMemStore(),
+		Indexer: idx,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager without DataDir: %v", err)
+	}
+	ctx := context.Background()
+
+	pb := samplePlaybook("Injected")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !idx.indexed[pb.ID] {
+		t.Error("Create did not index through the injected indexer")
+	}
+	if err := pm.Delete(ctx, pb.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if idx.indexed[pb.ID] {
+		t.Error("Delete did not remove from the injected indexer")
+	}
+
+	if err := pm.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !idx.closed {
+		t.Error("Close did not close the injected indexer")
+	}
+
+	// SuggestIDs is optional; without it, Suggest reports an error.
+	if _, err := pm.Suggest(ctx, "inj", 0); err == nil {
+		t.Error("Suggest with an indexer lacking SuggestIDs: expected error, got nil")
+	}
+}
+
+func TestManagerIndexAnalyzer(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pm, err := NewPlaybookManager(ManagerConfig{DataDir: dir, IndexAnalyzer: "de", Logger: logger})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	pb := samplePlaybook("Sicherung")
+	pb.Description = "Häuser nachts sichern"
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// The German stemmer reduces "Häuser" to the same term as "Haus".
+	results, err := pm.Search(ctx, SearchQuery{Text: "Haus", Mode: SearchModeBM25})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Search(Haus) returned %d results with the German analyzer, want 1", len(results))
+	}
+	pm.Close()
+
+	_, err = NewPlaybookManager(ManagerConfig{DataDir: dir, Logger: logger})
+	if !errors.Is(err, ErrAnalyzerMismatch) {
+		t.Errorf("reopening with the default analyzer: error = %v, want ErrAnalyzerMismatch", err)
+	}
+
+	if _, err := NewPlaybookManager(ManagerConfig{DataDir: t.TempDir(), IndexAnalyzer: "klingon", Logger: logger}); err == nil {
+		t.Error("unknown analyzer: expected error, got nil")
+	}
+}
+
+func TestManagerIndexSynonymsAndStopWords(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	cfg := ManagerConfig{
+		DataDir:        dir,
+		IndexStopWords: []string{"acme"},
+		IndexSynonyms:  map[string][]string{"kubernetes": {"k8s"}},
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	pm, err := NewPlaybookManager(cfg)
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	pb := samplePlaybook("Cluster upgrade")
+	pb.Description = "Upgrade the Acme kubernetes cluster"
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"k8s", 1},        // synonym of a word in the text
+		{"kubernetes", 1}, // the word itself still matches
+		{"acme", 0},       // custom stop word
+	} {
+		results, err := pm.Search(ctx, SearchQuery{Text: tt.query, Mode: SearchModeBM25})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.query, err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("Search(%q) returned %d results, want %d", tt.query, len(results), tt.want)
+		}
+	}
+	pm.Close()
+
+	// The index keeps the vocabulary it was built with.
+	pm, err = NewPlaybookManager(cfg)
+	if err != nil {
+		t.Fatalf("reopening with the same config: %v", err)
+	}
+	pm.Close()
+	cfg.IndexSynonyms = map[string][]string{"kubernetes": {"k8s", "kube"}}
+	if _, err := NewPlaybookManager(cfg); !errors.Is(err, ErrAnalyzerMismatch) {
+		t.Errorf("reopening with changed synonyms: error = %v, want ErrAnalyzerMismatch", err)
+	}
+
+	cfg.DataDir = t.TempDir()
+	cfg.IndexSynonyms = map[string][]string{"ci": {"continuous integration"}}
+	if _, err := NewPlaybookManager(cfg); err == nil {
+		t.Error("multi-word synonym: expected error, got nil")
+	}
+}
+
+func TestManagerOutdatedIndex(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// An index created before tag_keywords and task_contexts were mapped.
+	old, err := buildBaseIndexMapping(textAnalyzerConfig{Base: DefaultAnalyzer})
+	if err != nil {
+		t.Fatalf("buildBaseIndexMapping: %v", err)
+	}
+	delete(old.DefaultMapping.Properties, "tag_keywords")
+	delete(old.DefaultMapping.Properties, TaskContextField)
+	delete(old.DefaultMapping.Properties, "suggest_words")
+	idx, err := bleve.New(filepath.Join(dir, "index"), old)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	idx.Close()
+
+	_, err = NewPlaybookManager(ManagerConfig{DataDir: dir, Logger: logger})
+	if !errors.Is(err, ErrIndexOutdated) {
+		t.Fatalf("opening an outdated index: error = %v, want ErrIndexOutdated", err)
+	}
+	for _, want := range []string{"tag_keywords", TaskContextField, "playbookd reindex"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, "index")); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	pm, err := NewPlaybookManager(ManagerConfig{DataDir: dir, Logger: logger})
+	if err != nil {
+		t.Fatalf("reopening after deleting the index: %v", err)
+	}
+	pm.Close()
+}
+
+func TestManagerSearchExplain(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Rollback Release")
+	pb.Description = "rollback a bad release"
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{Text: "rollback", Mode: SearchModeBM25})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Explanation != nil {
+		t.Fatalf("results = %+v, want one result without an explanation", results)
+	}
+
+	results, err = pm.Search(ctx, SearchQuery{Text: "rollback", Mode: SearchModeBM25, Explain: true})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	expl := results[0].Explanation
+	if expl == nil || len(expl.Children) == 0 {
+		t.Fatalf("Explanation = %+v, want a tree of contributions", expl)
+	}
+	if math.Abs(expl.Value-results[0].Score) > 1e-9 {
+		t.Errorf("Explanation.Value = %v, want the score %v", expl.Value, results[0].Score)
+	}
+	textScore := results[0].Score
+
+	// Blending wraps the text explanation under the composite score.
+	results, err = pm.Search(ctx, SearchQuery{Text: "rollback", Mode: SearchModeBM25, Explain: true, ConfidenceWeight: 0.5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	expl = results[0].Explanation
+	if expl == nil || expl.Value != results[0].Score || len(expl.Children) != 2 {
+		t.Fatalf("Explanation = %+v, want a composite node with score %v", expl, results[0].Score)
+	}
+	text := expl.Children[0]
+	if len(text.Children) != 1 || math.Abs(text.Children[0].Value-textScore) > 1e-9 {
+		t.Errorf("composite explanation does not carry the text explanation: %+v", text)
+	}
+}
+
+func TestManagerSearchCompositeScore(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	// Create two playbooks with the same keyword so BM25 finds both.
+	pbA := samplePlaybook("Deployment Alpha")
+	pbA.Description = "deployment procedure for production servers"
+	pbA.Tags = []string{"deployment"}
+	if err := pm.Create(ctx, pbA); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	pbB := samplePlaybook("Deployment Beta")
+	pbB.Description = "deployment procedure for staging servers"
+	pbB.Tags = []string{"deployment"}
+	if err := pm.Create(ctx, pbB); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// Give playbook A low confidence (1 success, 9 failures).
+	gotA, err := pm.Get(ctx, pbA.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	gotA.SuccessCount = 1
+	gotA.FailureCount = 9
+	gotA.UpdateStats()
+	if err := pm.store.SavePlaybook(ctx, gotA); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// Give playbook B high confidence (9 successes, 1 failure).
+	gotB, err := pm.Get(ctx, pbB.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	gotB.SuccessCount = 9
+	gotB.FailureCount = 1
+	gotB.UpdateStats()
+	if err := pm.store.SavePlaybook(ctx, gotB); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{
+		Text:             "deployment",
+		Mode:             SearchModeBM25,
+		ConfidenceWeight: 0.9,
+		Limit:            10,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) < 2 {
+		t.Fatalf("expected at least 2 results, got %d", len(results))
+	}
+
+	// High-confidence playbook B should be first.
+	if results[0].Playbook.ID != pbB.ID {
+		t.Errorf("expected high-confidence playbook %q first, got %q", pbB.ID, results[0].Playbook.ID)
+	}
+
+	// TextScore keeps the unblended relevance score.
+	plain, err := pm.Search(ctx, SearchQuery{Text: "deployment", Mode: SearchModeBM25, Limit: 10})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	textScores := make(map[string]float64)
+	for _, r := range plain {
+		if r.TextScore != r.Score {
+			t.Errorf("unblended %s: TextScore = %v, Score = %v, want equal", r.Playbook.Name, r.TextScore, r.Score)
+		}
+		textScores[r.Playbook.ID] = r.Score
+	}
+	for _, r := range results {
+		if r.TextScore != textScores[r.Playbook.ID] {
+			t.Errorf("blended %s: TextScore = %v, want raw score %v", r.Playbook.Name, r.TextScore, textScores[r.Playbook.ID])
+		}
+	}
+}
+
+func TestManagerSearchRecencyBoost(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	// Two playbooks with the same text are equally relevant; only when they
+	// were last used differs.
+	old := samplePlaybook("Deploy Service")
+	recent := samplePlaybook("Deploy Service")
+	if err := pm.CreateBatch(ctx, []*Playbook{old, recent}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	for id, lastUsed := range map[string]time.Time{
+		old.ID:    time.Now().Add(-2 * 365 * 24 * time.Hour),
+		recent.ID: time.Now().Add(-7 * 24 * time.Hour),
+	} {
+		pb, err := pm.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		pb.LastUsedAt = lastUsed
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{Text: "deploy", Mode: SearchModeBM25, MinScore: NoMinScore, RecencyBoost: 1})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Playbook.ID != recent.ID {
+		t.Errorf("first result = %s, want the recently used %s", results[0].Playbook.ID, recent.ID)
+	}
+	if results[0].TextScore != results[1].TextScore {
+		t.Errorf("TextScores = %v and %v, want the boost to leave them equal", results[0].TextScore, results[1].TextScore)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("Scores = %v and %v, want the recent playbook boosted above the old one", results[0].Score, results[1].Score)
+	}
+}
+
+func TestManagerSearchLimitClamped(t *testing.T) {
+	pm := newTestManager(t)
+	pm.cfg.MaxSearchResults = 2
+	ctx := context.Background()
+
+	for _, name := range []string{"Deploy Alpha", "Deploy Beta", "Deploy Gamma"} {
+		if err := pm.Create(ctx, samplePlaybook(name)); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{Text: "deploy", Mode: SearchModeBM25, MinScore: NoMinScore, Limit: 1000})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("got %d results for Limit 1000, want them clamped to 2", len(results))
+	}
+
+	cr, err := pm.SearchWithContext(ctx, ContrastiveQuery{
+		SearchQuery:    SearchQuery{Text: "deploy", Mode: SearchModeBM25, Limit: 1000},
+		IncludeNeutral: true,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithContext: %v", err)
+	}
+	if len(cr.Neutral) != 2 {
+		t.Errorf("contrastive search found %d candidates, want them clamped to 2", len(cr.Neutral))
+	}
+}
+
+func TestManagerSearchStream(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Deploy Alpha", "Deploy Beta", "Deploy Gamma"} {
+		if err := pm.Create(ctx, samplePlaybook(name)); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	query := SearchQuery{Text: "deploy", Mode: SearchModeBM25, MinScore: NoMinScore, Limit: 10}
+
+	want, err := pm.Search(ctx, query)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	var got []SearchResult
+	if err := pm.SearchStream(ctx, query, func(r SearchResult) error {
+		got = append(got, r)
+		return nil
+	}); err != nil {
+		t.Fatalf("SearchStream: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("streamed %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Playbook.ID != want[i].Playbook.ID || got[i].TextScore != want[i].TextScore {
+			t.Errorf("result %d = %s (%v), want %s (%v)", i, got[i].Playbook.ID, got[i].TextScore, want[i].Playbook.ID, want[i].TextScore)
+		}
+	}
+
+	// An error from the callback stops the stream.
+	stop := errors.New("stop")
+	var calls int
+	err = pm.SearchStream(ctx, query, func(SearchResult) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("SearchStream = %v after %d calls, want the callback's error after 1", err, calls)
+	}
+
+	query.ConfidenceWeight = 0.5
+	if err := pm.SearchStream(ctx, query, func(SearchResult) error { return nil }); err == nil {
+		t.Error("SearchStream with ConfidenceWeight: want an error")
+	}
+}
+
+// countingStore counts playbook reads.
+type countingStore struct {
+	Store
+	gets int
+}
+
+func (c *countingStore) GetPlaybook(ctx context.Context, id string) (*Playbook, error) {
+	c.gets++
+	return c.Store.GetPlaybook(ctx, id)
+}
+
+func TestManagerSearchSkipHydration(t *testing.T) {
+	store := &countingStore{Store: NewMemStore()}
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:   t.TempDir(),
+		Store:     store,
+		EmbedFunc: embed.Noop(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	for _, name := range []string{"Deploy Alpha", "Deploy Beta", "Deploy Gamma"} {
+		if err := pm.Create(ctx, samplePlaybook(name)); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	query := SearchQuery{Text: "deploy", Mode: SearchModeBM25, MinScore: NoMinScore, Limit: 10}
+	want, err := pm.Search(ctx, query)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	store.gets = 0
+	query.SkipHydration = true
+	got, err := pm.Search(ctx, query)
+	if err != nil {
+		t.Fatalf("Search with SkipHydration: %v", err)
+	}
+	if store.gets != 0 {
+		t.Errorf("Search with SkipHydration read %d playbooks from the store, want 0", store.gets)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Playbook.ID != want[i].Playbook.ID || got[i].Score != want[i].Score || got[i].TextScore != want[i].TextScore {
+			t.Errorf("result %d = %s (%v), want %s (%v)", i, got[i].Playbook.ID, got[i].Score, want[i].Playbook.ID, want[i].Score)
+		}
+		if got[i].Playbook.Name != "" {
+			t.Errorf("result %d has Name %q, want only the ID", i, got[i].Playbook.Name)
+		}
+	}
+
+	var streamed int
+	if err := pm.SearchStream(ctx, query, func(r SearchResult) error {
+		streamed++
+		return nil
+	}); err != nil || streamed != len(want) || store.gets != 0 {
+		t.Errorf("SearchStream with SkipHydration = %v, %d results, %d store reads; want %d results and no reads", err, streamed, store.gets, len(want))
+	}
+
+	query.ConfidenceWeight = 0.5
+	if _, err := pm.Search(ctx, query); err == nil {
+		t.Error("Search with SkipHydration and ConfidenceWeight: want an error")
+	}
+}
+
+func TestManagerSearchCompositeScoreZeroWeightUnchanged(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pbA := samplePlaybook("Scaling Alpha")
+	pbA.Description = "scaling procedure for web services"
+	pbA.Tags = []string{"scaling"}
+	if err := pm.Create(ctx, pbA); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	pbB := samplePlaybook("Scaling Beta")
+	pbB.Description = "scaling procedure for databases"
+	pbB.Tags = []string{"scaling"}
+	if err := pm.Create(ctx, pbB); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// Set different confidences.
+	gotA, err := pm.Get(ctx, pbA.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	gotA.SuccessCount = 1
+	gotA.FailureCount = 9
+	gotA.UpdateStats()
+	if err := pm.store.SavePlaybook(ctx, gotA); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	gotB, err := pm.Get(ctx, pbB.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	gotB.SuccessCount = 9
+	gotB.FailureCount = 1
+	gotB.UpdateStats()
+	if err := pm.store.SavePlaybook(ctx, gotB); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// Search with zero weight (disabled) — should just return results without reordering.
+	results, err := pm.Search(ctx, SearchQuery{
+		Text:             "scaling",
+		Mode:             SearchModeBM25,
+		ConfidenceWeight: 0,
+		Limit:            10,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("expected at least 1 result")
+	}
+}
+
+func TestNormalizeScore(t *testing.T) {
+	tests := []struct {
+		score, min, max float64
+		want            float64
+	}{
+		{score: 10, min: 0, max: 10, want: 1.0},
+		{score: 0, min: 0, max: 10, want: 0.0},
+		{score: 5, min: 0, max: 10, want: 0.5},
+		{score: 5, min: 5, max: 5, want: 1.0}, // all equal
+	}
+	for _, tc := range tests {
+		got := normalizeScore(tc.score, tc.min, tc.max)
+		if got != tc.want {
+			t.Errorf("normalizeScore(%v, %v, %v) = %v, want %v", tc.score, tc.min, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestManagerUpdateVersionConflict(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Conflict Target")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	stale, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// A concurrent writer updates the playbook first.
+	fresh, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	fresh.Description = "updated concurrently"
+	if err := pm.Update(ctx, fresh); err != nil {
+		t.Fatalf("Update fresh: %v", err)
+	}
+
+	stale.Description = "stale write"
+	err = pm.Update(ctx, stale)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Update stale: err = %v, want ErrVersionConflict", err)
+	}
+}
+
+func TestManagerUpdateWithRetry(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Retry Target")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	calls := 0
+	updated, err := pm.UpdateWithRetry(ctx, pb.ID, func(p *Playbook) error {
+		calls++
+		if calls == 1 {
+			// Simulate a concurrent writer bumping the stored version after we loaded it.
+			other, err := pm.Get(ctx, p.ID)
+			if err != nil {
+				return err
+			}
+			other.Tags = append(other.Tags, "concurrent")
+			if err := pm.Update(ctx, other); err != nil {
+				return err
+			}
+		}
+		p.Description = "updated with retry"
+		return nil
+	}, 3)
+	if err != nil {
+		t.Fatalf("UpdateWithRetry: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("mutate called %d times, want 2", calls)
+	}
+	if updated.Version != 3 {
+		t.Errorf("Version = %d, want 3", updated.Version)
+	}
+
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "updated with retry" {
+		t.Errorf("Description = %q, want %q", got.Description, "updated with retry")
+	}
+	if len(got.Tags) != 2 {
+		t.Errorf("Tags = %v, want concurrent tag preserved", got.Tags)
+	}
+}
+
+func TestManagerUpdateWithRetryExhausted(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Always Conflicting")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	_, err := pm.UpdateWithRetry(ctx, pb.ID, func(p *Playbook) error {
+		other, err := pm.Get(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		return pm.Update(ctx, other)
+	}, 2)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("err = %v, want ErrVersionConflict", err)
+	}
+}
+
+func TestManagerIndexDrift(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	kept := samplePlaybook("Drift Kept")
+	if err := pm.Create(ctx, kept); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	deleted := samplePlaybook("Drift Deleted")
+	if err := pm.Create(ctx, deleted); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	missing, extra, err := pm.IndexDrift(ctx)
+	if err != nil {
+		t.Fatalf("IndexDrift: %v", err)
+	}
+	if len(missing) != 0 || len(extra) != 0 {
+		t.Fatalf("expected no drift, got missing=%v extra=%v", missing, extra)
+	}
+
+	// Remove the playbook file behind the manager's back.
+	path := filepath.Join(pm.cfg.DataDir, "playbooks", deleted.ID+".json")
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove playbook file: %v", err)
+	}
+
+	// Save a playbook directly to the store without indexing it.
+	unindexed := newTestPlaybook("unindexed-id", "Drift Unindexed")
+	if err := pm.store.SavePlaybook(ctx, unindexed); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	missing, extra, err = pm.IndexDrift(ctx)
+	if err != nil {
+		t.Fatalf("IndexDrift: %v", err)
+	}
+	if len(extra) != 1 || extra[0] != deleted.ID {
+		t.Errorf("extraInIndex = %v, want [%s]", extra, deleted.ID)
+	}
+	if len(missing) != 1 || missing[0] != unindexed.ID {
+		t.Errorf("missingFromIndex = %v, want [%s]", missing, unindexed.ID)
+	}
+}
+
+func TestManagerVerifyAndRepair(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	deleted := samplePlaybook("Repair Deleted")
+	if err := pm.Create(ctx, deleted); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.Remove(filepath.Join(pm.cfg.DataDir, "playbooks", deleted.ID+".json")); err != nil {
+		t.Fatalf("remove playbook file: %v", err)
+	}
+	unindexed := newTestPlaybook("unindexed-id", "Repair Unindexed")
+	if err := pm.store.SavePlaybook(ctx, unindexed); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	report, err := pm.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if report.InSync() || !slices.Equal(report.MissingFromIndex, []string{unindexed.ID}) || !slices.Equal(report.OrphanedInIndex, []string{deleted.ID}) {
+		t.Fatalf("Verify = %+v, want %s missing and %s orphaned", report, unindexed.ID, deleted.ID)
+	}
+
+	repaired, err := pm.Repair(ctx)
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if !slices.Equal(repaired.MissingFromIndex, report.MissingFromIndex) || !slices.Equal(repaired.OrphanedInIndex, report.OrphanedInIndex) {
+		t.Errorf("Repair = %+v, want the drift from Verify %+v", repaired, report)
+	}
+	if report, err = pm.Verify(ctx); err != nil || !report.InSync() {
+		t.Errorf("after Repair: Verify = %+v, %v; want in sync", report, err)
+	}
+}
+
+func TestManagerNormalizesEmbeddings(t *testing.T) {
+	newManager := func(disable bool) *PlaybookManager {
+		pm, err := NewPlaybookManager(ManagerConfig{
+			DataDir: t.TempDir(),
+			EmbedFunc: func(_ context.Context, _ string) ([]float32, error) {
+				return []float32{3, 4}, nil
+			},
+			DisableNormalize: disable,
+		})
+		if err != nil {
+			t.Fatalf("NewPlaybookManager: %v", err)
+		}
+		t.Cleanup(func() { pm.Close() })
+		return pm
+	}
+	ctx := context.Background()
+
+	pm := newManager(false)
+	pb := samplePlaybook("Normalized Deploy")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := pb.Embedding; len(got) != 2 || math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("Embedding = %v, want [0.6 0.8]", got)
+	}
+
+	raw := newManager(true)
+	pb = samplePlaybook("Raw Deploy")
+	if err := raw.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := pb.Embedding; len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("Embedding with DisableNormalize = %v, want [3 4]", got)
+	}
+}
+
+func TestManagerRejectsMismatchedEmbeddingDims(t *testing.T) {
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(_ context.Context, _ string) ([]float32, error) {
+			return []float32{0.1, 0.2}, nil
+		},
+		EmbedDims: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	if err := pm.Create(ctx, samplePlaybook("Short Vector")); err == nil || !strings.Contains(err.Error(), "dimensions") {
+		t.Errorf("Create with 2-dim embedding and EmbedDims 3: error = %v, want dimensions error", err)
+	}
+	// Search falls back to BM25 instead of sending the mismatched vector to the index.
+	if _, err := pm.Search(ctx, SearchQuery{Text: "vector"}); err != nil {
+		t.Errorf("Search: %v", err)
+	}
+}
+
+func TestManagerNormalizesStepOrder(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Hand Edited")
+	pb.Steps = []Step{
+		{Order: 2, Action: "Second"},
+		{Order: 1, Action: "First"},
+		{Order: 2, Action: "Third"},
+	}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	for i, want := range []string{"First", "Second", "Third"} {
+		if got.Steps[i].Action != want || got.Steps[i].Order != i+1 {
+			t.Errorf("Steps[%d] = %d %q, want %d %q", i, got.Steps[i].Order, got.Steps[i].Action, i+1, want)
+		}
+	}
+
+	got.Steps = append(got.Steps, Step{Action: "Fourth"})
+	if err := pm.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if last := got.Steps[len(got.Steps)-1]; last.Order != 4 || last.Action != "Fourth" {
+		t.Errorf("appended step = %d %q, want 4 %q", last.Order, last.Action, "Fourth")
+	}
+}
+
+func TestManagerWarnsWhenVectorSearchUnavailable(t *testing.T) {
+	var logBuf bytes.Buffer
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(_ context.Context, _ string) ([]float32, error) {
+			return []float32{0.1, 0.2, 0.3}, nil
+		},
+		// No vector field in the index, whatever the build tags
+		EmbedDims: 0,
+		Logger:    slog.New(slog.NewTextHandler(&logBuf, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	if _, err := pm.Search(ctx, SearchQuery{Text: "deploy", Mode: SearchModeBM25}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if strings.Contains(logBuf.String(), "vector search unavailable") {
+		t.Errorf("BM25 search should not warn, log:\n%s", logBuf.String())
+	}
+
+	for _, mode := range []SearchMode{SearchModeHybrid, SearchModeVector} {
+		if _, err := pm.Search(ctx, SearchQuery{Text: "deploy", Mode: mode}); err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+	}
+	if n := strings.Count(logBuf.String(), "vector search unavailable"); n != 1 {
+		t.Errorf("got %d warnings, want exactly 1, log:\n%s", n, logBuf.String())
+	}
+}
+
+// newCountingManager returns a manager whose embedder counts its calls.
+func newCountingManager(t *testing.T) (*PlaybookManager, *int) {
+	t.Helper()
+	calls := new(int)
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(_ context.Context, text string) ([]float32, error) {
+			*calls++
+			return []float32{float32(len(text)), 1, 2}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	return pm, calls
+}
+
+func TestManagerUpdateMetadata(t *testing.T) {
+	pm, calls := newCountingManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Metadata Only")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	embedding := slices.Clone(pb.Embedding)
+	*calls = 0
+
+	rec := &ExecutionRecord{PlaybookID: pb.ID, Outcome: OutcomeSuccess, StartedAt: time.Now(), CompletedAt: time.Now()}
+	if err := pm.RecordExecution(ctx, rec); err != nil {
+		t.Fatalf("RecordExecution: %v", err)
+	}
+	if err := pm.SetStatus(ctx, pb.ID, StatusDeprecated); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	if *calls != 0 {
+		t.Errorf("RecordExecution and SetStatus called the embedder %d times, want 0", *calls)
+	}
+
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.SuccessCount != 1 || got.Version != pb.Version {
+		t.Errorf("SuccessCount = %d, Version = %d; want 1 and unchanged %d", got.SuccessCount, got.Version, pb.Version)
+	}
+	if !slices.Equal(got.Embedding, embedding) {
+		t.Errorf("Embedding = %v, want the stored %v", got.Embedding, embedding)
+	}
+
+	// A content change through UpdateMetadata re-embeds.
+	got.Description = "A much longer description that changes the embedded text"
+	if err := pm.UpdateMetadata(ctx, got); err != nil {
+		t.Fatalf("UpdateMetadata: %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("UpdateMetadata with a content change called the embedder %d times, want 1", *calls)
+	}
+
+	stale := *got
+	stale.Version--
+	if err := pm.UpdateMetadata(ctx, &stale); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("UpdateMetadata with a stale version: error = %v, want ErrVersionConflict", err)
+	}
+
+	// Content saved through UpdateMetadata is validated like any other write.
+	emptied := *got
+	emptied.Name, emptied.Steps = "", nil
+	if err := pm.UpdateMetadata(ctx, &emptied); !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("UpdateMetadata without a name or steps: error = %v, want ErrInvalidPlaybook", err)
+	}
+	pm.cfg.MaxTags = 1
+	got.Tags = []string{"one", "two"}
+	if err := pm.UpdateMetadata(ctx, got); !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("UpdateMetadata over MaxTags: error = %v, want ErrInvalidPlaybook", err)
+	}
+}
+
+func TestManagerUpdateSkipsUnchangedEmbedding(t *testing.T) {
+	pm, calls := newCountingManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Recategorized")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if pb.EmbedHash == "" {
+		t.Fatal("Create did not record EmbedHash")
+	}
+	embedding, hash := slices.Clone(pb.Embedding), pb.EmbedHash
+	*calls = 0
+
+	// Category is not part of the embedded text.
+	pb.Category = "operations"
+	if err := pm.Update(ctx, pb); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if *calls != 0 {
+		t.Errorf("Update of a non-embedded field called the embedder %d times, want 0", *calls)
+	}
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !slices.Equal(got.Embedding, embedding) || got.EmbedHash != hash {
+		t.Errorf("Embedding = %v (hash %s), want unchanged %v (hash %s)", got.Embedding, got.EmbedHash, embedding, hash)
+	}
+
+	pb.Description = "Now with a different description"
+	if err := pm.Update(ctx, pb); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Update of the description called the embedder %d times, want 1", *calls)
+	}
+	if pb.EmbedHash == hash {
+		t.Error("EmbedHash not updated after the embedded text changed")
+	}
+
+	// Playbooks saved before EmbedHash existed are re-embedded once.
+	pb.EmbedHash = ""
+	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	pb.Category = "legacy"
+	if err := pm.Update(ctx, pb); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if *calls != 2 || pb.EmbedHash == "" {
+		t.Errorf("Update of a playbook without EmbedHash: calls = %d, EmbedHash = %q; want 2 and a hash", *calls, pb.EmbedHash)
+	}
+}
+
+func TestManagerUpdateEmbedsOutsideLock(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(ctx context.Context, text string) ([]float32, error) {
+			if strings.Contains(text, "slow embedding") {
+				entered <- struct{}{}
+				<-release
+			}
+			return []float32{float32(len(text)), 1, 2}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name        string
+		write       func(context.Context, *Playbook) error
+		wantVersion int
+	}{
+		{"Update", pm.Update, 2},
+		{"UpdateMetadata", pm.UpdateMetadata, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			release = make(chan struct{})
+			slow := samplePlaybook("Slow " + tt.name)
+			if err := pm.Create(ctx, slow); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			slow.Description = "Needs a slow embedding"
+			done := make(chan error, 1)
+			go func() { done <- tt.write(ctx, slow) }()
+			<-entered
+
+			// The slow write is blocked in the embedder; other writes must proceed.
+			written := make(chan error, 1)
+			go func() { written <- pm.Create(ctx, samplePlaybook("Fast "+tt.name)) }()
+			select {
+			case err := <-written:
+				if err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				close(release)
+				t.Fatalf("Create blocked while %s was embedding", tt.name)
+			}
+
+			close(release)
+			if err := <-done; err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			got, err := pm.Get(ctx, slow.ID)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got.Description != slow.Description || got.Version != tt.wantVersion {
+				t.Errorf("Get = %q v%d, want %q v%d", got.Description, got.Version, slow.Description, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestManagerRecordsEmbedModel(t *testing.T) {
+	var logBuf bytes.Buffer
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(_ context.Context, _ string) ([]float32, error) {
+			return []float32{0.1, 0.2, 0.3}, nil
+		},
+		EmbedModel: "test/model-a",
+		Logger:     slog.New(slog.NewTextHandler(&logBuf, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	pb := samplePlaybook("Embedded Deploy")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.EmbedModel != "test/model-a" {
+		t.Errorf("EmbedModel = %q, want %q", got.EmbedModel, "test/model-a")
+	}
+	if got.EmbedDims != 3 {
+		t.Errorf("EmbedDims = %d, want 3", got.EmbedDims)
+	}
+
+	// A playbook embedded by a previous provider.
+	legacy := newTestPlaybook("legacy-id", "Legacy Deploy")
+	legacy.Embedding = []float32{0.5, 0.5}
+	legacy.EmbedModel = "test/model-old"
+	legacy.EmbedDims = 2
+	if err := pm.store.SavePlaybook(ctx, legacy); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := pm.indexer.Index(ctx, legacy); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	stale, err := pm.StaleEmbeddings(ctx)
+	if err != nil {
+		t.Fatalf("StaleEmbeddings: %v", err)
+	}
+	if len(stale) != 1 || stale[0].ID != legacy.ID {
+		t.Errorf("StaleEmbeddings = %v, want only %s", stale, legacy.ID)
+	}
+
+	if _, err := pm.Search(ctx, SearchQuery{Text: "deploy", Mode: SearchModeHybrid}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if !strings.Contains(logBuf.String(), "different model") {
+		t.Errorf("expected mixed-model warning in log, got: %s", logBuf.String())
+	}
+}
+
+func TestManagerClone(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	src := samplePlaybook("Original Procedure")
+	src.Steps[0].ToolArgs = map[string]any{"cmd": "build"}
+	src.Lessons = []Lesson{{ID: "l1", Content: "cache layers"}}
+	src.CreatedBy = "alice"
+	if err := pm.Create(ctx, src); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	recordOutcomes(t, pm, src.ID, OutcomeSuccess, 2)
+	src, err := pm.Get(ctx, src.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	src.Description = "revised"
+	if err := pm.Update(ctx, src); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	clone, err := pm.Clone(ctx, src.ID, "Variant Procedure")
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+
+	if clone.ID == src.ID {
+		t.Error("clone should have a fresh ID")
+	}
+	if clone.Slug != "variant-procedure" {
+		t.Errorf("Slug = %q, want %q", clone.Slug, "variant-procedure")
+	}
+	if clone.Version != 1 {
+		t.Errorf("Version = %d, want 1", clone.Version)
+	}
+	if clone.SuccessCount != 0 || clone.FailureCount != 0 || clone.Confidence != 0 {
+		t.Errorf("stats not reset: %d/%d conf=%.2f", clone.SuccessCount, clone.FailureCount, clone.Confidence)
+	}
+	if !clone.LastUsedAt.IsZero() {
+		t.Errorf("LastUsedAt = %v, want zero", clone.LastUsedAt)
+	}
+	if clone.ForkedFrom != src.ID {
+		t.Errorf("ForkedFrom = %q, want %q", clone.ForkedFrom, src.ID)
+	}
+	if len(clone.Steps) != len(src.Steps) || clone.Category != src.Category || len(clone.Lessons) != 1 {
+		t.Fatalf("content not preserved: %+v", clone)
+	}
+	if clone.Lessons[0].Content != "cache layers" || clone.Lessons[0].ID == "" || clone.Lessons[0].ID == "l1" {
+		t.Errorf("cloned lesson = %+v, want the same content under a fresh ID", clone.Lessons[0])
+	}
+	if clone.CreatedBy != "" {
+		t.Errorf("CreatedBy = %q, want empty without an Actor, not the source's author", clone.CreatedBy)
+	}
+
+	// Mutating the clone must not affect the source.
+	clone.Steps[0].ToolArgs["cmd"] = "changed"
+	again, err := pm.Get(ctx, src.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if again.Steps[0].ToolArgs["cmd"] != "build" {
+		t.Errorf("source ToolArgs modified through clone: %v", again.Steps[0].ToolArgs)
+	}
+
+	execs, err := pm.ListExecutions(ctx, clone.ID, 0)
+	if err != nil {
+		t.Fatalf("ListExecutions: %v", err)
+	}
+	if len(execs) != 0 {
+		t.Errorf("clone has %d executions, want 0", len(execs))
+	}
+
+	if _, err := pm.Clone(ctx, src.ID, " "); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestManagerQueriesLeadingToFailures(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Query Tracked")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	records := []struct {
+		query   string
+		outcome Outcome
+	}{
+		{"deploy api", OutcomeFailure},
+		{"deploy api", OutcomeFailure},
+		{"deploy api", OutcomeSuccess},
+		{"ship service", OutcomeFailure},
+		{"rollout", OutcomeSuccess},
+		{"", OutcomeFailure},
+	}
+	for i, r := range records {
+		rec := &ExecutionRecord{
+			PlaybookID:        pb.ID,
+			Outcome:           r.outcome,
+			SelectedFromQuery: r.query,
+			StartedAt:         time.Now().Add(time.Duration(i) * time.Second),
+			CompletedAt:       time.Now(),
+		}
+		if err := pm.RecordExecution(ctx, rec); err != nil {
+			t.Fatalf("RecordExecution: %v", err)
+		}
+	}
+
+	execs, err := pm.ListExecutions(ctx, pb.ID, 1)
+	if err != nil {
+		t.Fatalf("ListExecutions: %v", err)
+	}
+	if len(execs) != 1 || execs[0].SelectedFromQuery != "" {
+		t.Fatalf("newest execution = %+v, want empty query", execs)
+	}
+	execs, err = pm.ListExecutions(ctx, pb.ID, 2)
+	if err != nil {
+		t.Fatalf("ListExecutions: %v", err)
+	}
+	if execs[1].SelectedFromQuery != "rollout" {
+		t.Errorf("SelectedFromQuery = %q, want %q (field should persist)", execs[1].SelectedFromQuery, "rollout")
+	}
+
+	stats, err := pm.QueriesLeadingToFailures(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("QueriesLeadingToFailures: %v", err)
+	}
+	want := []QueryFailureStat{
+		{Query: "deploy api", Failures: 2, Executions: 3},
+		{Query: "ship service", Failures: 1, Executions: 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %d stats, want %d: %+v", len(stats), len(want), stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("stats[%d] = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+}
+
+func TestManagerValidateLimits(t *testing.T) {
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:             t.TempDir(),
+		MaxTags:             3,
+		MaxDescriptionChars: 20,
+		Logger:              slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	tooManyTags := samplePlaybook("Many Tags")
+	tooManyTags.Description = "short"
+	tooManyTags.Tags = []string{"a", "b", "c", "d"}
+	err = pm.Create(ctx, tooManyTags)
+	if !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("Create with 4 tags: err = %v, want ErrInvalidPlaybook", err)
+	}
+
+	longDesc := samplePlaybook("Long Description")
+	longDesc.Description = strings.Repeat("é", 21)
+	err = pm.Create(ctx, longDesc)
+	if !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("Create with long description: err = %v, want ErrInvalidPlaybook", err)
+	}
+
+	ok := samplePlaybook("Within Limits")
+	ok.Description = strings.Repeat("é", 20)
+	if err := pm.Create(ctx, ok); err != nil {
+		t.Fatalf("Create within limits: %v", err)
+	}
+
+	ok.Tags = []string{"a", "b", "c", "d"}
+	err = pm.Update(ctx, ok)
+	if !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("Update with 4 tags: err = %v, want ErrInvalidPlaybook", err)
+	}
+	if ok.Version != 1 {
+		t.Errorf("Version = %d after rejected update, want 1", ok.Version)
+	}
+}
+
+func TestManagerValidateStepLimits(t *testing.T) {
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:            t.TempDir(),
+		MaxSteps:           3,
+		MaxStepActionChars: 10,
+		Logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	withSteps := func(name string, actions ...string) *Playbook {
+		pb := samplePlaybook(name)
+		pb.Steps = nil
+		for i, a := range actions {
+			pb.Steps = append(pb.Steps, Step{Order: i + 1, Action: a})
+		}
+		return pb
+	}
+
+	if err := pm.Create(ctx, withSteps("At Limits", "one", "two", strings.Repeat("é", 10))); err != nil {
+		t.Errorf("Create with 3 steps and a 10-character action: %v", err)
+	}
+	if err := pm.Create(ctx, withSteps("Too Many", "one", "two", "three", "four")); !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("Create with 4 steps: err = %v, want ErrInvalidPlaybook", err)
+	}
+	err = pm.Create(ctx, withSteps("Too Long", "one", strings.Repeat("é", 11)))
+	if !errors.Is(err, ErrInvalidPlaybook) || !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("Create with an 11-character action: err = %v, want ErrInvalidPlaybook naming step 2", err)
+	}
+}
+
+func TestManagerConfidenceZ(t *testing.T) {
+	ctx := context.Background()
+	create := func(z float64) (*PlaybookManager, *Playbook) {
+		t.Helper()
+		pm, err := NewPlaybookManager(ManagerConfig{
+			DataDir:     t.TempDir(),
+			ConfidenceZ: z,
+			Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		if err != nil {
+			t.Fatalf("NewPlaybookManager: %v", err)
+		}
+		t.Cleanup(func() { pm.Close() })
+		pb := samplePlaybook("Deploy")
+		pb.SuccessCount, pb.FailureCount = 6, 4
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		return pm, pb
+	}
+
+	_, def := create(0)
+	if want := WilsonConfidence(6, 4); def.Confidence != want {
+		t.Errorf("default Confidence = %f, want the 95%% bound %f", def.Confidence, want)
+	}
+	pm, strict := create(2.576)
+	if strict.Confidence >= def.Confidence {
+		t.Errorf("Confidence at z=2.576 = %f, want below the default %f", strict.Confidence, def.Confidence)
+	}
+	if ci := pm.ConfidenceInterval(strict); ci.Lower != strict.Confidence || ci.Estimate != 0.6 {
+		t.Errorf("ConfidenceInterval = %+v, want a lower bound of %f around 0.6", ci, strict.Confidence)
+	}
+}
+
+func TestManagerMaxLessons(t *testing.T) {
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:    t.TempDir(),
+		MaxLessons: 3,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	pb := samplePlaybook("Lessons")
+	pb.Lessons = []Lesson{
+		{Content: "a", Confidence: 0.5},
+		{Content: "b", Confidence: 0.9},
+		{Content: "c", Confidence: 0.5},
+	}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(pb.Lessons) != 3 {
+		t.Fatalf("Create at the limit kept %d lessons, want 3", len(pb.Lessons))
+	}
+
+	// Of the lessons tied at 0.5, the last ones listed are dropped first, so
+	// "e" displaces "c" and "d" is not kept.
+	pb.Lessons = append(pb.Lessons, Lesson{Content: "d", Confidence: 0.5}, Lesson{Content: "e", Confidence: 0.7})
+	if err := pm.Update(ctx, pb); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	var contents []string
+	for _, l := range got.Lessons {
+		contents = append(contents, l.Content)
+	}
+	if strings.Join(contents, ",") != "a,b,e" {
+		t.Errorf("lessons = %v, want [a b e]", contents)
+	}
+
+	if err := pm.ApplyReflection(ctx, pb.ID, &Reflection{Improvements: []string{"f"}}); err != nil {
+		t.Fatalf("ApplyReflection: %v", err)
+	}
+	if got, _ = pm.Get(ctx, pb.ID); len(got.Lessons) != 3 {
+		t.Errorf("after ApplyReflection: %d lessons, want 3", len(got.Lessons))
+	}
+}
+
+func TestManagerValidateUnboundedByDefault(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Unbounded")
+	pb.Description = strings.Repeat("x", 10000)
+	for i := 0; i < 500; i++ {
+		pb.Tags = append(pb.Tags, fmt.Sprintf("tag-%d", i))
+	}
+	pb.Steps = nil
+	for i := range 500 {
+		pb.Steps = append(pb.Steps, Step{Order: i + 1, Action: strings.Repeat("x", 5000)})
+	}
+	for i := range 500 {
+		pb.Lessons = append(pb.Lessons, Lesson{Content: fmt.Sprintf("lesson %d", i)})
+	}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(pb.Lessons) != 500 {
+		t.Errorf("Create kept %d lessons, want all 500", len(pb.Lessons))
+	}
+}
+
+func TestManagerPruneExecutions(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Execution Retention")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// Five executions, one per 30 days, newest now.
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		started := now.Add(-time.Duration(i) * 30 * 24 * time.Hour)
+		rec := &ExecutionRecord{
+			ID:          fmt.Sprintf("exec-%d", i),
+			PlaybookID:  pb.ID,
+			Outcome:     OutcomeSuccess,
+			StartedAt:   started,
+			CompletedAt: started,
+		}
+		if err := pm.RecordExecution(ctx, rec); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
 	}
-	if len(afterReflect.Lessons) == 0 {
-		t.Error("expected lessons to be added after reflection")
+
+	// Dry run reports but keeps everything.
+	result, err := pm.PruneExecutions(ctx, ExecutionPruneOptions{MaxAge: 75 * 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("PruneExecutions dry run: %v", err)
 	}
-	if afterReflect.Version <= 1 {
-		t.Errorf("Version = %d, want > 1 after update", afterReflect.Version)
+	if result.Removed != 2 {
+		t.Errorf("dry run Removed = %d, want 2", result.Removed)
+	}
+	execs, _ := pm.ListExecutions(ctx, pb.ID, 0)
+	if len(execs) != 5 {
+		t.Fatalf("dry run left %d executions, want 5", len(execs))
 	}
 
-	// 5. Search again — playbook should still be findable.
-	results2, err := pm.Search(ctx, SearchQuery{
-		Text:  "incident response",
-		Mode:  SearchModeBM25,
-		Limit: 5,
-	})
+	// Age rule removes the two records older than 75 days.
+	result, err = pm.PruneExecutions(ctx, ExecutionPruneOptions{MaxAge: 75 * 24 * time.Hour})
 	if err != nil {
-		t.Fatalf("Search after reflection: %v", err)
+		t.Fatalf("PruneExecutions: %v", err)
 	}
-	if len(results2) == 0 {
-		t.Error("expected search to find playbook after reflection and re-index")
+	if result.Removed != 2 || result.ByPlaybook[pb.ID] != 2 {
+		t.Errorf("Removed = %d (by playbook %v), want 2", result.Removed, result.ByPlaybook)
 	}
 
-	// 6. Stats should reflect one playbook.
-	stats, err := pm.Stats(ctx)
+	// Count rule keeps only the newest record.
+	result, err = pm.PruneExecutions(ctx, ExecutionPruneOptions{MaxPerPlaybook: 1})
 	if err != nil {
-		t.Fatalf("Stats: %v", err)
+		t.Fatalf("PruneExecutions: %v", err)
 	}
-	if stats.TotalPlaybooks != 1 {
-		t.Errorf("TotalPlaybooks = %d, want 1", stats.TotalPlaybooks)
+	if result.Removed != 2 {
+		t.Errorf("Removed = %d, want 2", result.Removed)
 	}
-	if stats.TotalExecs != 3 {
-		t.Errorf("TotalExecs = %d, want 3", stats.TotalExecs)
+	execs, _ = pm.ListExecutions(ctx, pb.ID, 0)
+	if len(execs) != 1 || execs[0].ID != "exec-0" {
+		t.Errorf("remaining executions = %v, want only exec-0", execs)
 	}
-}
 
-func TestNewPlaybookManagerRequiresDataDir(t *testing.T) {
-	_, err := NewPlaybookManager(ManagerConfig{})
-	if err == nil {
-		t.Error("expected error when DataDir is empty")
+	// Aggregate counts survive.
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.SuccessCount != 5 {
+		t.Errorf("SuccessCount = %d, want 5 after pruning records", got.SuccessCount)
 	}
 }
 
-func TestManagerSearchCompositeScore(t *testing.T) {
+func TestManagerRename(t *testing.T) {
 	pm := newTestManager(t)
 	ctx := context.Background()
 
-	// Create two playbooks with the same keyword so BM25 finds both.
-	pbA := samplePlaybook("Deployment Alpha")
-	pbA.Description = "deployment procedure for production servers"
-	pbA.Tags = []string{"deployment"}
-	if err := pm.Create(ctx, pbA); err != nil {
+	pb := samplePlaybook("Old Procedure Name")
+	if err := pm.Create(ctx, pb); err != nil {
 		t.Fatalf("setup: %v", err)
 	}
-
-	pbB := samplePlaybook("Deployment Beta")
-	pbB.Description = "deployment procedure for staging servers"
-	pbB.Tags = []string{"deployment"}
-	if err := pm.Create(ctx, pbB); err != nil {
+	other := samplePlaybook("Zephyr Rollout")
+	if err := pm.Create(ctx, other); err != nil {
 		t.Fatalf("setup: %v", err)
 	}
 
-	// Give playbook A low confidence (1 success, 9 failures).
-	gotA, err := pm.Get(ctx, pbA.ID)
+	if err := pm.Rename(ctx, pb.ID, "Zephyr Rollout"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	got, err := pm.Get(ctx, pb.ID)
 	if err != nil {
-		t.Fatalf("setup: %v", err)
+		t.Fatalf("Get: %v", err)
 	}
-	gotA.SuccessCount = 1
-	gotA.FailureCount = 9
-	gotA.UpdateStats()
-	if err := pm.store.SavePlaybook(ctx, gotA); err != nil {
+	if got.Name != "Zephyr Rollout" {
+		t.Errorf("Name = %q, want %q", got.Name, "Zephyr Rollout")
+	}
+	if got.Slug != "zephyr-rollout-2" {
+		t.Errorf("Slug = %q, want %q (unique)", got.Slug, "zephyr-rollout-2")
+	}
+	if got.Version != 2 {
+		t.Errorf("Version = %d, want 2", got.Version)
+	}
+
+	results, err := pm.Search(ctx, SearchQuery{Text: "zephyr", Mode: SearchModeBM25, Limit: 5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	found := false
+	for _, r := range results {
+		if r.Playbook.ID == pb.ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected search by the new name to find the renamed playbook")
+	}
+
+	// Renaming to its own current name keeps the slug.
+	if err := pm.Rename(ctx, other.ID, "Zephyr Rollout"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	again, _ := pm.Get(ctx, other.ID)
+	if again.Slug != "zephyr-rollout" {
+		t.Errorf("Slug = %q, want %q", again.Slug, "zephyr-rollout")
+	}
+
+	if err := pm.Rename(ctx, pb.ID, "  "); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestManagerStatsSince(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Windowed Stats")
+	if err := pm.Create(ctx, pb); err != nil {
 		t.Fatalf("setup: %v", err)
 	}
 
-	// Give playbook B high confidence (9 successes, 1 failure).
-	gotB, err := pm.Get(ctx, pbB.ID)
+	now := time.Now()
+	records := []struct {
+		age     time.Duration
+		outcome Outcome
+	}{
+		{60 * 24 * time.Hour, OutcomeFailure},
+		{45 * 24 * time.Hour, OutcomeFailure},
+		{10 * 24 * time.Hour, OutcomeSuccess},
+		{5 * 24 * time.Hour, OutcomePartial},
+		{1 * 24 * time.Hour, OutcomeFailure},
+	}
+	for _, r := range records {
+		rec := &ExecutionRecord{
+			PlaybookID:  pb.ID,
+			Outcome:     r.outcome,
+			StartedAt:   now.Add(-r.age),
+			CompletedAt: now.Add(-r.age),
+		}
+		if err := pm.RecordExecution(ctx, rec); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	stats, err := pm.Stats(ctx, StatsOptions{Since: now.Add(-30 * 24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.TotalExecs != 5 {
+		t.Errorf("TotalExecs = %d, want 5", stats.TotalExecs)
+	}
+	if stats.RecentExecs != 3 {
+		t.Errorf("RecentExecs = %d, want 3", stats.RecentExecs)
+	}
+	if want := 2.0 / 3.0; stats.RecentSuccessRate != want {
+		t.Errorf("RecentSuccessRate = %v, want %v", stats.RecentSuccessRate, want)
+	}
+
+	allTime, err := pm.Stats(ctx)
 	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if allTime.RecentExecs != 0 {
+		t.Errorf("RecentExecs without window = %d, want 0", allTime.RecentExecs)
+	}
+}
+
+func TestManagerSearchGroupedByLineage(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	root := samplePlaybook("Deploy App")
+	root.Description = "deploy the app to production"
+	if err := pm.Create(ctx, root); err != nil {
 		t.Fatalf("setup: %v", err)
 	}
-	gotB.SuccessCount = 9
-	gotB.FailureCount = 1
-	gotB.UpdateStats()
-	if err := pm.store.SavePlaybook(ctx, gotB); err != nil {
+	fork, err := pm.Clone(ctx, root.ID, "Deploy App Canary")
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	// A fork of a fork still belongs to the root's lineage.
+	if _, err := pm.Clone(ctx, fork.ID, "Deploy App Staging"); err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	other := samplePlaybook("Deploy Database")
+	other.Description = "deploy schema migrations"
+	if err := pm.Create(ctx, other); err != nil {
 		t.Fatalf("setup: %v", err)
 	}
 
-	results, err := pm.Search(ctx, SearchQuery{
-		Text:             "deployment",
-		Mode:             SearchModeBM25,
-		ConfidenceWeight: 0.9,
-		Limit:            10,
-	})
+	query := SearchQuery{Text: "deploy", Mode: SearchModeBM25, Limit: 10, MinScore: 0.01}
+
+	flat, err := pm.SearchGrouped(ctx, query)
 	if err != nil {
-		t.Fatalf("Search: %v", err)
+		t.Fatalf("SearchGrouped: %v", err)
 	}
-	if len(results) < 2 {
-		t.Fatalf("expected at least 2 results, got %d", len(results))
+	if len(flat) != 4 {
+		t.Fatalf("ungrouped: got %d groups, want 4", len(flat))
 	}
 
-	// High-confidence playbook B should be first.
-	if results[0].Playbook.ID != pbB.ID {
-		t.Errorf("expected high-confidence playbook %q first, got %q", pbB.ID, results[0].Playbook.ID)
+	query.GroupByLineage = true
+	groups, err := pm.SearchGrouped(ctx, query)
+	if err != nil {
+		t.Fatalf("SearchGrouped: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+
+	var lineage *SearchGroup
+	for i := range groups {
+		if groups[i].RootID == root.ID {
+			lineage = &groups[i]
+		}
+	}
+	if lineage == nil {
+		t.Fatalf("no group rooted at %s: %+v", root.ID, groups)
+	}
+	if lineage.Size() != 3 {
+		t.Errorf("lineage size = %d, want 3", lineage.Size())
+	}
+	for _, v := range lineage.Variants {
+		if v.Score > lineage.Best.Score {
+			t.Errorf("variant %s scored %.3f above best %.3f", v.Playbook.Name, v.Score, lineage.Best.Score)
+		}
+	}
+	if groups[0].Best.Score < groups[1].Best.Score {
+		t.Error("groups should be ordered by best score")
 	}
 }
 
-func TestManagerSearchCompositeScoreZeroWeightUnchanged(t *testing.T) {
+func TestManagerColdStartCandidates(t *testing.T) {
 	pm := newTestManager(t)
 	ctx := context.Background()
 
-	pbA := samplePlaybook("Scaling Alpha")
-	pbA.Description = "scaling procedure for web services"
-	pbA.Tags = []string{"scaling"}
-	if err := pm.Create(ctx, pbA); err != nil {
-		t.Fatalf("setup: %v", err)
+	fresh := samplePlaybook("Fresh Procedure")
+	warming := samplePlaybook("Warming Procedure")
+	established := samplePlaybook("Established Procedure")
+	failing := samplePlaybook("Failing Procedure")
+	archived := samplePlaybook("Archived Procedure")
+	for _, pb := range []*Playbook{fresh, warming, established, failing, archived} {
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
 	}
+	recordOutcomes(t, pm, warming.ID, OutcomeSuccess, 2)
+	recordOutcomes(t, pm, established.ID, OutcomeSuccess, DefaultColdExecutionThreshold)
+	recordOutcomes(t, pm, failing.ID, OutcomeFailure, 2)
 
-	pbB := samplePlaybook("Scaling Beta")
-	pbB.Description = "scaling procedure for databases"
-	pbB.Tags = []string{"scaling"}
-	if err := pm.Create(ctx, pbB); err != nil {
-		t.Fatalf("setup: %v", err)
+	got, err := pm.Get(ctx, archived.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got.Archived = true
+	if err := pm.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
 	}
 
-	// Set different confidences.
-	gotA, err := pm.Get(ctx, pbA.ID)
+	cold, err := pm.ColdStartCandidates(ctx)
 	if err != nil {
-		t.Fatalf("setup: %v", err)
+		t.Fatalf("ColdStartCandidates: %v", err)
 	}
-	gotA.SuccessCount = 1
-	gotA.FailureCount = 9
-	gotA.UpdateStats()
-	if err := pm.store.SavePlaybook(ctx, gotA); err != nil {
-		t.Fatalf("setup: %v", err)
+	if len(cold) != 2 {
+		t.Fatalf("got %d candidates, want 2: %v", len(cold), cold)
 	}
+	if cold[0].ID != fresh.ID || cold[1].ID != warming.ID {
+		t.Errorf("candidates = [%s, %s], want [%s, %s]", cold[0].Name, cold[1].Name, fresh.Name, warming.Name)
+	}
+}
 
-	gotB, err := pm.Get(ctx, pbB.ID)
+func TestManagerIDGenerator(t *testing.T) {
+	var n int
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:   t.TempDir(),
+		EmbedFunc: embed.Noop(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		IDGenerator: func() string {
+			n++
+			return fmt.Sprintf("id-%03d", n)
+		},
+	})
 	if err != nil {
-		t.Fatalf("setup: %v", err)
+		t.Fatalf("NewPlaybookManager: %v", err)
 	}
-	gotB.SuccessCount = 9
-	gotB.FailureCount = 1
-	gotB.UpdateStats()
-	if err := pm.store.SavePlaybook(ctx, gotB); err != nil {
+	defer pm.Close()
+	ctx := context.Background()
+
+	pb := samplePlaybook("Deterministic")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if pb.ID != "id-001" {
+		t.Errorf("playbook ID = %q, want %q", pb.ID, "id-001")
+	}
+
+	rec := &ExecutionRecord{PlaybookID: pb.ID, Outcome: OutcomeSuccess, StartedAt: time.Now()}
+	if err := pm.RecordExecution(ctx, rec); err != nil {
+		t.Fatalf("RecordExecution: %v", err)
+	}
+	if rec.ID != "id-002" {
+		t.Errorf("execution ID = %q, want %q", rec.ID, "id-002")
+	}
+
+	if err := pm.ApplyReflection(ctx, pb.ID, &Reflection{Improvements: []string{"check disk space"}}); err != nil {
+		t.Fatalf("ApplyReflection: %v", err)
+	}
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Lessons) != 1 || got.Lessons[0].ID != "id-003" {
+		t.Errorf("lessons = %+v, want one lesson with ID %q", got.Lessons, "id-003")
+	}
+}
+
+func TestManagerSetStatus(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Status Procedure")
+	pb.Status = StatusDraft
+	if err := pm.Create(ctx, pb); err != nil {
 		t.Fatalf("setup: %v", err)
 	}
 
-	// Search with zero weight (disabled) — should just return results without reordering.
-	results, err := pm.Search(ctx, SearchQuery{
-		Text:             "scaling",
-		Mode:             SearchModeBM25,
-		ConfidenceWeight: 0,
-		Limit:            10,
-	})
+	if err := pm.SetStatus(ctx, pb.ID, StatusActive); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	got, err := pm.Get(ctx, pb.ID)
 	if err != nil {
-		t.Fatalf("Search: %v", err)
+		t.Fatalf("Get: %v", err)
 	}
-	if len(results) == 0 {
-		t.Fatal("expected at least 1 result")
+	if got.Status != StatusActive {
+		t.Errorf("Status = %q, want %q", got.Status, StatusActive)
+	}
+	if got.Version != 1 {
+		t.Errorf("Version = %d, want 1 (status changes don't bump the version)", got.Version)
+	}
+
+	// Update from a copy loaded before the status change keeps the stored status.
+	pb.Description = "edited"
+	if err := pm.Update(ctx, pb); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != StatusActive {
+		t.Errorf("Status after Update = %q, want %q", got.Status, StatusActive)
+	}
+
+	if err := pm.SetStatus(ctx, pb.ID, "retired"); err == nil {
+		t.Error("expected error for unknown status, got nil")
 	}
 }
 
-func TestNormalizeScore(t *testing.T) {
+func TestValidateStatusTransition(t *testing.T) {
 	tests := []struct {
-		score, min, max float64
-		want            float64
+		from, to Status
+		ok       bool
 	}{
-		{score: 10, min: 0, max: 10, want: 1.0},
-		{score: 0, min: 0, max: 10, want: 0.0},
-		{score: 5, min: 0, max: 10, want: 0.5},
-		{score: 5, min: 5, max: 5, want: 1.0}, // all equal
+		{StatusDraft, StatusActive, true},
+		{StatusDraft, StatusDeprecated, true},
+		{StatusActive, StatusDeprecated, true},
+		{"", StatusDeprecated, true},
+		{"", StatusActive, false},
+		{StatusActive, StatusActive, false},
+		{StatusDeprecated, StatusActive, false},
+		{StatusDeprecated, StatusDeprecated, false},
+		{StatusActive, StatusDraft, false},
+	}
+	for _, tt := range tests {
+		err := ValidateStatusTransition(tt.from, tt.to)
+		if tt.ok && err != nil {
+			t.Errorf("%q -> %q: unexpected error: %v", tt.from, tt.to, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidStatusTransition) {
+			t.Errorf("%q -> %q: err = %v, want ErrInvalidStatusTransition", tt.from, tt.to, err)
+		}
 	}
-	for _, tc := range tests {
-		got := normalizeScore(tc.score, tc.min, tc.max)
-		if got != tc.want {
-			t.Errorf("normalizeScore(%v, %v, %v) = %v, want %v", tc.score, tc.min, tc.max, got, tc.want)
+}
+
+func TestManagerAllLessons(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	deploy := samplePlaybook("Deploy App")
+	deploy.Category = "deployment"
+	deploy.Lessons = []Lesson{
+		{ID: "d1", Content: "warm the cache before switching traffic", Confidence: 0.9},
+		{ID: "d2", Content: "tag images with the commit SHA", Confidence: 0.4},
+	}
+	incident := samplePlaybook("Database Failover")
+	incident.Category = "incident"
+	incident.Lessons = []Lesson{
+		{ID: "i1", Content: "check replication lag first", Confidence: 0.7},
+	}
+	for _, pb := range []*Playbook{deploy, incident} {
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	lessons, err := pm.AllLessons(ctx, LessonFilter{})
+	if err != nil {
+		t.Fatalf("AllLessons: %v", err)
+	}
+	if len(lessons) != 3 {
+		t.Fatalf("got %d lessons, want 3", len(lessons))
+	}
+	wantOrder := []struct{ id, source string }{
+		{"d1", deploy.ID}, {"i1", incident.ID}, {"d2", deploy.ID},
+	}
+	for i, w := range wantOrder {
+		if lessons[i].ID != w.id || lessons[i].PlaybookID != w.source {
+			t.Errorf("lessons[%d] = %s from %s, want %s from %s", i, lessons[i].ID, lessons[i].PlaybookID, w.id, w.source)
 		}
 	}
+	if lessons[1].PlaybookName != "Database Failover" || lessons[1].Category != "incident" {
+		t.Errorf("source not annotated: %+v", lessons[1])
+	}
+
+	lessons, err = pm.AllLessons(ctx, LessonFilter{Category: "deployment", MinConfidence: 0.5})
+	if err != nil {
+		t.Fatalf("AllLessons: %v", err)
+	}
+	if len(lessons) != 1 || lessons[0].ID != "d1" {
+		t.Errorf("filtered lessons = %+v, want only d1", lessons)
+	}
 }
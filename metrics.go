@@ -0,0 +1,87 @@
+package playbookd
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucas-stellet/playbookd/embed"
+)
+
+// Metrics receives instrumentation from a PlaybookManager. The manager calls
+// it synchronously, so implementations should only update counters. The
+// prommetrics package implements it with Prometheus collectors; keeping the
+// interface here means the root package does not depend on Prometheus.
+type Metrics interface {
+	// PlaybookCreated is called for each playbook saved by Create, CreateBatch,
+	// or an import.
+	PlaybookCreated()
+	// PlaybookUpdated is called for each successful Update, UpdateMetadata,
+	// or SetStatus, including those made by Rename and ApplyReflection.
+	PlaybookUpdated()
+	// PlaybookDeleted is called for each successful Delete, and for each Purge
+	// of a playbook that was not already in the trash.
+	PlaybookDeleted()
+	// SearchCompleted is called once per Search with the requested mode
+	// (hybrid when unset), the time it took, and its error, if any.
+	SearchCompleted(mode SearchMode, elapsed time.Duration, err error)
+	// SearchDegraded is called once per hybrid or vector search that fell
+	// back to BM25 because its query could not be embedded.
+	SearchDegraded(reason DegradeReason)
+	// EmbeddingCompleted is called once per call to the configured EmbedFunc.
+	EmbeddingCompleted(elapsed time.Duration, err error)
+	// IndexSize is called with the number of indexed documents after each
+	// change to the index.
+	IndexSize(docs int)
+}
+
+// noopMetrics is the Metrics used when ManagerConfig.Metrics is nil.
+type noopMetrics struct{}
+
+func (noopMetrics) PlaybookCreated()                                 {}
+func (noopMetrics) PlaybookUpdated()                                 {}
+func (noopMetrics) PlaybookDeleted()                                 {}
+func (noopMetrics) SearchCompleted(SearchMode, time.Duration, error) {}
+func (noopMetrics) SearchDegraded(DegradeReason)                     {}
+func (noopMetrics) EmbeddingCompleted(time.Duration, error)          {}
+func (noopMetrics) IndexSize(int)                                    {}
+
+// instrumentEmbed wraps fn so every call is reported to m.
+func instrumentEmbed(fn embed.EmbeddingFunc, m Metrics) embed.EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		start := time.Now()
+		emb, err := fn(ctx, text)
+		m.EmbeddingCompleted(time.Since(start), err)
+		return emb, err
+	}
+}
+
+// instrumentedIndexer reports the index size to metrics after each change.
+type instrumentedIndexer struct {
+	Indexer
+	metrics Metrics
+}
+
+func (ii *instrumentedIndexer) Index(ctx context.Context, pb *Playbook) error {
+	err := ii.Indexer.Index(ctx, pb)
+	ii.reportSize(ctx)
+	return err
+}
+
+func (ii *instrumentedIndexer) Remove(ctx context.Context, id string) error {
+	err := ii.Indexer.Remove(ctx, id)
+	ii.reportSize(ctx)
+	return err
+}
+
+func (ii *instrumentedIndexer) Reindex(ctx context.Context, playbooks []*Playbook) error {
+	err := ii.Indexer.Reindex(ctx, playbooks)
+	ii.reportSize(ctx)
+	return err
+}
+
+// reportSize reports the number of indexed documents, see indexDocCount.
+func (ii *instrumentedIndexer) reportSize(ctx context.Context) {
+	if n, err := indexDocCount(ctx, ii.Indexer); err == nil {
+		ii.metrics.IndexSize(n)
+	}
+}
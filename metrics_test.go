@@ -0,0 +1,118 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// recordingMetrics is a Metrics that counts each call.
+type recordingMetrics struct {
+	created, updated, deleted int
+	searches                  []SearchMode
+	degraded                  []DegradeReason
+	embeddings, embedErrors   int
+	indexSize                 int
+}
+
+func (r *recordingMetrics) PlaybookCreated() { r.created++ }
+func (r *recordingMetrics) PlaybookUpdated() { r.updated++ }
+func (r *recordingMetrics) PlaybookDeleted() { r.deleted++ }
+
+func (r *recordingMetrics) SearchCompleted(mode SearchMode, _ time.Duration, _ error) {
+	r.searches = append(r.searches, mode)
+}
+
+func (r *recordingMetrics) SearchDegraded(reason DegradeReason) {
+	r.degraded = append(r.degraded, reason)
+}
+
+func (r *recordingMetrics) EmbeddingCompleted(_ time.Duration, err error) {
+	r.embeddings++
+	if err != nil {
+		r.embedErrors++
+	}
+}
+
+func (r *recordingMetrics) IndexSize(docs int) { r.indexSize = docs }
+
+func TestManagerMetrics(t *testing.T) {
+	rec := &recordingMetrics{}
+	failEmbed := false
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(context.Context, string) ([]float32, error) {
+			if failEmbed {
+				return nil, errors.New("provider down")
+			}
+			return []float32{1, 0, 0}, nil
+		},
+		EmbedDims: 3,
+		Metrics:   rec,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	defer pm.Close()
+	ctx := context.Background()
+
+	a, b := samplePlaybook("Alpha"), samplePlaybook("Beta")
+	for _, pb := range []*Playbook{a, b} {
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if rec.created != 2 || rec.indexSize != 2 {
+		t.Errorf("after two creates: created = %d, index size = %d; want 2, 2", rec.created, rec.indexSize)
+	}
+
+	a.Description = "changed"
+	if err := pm.Update(ctx, a); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := pm.SetStatus(ctx, a.ID, StatusDeprecated); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	if rec.updated != 2 {
+		t.Errorf("updated = %d, want 2", rec.updated)
+	}
+
+	failEmbed = true
+	if _, err := pm.Search(ctx, SearchQuery{Text: "alpha", Mode: SearchModeBM25}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	results, err := pm.Search(ctx, SearchQuery{Text: "alpha", MinScore: NoMinScore})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(rec.searches) != 2 || rec.searches[0] != SearchModeBM25 || rec.searches[1] != SearchModeHybrid {
+		t.Errorf("searches = %v, want [bm25 hybrid]", rec.searches)
+	}
+	// Only the hybrid search lost anything by falling back to BM25.
+	if len(rec.degraded) != 1 || rec.degraded[0] != DegradeEmbeddingFailed {
+		t.Errorf("degraded = %v, want [%s]", rec.degraded, DegradeEmbeddingFailed)
+	}
+	if len(results) == 0 {
+		t.Fatal("hybrid search returned no results")
+	}
+	for _, r := range results {
+		if !r.DegradedToBM25 || r.DegradedReason != DegradeEmbeddingFailed {
+			t.Errorf("result %s degraded = %v (%q), want true (%s)", r.Playbook.Name, r.DegradedToBM25, r.DegradedReason, DegradeEmbeddingFailed)
+		}
+	}
+	// Two creates and an update embed; both searches try to embed and fail.
+	if rec.embeddings != 5 || rec.embedErrors != 2 {
+		t.Errorf("embeddings = %d (%d failed), want 5 (2 failed)", rec.embeddings, rec.embedErrors)
+	}
+
+	if err := pm.Delete(ctx, b.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if rec.deleted != 1 || rec.indexSize != 1 {
+		t.Errorf("after delete: deleted = %d, index size = %d; want 1, 1", rec.deleted, rec.indexSize)
+	}
+}
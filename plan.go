@@ -0,0 +1,88 @@
+package playbookd
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+// PlanLessonLimit is how many lessons ExecutionPlan includes, most confident
+// first.
+const PlanLessonLimit = 5
+
+// Plan is what an agent needs to carry out a playbook: its steps in order and
+// its most confident lessons, without stats or bookkeeping. It is the JSON
+// contract printed by "playbookd plan".
+type Plan struct {
+	PlaybookID string       `json:"playbook_id"`
+	Name       string       `json:"name"`
+	Version    int          `json:"version"`
+	Steps      []PlanStep   `json:"steps"`
+	Lessons    []PlanLesson `json:"lessons,omitempty"`
+}
+
+// PlanStep is one step of a Plan.
+type PlanStep struct {
+	Order    int            `json:"order"`
+	Action   string         `json:"action"`
+	Tool     string         `json:"tool,omitempty"`
+	ToolArgs map[string]any `json:"tool_args,omitempty"`
+	Expected string         `json:"expected,omitempty"`
+	Fallback string         `json:"fallback,omitempty"`
+	Notes    string         `json:"notes,omitempty"`
+	Optional bool           `json:"optional"`
+	Refs     []string       `json:"refs,omitempty"` // Sub-playbooks that carry out this step
+}
+
+// PlanLesson is a lesson included in a Plan.
+type PlanLesson struct {
+	Content    string  `json:"content"`
+	Applies    string  `json:"applies,omitempty"`
+	Confidence float64 `json:"confidence"`
+}
+
+// ExecutionPlan returns the plan for running the playbook with the given ID:
+// its steps sorted by Order, with optional steps marked, and up to
+// PlanLessonLimit of its lessons, most confident first. Steps is never nil.
+func (pm *PlaybookManager) ExecutionPlan(ctx context.Context, id string) (*Plan, error) {
+	pb, err := pm.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return planFor(pb), nil
+}
+
+// planFor builds the execution plan for pb.
+func planFor(pb *Playbook) *Plan {
+	plan := &Plan{
+		PlaybookID: pb.ID,
+		Name:       pb.Name,
+		Version:    pb.Version,
+		Steps:      make([]PlanStep, 0, len(pb.Steps)),
+	}
+	for _, s := range pb.Steps {
+		plan.Steps = append(plan.Steps, PlanStep{
+			Order:    s.Order,
+			Action:   s.Action,
+			Tool:     s.Tool,
+			ToolArgs: s.ToolArgs,
+			Expected: s.Expected,
+			Fallback: s.Fallback,
+			Notes:    s.Notes,
+			Optional: s.Optional,
+			Refs:     slices.Clone(s.Refs),
+		})
+	}
+	sort.SliceStable(plan.Steps, func(i, j int) bool {
+		return plan.Steps[i].Order < plan.Steps[j].Order
+	})
+
+	lessons := slices.Clone(pb.Lessons)
+	sort.SliceStable(lessons, func(i, j int) bool {
+		return lessons[i].Confidence > lessons[j].Confidence
+	})
+	for _, l := range lessons[:min(len(lessons), PlanLessonLimit)] {
+		plan.Lessons = append(plan.Lessons, PlanLesson{Content: l.Content, Applies: l.Applies, Confidence: l.Confidence})
+	}
+	return plan
+}
@@ -0,0 +1,64 @@
+package playbookd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExecutionPlan(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Planned Deploy")
+	pb.Steps = []Step{
+		{Order: 2, Action: "Smoke test", Expected: "200 OK", Fallback: "Roll back", Optional: true},
+		{Order: 1, Action: "Deploy", Tool: "kubectl", ToolArgs: map[string]any{"cmd": "apply"}, Refs: []string{"rollback"}},
+	}
+	for i := range PlanLessonLimit + 2 {
+		pb.Lessons = append(pb.Lessons, Lesson{Content: fmt.Sprintf("lesson %d", i), Confidence: float64(i) / 10})
+	}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	plan, err := pm.ExecutionPlan(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("ExecutionPlan: %v", err)
+	}
+	if plan.PlaybookID != pb.ID || plan.Name != pb.Name || plan.Version != 1 {
+		t.Errorf("plan = %s %q v%d, want %s %q v1", plan.PlaybookID, plan.Name, plan.Version, pb.ID, pb.Name)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].Order != 1 || plan.Steps[1].Order != 2 {
+		t.Fatalf("Steps = %+v, want steps 1 and 2 in order", plan.Steps)
+	}
+	if s := plan.Steps[0]; s.Tool != "kubectl" || s.ToolArgs["cmd"] != "apply" || s.Optional || len(s.Refs) != 1 {
+		t.Errorf("step 1 = %+v, want the kubectl tool call with its ref", s)
+	}
+	if s := plan.Steps[1]; s.Expected != "200 OK" || s.Fallback != "Roll back" || !s.Optional {
+		t.Errorf("step 2 = %+v, want an optional step with expected and fallback", s)
+	}
+	if len(plan.Lessons) != PlanLessonLimit || plan.Lessons[0].Content != fmt.Sprintf("lesson %d", PlanLessonLimit+1) {
+		t.Errorf("Lessons = %+v, want the %d most confident first", plan.Lessons, PlanLessonLimit)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, noise := range []string{"success_rate", "execution_count", "created_at", "learned_from"} {
+		if strings.Contains(string(data), noise) {
+			t.Errorf("plan JSON contains %q: %s", noise, data)
+		}
+	}
+	if !strings.Contains(string(data), `"optional":false`) {
+		t.Errorf("plan JSON = %s, want every step's optional marker", data)
+	}
+
+	if _, err := pm.ExecutionPlan(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ExecutionPlan(missing) error = %v, want ErrNotFound", err)
+	}
+}
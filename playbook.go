@@ -1,11 +1,19 @@
 package playbookd
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
-const z95 = 1.96 // z-score for 95% confidence interval
+// DefaultConfidenceZ is the z-score of the Wilson interval behind
+// Playbook.Confidence when ManagerConfig.ConfidenceZ is unset: 1.96, for a 95%
+// confidence level.
+const DefaultConfidenceZ = 1.96
 
 // Outcome represents the result of an execution.
 type Outcome string
@@ -16,27 +24,51 @@ const (
 	OutcomeFailure Outcome = "failure"
 )
 
+// Status is the lifecycle state of a playbook.
+type Status string
+
+const (
+	StatusDraft      Status = "draft"      // Not yet vetted
+	StatusActive     Status = "active"     // In use; the default
+	StatusDeprecated Status = "deprecated" // Kept for reference, should not be followed
+)
+
 // Playbook represents a learned procedure that an agent can follow.
 type Playbook struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	Slug         string    `json:"slug"`
-	Description  string    `json:"description"`
-	Tags         []string  `json:"tags"`
-	Category     string    `json:"category"`
-	Steps        []Step    `json:"steps"`
-	Version      int       `json:"version"`
-	SuccessCount int       `json:"success_count"`
-	FailureCount int       `json:"failure_count"`
-	SuccessRate  float64   `json:"success_rate"`
-	Confidence   float64   `json:"confidence"`
-	Archived     bool      `json:"archived,omitempty"`
-	Lessons      []Lesson  `json:"lessons"`
-	Embedding    []float32 `json:"embedding,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	LastUsedAt   time.Time `json:"last_used_at"`
-	CreatedBy    string    `json:"created_by"`
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Slug         string        `json:"slug"`
+	Description  string        `json:"description"`
+	Tags         []string      `json:"tags"`
+	Category     string        `json:"category"`
+	Steps        []Step        `json:"steps"`
+	Version      int           `json:"version"`
+	SuccessCount int           `json:"success_count"`
+	FailureCount int           `json:"failure_count"`
+	SuccessRate  float64       `json:"success_rate"`
+	Confidence   float64       `json:"confidence"`
+	AvgDuration  time.Duration `json:"avg_duration,omitempty"` // Mean run time of recent executions; see ExecutionDurations
+	P95Duration  time.Duration `json:"p95_duration,omitempty"` // 95th percentile run time of recent executions
+	Archived     bool          `json:"archived,omitempty"`
+	Status       Status        `json:"status,omitempty"` // Empty means active; change with SetStatus
+	Lessons      []Lesson      `json:"lessons"`
+	Embedding    []float32     `json:"embedding,omitempty"`
+	EmbedModel   string        `json:"embed_model,omitempty"`
+	EmbedDims    int           `json:"embed_dims,omitempty"`
+	EmbedHash    string        `json:"embed_hash,omitempty"` // SHA-256 of the text Embedding was generated from
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	LastUsedAt   time.Time     `json:"last_used_at"`
+	DeletedAt    *time.Time    `json:"deleted_at,omitempty"` // Set while the playbook is in the trash
+	CreatedBy    string        `json:"created_by"`
+	UpdatedBy    string        `json:"updated_by,omitempty"`    // ManagerConfig.Actor of the last Update
+	LastUsedBy   string        `json:"last_used_by,omitempty"`  // AgentID of the last recorded execution
+	TaskContexts []string      `json:"task_contexts,omitempty"` // Distinct TaskContexts of recent executions; see SearchByTaskContext
+	ForkedFrom   string        `json:"forked_from,omitempty"`   // ID of the playbook this was cloned from
+
+	// RawExtra holds JSON keys this version does not know about, so they
+	// survive a load and save. See compat.go.
+	RawExtra json.RawMessage `json:"-"`
 }
 
 // Step represents a single action within a playbook procedure.
@@ -49,6 +81,13 @@ type Step struct {
 	Fallback string         `json:"fallback,omitempty"`
 	Notes    string         `json:"notes,omitempty"`
 	Optional bool           `json:"optional,omitempty"`
+
+	// Refs names sub-playbooks (by ID or slug) that carry out this step,
+	// such as a shared rollback procedure.
+	Refs []string `json:"refs,omitempty"`
+	// Subplaybooks holds the playbooks named by Refs, filled in by Resolve
+	// and by SearchWithContext with ResolveRefs. It is never stored.
+	Subplaybooks []*Playbook `json:"-"`
 }
 
 // ExecutionRecord captures a single run of a playbook.
@@ -63,15 +102,20 @@ type ExecutionRecord struct {
 	StepResults []StepResult `json:"step_results"`
 	TaskContext string       `json:"task_context"`
 	Reflection  *Reflection  `json:"reflection,omitempty"`
+
+	SelectedFromQuery string `json:"selected_from_query,omitempty"` // Search query that led to this playbook
+
+	// RawExtra holds unknown JSON keys, as on Playbook.
+	RawExtra json.RawMessage `json:"-"`
 }
 
 // StepResult captures the outcome of executing a single step.
 type StepResult struct {
-	StepOrder int     `json:"step_order"`
-	Outcome   Outcome `json:"outcome"`
-	Output    string  `json:"output,omitempty"`
-	Error     string  `json:"error,omitempty"`
-	Duration  string  `json:"duration,omitempty"`
+	StepOrder int           `json:"step_order"`
+	Outcome   Outcome       `json:"outcome"`
+	Output    string        `json:"output,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"` // Written to JSON as a string such as "1.5s"; see compat.go
 }
 
 // Reflection captures an agent's analysis of an execution.
@@ -92,33 +136,114 @@ type Lesson struct {
 	Confidence  float64   `json:"confidence"`
 }
 
+// LessonFilter configures lesson aggregation. Zero values match everything.
+type LessonFilter struct {
+	Category      string  // Only lessons from playbooks in this category
+	MinConfidence float64 // Only lessons with at least this confidence
+}
+
+// LessonWithSource is a lesson annotated with the playbook it belongs to.
+type LessonWithSource struct {
+	Lesson
+	PlaybookID   string `json:"playbook_id"`
+	PlaybookName string `json:"playbook_name"`
+	PlaybookSlug string `json:"playbook_slug"`
+	Category     string `json:"category"`
+}
+
 // ListFilter configures playbook listing.
 type ListFilter struct {
 	IncludeArchived bool
+	OnlyArchived    bool // Match archived playbooks only; implies IncludeArchived
 	Category        string
 	Tags            []string
-	Limit           int
+	Limit           int       // Applied after sorting
+	SortBy          SortField // Default SortByConfidence
+	Ascending       bool      // Sort smallest (or A to Z) first; the default is largest first
+}
+
+// SortField is a playbook field that ListFilter.SortBy can order by. Ties
+// are broken by name, then ID, always A to Z.
+type SortField string
+
+const (
+	SortByName        SortField = "name" // Case-insensitive
+	SortByConfidence  SortField = "confidence"
+	SortByCreated     SortField = "created"
+	SortByUpdated     SortField = "updated"
+	SortBySuccessRate SortField = "success-rate"
+	SortByExecutions  SortField = "executions" // SuccessCount + FailureCount
+)
+
+// SortFields lists the valid SortField values.
+var SortFields = []SortField{SortByName, SortByConfidence, SortByCreated, SortByUpdated, SortBySuccessRate, SortByExecutions}
+
+// ExecutionFilter configures execution listing. Zero values match everything.
+type ExecutionFilter struct {
+	Outcome       Outcome
+	AgentID       string
+	StartedAfter  time.Time
+	StartedBefore time.Time
+	Limit         int
 }
 
 // WilsonConfidence calculates the Wilson score interval lower bound at 95% CI.
 // This prevents a playbook with 1/1 success from outranking one with 95/100.
 func WilsonConfidence(successes, failures int) float64 {
+	return WilsonInterval(successes, failures).Lower
+}
+
+// ConfidenceInterval is a Wilson score interval around an observed success
+// rate. Lower is what Playbook.Confidence holds; the width shows how much
+// uncertainty remains, which is large for playbooks with few executions.
+type ConfidenceInterval struct {
+	Lower    float64 `json:"lower"`
+	Estimate float64 `json:"estimate"` // Observed success rate
+	Upper    float64 `json:"upper"`
+}
+
+// WilsonInterval calculates the 95% Wilson score interval for the given
+// counts. With no executions it is the zero interval.
+func WilsonInterval(successes, failures int) ConfidenceInterval {
+	return WilsonIntervalZ(successes, failures, DefaultConfidenceZ)
+}
+
+// WilsonIntervalZ calculates the Wilson score interval for the given counts
+// at z-score z, such as 1.645 for 90% or 2.576 for 99%. A higher z widens the
+// interval, penalizing playbooks with few executions more.
+func WilsonIntervalZ(successes, failures int, z float64) ConfidenceInterval {
 	n := float64(successes + failures)
 	if n == 0 {
-		return 0
+		return ConfidenceInterval{}
 	}
 	p := float64(successes) / n
-	z := z95
 
 	denominator := 1 + z*z/n
 	center := p + z*z/(2*n)
 	spread := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
 
-	return (center - spread) / denominator
+	return ConfidenceInterval{
+		Lower:    (center - spread) / denominator,
+		Estimate: p,
+		Upper:    (center + spread) / denominator,
+	}
+}
+
+// ConfidenceInterval returns the 95% Wilson score interval of pb's success
+// and failure counts. PlaybookManager.ConfidenceInterval uses the manager's
+// ConfidenceZ instead.
+func (pb *Playbook) ConfidenceInterval() ConfidenceInterval {
+	return WilsonInterval(pb.SuccessCount, pb.FailureCount)
 }
 
-// UpdateStats recalculates success rate and confidence from counts.
+// UpdateStats recalculates success rate and confidence from counts, at 95%.
 func (pb *Playbook) UpdateStats() {
+	pb.UpdateStatsZ(DefaultConfidenceZ)
+}
+
+// UpdateStatsZ recalculates success rate and confidence from counts, taking
+// the lower bound of the Wilson interval at z-score z as the confidence.
+func (pb *Playbook) UpdateStatsZ(z float64) {
 	total := pb.SuccessCount + pb.FailureCount
 	if total == 0 {
 		pb.SuccessRate = 0
@@ -126,6 +251,100 @@ func (pb *Playbook) UpdateStats() {
 		return
 	}
 	pb.SuccessRate = float64(pb.SuccessCount) / float64(total)
-	pb.Confidence = WilsonConfidence(pb.SuccessCount, pb.FailureCount)
+	pb.Confidence = WilsonIntervalZ(pb.SuccessCount, pb.FailureCount, z).Lower
+}
+
+// ValidateOptions sets size limits for ValidatePlaybook, such as those
+// configured on a manager. Zero values are unbounded.
+type ValidateOptions struct {
+	MaxSteps           int // Max steps per playbook
+	MaxStepActionChars int // Max step action length in characters
 }
 
+// check returns an error wrapping ErrInvalidPlaybook if pb exceeds a limit.
+func (o ValidateOptions) check(pb *Playbook) error {
+	if o.MaxSteps > 0 && len(pb.Steps) > o.MaxSteps {
+		return fmt.Errorf("%w: %d steps exceeds the maximum of %d", ErrInvalidPlaybook, len(pb.Steps), o.MaxSteps)
+	}
+	if o.MaxStepActionChars > 0 {
+		for i, s := range pb.Steps {
+			if n := utf8.RuneCountInString(s.Action); n > o.MaxStepActionChars {
+				return fmt.Errorf("%w: step %d: action is %d characters, exceeds the maximum of %d",
+					ErrInvalidPlaybook, i+1, n, o.MaxStepActionChars)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatePlaybook checks the structural rules every playbook must satisfy: a
+// non-empty name, at least one step, a non-empty action on every step, and
+// step orders that are unique and increasing in list order, then the limits
+// in opts, if given. It returns the first problem found, wrapping
+// ErrInvalidPlaybook. Other manager-specific limits are checked by
+// PlaybookManager.Validate.
+func ValidatePlaybook(pb *Playbook, opts ...ValidateOptions) error {
+	if strings.TrimSpace(pb.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPlaybook)
+	}
+	if len(pb.Steps) == 0 {
+		return fmt.Errorf("%w: at least one step is required", ErrInvalidPlaybook)
+	}
+	seen := make(map[int]int, len(pb.Steps))
+	for i, s := range pb.Steps {
+		if strings.TrimSpace(s.Action) == "" {
+			return fmt.Errorf("%w: step %d: action is required", ErrInvalidPlaybook, i+1)
+		}
+		if prev, ok := seen[s.Order]; ok {
+			return fmt.Errorf("%w: step %d: order %d duplicates step %d", ErrInvalidPlaybook, i+1, s.Order, prev)
+		}
+		if i > 0 && s.Order < pb.Steps[i-1].Order {
+			return fmt.Errorf("%w: step %d: order %d comes after order %d; steps must be listed in increasing order",
+				ErrInvalidPlaybook, i+1, s.Order, pb.Steps[i-1].Order)
+		}
+		seen[s.Order] = i + 1
+	}
+	if len(opts) > 0 {
+		return opts[0].check(pb)
+	}
+	return nil
+}
+
+// normalizeStepOrder sorts steps by Order and renumbers them 1..N if the
+// orders are not already exactly 1..N in list order, e.g. after hand editing
+// left duplicates or gaps. A step with no order (zero or negative) stays right
+// after the step listed before it. It reports whether anything changed.
+func normalizeStepOrder(steps []Step) bool {
+	sequential := true
+	for i, s := range steps {
+		if s.Order != i+1 {
+			sequential = false
+			break
+		}
+	}
+	if sequential {
+		return false
+	}
+
+	// Sort positions by effective order; the stable sort keeps list order for ties.
+	keys := make([]int, len(steps))
+	for i, s := range steps {
+		keys[i] = s.Order
+		if s.Order <= 0 && i > 0 {
+			keys[i] = keys[i-1]
+		}
+	}
+	idx := make([]int, len(steps))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortStableFunc(idx, func(a, b int) int { return keys[a] - keys[b] })
+
+	sorted := make([]Step, len(steps))
+	for i, j := range idx {
+		sorted[i] = steps[j]
+		sorted[i].Order = i + 1
+	}
+	copy(steps, sorted)
+	return true
+}
@@ -0,0 +1,163 @@
+package playbookd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PlaybookTemplate is a reusable starting point for a family of similar
+// playbooks: the structure of a playbook without its identity, stats,
+// confidence, lessons, or history. Templates are stored as JSON under
+// <DataDir>/templates.
+type PlaybookTemplate struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Steps       []Step    `json:"steps"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func (pm *PlaybookManager) templateDir() (string, error) {
+	if pm.cfg.DataDir == "" {
+		return "", fmt.Errorf("templates require a data directory")
+	}
+	return filepath.Join(pm.cfg.DataDir, "templates"), nil
+}
+
+func validateTemplateName(name string) error {
+	if !templateNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid template name %q: use letters, digits, '.', '_', and '-'", name)
+	}
+	return nil
+}
+
+// SaveTemplate saves the structure of pb as a template called name, replacing
+// any template with that name. The description, tags, category, and steps are
+// kept; reserved health tags, stats, confidence, lessons, and IDs are not.
+func (pm *PlaybookManager) SaveTemplate(_ context.Context, name string, pb *Playbook) (*PlaybookTemplate, error) {
+	if err := validateTemplateName(name); err != nil {
+		return nil, err
+	}
+	dir, err := pm.templateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &PlaybookTemplate{
+		Name:        name,
+		Description: pb.Description,
+		Tags:        withoutHealthTags(pb.Tags),
+		Category:    pb.Category,
+		Steps:       cloneSteps(pb.Steps),
+		CreatedAt:   time.Now(),
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create template dir: %w", err)
+	}
+	if err := atomicWriteJSON(filepath.Join(dir, name+".json"), tmpl); err != nil {
+		return nil, fmt.Errorf("save template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// GetTemplate loads a template by name.
+func (pm *PlaybookManager) GetTemplate(_ context.Context, name string) (*PlaybookTemplate, error) {
+	if err := validateTemplateName(name); err != nil {
+		return nil, err
+	}
+	dir, err := pm.templateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("template %s: %w", name, ErrNotFound)
+		}
+		return nil, fmt.Errorf("read template %s: %w", name, err)
+	}
+
+	var tmpl PlaybookTemplate
+	if err := json.Unmarshal(data, &tmpl); err != nil {
+		return nil, fmt.Errorf("unmarshal template %s: %w", name, err)
+	}
+	return &tmpl, nil
+}
+
+// ListTemplates returns all saved templates, sorted by name.
+func (pm *PlaybookManager) ListTemplates(ctx context.Context) ([]*PlaybookTemplate, error) {
+	dir, err := pm.templateDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read templates dir: %w", err)
+	}
+
+	var templates []*PlaybookTemplate
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || validateTemplateName(name) != nil {
+			continue
+		}
+		tmpl, err := pm.GetTemplate(ctx, name)
+		if err != nil {
+			// Skip unreadable files, as ListPlaybooks does.
+			continue
+		}
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// InstantiateTemplate returns a new, unsaved playbook called playbookName with
+// the template's description, tags, category, and steps. Fill in anything
+// else and pass it to Create.
+func (pm *PlaybookManager) InstantiateTemplate(ctx context.Context, name, playbookName string) (*Playbook, error) {
+	if strings.TrimSpace(playbookName) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	tmpl, err := pm.GetTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Playbook{
+		Name:        playbookName,
+		Description: tmpl.Description,
+		Tags:        slices.Clone(tmpl.Tags),
+		Category:    tmpl.Category,
+		Steps:       cloneSteps(tmpl.Steps),
+	}, nil
+}
+
+// cloneSteps copies steps deeply enough that edits to the copy's tool
+// arguments and references do not affect the original. Resolved
+// sub-playbooks are not copied.
+func cloneSteps(steps []Step) []Step {
+	out := make([]Step, len(steps))
+	for i, s := range steps {
+		s.ToolArgs = maps.Clone(s.ToolArgs)
+		s.Refs = slices.Clone(s.Refs)
+		s.Subplaybooks = nil
+		out[i] = s
+	}
+	return out
+}
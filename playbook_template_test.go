@@ -0,0 +1,66 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestManagerTemplates(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	src := samplePlaybook("Deploy Service")
+	src.Tags = []string{"deploy", TagProven}
+	src.Steps[0].ToolArgs = map[string]any{"target": "prod"}
+	if err := pm.Create(ctx, src); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	src.SuccessCount, src.Confidence = 9, 0.8
+	src.Lessons = []Lesson{{ID: "l1", Content: "check the canary"}}
+
+	tmpl, err := pm.SaveTemplate(ctx, "deploy", src)
+	if err != nil {
+		t.Fatalf("SaveTemplate: %v", err)
+	}
+	if !slices.Equal(tmpl.Tags, []string{"deploy"}) {
+		t.Errorf("template tags = %v, want [deploy] without health tags", tmpl.Tags)
+	}
+	if _, err := pm.SaveTemplate(ctx, "../escape", src); err == nil {
+		t.Error("SaveTemplate accepted a name with a path separator")
+	}
+	if _, err := pm.SaveTemplate(ctx, "another", samplePlaybook("Other")); err != nil {
+		t.Fatalf("SaveTemplate: %v", err)
+	}
+
+	list, err := pm.ListTemplates(ctx)
+	if err != nil {
+		t.Fatalf("ListTemplates: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "another" || list[1].Name != "deploy" {
+		t.Errorf("ListTemplates = %v, want [another deploy]", list)
+	}
+
+	pb, err := pm.InstantiateTemplate(ctx, "deploy", "Deploy Worker")
+	if err != nil {
+		t.Fatalf("InstantiateTemplate: %v", err)
+	}
+	if pb.ID != "" || pb.SuccessCount != 0 || pb.Confidence != 0 || len(pb.Lessons) != 0 {
+		t.Errorf("instantiated playbook carries identity or stats: %+v", pb)
+	}
+	if pb.Name != "Deploy Worker" || pb.Category != src.Category || len(pb.Steps) != len(src.Steps) {
+		t.Errorf("instantiated playbook = %+v, want the template's structure", pb)
+	}
+	pb.Steps[0].ToolArgs["target"] = "staging"
+	if again, _ := pm.InstantiateTemplate(ctx, "deploy", "Again"); again.Steps[0].ToolArgs["target"] != "prod" {
+		t.Error("editing an instance changed the template")
+	}
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create from template: %v", err)
+	}
+
+	if _, err := pm.InstantiateTemplate(ctx, "missing", "X"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("InstantiateTemplate(missing) error = %v, want ErrNotFound", err)
+	}
+}
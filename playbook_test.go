@@ -1,7 +1,9 @@
 package playbookd
 
 import (
+	"errors"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -96,6 +98,68 @@ func TestWilsonConfidenceAlwaysNonNegative(t *testing.T) {
 	}
 }
 
+func TestWilsonInterval(t *testing.T) {
+	ci := WilsonInterval(6, 4)
+	if ci.Lower != WilsonConfidence(6, 4) {
+		t.Errorf("Lower = %f, want WilsonConfidence %f", ci.Lower, WilsonConfidence(6, 4))
+	}
+	if ci.Estimate != 0.6 {
+		t.Errorf("Estimate = %f, want 0.6", ci.Estimate)
+	}
+	if math.Abs(ci.Upper-0.8318) > 0.001 {
+		t.Errorf("Upper = %f, want ~0.8318", ci.Upper)
+	}
+
+	// More executions at the same rate narrow the interval around it.
+	wide, narrow := WilsonInterval(6, 4), WilsonInterval(60, 40)
+	if narrow.Upper-narrow.Lower >= wide.Upper-wide.Lower {
+		t.Errorf("interval for 60/40 %+v is not narrower than for 6/4 %+v", narrow, wide)
+	}
+	if narrow.Lower > 0.6 || narrow.Upper < 0.6 {
+		t.Errorf("interval %+v does not contain the estimate", narrow)
+	}
+
+	if ci := WilsonInterval(0, 0); ci != (ConfidenceInterval{}) {
+		t.Errorf("WilsonInterval(0, 0) = %+v, want the zero interval", ci)
+	}
+	if ci := (&Playbook{SuccessCount: 10}).ConfidenceInterval(); math.Abs(ci.Upper-1) > 1e-9 || ci.Estimate != 1 {
+		t.Errorf("ConfidenceInterval for 10/0 = %+v, want an estimate and upper bound of 1", ci)
+	}
+}
+
+func TestWilsonIntervalZ(t *testing.T) {
+	if got, want := WilsonIntervalZ(6, 4, DefaultConfidenceZ), WilsonInterval(6, 4); got != want {
+		t.Errorf("WilsonIntervalZ at the default z = %+v, want %+v", got, want)
+	}
+
+	// A higher z is more conservative for the same counts.
+	zs := []float64{1.282, 1.645, 1.96, 2.576, 3.291}
+	for _, c := range [][2]int{{1, 0}, {6, 4}, {95, 5}} {
+		prev := 1.0
+		for _, z := range zs {
+			got := WilsonIntervalZ(c[0], c[1], z).Lower
+			if got >= prev {
+				t.Errorf("WilsonIntervalZ(%d, %d, %v).Lower = %f, want below %f at the lower z", c[0], c[1], z, got, prev)
+			}
+			prev = got
+		}
+	}
+
+	// More successes with the same failures still give higher confidence.
+	for _, z := range zs {
+		low, high := WilsonIntervalZ(5, 5, z).Lower, WilsonIntervalZ(10, 5, z).Lower
+		if low >= high {
+			t.Errorf("at z=%v: 5/5 gives %f, not below 10/5's %f", z, low, high)
+		}
+	}
+
+	pb := &Playbook{SuccessCount: 6, FailureCount: 4}
+	pb.UpdateStatsZ(2.576)
+	if want := WilsonIntervalZ(6, 4, 2.576).Lower; pb.Confidence != want || pb.SuccessRate != 0.6 {
+		t.Errorf("UpdateStatsZ(2.576) = confidence %f, rate %f; want %f, 0.6", pb.Confidence, pb.SuccessRate, want)
+	}
+}
+
 func TestUpdateStats(t *testing.T) {
 	t.Run("no executions", func(t *testing.T) {
 		pb := &Playbook{}
@@ -141,3 +205,103 @@ func TestUpdateStats(t *testing.T) {
 	})
 }
 
+func TestValidatePlaybook(t *testing.T) {
+	steps := func(orders ...int) []Step {
+		var out []Step
+		for _, o := range orders {
+			out = append(out, Step{Order: o, Action: "do something"})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		pb      Playbook
+		wantErr string
+	}{
+		{"valid", Playbook{Name: "Deploy", Steps: steps(1, 2, 3)}, ""},
+		{"gaps are allowed", Playbook{Name: "Deploy", Steps: steps(1, 5, 10)}, ""},
+		{"missing name", Playbook{Name: "  ", Steps: steps(1)}, "name is required"},
+		{"no steps", Playbook{Name: "Deploy"}, "at least one step"},
+		{"empty action", Playbook{Name: "Deploy", Steps: []Step{{Order: 1, Action: "ok"}, {Order: 2}}}, "step 2: action is required"},
+		{"duplicate order", Playbook{Name: "Deploy", Steps: steps(1, 2, 2)}, "step 3: order 2 duplicates step 2"},
+		{"all zero orders", Playbook{Name: "Deploy", Steps: steps(0, 0)}, "duplicates"},
+		{"out of order", Playbook{Name: "Deploy", Steps: steps(2, 1)}, "increasing order"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePlaybook(&tt.pb)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidPlaybook) {
+				t.Errorf("error = %v, want it to wrap ErrInvalidPlaybook", err)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePlaybookLimits(t *testing.T) {
+	pb := &Playbook{Name: "Deploy", Steps: []Step{
+		{Order: 1, Action: "one"},
+		{Order: 2, Action: strings.Repeat("é", 10)},
+	}}
+	if err := ValidatePlaybook(pb, ValidateOptions{MaxSteps: 2, MaxStepActionChars: 10}); err != nil {
+		t.Errorf("at the limits: %v", err)
+	}
+	if err := ValidatePlaybook(pb, ValidateOptions{MaxSteps: 1}); !errors.Is(err, ErrInvalidPlaybook) || !strings.Contains(err.Error(), "2 steps") {
+		t.Errorf("over MaxSteps: err = %v, want ErrInvalidPlaybook for 2 steps", err)
+	}
+	if err := ValidatePlaybook(pb, ValidateOptions{MaxStepActionChars: 9}); !errors.Is(err, ErrInvalidPlaybook) || !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("over MaxStepActionChars: err = %v, want ErrInvalidPlaybook naming step 2", err)
+	}
+	if err := ValidatePlaybook(pb); err != nil {
+		t.Errorf("without options: %v", err)
+	}
+}
+
+func TestNormalizeStepOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		orders      []int
+		wantActions string // actions after normalization, in list order
+		wantChanged bool
+	}{
+		{"already sequential", []int{1, 2, 3}, "abc", false},
+		{"duplicate orders", []int{1, 2, 2}, "abc", true},
+		{"gaps", []int{10, 20, 30}, "abc", true},
+		{"out of sequence", []int{3, 1, 2}, "bca", true},
+		{"zero orders", []int{0, 0, 0}, "abc", true},
+		{"zero order follows its predecessor", []int{2, 0, 1}, "cab", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := make([]Step, len(tt.orders))
+			for i, o := range tt.orders {
+				steps[i] = Step{Order: o, Action: string(rune('a' + i))}
+			}
+
+			if got := normalizeStepOrder(steps); got != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", got, tt.wantChanged)
+			}
+			var actions string
+			for i, s := range steps {
+				actions += s.Action
+				if s.Order != i+1 {
+					t.Errorf("steps[%d].Order = %d, want %d", i, s.Order, i+1)
+				}
+			}
+			if actions != tt.wantActions {
+				t.Errorf("actions = %q, want %q", actions, tt.wantActions)
+			}
+		})
+	}
+}
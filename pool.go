@@ -0,0 +1,207 @@
+package playbookd
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultPoolSize is how many managers a ManagerPool keeps open when no size
+// is given.
+const DefaultPoolSize = 16
+
+// ErrPoolClosed is returned by ManagerPool.Acquire after Close.
+var ErrPoolClosed = errors.New("manager pool closed")
+
+// ManagerPool keeps a PlaybookManager open per tenant, each with its own data
+// directory and search index under a shared root, so a server can select a
+// tenant per request without reopening the index every time. The least
+// recently used managers beyond the pool's size are closed; a manager still in
+// use when it is evicted is closed when its last user releases it. A
+// ManagerPool is safe for concurrent use.
+type ManagerPool struct {
+	base ManagerConfig
+	size int
+
+	mu       sync.Mutex
+	closed   bool
+	order    *list.List               // of *poolEntry, most recently used first
+	items    map[string]*list.Element // tenant -> element of order
+	draining map[string]*poolEntry    // evicted but still in use, by tenant
+}
+
+type poolEntry struct {
+	tenant  string
+	ready   chan struct{} // closed once pm or err is set
+	pm      *PlaybookManager
+	err     error
+	refs    int  // Acquires not yet released
+	evicted bool // no longer in the pool; closed when refs drops to 0
+}
+
+// NewManagerPool returns a pool that opens each tenant's manager with base,
+// its DataDir replaced by base.DataDir/<tenant>. base must not set Store or
+// Indexer, which would be shared by every tenant. size caps the open managers
+// (0 = DefaultPoolSize).
+func NewManagerPool(base ManagerConfig, size int) (*ManagerPool, error) {
+	if base.DataDir == "" {
+		return nil, fmt.Errorf("manager pool: DataDir is required")
+	}
+	if base.Store != nil || base.Indexer != nil {
+		return nil, fmt.Errorf("manager pool: Store and Indexer cannot be shared between tenants")
+	}
+	if size <= 0 {
+		size = DefaultPoolSize
+	}
+	return &ManagerPool{
+		base:     base,
+		size:     size,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		draining: make(map[string]*poolEntry),
+	}, nil
+}
+
+// Acquire returns the manager for tenant, opening it if it is not in the
+// pool, and a release func to call when done with it. The manager must not be
+// used, or closed, after release. A tenant is a single path element: not
+// empty, ".", or "..", and without path separators.
+func (p *ManagerPool) Acquire(tenant string) (*PlaybookManager, func(), error) {
+	if tenant == "" || tenant == "." || tenant == ".." || strings.ContainsAny(tenant, `/\`) {
+		return nil, nil, fmt.Errorf("invalid tenant %q", tenant)
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, nil, ErrPoolClosed
+	}
+	if el, ok := p.items[tenant]; ok {
+		e := el.Value.(*poolEntry)
+		e.refs++
+		p.order.MoveToFront(el)
+		p.mu.Unlock()
+		return p.await(e)
+	}
+	if e, ok := p.draining[tenant]; ok {
+		// Still open for an earlier caller; put it back rather than open the index twice
+		delete(p.draining, tenant)
+		e.evicted = false
+		e.refs++
+		p.items[tenant] = p.order.PushFront(e)
+		evicted := p.evictLocked()
+		p.mu.Unlock()
+		p.closeEntries(evicted)
+		return p.await(e)
+	}
+	e := &poolEntry{tenant: tenant, ready: make(chan struct{}), refs: 1}
+	p.items[tenant] = p.order.PushFront(e)
+	evicted := p.evictLocked()
+	p.mu.Unlock()
+	p.closeEntries(evicted)
+
+	cfg := p.base
+	cfg.DataDir = filepath.Join(p.base.DataDir, tenant)
+	e.pm, e.err = NewPlaybookManager(cfg)
+	if e.err != nil {
+		e.err = fmt.Errorf("open tenant %q: %w", tenant, e.err)
+		p.mu.Lock()
+		if el, ok := p.items[tenant]; ok && el.Value == e {
+			p.order.Remove(el)
+			delete(p.items, tenant)
+		}
+		p.mu.Unlock()
+	}
+	close(e.ready)
+	return p.await(e)
+}
+
+// await waits for e to be opened and returns its manager and release func,
+// or drops the reference taken on it if opening failed.
+func (p *ManagerPool) await(e *poolEntry) (*PlaybookManager, func(), error) {
+	<-e.ready
+	if e.err != nil {
+		p.release(e)
+		return nil, nil, e.err
+	}
+	var once sync.Once
+	return e.pm, func() { once.Do(func() { p.release(e) }) }, nil
+}
+
+// release drops a reference to e, closing its manager if it was the last
+// reference to an evicted entry.
+func (p *ManagerPool) release(e *poolEntry) {
+	p.mu.Lock()
+	e.refs--
+	done := e.refs == 0 && e.evicted
+	if done && p.draining[e.tenant] == e {
+		delete(p.draining, e.tenant)
+	}
+	p.mu.Unlock()
+	if done {
+		p.closeEntries([]*poolEntry{e})
+	}
+}
+
+// evictLocked removes the least recently used entries beyond the pool's size
+// and returns those no one is using, for the caller to close once p.mu is
+// released. Entries in use are kept open in draining.
+func (p *ManagerPool) evictLocked() []*poolEntry {
+	var idle []*poolEntry
+	for p.order.Len() > p.size {
+		el := p.order.Back()
+		p.order.Remove(el)
+		e := el.Value.(*poolEntry)
+		delete(p.items, e.tenant)
+		e.evicted = true
+		if e.refs == 0 {
+			idle = append(idle, e)
+		} else {
+			p.draining[e.tenant] = e
+		}
+	}
+	return idle
+}
+
+// closeEntries closes the managers of entries, logging failures.
+func (p *ManagerPool) closeEntries(entries []*poolEntry) error {
+	var errs []error
+	for _, e := range entries {
+		if e.pm == nil {
+			continue
+		}
+		if err := e.pm.Close(); err != nil {
+			e.pm.log.Warn("closing tenant manager failed", "tenant", e.tenant, "error", err)
+			errs = append(errs, fmt.Errorf("close tenant %q: %w", e.tenant, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every manager no one is using and makes further Acquires fail
+// with ErrPoolClosed. Managers still in use are closed when released.
+func (p *ManagerPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	var idle []*poolEntry
+	for el := p.order.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*poolEntry)
+		e.evicted = true
+		if e.refs == 0 {
+			idle = append(idle, e)
+		} else {
+			p.draining[e.tenant] = e
+		}
+	}
+	p.order.Init()
+	clear(p.items)
+	p.mu.Unlock()
+	return p.closeEntries(idle)
+}
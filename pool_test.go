@@ -0,0 +1,129 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func newTestPool(t *testing.T, size int) *ManagerPool {
+	t.Helper()
+	pool, err := NewManagerPool(ManagerConfig{
+		DataDir: t.TempDir(),
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, size)
+	if err != nil {
+		t.Fatalf("NewManagerPool: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func TestManagerPoolSeparatesTenants(t *testing.T) {
+	pool := newTestPool(t, 4)
+	ctx := context.Background()
+
+	a, releaseA, err := pool.Acquire("acme")
+	if err != nil {
+		t.Fatalf("Acquire(acme): %v", err)
+	}
+	defer releaseA()
+	if err := a.Create(ctx, samplePlaybook("Deploy")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	again, releaseAgain, err := pool.Acquire("acme")
+	if err != nil {
+		t.Fatalf("Acquire(acme) again: %v", err)
+	}
+	releaseAgain()
+	if again != a {
+		t.Error("a second Acquire of the same tenant opened another manager")
+	}
+
+	b, releaseB, err := pool.Acquire("globex")
+	if err != nil {
+		t.Fatalf("Acquire(globex): %v", err)
+	}
+	defer releaseB()
+	if n, err := b.Count(ctx, ListFilter{}); err != nil || n != 0 {
+		t.Errorf("globex Count = %d, %v; want 0, acme's playbooks are not shared", n, err)
+	}
+
+	for _, tenant := range []string{"", ".", "..", "a/b", `a\b`} {
+		if _, _, err := pool.Acquire(tenant); err == nil {
+			t.Errorf("Acquire(%q) should fail", tenant)
+		}
+	}
+}
+
+func TestManagerPoolEviction(t *testing.T) {
+	pool := newTestPool(t, 1)
+	ctx := context.Background()
+
+	a, releaseA, err := pool.Acquire("acme")
+	if err != nil {
+		t.Fatalf("Acquire(acme): %v", err)
+	}
+	if err := a.Create(ctx, samplePlaybook("Deploy")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Evicting a manager in use leaves it open until it is released
+	_, releaseB, err := pool.Acquire("globex")
+	if err != nil {
+		t.Fatalf("Acquire(globex): %v", err)
+	}
+	if _, err := a.Search(ctx, SearchQuery{Text: "deploy"}); err != nil {
+		t.Errorf("Search on an evicted manager still in use: %v", err)
+	}
+	revived, releaseRevived, err := pool.Acquire("acme")
+	if err != nil {
+		t.Fatalf("Acquire(acme) while in use: %v", err)
+	}
+	if revived != a {
+		t.Error("Acquire of an evicted tenant still in use opened its index again")
+	}
+	releaseRevived()
+	releaseA()
+	releaseA() // release is idempotent
+	releaseB()
+
+	// An idle manager is closed when evicted; the tenant reopens with its data
+	if _, releaseB, err = pool.Acquire("globex"); err != nil {
+		t.Fatalf("Acquire(globex): %v", err)
+	}
+	releaseB()
+	reopened, releaseReopened, err := pool.Acquire("acme")
+	if err != nil {
+		t.Fatalf("Acquire(acme) after eviction: %v", err)
+	}
+	defer releaseReopened()
+	if reopened == a {
+		t.Error("Acquire after eviction returned the closed manager")
+	}
+	if n, err := reopened.Count(ctx, ListFilter{}); err != nil || n != 1 {
+		t.Errorf("reopened acme Count = %d, %v; want 1", n, err)
+	}
+}
+
+func TestManagerPoolClose(t *testing.T) {
+	pool := newTestPool(t, 2)
+	if _, release, err := pool.Acquire("acme"); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	} else {
+		release()
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, _, err := pool.Acquire("acme"); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Acquire after Close: err = %v, want ErrPoolClosed", err)
+	}
+
+	if _, err := NewManagerPool(ManagerConfig{DataDir: t.TempDir(), Store: NewMemStore()}, 0); err == nil {
+		t.Error("NewManagerPool with a shared Store should fail")
+	}
+}
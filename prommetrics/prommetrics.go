@@ -0,0 +1,121 @@
+// Package prommetrics implements playbookd.Metrics with Prometheus collectors.
+// It lives in its own package so that only programs that want Prometheus
+// import it.
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/lucas-stellet/playbookd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Compile-time check that Metrics implements playbookd.Metrics.
+var _ playbookd.Metrics = (*Metrics)(nil)
+
+// Metrics holds the collectors for one PlaybookManager. Pass it as
+// ManagerConfig.Metrics.
+type Metrics struct {
+	created          prometheus.Counter
+	updated          prometheus.Counter
+	deleted          prometheus.Counter
+	searches         *prometheus.CounterVec
+	searchDuration   *prometheus.HistogramVec
+	searchDegraded   *prometheus.CounterVec
+	embeddings       prometheus.Counter
+	embeddingErrors  prometheus.Counter
+	embedDuration    prometheus.Histogram
+	indexedDocuments prometheus.Gauge
+}
+
+// New creates the collectors and registers them with reg, such as
+// prometheus.DefaultRegisterer. It fails if they are already registered, as
+// when two managers share a registerer; wrap it with
+// prometheus.WrapRegistererWith to tell them apart by label.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		created: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "playbookd_playbooks_created_total",
+			Help: "Playbooks created.",
+		}),
+		updated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "playbookd_playbooks_updated_total",
+			Help: "Playbooks updated, including status and metadata changes.",
+		}),
+		deleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "playbookd_playbooks_deleted_total",
+			Help: "Playbooks deleted.",
+		}),
+		searches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "playbookd_searches_total",
+			Help: "Searches performed, by requested mode and result (ok or error).",
+		}, []string{"mode", "result"}),
+		searchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "playbookd_search_duration_seconds",
+			Help:    "Search latency, including the query embedding, by requested mode.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"mode"}),
+		searchDegraded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "playbookd_search_degraded_total",
+			Help: "Hybrid and vector searches that fell back to BM25 because the query could not be embedded, by reason.",
+		}, []string{"reason"}),
+		embeddings: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "playbookd_embedding_requests_total",
+			Help: "Calls to the embedding provider.",
+		}),
+		embeddingErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "playbookd_embedding_failures_total",
+			Help: "Calls to the embedding provider that returned an error.",
+		}),
+		embedDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "playbookd_embedding_duration_seconds",
+			Help:    "Embedding provider latency.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		indexedDocuments: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "playbookd_index_documents",
+			Help: "Documents in the search index.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.created, m.updated, m.deleted,
+		m.searches, m.searchDuration, m.searchDegraded,
+		m.embeddings, m.embeddingErrors, m.embedDuration,
+		m.indexedDocuments,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// The methods below implement playbookd.Metrics.
+
+func (m *Metrics) PlaybookCreated() { m.created.Inc() }
+func (m *Metrics) PlaybookUpdated() { m.updated.Inc() }
+func (m *Metrics) PlaybookDeleted() { m.deleted.Inc() }
+
+func (m *Metrics) SearchCompleted(mode playbookd.SearchMode, elapsed time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.searches.WithLabelValues(string(mode), result).Inc()
+	m.searchDuration.WithLabelValues(string(mode)).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) SearchDegraded(reason playbookd.DegradeReason) {
+	m.searchDegraded.WithLabelValues(string(reason)).Inc()
+}
+
+func (m *Metrics) EmbeddingCompleted(elapsed time.Duration, err error) {
+	m.embeddings.Inc()
+	if err != nil {
+		m.embeddingErrors.Inc()
+	}
+	m.embedDuration.Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) IndexSize(docs int) { m.indexedDocuments.Set(float64(docs)) }
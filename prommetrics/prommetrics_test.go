@@ -0,0 +1,64 @@
+package prommetrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lucas-stellet/playbookd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	m.PlaybookCreated()
+	m.PlaybookCreated()
+	m.SearchCompleted(playbookd.SearchModeBM25, 10*time.Millisecond, nil)
+	m.SearchCompleted(playbookd.SearchModeBM25, 10*time.Millisecond, errors.New("boom"))
+	m.SearchDegraded(playbookd.DegradeEmbeddingFailed)
+	m.EmbeddingCompleted(time.Millisecond, errors.New("down"))
+	m.IndexSize(7)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	got := make(map[string]float64)
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			switch {
+			case metric.Counter != nil:
+				got[f.GetName()] += metric.Counter.GetValue()
+			case metric.Gauge != nil:
+				got[f.GetName()] = metric.Gauge.GetValue()
+			case metric.Histogram != nil:
+				got[f.GetName()] += float64(metric.Histogram.GetSampleCount())
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"playbookd_playbooks_created_total":    2,
+		"playbookd_searches_total":             2,
+		"playbookd_search_duration_seconds":    2,
+		"playbookd_search_degraded_total":      1,
+		"playbookd_embedding_requests_total":   1,
+		"playbookd_embedding_failures_total":   1,
+		"playbookd_embedding_duration_seconds": 1,
+		"playbookd_index_documents":            7,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("%s = %g, want %g", name, got[name], v)
+		}
+	}
+
+	if _, err := New(reg); err == nil {
+		t.Error("registering twice with the same registerer should fail")
+	}
+}
@@ -0,0 +1,93 @@
+package playbookd
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReembedResult reports what ReembedAll or Reembed did.
+type ReembedResult struct {
+	Reembedded int `json:"reembedded"` // Playbooks given a new embedding
+	EmbedDims  int `json:"embed_dims"` // Dimensions of the new embeddings (0 = none, e.g. the noop provider)
+	IndexDims  int `json:"index_dims"` // Dimensions of the index's vector field (0 = none)
+
+	// IndexOutdated is set when this build has vector search and the new
+	// embeddings do not fit the index's vector field. Vector search cannot
+	// use them until the index directory is deleted and rebuilt with
+	// "playbookd reindex".
+	IndexOutdated bool `json:"index_outdated,omitempty"`
+}
+
+// ReembedAll regenerates the embedding of every playbook, archived ones
+// included, with the current provider and model, whether or not its content
+// changed, and re-indexes the playbooks that are not archived. It is the
+// companion to switching embedding providers or models; Reindex only
+// re-embeds playbooks whose text or model changed. Concurrency and Progress
+// work as they do for Reindex. Playbooks that fail to embed keep their old
+// embedding, and the failures are returned joined, after the rest are done.
+func (pm *PlaybookManager) ReembedAll(ctx context.Context, opts ...ReindexOptions) (*ReembedResult, error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
+	if err != nil {
+		return nil, fmt.Errorf("list playbooks: %w", err)
+	}
+	return pm.reembed(ctx, playbooks, opts...)
+}
+
+// Reembed regenerates the embedding of one playbook, as ReembedAll does for
+// all of them.
+func (pm *PlaybookManager) Reembed(ctx context.Context, id string) (*ReembedResult, error) {
+	pb, err := pm.store.GetPlaybook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get playbook: %w", err)
+	}
+	return pm.reembed(ctx, []*Playbook{pb})
+}
+
+func (pm *PlaybookManager) reembed(ctx context.Context, playbooks []*Playbook, opts ...ReindexOptions) (*ReembedResult, error) {
+	var o ReindexOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	var active []*Playbook
+	for _, pb := range playbooks {
+		if !pb.Archived {
+			active = append(active, pb)
+		}
+	}
+	progress := newReindexProgress(o.Progress, len(active), len(playbooks))
+	embedErr := pm.refreshEmbeddings(ctx, playbooks, func(*Playbook) bool { return true }, o.Concurrency, progress)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(active); start += ReindexBatchSize {
+		batch := active[start:min(start+ReindexBatchSize, len(active))]
+		if err := pm.indexer.Reindex(ctx, batch); err != nil {
+			return nil, err
+		}
+		progress.indexed(len(batch))
+	}
+
+	result := &ReembedResult{EmbedDims: pm.cfg.EmbedDims}
+	for _, pb := range playbooks {
+		if result.EmbedDims == 0 && pb.EmbedModel == pm.cfg.EmbedModel {
+			result.EmbedDims = pb.EmbedDims
+		}
+	}
+	result.Reembedded = len(playbooks)
+	if joined, ok := embedErr.(interface{ Unwrap() []error }); ok {
+		result.Reembedded -= len(joined.Unwrap())
+	}
+	if v, ok := pm.baseIndexer.(interface{ VectorDims() int }); ok {
+		result.IndexDims = v.VectorDims()
+		if VectorSearchEnabled && result.EmbedDims > 0 && result.IndexDims != result.EmbedDims {
+			result.IndexOutdated = true
+			pm.log.Warn("new embeddings do not fit the index's vector field; delete the index directory and run `playbookd reindex`",
+				"embed_dims", result.EmbedDims, "index_dims", result.IndexDims)
+		}
+	}
+	if embedErr != nil {
+		return result, fmt.Errorf("some playbooks kept their old embedding: %w", embedErr)
+	}
+	return result, nil
+}
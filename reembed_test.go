@@ -0,0 +1,92 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestManagerReembedAll(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	newManager := func(embedFn func(context.Context, string) ([]float32, error)) *PlaybookManager {
+		t.Helper()
+		pm, err := NewPlaybookManager(ManagerConfig{
+			DataDir:    dir,
+			EmbedFunc:  embedFn,
+			EmbedDims:  2,
+			EmbedModel: "shared-name",
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		if err != nil {
+			t.Fatalf("NewPlaybookManager: %v", err)
+		}
+		return pm
+	}
+
+	old := newManager(func(context.Context, string) ([]float32, error) { return []float32{1, 0}, nil })
+	deploy, rollback, retired := samplePlaybook("Deploy"), samplePlaybook("Rollback"), samplePlaybook("Retired")
+	if err := old.CreateBatch(ctx, []*Playbook{deploy, rollback, retired}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := old.archive(ctx, retired.ID); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	old.Close()
+
+	// A new provider under the same model name: nothing looks stale, so only
+	// a forced re-embed replaces the vectors.
+	var calls atomic.Int32
+	pm := newManager(func(_ context.Context, text string) ([]float32, error) {
+		calls.Add(1)
+		if strings.Contains(text, "Rollback") {
+			return nil, errors.New("rate limited")
+		}
+		return []float32{0, 1}, nil
+	})
+	t.Cleanup(func() { pm.Close() })
+
+	if est, err := pm.EstimateReindex(ctx); err != nil || est.EmbeddingCalls != 0 {
+		t.Fatalf("EstimateReindex = %+v, %v; want no embedding calls", est, err)
+	}
+
+	result, err := pm.ReembedAll(ctx)
+	if err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("ReembedAll error = %v, want the failed playbook reported", err)
+	}
+	if result == nil || result.Reembedded != 2 || result.EmbedDims != 2 || result.IndexOutdated {
+		t.Fatalf("result = %+v, want 2 re-embedded at 2 dimensions", result)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("embedding calls = %d, want 3, archived playbook included", calls.Load())
+	}
+	for id, want := range map[string][]float32{deploy.ID: {0, 1}, retired.ID: {0, 1}, rollback.ID: {1, 0}} {
+		pb, err := pm.store.GetPlaybook(ctx, id)
+		if err != nil {
+			t.Fatalf("GetPlaybook: %v", err)
+		}
+		if !slices.Equal(pb.Embedding, want) {
+			t.Errorf("%s embedding = %v, want %v", pb.Name, pb.Embedding, want)
+		}
+	}
+	ids, err := pm.indexer.DocIDs(ctx)
+	if err != nil {
+		t.Fatalf("DocIDs: %v", err)
+	}
+	if slices.Contains(ids, retired.ID) || len(ids) != 2 {
+		t.Errorf("indexed = %v, want the 2 active playbooks only", ids)
+	}
+
+	calls.Store(0)
+	if result, err := pm.Reembed(ctx, deploy.ID); err != nil || result.Reembedded != 1 || calls.Load() != 1 {
+		t.Errorf("Reembed = %+v, %v with %d calls; want 1 playbook re-embedded with 1 call", result, err, calls.Load())
+	}
+	if _, err := pm.Reembed(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Reembed(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,134 @@
+package playbookd
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Refine narrows and re-ranks the results of an earlier search in memory,
+// without querying the index again, so an agent can drill down into a broad
+// search cheaply. base is not modified.
+//
+// Category, Tags, MustTerms, and MustNotTerms (including "+term" and "-term"
+// in Text) filter the results as they do in Search. The remaining words of
+// Text must each appear in a result's text fields (Fields, or all of
+// SearchFields), ignoring case, for the result to match them; results that
+// match none are dropped, and those matching more rank first. Weights,
+// ConfidenceWeight, and RecencyBoost re-score the results from each result's
+// TextScore, which Refine keeps as it was. A positive MinScore drops results
+// scoring below it, and a positive Limit caps the results; unlike Search, zero
+// means no minimum and no cap. Mode, Embedding, Explain, and GroupByLineage
+// are ignored.
+func (pm *PlaybookManager) Refine(ctx context.Context, base []SearchResult, query SearchQuery) ([]SearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query = query.withTermOperators()
+	fields := query.Fields
+	if len(fields) == 0 {
+		fields = SearchFields
+	}
+	if err := checkSearchFields(fields); err != nil {
+		return nil, err
+	}
+	terms := strings.Fields(strings.ToLower(query.Text))
+
+	refined := make([]SearchResult, 0, len(base))
+	matched := make(map[string]int, len(base))
+	for _, r := range base {
+		pb := r.Playbook
+		if pb == nil || (query.Category != "" && pb.Category != query.Category) {
+			continue
+		}
+		if !containsAll(pb.Tags, query.Tags) {
+			continue
+		}
+		text := refineText(pb, fields)
+		if !matchesTerms(text, query.MustTerms, query.MustNotTerms) {
+			continue
+		}
+		var n int
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				n++
+			}
+		}
+		if len(terms) > 0 && n == 0 {
+			continue
+		}
+		matched[pb.ID] = n
+		refined = append(refined, r)
+	}
+
+	w, blend := query.effectiveWeights()
+	if blend || query.RecencyBoost > 0 {
+		for i := range refined {
+			refined[i].Score = refined[i].TextScore
+		}
+	}
+	if blend {
+		blendScores(refined, w)
+	}
+	if query.RecencyBoost > 0 {
+		applyRecencyBoost(refined, query.RecencyBoost, time.Now())
+	}
+	if len(terms) > 1 {
+		sort.SliceStable(refined, func(i, j int) bool {
+			return matched[refined[i].Playbook.ID] > matched[refined[j].Playbook.ID]
+		})
+	}
+	if query.MinScore > 0 {
+		refined = slices.DeleteFunc(refined, func(r SearchResult) bool { return r.Score < query.MinScore })
+	}
+	if query.Limit > 0 && len(refined) > query.Limit {
+		refined = refined[:query.Limit]
+	}
+	return refined, nil
+}
+
+// refineText returns the lowercased text of pb's fields, as they are indexed.
+func refineText(pb *Playbook, fields []string) string {
+	doc := playbookToDoc(pb)
+	byField := map[string]string{
+		"name":           doc.Name,
+		"description":    doc.Description,
+		"tags":           doc.Tags,
+		"steps":          doc.Steps,
+		"lessons":        doc.Lessons,
+		TaskContextField: doc.TaskContexts,
+	}
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, byField[field])
+	}
+	return strings.ToLower(strings.Join(parts, "\n"))
+}
+
+// matchesTerms reports whether text contains every must term and no mustNot
+// term, ignoring case.
+func matchesTerms(text string, must, mustNot []string) bool {
+	for _, term := range must {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	for _, term := range mustNot {
+		if strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAll reports whether have includes every element of want.
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
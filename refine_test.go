@@ -0,0 +1,81 @@
+package playbookd
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestManagerRefine(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	k8s := samplePlaybook("Deploy to Kubernetes")
+	k8s.Tags = []string{"k8s"}
+	k8s.Steps[0].Action = "Apply the helm chart"
+	vm := samplePlaybook("Deploy to VMs")
+	vm.Tags = []string{"ansible"}
+	rollback := samplePlaybook("Rollback a Deploy")
+	rollback.Tags = []string{"k8s"}
+	if err := pm.CreateBatch(ctx, []*Playbook{k8s, vm, rollback}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	recordOutcomes(t, pm, rollback.ID, OutcomeSuccess, 10)
+
+	base, err := pm.Search(ctx, SearchQuery{Text: "deploy", Mode: SearchModeBM25, MinScore: NoMinScore, Limit: 10})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(base) != 3 {
+		t.Fatalf("setup: search found %d results, want 3", len(base))
+	}
+	before := slices.Clone(base)
+
+	ids := func(results []SearchResult) []string {
+		out := make([]string, len(results))
+		for i, r := range results {
+			out[i] = r.Playbook.ID
+		}
+		return out
+	}
+	refine := func(q SearchQuery) []SearchResult {
+		t.Helper()
+		results, err := pm.Refine(ctx, base, q)
+		if err != nil {
+			t.Fatalf("Refine(%+v): %v", q, err)
+		}
+		return results
+	}
+
+	if got := refine(SearchQuery{Tags: []string{"k8s"}, Text: "-rollback"}); !slices.Equal(ids(got), []string{k8s.ID}) {
+		t.Errorf("tag k8s without rollback = %v, want [%s]", ids(got), k8s.ID)
+	}
+	if got := refine(SearchQuery{Text: "HELM", Fields: []string{"steps"}}); !slices.Equal(ids(got), []string{k8s.ID}) {
+		t.Errorf("helm in steps = %v, want [%s]", ids(got), k8s.ID)
+	}
+	if got := refine(SearchQuery{Text: "helm", Fields: []string{"name"}}); len(got) != 0 {
+		t.Errorf("helm in name = %v, want none", ids(got))
+	}
+
+	// Ranking by confidence puts the proven playbook first and keeps each
+	// result's TextScore.
+	got := refine(SearchQuery{Weights: ScoreWeights{Confidence: 1}, Limit: 2})
+	if len(got) != 2 || got[0].Playbook.ID != rollback.ID {
+		t.Fatalf("confidence ranking = %v, want %s first and 2 results", ids(got), rollback.ID)
+	}
+	for _, r := range got {
+		i := slices.IndexFunc(before, func(b SearchResult) bool { return b.Playbook.ID == r.Playbook.ID })
+		if r.TextScore != before[i].TextScore {
+			t.Errorf("%s TextScore = %v, want %v", r.Playbook.ID, r.TextScore, before[i].TextScore)
+		}
+	}
+	for i := range base {
+		if base[i].Score != before[i].Score || base[i].Playbook != before[i].Playbook {
+			t.Errorf("Refine modified base[%d]", i)
+		}
+	}
+
+	if _, err := pm.Refine(ctx, base, SearchQuery{Fields: []string{"owner"}}); err == nil {
+		t.Error("Refine with an unknown field: want an error")
+	}
+}
@@ -0,0 +1,111 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRefCycle is returned by Resolve when sub-playbook references form a cycle.
+var ErrRefCycle = errors.New("sub-playbook reference cycle")
+
+// Resolve loads a playbook by ID or slug and fills in Step.Subplaybooks with
+// the playbooks its steps reference, following references up to depth levels
+// (0 resolves none). It returns ErrRefCycle if a playbook references one of
+// its own ancestors. References to playbooks that do not exist are logged and
+// skipped.
+func (pm *PlaybookManager) Resolve(ctx context.Context, id string, depth int) (*Playbook, error) {
+	pb, err := pm.lookupRef(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := pm.resolveRefs(ctx, pb, depth, []string{pb.ID}); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+// resolveRefs fills in the sub-playbooks of pb's steps. path holds the IDs of
+// pb and its ancestors, ending with pb.
+func (pm *PlaybookManager) resolveRefs(ctx context.Context, pb *Playbook, depth int, path []string) error {
+	if depth <= 0 {
+		return nil
+	}
+	for i := range pb.Steps {
+		step := &pb.Steps[i]
+		step.Subplaybooks = nil
+		for _, ref := range step.Refs {
+			sub, err := pm.lookupRef(ctx, ref)
+			if errors.Is(err, ErrNotFound) {
+				pm.log.Warn("dangling sub-playbook reference", "playbook_id", pb.ID, "step", step.Order, "ref", ref)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("resolve %s step %d ref %s: %w", pb.ID, step.Order, ref, err)
+			}
+			for _, ancestor := range path {
+				if sub.ID == ancestor {
+					return fmt.Errorf("%w: %s -> %s", ErrRefCycle, strings.Join(path, " -> "), sub.ID)
+				}
+			}
+			if err := pm.resolveRefs(ctx, sub, depth-1, append(path[:len(path):len(path)], sub.ID)); err != nil {
+				return err
+			}
+			step.Subplaybooks = append(step.Subplaybooks, sub)
+		}
+	}
+	return nil
+}
+
+// DanglingRefs returns the step references in pb that do not name an
+// existing playbook, in step order. Create and Update log a warning for each
+// one rather than rejecting the playbook, so that playbooks which reference
+// each other can be created in any order.
+func (pm *PlaybookManager) DanglingRefs(ctx context.Context, pb *Playbook) ([]string, error) {
+	var dangling []string
+	for _, step := range pb.Steps {
+		for _, ref := range step.Refs {
+			if _, err := pm.lookupRef(ctx, ref); err != nil {
+				if !errors.Is(err, ErrNotFound) {
+					return nil, err
+				}
+				dangling = append(dangling, ref)
+			}
+		}
+	}
+	return dangling, nil
+}
+
+// warnDanglingRefs logs a warning for each reference in pb that does not name
+// an existing playbook.
+func (pm *PlaybookManager) warnDanglingRefs(ctx context.Context, pb *Playbook) {
+	dangling, err := pm.DanglingRefs(ctx, pb)
+	if err != nil {
+		pm.log.Warn("check sub-playbook references", "playbook_id", pb.ID, "error", err)
+		return
+	}
+	for _, ref := range dangling {
+		pm.log.Warn("dangling sub-playbook reference", "playbook_id", pb.ID, "ref", ref)
+	}
+}
+
+// lookupRef loads the playbook a reference names: by ID, or failing that by
+// slug, including archived playbooks.
+func (pm *PlaybookManager) lookupRef(ctx context.Context, ref string) (*Playbook, error) {
+	pb, err := pm.store.GetPlaybook(ctx, ref)
+	if !errors.Is(err, ErrNotFound) {
+		return pb, err
+	}
+
+	playbooks, listErr := pm.store.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
+	if listErr != nil {
+		return nil, fmt.Errorf("list playbooks: %w", listErr)
+	}
+	for _, p := range playbooks {
+		if p.Slug == ref {
+			return p, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,84 @@
+package playbookd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd/embed"
+)
+
+func TestResolveSubPlaybooks(t *testing.T) {
+	var logBuf bytes.Buffer
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:   t.TempDir(),
+		EmbedFunc: embed.Noop(),
+		Logger:    slog.New(slog.NewTextHandler(&logBuf, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	verify := samplePlaybook("Verify Health")
+	if err := pm.Create(ctx, verify); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	rollback := samplePlaybook("Rollback")
+	rollback.Steps[1].Refs = []string{"verify-health"}
+	if err := pm.Create(ctx, rollback); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	deploy := samplePlaybook("Deploy")
+	deploy.Steps[1].Refs = []string{rollback.ID, "missing-playbook"}
+	if err := pm.Create(ctx, deploy); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.Contains(logBuf.String(), "ref=missing-playbook") {
+		t.Errorf("Create did not warn about the dangling ref, log:\n%s", logBuf.String())
+	}
+	if dangling, _ := pm.DanglingRefs(ctx, deploy); !slices.Equal(dangling, []string{"missing-playbook"}) {
+		t.Errorf("DanglingRefs = %v, want [missing-playbook]", dangling)
+	}
+
+	got, err := pm.Resolve(ctx, "deploy", 1)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	subs := got.Steps[1].Subplaybooks
+	if len(subs) != 1 || subs[0].ID != rollback.ID {
+		t.Fatalf("depth 1 sub-playbooks = %v, want [rollback]", subs)
+	}
+	if subs[0].Steps[1].Subplaybooks != nil {
+		t.Error("depth 1 resolved the rollback's own refs")
+	}
+
+	got, err = pm.Resolve(ctx, deploy.ID, 2)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if nested := got.Steps[1].Subplaybooks[0].Steps[1].Subplaybooks; len(nested) != 1 || nested[0].ID != verify.ID {
+		t.Errorf("depth 2 nested sub-playbooks = %v, want [verify-health]", nested)
+	}
+
+	// Closing the loop verify -> deploy makes deploy -> rollback -> verify -> deploy a cycle.
+	verify.Steps[0].Refs = []string{deploy.ID}
+	if err := pm.Update(ctx, verify); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := pm.Resolve(ctx, deploy.ID, 5); !errors.Is(err, ErrRefCycle) {
+		t.Errorf("Resolve error = %v, want ErrRefCycle", err)
+	}
+	if _, err := pm.Resolve(ctx, deploy.ID, 2); err != nil {
+		t.Errorf("Resolve stopping short of the cycle: %v", err)
+	}
+
+	if _, err := pm.Resolve(ctx, "nope", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(nope) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,147 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultReindexConcurrency is how many playbooks Reindex embeds at once when
+// ReindexOptions.Concurrency is not set.
+const DefaultReindexConcurrency = 4
+
+// ReindexBatchSize is how many playbooks Reindex indexes per batch; progress
+// is reported after each one.
+const ReindexBatchSize = 500
+
+// EstimateReindex counts the playbooks Reindex would index and the embedding
+// calls it would make, without changing anything.
+func (pm *PlaybookManager) EstimateReindex(ctx context.Context) (*ReindexEstimate, error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return &ReindexEstimate{Playbooks: len(playbooks), EmbeddingCalls: pm.countStaleEmbeddings(playbooks)}, nil
+}
+
+// countStaleEmbeddings returns how many of playbooks Reindex would re-embed.
+func (pm *PlaybookManager) countStaleEmbeddings(playbooks []*Playbook) int {
+	if pm.cfg.EmbedFunc == nil {
+		return 0
+	}
+	n := 0
+	for _, pb := range playbooks {
+		if pm.needsEmbedding(pb) {
+			n++
+		}
+	}
+	return n
+}
+
+// reindexProgress serializes calls to a ReindexOptions.Progress callback.
+// A nil *reindexProgress reports nothing.
+type reindexProgress struct {
+	mu    sync.Mutex
+	fn    func(ReindexProgress)
+	state ReindexProgress
+}
+
+func newReindexProgress(fn func(ReindexProgress), total, toEmbed int) *reindexProgress {
+	if fn == nil {
+		return nil
+	}
+	return &reindexProgress{fn: fn, state: ReindexProgress{Total: total, ToEmbed: toEmbed}}
+}
+
+func (p *reindexProgress) embedded() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.EmbeddingCalls++
+	p.fn(p.state)
+}
+
+func (p *reindexProgress) indexed(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Indexed += n
+	p.fn(p.state)
+}
+
+// needsEmbedding reports whether pb's stored embedding should be regenerated:
+// it was made from different text or by another model, or it is missing while
+// vector search is configured.
+func (pm *PlaybookManager) needsEmbedding(pb *Playbook) bool {
+	return pb.EmbedHash != pm.embedTextHash(pb) || pm.hasStaleEmbedding(pb) ||
+		(len(pb.Embedding) == 0 && pm.cfg.EmbedDims > 0)
+}
+
+// refreshEmbeddings re-embeds and saves the playbooks for which need returns
+// true, with at most concurrency embedding calls in flight, updating
+// playbooks in place. It stops starting new calls when ctx is cancelled.
+// Failures are returned joined, in the order of playbooks.
+func (pm *PlaybookManager) refreshEmbeddings(ctx context.Context, playbooks []*Playbook, need func(*Playbook) bool, concurrency int, progress *reindexProgress) error {
+	if concurrency <= 0 {
+		concurrency = DefaultReindexConcurrency
+	}
+
+	sem := make(chan struct{}, concurrency)
+	errs := make([]error, len(playbooks))
+	var wg sync.WaitGroup
+dispatch:
+	for i, pb := range playbooks {
+		if !need(pb) {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		go func(i int, pb *Playbook) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = pm.refreshEmbedding(ctx, pb)
+			progress.embedded()
+		}(i, pb)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// refreshEmbedding embeds pb and saves the new embedding onto the stored
+// playbook, then reloads pb from the store. If the text has changed since pb
+// was loaded, the change that did it has already re-embedded it, and only the
+// reload happens.
+func (pm *PlaybookManager) refreshEmbedding(ctx context.Context, pb *Playbook) error {
+	fresh := *pb
+	if err := pm.generateEmbedding(ctx, &fresh); err != nil {
+		return fmt.Errorf("embed playbook %s: %w", pb.ID, err)
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	current, err := pm.store.GetPlaybook(ctx, pb.ID)
+	if err != nil {
+		return fmt.Errorf("get playbook %s: %w", pb.ID, err)
+	}
+	if pm.embedTextHash(current) != fresh.EmbedHash {
+		*pb = *current
+		return nil
+	}
+	current.Embedding, current.EmbedModel, current.EmbedDims, current.EmbedHash = fresh.Embedding, fresh.EmbedModel, fresh.EmbedDims, fresh.EmbedHash
+	if err := pm.store.SavePlaybook(ctx, current); err != nil {
+		return fmt.Errorf("save playbook %s: %w", pb.ID, err)
+	}
+	*pb = *current
+	return nil
+}
@@ -0,0 +1,193 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestManagerReindexReembedsWithBoundedConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	newManager := func(model string, embedFn func(context.Context, string) ([]float32, error)) *PlaybookManager {
+		t.Helper()
+		pm, err := NewPlaybookManager(ManagerConfig{
+			DataDir:    dir,
+			EmbedFunc:  embedFn,
+			EmbedDims:  2,
+			EmbedModel: model,
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		if err != nil {
+			t.Fatalf("NewPlaybookManager: %v", err)
+		}
+		return pm
+	}
+
+	old := newManager("old-model", func(context.Context, string) ([]float32, error) { return []float32{1, 0}, nil })
+	for i := 0; i < 12; i++ {
+		if err := old.Create(ctx, samplePlaybook(fmt.Sprintf("Playbook %d", i))); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	old.Close()
+
+	var calls, inFlight, maxInFlight atomic.Int32
+	pm := newManager("new-model", func(context.Context, string) ([]float32, error) {
+		calls.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return []float32{0, 1}, nil
+	})
+	t.Cleanup(func() { pm.Close() })
+
+	est, err := pm.EstimateReindex(ctx)
+	if err != nil {
+		t.Fatalf("EstimateReindex: %v", err)
+	}
+	if est.Playbooks != 12 || est.EmbeddingCalls != 12 || calls.Load() != 0 {
+		t.Fatalf("EstimateReindex = %+v with %d calls made, want 12 playbooks and 12 calls estimated, none made", est, calls.Load())
+	}
+
+	var last ReindexProgress
+	var updates int
+	opts := ReindexOptions{Concurrency: 3, Progress: func(p ReindexProgress) {
+		last = p
+		updates++
+	}}
+	result, err := pm.Reindex(ctx, opts)
+	if err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if want := (ReindexResult{Indexed: 12, Reembedded: 12}); *result != want {
+		t.Errorf("Reindex = %+v, want %+v", *result, want)
+	}
+	if want := (ReindexProgress{Total: 12, Indexed: 12, ToEmbed: 12, EmbeddingCalls: 12}); last != want || updates != 13 {
+		t.Errorf("last progress = %+v after %d updates, want %+v after 13", last, updates, want)
+	}
+	if calls.Load() != 12 {
+		t.Errorf("embedding calls = %d, want 12", calls.Load())
+	}
+	if m := maxInFlight.Load(); m > 3 || m < 2 {
+		t.Errorf("max embedding calls in flight = %d, want 2 or 3", m)
+	}
+	if stale, _ := pm.StaleEmbeddings(ctx); len(stale) != 0 {
+		t.Errorf("%d playbooks still have stale embeddings", len(stale))
+	}
+
+	// Up-to-date embeddings are not regenerated.
+	if result, err = pm.Reindex(ctx); err != nil {
+		t.Fatalf("second Reindex: %v", err)
+	}
+	if result.Reembedded != 0 {
+		t.Errorf("second Reindex re-embedded %d playbooks, want 0", result.Reembedded)
+	}
+	if calls.Load() != 12 {
+		t.Errorf("embedding calls after second Reindex = %d, want still 12", calls.Load())
+	}
+}
+
+func TestManagerReindexCancelled(t *testing.T) {
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:    t.TempDir(),
+		EmbedFunc:  func(context.Context, string) ([]float32, error) { return []float32{1, 0}, nil },
+		EmbedDims:  2,
+		EmbedModel: "model",
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	if err := pm.Create(context.Background(), samplePlaybook("Deploy")); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pm.Reindex(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Reindex with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestManagerReindexRemovesOrphans(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Deploy")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	// An index entry the store knows nothing about, as left by a crash
+	ghost := samplePlaybook("Ghost")
+	ghost.ID = "ghost"
+	if err := pm.indexer.Index(ctx, ghost); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	result, err := pm.Reindex(ctx)
+	if err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if result.Indexed != 1 || result.Removed != 1 {
+		t.Errorf("Reindex = %+v, want 1 indexed and 1 removed", *result)
+	}
+	missing, extra, err := pm.IndexDrift(ctx)
+	if err != nil || len(missing)+len(extra) != 0 {
+		t.Errorf("IndexDrift after Reindex = %v, %v, %v; want no drift", missing, extra, err)
+	}
+}
+
+func TestManagerReindexCountsEmbedErrors(t *testing.T) {
+	var fail atomic.Bool
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		EmbedFunc: func(context.Context, string) ([]float32, error) {
+			if fail.Load() {
+				return nil, errors.New("provider down")
+			}
+			return []float32{1, 0}, nil
+		},
+		EmbedDims:  2,
+		EmbedModel: "model",
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	for _, name := range []string{"Deploy", "Rollback"} {
+		pb := samplePlaybook(name)
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		// Make the stored embedding stale so Reindex tries to regenerate it
+		pb.EmbedHash = "stale"
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	fail.Store(true)
+	result, err := pm.Reindex(ctx)
+	if err == nil {
+		t.Fatal("Reindex with a failing provider should report the failures")
+	}
+	if want := (ReindexResult{Indexed: 2, EmbedErrors: 2}); result == nil || *result != want {
+		t.Errorf("Reindex = %+v, want %+v", result, want)
+	}
+}
@@ -0,0 +1,145 @@
+package playbookd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// effectiveWeights returns the weights a query blends its results with, and
+// false if it asks for text relevance alone. Weights takes precedence; a
+// ConfidenceWeight of w is the same as Weights{Text: 1 - w, Confidence: w}.
+func (q SearchQuery) effectiveWeights() (ScoreWeights, bool) {
+	w := ScoreWeights{
+		Text:       math.Max(q.Weights.Text, 0),
+		Confidence: math.Max(q.Weights.Confidence, 0),
+		Recency:    math.Max(q.Weights.Recency, 0),
+		Volume:     math.Max(q.Weights.Volume, 0),
+	}
+	if w != (ScoreWeights{}) {
+		return w, true
+	}
+	if q.ConfidenceWeight > 0 {
+		c := math.Min(q.ConfidenceWeight, 1)
+		return ScoreWeights{Text: 1 - c, Confidence: c}, true
+	}
+	return ScoreWeights{}, false
+}
+
+// checkSkipHydration rejects a query that skips hydration but re-ranks its
+// results with playbook data.
+func (q SearchQuery) checkSkipHydration() error {
+	if _, ok := q.effectiveWeights(); q.SkipHydration && (ok || q.RecencyBoost > 0) {
+		return fmt.Errorf("search: Weights, ConfidenceWeight, and RecencyBoost need hydrated results; unset SkipHydration")
+	}
+	return nil
+}
+
+// blendScores replaces each result's Score with the weighted mean of its
+// signals (see ScoreWeights) and re-sorts the results by it.
+func blendScores(results []SearchResult, w ScoreWeights) {
+	if len(results) == 0 {
+		return
+	}
+	total := w.Text + w.Confidence + w.Recency + w.Volume
+
+	text := make([]float64, len(results))
+	recency := make([]float64, len(results))
+	volume := make([]float64, len(results))
+	for i, r := range results {
+		text[i] = r.Score
+		if !r.Playbook.LastUsedAt.IsZero() {
+			recency[i] = float64(r.Playbook.LastUsedAt.Unix())
+		}
+		volume[i] = math.Log1p(float64(r.Playbook.SuccessCount + r.Playbook.FailureCount))
+	}
+	normalizeAll(text)
+	normalizeAll(recency)
+	normalizeAll(volume)
+	for i, r := range results {
+		if r.Playbook.LastUsedAt.IsZero() {
+			recency[i] = 0
+		}
+	}
+
+	for i := range results {
+		signals := []struct {
+			weight, value float64
+			name          string
+			expl          *Explanation
+		}{
+			{w.Text, text[i], "normalized text score", results[i].Explanation},
+			{w.Confidence, results[i].Playbook.Confidence, "confidence", nil},
+			{w.Recency, recency[i], "normalized recency", nil},
+			{w.Volume, volume[i], "normalized execution volume", nil},
+		}
+
+		var blended float64
+		var terms []string
+		var children []*Explanation
+		for _, s := range signals {
+			if s.weight == 0 {
+				continue
+			}
+			blended += s.weight / total * s.value
+			terms = append(terms, fmt.Sprintf("%.2f*%s", s.weight/total, s.name))
+			child := &Explanation{Value: s.value, Message: s.name}
+			if s.expl != nil {
+				child.Children = []*Explanation{s.expl}
+			}
+			children = append(children, child)
+		}
+
+		if results[i].Explanation != nil {
+			results[i].Explanation = &Explanation{
+				Value:    blended,
+				Message:  "composite " + strings.Join(terms, " + "),
+				Children: children,
+			}
+		}
+		results[i].Score = blended
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+}
+
+// applyRecencyBoost multiplies each result's Score by 1 + boost*decay, where
+// decay is 1 for a playbook used at now, halves every RecencyHalfLife before
+// that, and is 0 for a playbook never used, then re-sorts the results by it.
+func applyRecencyBoost(results []SearchResult, boost float64, now time.Time) {
+	for i, r := range results {
+		var decay float64
+		if !r.Playbook.LastUsedAt.IsZero() {
+			age := max(now.Sub(r.Playbook.LastUsedAt), 0)
+			decay = math.Exp2(-float64(age) / float64(RecencyHalfLife))
+		}
+		factor := 1 + boost*decay
+		results[i].Score = r.Score * factor
+		if r.Explanation != nil {
+			results[i].Explanation = &Explanation{
+				Value:    results[i].Score,
+				Message:  fmt.Sprintf("recency boost x%.3f", factor),
+				Children: []*Explanation{r.Explanation},
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+}
+
+// normalizeAll min-max normalizes values in place, as normalizeScore does.
+func normalizeAll(values []float64) {
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	for i, v := range values {
+		values[i] = normalizeScore(v, lo, hi)
+	}
+}
@@ -0,0 +1,80 @@
+package playbookd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBlendScoresRecencyAndVolume(t *testing.T) {
+	now := time.Now()
+	newResults := func() []SearchResult {
+		return []SearchResult{
+			{Score: 3, Playbook: &Playbook{ID: "relevant"}},
+			{Score: 2, Playbook: &Playbook{ID: "recent", LastUsedAt: now, SuccessCount: 2}},
+			{Score: 1, Playbook: &Playbook{ID: "busy", LastUsedAt: now.Add(-time.Hour), SuccessCount: 80, FailureCount: 20}},
+		}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		weights ScoreWeights
+		first   string
+	}{
+		{"text", ScoreWeights{Text: 1}, "relevant"},
+		{"recency", ScoreWeights{Text: 1, Recency: 2}, "recent"},
+		{"volume", ScoreWeights{Text: 1, Volume: 2}, "busy"},
+	} {
+		results := newResults()
+		blendScores(results, tt.weights)
+		if results[0].Playbook.ID != tt.first {
+			t.Errorf("%s: first result = %s, want %s", tt.name, results[0].Playbook.ID, tt.first)
+		}
+		for _, r := range results {
+			if r.Score < 0 || r.Score > 1 {
+				t.Errorf("%s: %s score %v outside [0,1]", tt.name, r.Playbook.ID, r.Score)
+			}
+		}
+	}
+
+	// Only the ratios between weights matter.
+	a, b := newResults(), newResults()
+	blendScores(a, ScoreWeights{Text: 1, Recency: 1})
+	blendScores(b, ScoreWeights{Text: 5, Recency: 5})
+	for i := range a {
+		if math.Abs(a[i].Score-b[i].Score) > 1e-9 {
+			t.Errorf("scaled weights changed %s: %v vs %v", a[i].Playbook.ID, a[i].Score, b[i].Score)
+		}
+	}
+}
+
+func TestEffectiveWeights(t *testing.T) {
+	if _, ok := (SearchQuery{}).effectiveWeights(); ok {
+		t.Error("zero query should rank by text alone")
+	}
+	if w, _ := (SearchQuery{ConfidenceWeight: 0.3}).effectiveWeights(); w != (ScoreWeights{Text: 0.7, Confidence: 0.3}) {
+		t.Errorf("ConfidenceWeight 0.3 = %+v, want text 0.7 and confidence 0.3", w)
+	}
+	w, _ := (SearchQuery{ConfidenceWeight: 0.3, Weights: ScoreWeights{Text: 1, Volume: -1}}).effectiveWeights()
+	if w != (ScoreWeights{Text: 1}) {
+		t.Errorf("Weights = %+v, want Weights to win with negatives clamped", w)
+	}
+}
+
+func TestApplyRecencyBoost(t *testing.T) {
+	now := time.Now()
+	results := []SearchResult{
+		{Score: 1, Playbook: &Playbook{ID: "never"}},
+		{Score: 1, Playbook: &Playbook{ID: "half-life", LastUsedAt: now.Add(-RecencyHalfLife)}},
+		{Score: 1, Playbook: &Playbook{ID: "today", LastUsedAt: now}},
+	}
+	applyRecencyBoost(results, 1, now)
+
+	want := map[string]float64{"today": 2, "half-life": 1.5, "never": 1}
+	for i, id := range []string{"today", "half-life", "never"} {
+		r := results[i]
+		if r.Playbook.ID != id || math.Abs(r.Score-want[id]) > 1e-9 {
+			t.Errorf("results[%d] = %s with score %v, want %s with %v", i, r.Playbook.ID, r.Score, id, want[id])
+		}
+	}
+}
@@ -1,33 +1,178 @@
 package playbookd
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
 // SearchMode determines the search strategy.
 type SearchMode string
 
 const (
-	SearchModeHybrid  SearchMode = "hybrid"
-	SearchModeBM25    SearchMode = "bm25"
-	SearchModeVector  SearchMode = "vector"
+	SearchModeHybrid SearchMode = "hybrid"
+	SearchModeBM25   SearchMode = "bm25"
+	SearchModeVector SearchMode = "vector"
 )
 
 // SearchQuery configures a playbook search.
 type SearchQuery struct {
-	Text             string     // Natural language query
-	Mode             SearchMode // hybrid, bm25, or vector
-	Category         string     // Filter by category
-	MinScore         float64    // Minimum result score
-	Limit            int        // Max results (default 5)
-	Embedding        []float32  // Pre-computed query embedding (optional)
-	ConfidenceWeight float64    // 0=disabled. final = (1-w)*textScore + w*confidence
+	Text             string       // Natural language query; "+term" requires a term, "-term" excludes it
+	Mode             SearchMode   // hybrid, bm25, or vector
+	Category         string       // Filter by category
+	Tags             []string     // Filter by tags (all must match exactly)
+	MinScore         float64      // Minimum result score (default: see DefaultMinScore; NoMinScore for none)
+	Limit            int          // Max results (default 5)
+	Embedding        []float32    // Pre-computed query embedding (optional)
+	ConfidenceWeight float64      // 0=disabled. final = (1-w)*textScore + w*confidence; ignored when Weights is set
+	Weights          ScoreWeights // Blend several signals into the final score (default: text only, or ConfidenceWeight)
+	RecencyBoost     float64      // 0=disabled. Multiplies the final score by 1 + RecencyBoost*d, where d halves every RecencyHalfLife since LastUsedAt
+	GroupByLineage   bool         // SearchGrouped only: group forks of the same root together
+	Fields           []string     // Text fields to match (default: all of SearchFields)
+	Explain          bool         // Attach an Explanation of each score to its result (diagnostic; slower)
+	MustTerms        []string     // Terms every result must match, in any searched field
+	MustNotTerms     []string     // Terms no result may match, in any searched field
+	VectorK          int          // Nearest neighbours the vector search considers (default Limit); raise it for recall before re-ranking
+	VectorBoost      float64      // Weight of the vector match against the text match in hybrid search (default DefaultVectorBoost)
+	SkipHydration    bool         // Return only each result's playbook ID and scores, without reading playbooks from the store
+}
+
+// DefaultVectorBoost is the weight of the vector match when
+// SearchQuery.VectorBoost is unset.
+const DefaultVectorBoost = 1.0
+
+// knnParams returns the k and boost of the KNN part of query, searched with
+// the given result limit.
+func (q SearchQuery) knnParams(limit int) (k int64, boost float64) {
+	k, boost = int64(limit), DefaultVectorBoost
+	if q.VectorK > 0 {
+		k = int64(q.VectorK)
+	}
+	if q.VectorBoost > 0 {
+		boost = q.VectorBoost
+	}
+	return k, boost
+}
+
+// withTermOperators moves "+term" and "-term" words out of Text into
+// MustTerms and MustNotTerms, leaving the rest as free text. A lone "+" or "-"
+// is kept as text.
+func (q SearchQuery) withTermOperators() SearchQuery {
+	words := strings.Fields(q.Text)
+	free := words[:0:0]
+	var must, mustNot []string
+	for _, w := range words {
+		switch {
+		case len(w) > 1 && w[0] == '+':
+			must = append(must, w[1:])
+		case len(w) > 1 && w[0] == '-':
+			mustNot = append(mustNot, w[1:])
+		default:
+			free = append(free, w)
+		}
+	}
+	if len(must) == 0 && len(mustNot) == 0 {
+		return q
+	}
+	q.Text = strings.Join(free, " ")
+	q.MustTerms = append(append([]string(nil), q.MustTerms...), must...)
+	q.MustNotTerms = append(append([]string(nil), q.MustNotTerms...), mustNot...)
+	return q
+}
+
+// ScoreWeights sets how much each signal contributes to a result's final
+// score. Each signal is in [0,1]: the text score and recency are min-max
+// normalized across the result set, volume is log(1+executions) normalized the
+// same way, and confidence is the Wilson score as is. The final score is the
+// weighted mean, sum(weight*signal) / sum(weights), so only the ratios between
+// weights matter. Negative weights are treated as 0.
+type ScoreWeights struct {
+	Text       float64 // Relevance to the query
+	Confidence float64 // Playbook.Confidence
+	Recency    float64 // Playbook.LastUsedAt; never-used playbooks score 0
+	Volume     float64 // Playbook.SuccessCount + Playbook.FailureCount
+}
+
+// SearchFields lists the text fields a query can be restricted to with
+// SearchQuery.Fields.
+var SearchFields = []string{"name", "description", "tags", "steps", "lessons"}
+
+// TaskContextField is the text field holding a playbook's TaskContexts. It
+// can be named in SearchQuery.Fields but is not searched by default.
+const TaskContextField = "task_contexts"
+
+// checkSearchFields returns an error naming the first of fields that is
+// neither in SearchFields nor TaskContextField.
+func checkSearchFields(fields []string) error {
+	for _, field := range fields {
+		if field != TaskContextField && !slices.Contains(SearchFields, field) {
+			return fmt.Errorf("unknown search field %q (expected one of %s, %s)", field, strings.Join(SearchFields, ", "), TaskContextField)
+		}
+	}
+	return nil
 }
 
 // SearchResult represents a single search hit.
 type SearchResult struct {
-	Playbook *Playbook
-	Score    float64
+	Playbook    *Playbook
+	Score       float64      // Final score: TextScore, or the composite when Weights or ConfidenceWeight is set
+	TextScore   float64      // Raw BM25/vector relevance score, before blending
+	Explanation *Explanation `json:",omitempty"` // Set when SearchQuery.Explain is true
+
+	// DegradedToBM25 is set on every result of a hybrid or vector search
+	// whose query could not be embedded, so it ran as BM25 only, and
+	// DegradedReason says why.
+	DegradedToBM25 bool          `json:",omitempty"`
+	DegradedReason DegradeReason `json:",omitempty"`
+}
+
+// DegradeReason says why a hybrid or vector search fell back to BM25.
+type DegradeReason string
+
+const (
+	DegradeEmbeddingFailed   DegradeReason = "embedding_failed"   // The embedding provider returned an error
+	DegradeDimensionMismatch DegradeReason = "dimension_mismatch" // The query embedding did not have EmbedDims dimensions
+)
+
+// Explanation breaks a search score down into the contributions that produced
+// it, such as per-term and per-field BM25 weights. Value is the score of this
+// node; Children are the parts it was computed from.
+type Explanation struct {
+	Value    float64        `json:"value"`
+	Message  string         `json:"message"`
+	Children []*Explanation `json:"children,omitempty"`
+}
+
+// SearchGroup is a set of search hits that share a fork lineage.
+type SearchGroup struct {
+	RootID   string         // ID at the top of the ForkedFrom chain
+	Best     SearchResult   // Highest-scoring hit in the lineage
+	Variants []SearchResult // Other hits in the lineage, by descending score
+}
+
+// Size returns the number of hits in the group.
+func (g SearchGroup) Size() int {
+	return 1 + len(g.Variants)
 }
 
 // DefaultSearchLimit is the default number of results returned.
 const DefaultSearchLimit = 5
 
-// DefaultMinScore is the default minimum score for results.
+// DefaultMaxSearchResults is the default cap on SearchQuery.Limit; see
+// ManagerConfig.MaxSearchResults.
+const DefaultMaxSearchResults = 100
+
+// DefaultMinScore is the minimum score for results when SearchQuery.MinScore
+// is unset. Vector similarities are compared with it directly; BM25 and
+// hybrid scores, which depend on the size of the index, must be at least
+// DefaultMinScore times the best hit's score.
 const DefaultMinScore = 0.1
+
+// RecencyHalfLife is how long it takes SearchQuery.RecencyBoost to fall to
+// half its full effect after a playbook was last used.
+const RecencyHalfLife = 30 * 24 * time.Hour
+
+// NoMinScore, as SearchQuery.MinScore, returns every match however low it
+// scores. Any negative MinScore has the same effect.
+const NoMinScore = -1.0
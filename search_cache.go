@@ -0,0 +1,119 @@
+package playbookd
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// searchCache is an LRU cache of index hits by normalized query, enabled by
+// ManagerConfig.SearchCacheSize. It holds the hits as the indexer returned
+// them, before hydration, so every search still loads the playbooks from the
+// store and drops any that no longer exist. Any change to the index clears it.
+// A nil *searchCache is a disabled cache.
+type searchCache struct {
+	mu    sync.Mutex
+	size  int
+	gen   uint64 // bumped by invalidate, so a search that overlapped a change does not cache its hits
+	order *list.List
+	items map[string]*list.Element
+}
+
+type searchCacheEntry struct {
+	key   string
+	query SearchQuery // the query as searched, with its embedding and any BM25 fallback
+	hits  []SearchResult
+}
+
+func newSearchCache(size int) *searchCache {
+	if size <= 0 {
+		return nil
+	}
+	return &searchCache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns the entry cached under key, or nil, and the generation to pass
+// to put when it is missing.
+func (c *searchCache) get(key string) (*searchCacheEntry, uint64) {
+	if c == nil {
+		return nil, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*searchCacheEntry), c.gen
+	}
+	return nil, c.gen
+}
+
+// put caches an entry unless the index has changed since generation gen.
+func (c *searchCache) put(gen uint64, e *searchCacheEntry) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.items[e.key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[e.key] = c.order.PushFront(e)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*searchCacheEntry).key)
+	}
+}
+
+// invalidate empties the cache.
+func (c *searchCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.order.Init()
+	clear(c.items)
+}
+
+// searchCacheKey identifies a query for the cache: the text is lowercased with
+// runs of whitespace collapsed, and every other field is compared as is.
+func searchCacheKey(q SearchQuery) string {
+	q.Text = strings.ToLower(strings.Join(strings.Fields(q.Text), " "))
+	q.SkipHydration = false // The hits are the same either way
+	data, err := json.Marshal(q)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// invalidatingIndexer clears the search cache after every change to the index,
+// which covers every create, update, delete, and reindex.
+type invalidatingIndexer struct {
+	Indexer
+	cache *searchCache
+}
+
+func (ii *invalidatingIndexer) Index(ctx context.Context, pb *Playbook) error {
+	defer ii.cache.invalidate()
+	return ii.Indexer.Index(ctx, pb)
+}
+
+func (ii *invalidatingIndexer) Remove(ctx context.Context, id string) error {
+	defer ii.cache.invalidate()
+	return ii.Indexer.Remove(ctx, id)
+}
+
+func (ii *invalidatingIndexer) Reindex(ctx context.Context, playbooks []*Playbook) error {
+	defer ii.cache.invalidate()
+	return ii.Indexer.Reindex(ctx, playbooks)
+}
@@ -0,0 +1,163 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd/embed"
+)
+
+// countingIndexer counts searches that reach the index.
+type countingIndexer struct {
+	*BleveIndexer
+	searches atomic.Int32
+}
+
+func (c *countingIndexer) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	c.searches.Add(1)
+	return c.BleveIndexer.Search(ctx, query)
+}
+
+func TestManagerSearchCache(t *testing.T) {
+	dir := t.TempDir()
+	bi, err := NewBleveIndexer(IndexerConfig{Path: filepath.Join(dir, "index")})
+	if err != nil {
+		t.Fatalf("NewBleveIndexer: %v", err)
+	}
+	idx := &countingIndexer{BleveIndexer: bi}
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:         dir,
+		Indexer:         idx,
+		EmbedFunc:       embed.Noop(),
+		SearchCacheSize: 8,
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	deploy := samplePlaybook("Deploy Service")
+	rollback := samplePlaybook("Rollback Deploy")
+	if err := pm.CreateBatch(ctx, []*Playbook{deploy, rollback}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	search := func(text string) int {
+		t.Helper()
+		results, err := pm.Search(ctx, SearchQuery{Text: text, Mode: SearchModeBM25, MinScore: NoMinScore})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", text, err)
+		}
+		return len(results)
+	}
+
+	if n := search("deploy"); n != 2 {
+		t.Fatalf("first search found %d results, want 2", n)
+	}
+	if n := search("  DEPLOY "); n != 2 || idx.searches.Load() != 1 {
+		t.Fatalf("repeated search found %d results with %d index searches, want 2 from the cache", n, idx.searches.Load())
+	}
+
+	// Hits are still hydrated from the store, so a playbook removed behind
+	// the cache's back is not returned.
+	if err := pm.store.DeletePlaybook(ctx, rollback.ID); err != nil {
+		t.Fatalf("DeletePlaybook: %v", err)
+	}
+	if n := search("deploy"); n != 1 || idx.searches.Load() != 1 {
+		t.Errorf("cached search found %d results with %d index searches, want 1 from the cache", n, idx.searches.Load())
+	}
+
+	// Any change to the index empties the cache.
+	if err := pm.Create(ctx, samplePlaybook("Deploy Docs")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if n := search("deploy"); idx.searches.Load() != 2 {
+		t.Errorf("search after Create found %d results with %d index searches, want a fresh search", n, idx.searches.Load())
+	}
+}
+
+func TestSearchCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newSearchCache(2)
+	for _, key := range []string{"a", "b"} {
+		_, gen := c.get(key)
+		c.put(gen, &searchCacheEntry{key: key})
+	}
+	c.get("a")
+	_, gen := c.get("c")
+	c.put(gen, &searchCacheEntry{key: "c"})
+
+	if e, _ := c.get("b"); e != nil {
+		t.Error("b is still cached, want it evicted as least recently used")
+	}
+	if e, _ := c.get("a"); e == nil {
+		t.Error("a was evicted, want it kept")
+	}
+
+	// An entry computed before an invalidation is not cached.
+	_, gen = c.get("d")
+	c.invalidate()
+	c.put(gen, &searchCacheEntry{key: "d"})
+	if e, _ := c.get("d"); e != nil {
+		t.Error("d was cached across an invalidation")
+	}
+}
+
+func TestManagerSearchCacheSkipsDegraded(t *testing.T) {
+	dir := t.TempDir()
+	bi, err := NewBleveIndexer(IndexerConfig{Path: filepath.Join(dir, "index")})
+	if err != nil {
+		t.Fatalf("NewBleveIndexer: %v", err)
+	}
+	idx := &countingIndexer{BleveIndexer: bi}
+	var failEmbed atomic.Bool
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir: dir,
+		Indexer: idx,
+		EmbedFunc: func(context.Context, string) ([]float32, error) {
+			if failEmbed.Load() {
+				return nil, errors.New("provider down")
+			}
+			return []float32{1, 0, 0}, nil
+		},
+		EmbedDims:       3,
+		SearchCacheSize: 8,
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	ctx := context.Background()
+
+	if err := pm.Create(ctx, samplePlaybook("Deploy Service")); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// A search that fell back to BM25 is retried rather than served from
+	// the cache, so it recovers as soon as the provider does.
+	failEmbed.Store(true)
+	for range 2 {
+		if _, err := pm.Search(ctx, SearchQuery{Text: "deploy", MinScore: NoMinScore}); err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+	}
+	if n := idx.searches.Load(); n != 2 {
+		t.Fatalf("degraded searches reached the index %d times, want 2", n)
+	}
+
+	failEmbed.Store(false)
+	results, err := pm.Search(ctx, SearchQuery{Text: "deploy", MinScore: NoMinScore})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].DegradedToBM25 {
+		t.Errorf("search after recovery = %d results, degraded %v; want 1 hybrid result", len(results), len(results) > 0 && results[0].DegradedToBM25)
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // ErrNotFound is returned when a requested resource does not exist.
@@ -22,35 +25,64 @@ type Store interface {
 	SavePlaybook(ctx context.Context, pb *Playbook) error
 	GetPlaybook(ctx context.Context, id string) (*Playbook, error)
 	ListPlaybooks(ctx context.Context, filter ListFilter) ([]*Playbook, error)
+	ListPlaybookIDs(ctx context.Context) ([]string, error)
+	// CountPlaybooks returns how many playbooks ListPlaybooks would return
+	// without a Limit; SortBy and Ascending are ignored.
+	CountPlaybooks(ctx context.Context, filter ListFilter) (int, error)
+	// PlaybookExists reports whether GetPlaybook would find id.
+	PlaybookExists(ctx context.Context, id string) (bool, error)
 	DeletePlaybook(ctx context.Context, id string) error
+	SavePlaybookVersion(ctx context.Context, pb *Playbook) error
+	GetPlaybookVersion(ctx context.Context, id string, version int) (*Playbook, error)
 	SaveExecution(ctx context.Context, rec *ExecutionRecord) error
 	ListExecutions(ctx context.Context, playbookID string, limit int) ([]*ExecutionRecord, error)
+	ListExecutionsFiltered(ctx context.Context, playbookID string, filter ExecutionFilter) ([]*ExecutionRecord, error)
+	DeleteExecution(ctx context.Context, playbookID, execID string) error
+
+	// The trash holds playbooks removed by PlaybookManager.Delete. A trashed
+	// playbook is invisible to GetPlaybook and ListPlaybooks but keeps its
+	// executions and versions until it is purged.
+	TrashPlaybook(ctx context.Context, id string, deletedAt time.Time) error
+	ListTrash(ctx context.Context) ([]*Playbook, error)
+	RestoreTrashedPlaybook(ctx context.Context, id string) (*Playbook, error)
+	PurgeTrashedPlaybook(ctx context.Context, id string) error
 }
 
 // FileStore implements Store using JSON files on disk.
 type FileStore struct {
 	dataDir string
+	opts    FileStoreOptions
 	mu      sync.RWMutex
 }
 
 // NewFileStore creates a new file-based store at the given directory.
-func NewFileStore(dataDir string) (*FileStore, error) {
+func NewFileStore(dataDir string, opts ...FileStoreOptions) (*FileStore, error) {
 	playbooksDir := filepath.Join(dataDir, "playbooks")
 	executionsDir := filepath.Join(dataDir, "executions")
+	versionsDir := filepath.Join(dataDir, "versions")
+	trashDir := filepath.Join(dataDir, "trash")
 
-	for _, dir := range []string{playbooksDir, executionsDir} {
+	for _, dir := range []string{playbooksDir, executionsDir, versionsDir, trashDir} {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return nil, fmt.Errorf("create directory %s: %w", dir, err)
 		}
 	}
 
-	return &FileStore{dataDir: dataDir}, nil
+	fs := &FileStore{dataDir: dataDir}
+	if len(opts) > 0 {
+		fs.opts = opts[0]
+	}
+	return fs, nil
 }
 
 func (fs *FileStore) playbookPath(id string) string {
 	return filepath.Join(fs.dataDir, "playbooks", id+".json")
 }
 
+func (fs *FileStore) trashPath(id string) string {
+	return filepath.Join(fs.dataDir, "trash", id+".json")
+}
+
 func (fs *FileStore) executionDir(playbookID string) string {
 	return filepath.Join(fs.dataDir, "executions", playbookID)
 }
@@ -59,12 +91,20 @@ func (fs *FileStore) executionPath(playbookID, execID string) string {
 	return filepath.Join(fs.executionDir(playbookID), execID+".json")
 }
 
+func (fs *FileStore) versionDir(playbookID string) string {
+	return filepath.Join(fs.dataDir, "versions", playbookID)
+}
+
+func (fs *FileStore) versionPath(playbookID string, version int) string {
+	return filepath.Join(fs.versionDir(playbookID), strconv.Itoa(version)+".json")
+}
+
 // SavePlaybook persists a playbook to disk using atomic write (temp file + rename).
 func (fs *FileStore) SavePlaybook(_ context.Context, pb *Playbook) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	return atomicWriteJSON(fs.playbookPath(pb.ID), pb)
+	return fs.writePlaybookFile(fs.playbookPath(pb.ID), pb)
 }
 
 // GetPlaybook loads a playbook by ID.
@@ -72,24 +112,10 @@ func (fs *FileStore) GetPlaybook(_ context.Context, id string) (*Playbook, error
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	path := fs.playbookPath(id)
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("playbook %s: %w", id, ErrNotFound)
-		}
-		return nil, fmt.Errorf("read playbook %s: %w", id, err)
-	}
-
-	var pb Playbook
-	if err := json.Unmarshal(data, &pb); err != nil {
-		return nil, fmt.Errorf("unmarshal playbook %s: %w", id, err)
-	}
-
-	return &pb, nil
+	return readPlaybookFile(fs.playbookPath(id), id)
 }
 
-// ListPlaybooks returns all playbooks matching the filter.
+// ListPlaybooks returns all playbooks matching the filter, sorted as it asks.
 func (fs *FileStore) ListPlaybooks(_ context.Context, filter ListFilter) ([]*Playbook, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
@@ -109,7 +135,8 @@ func (fs *FileStore) ListPlaybooks(_ context.Context, filter ListFilter) ([]*Pla
 			continue
 		}
 
-		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
 		if err != nil {
 			// Intentionally skip unreadable files; a single corrupt file
 			// should not prevent listing the rest of the playbooks.
@@ -125,14 +152,16 @@ func (fs *FileStore) ListPlaybooks(_ context.Context, filter ListFilter) ([]*Pla
 		if !matchesFilter(&pb, filter) {
 			continue
 		}
+		if err := loadEmbedding(path, &pb); err != nil {
+			continue
+		}
 
 		playbooks = append(playbooks, &pb)
 	}
 
-	// Sort by confidence descending
-	sort.Slice(playbooks, func(i, j int) bool {
-		return playbooks[i].Confidence > playbooks[j].Confidence
-	})
+	if err := sortPlaybooks(playbooks, filter); err != nil {
+		return nil, err
+	}
 
 	if filter.Limit > 0 && len(playbooks) > filter.Limit {
 		playbooks = playbooks[:filter.Limit]
@@ -141,13 +170,92 @@ func (fs *FileStore) ListPlaybooks(_ context.Context, filter ListFilter) ([]*Pla
 	return playbooks, nil
 }
 
+// CountPlaybooks returns the number of playbooks matching the filter. Each
+// file is decoded only as far as the fields the filter looks at, and sidecar
+// embeddings are not read.
+func (fs *FileStore) CountPlaybooks(_ context.Context, filter ListFilter) (int, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	dir := filepath.Join(fs.dataDir, "playbooks")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("read playbooks dir: %w", err)
+	}
+
+	var n int
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue // skipped, as in ListPlaybooks
+		}
+		var fields struct {
+			Archived bool     `json:"archived"`
+			Category string   `json:"category"`
+			Tags     []string `json:"tags"`
+		}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			continue
+		}
+		if matchesFilter(&Playbook{Archived: fields.Archived, Category: fields.Category, Tags: fields.Tags}, filter) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// PlaybookExists reports whether a playbook file exists for id, without
+// reading it.
+func (fs *FileStore) PlaybookExists(_ context.Context, id string) (bool, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	_, err := os.Stat(fs.playbookPath(id))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("stat playbook %s: %w", id, err)
+}
+
+// ListPlaybookIDs returns the IDs of all playbooks, archived ones included,
+// sorted, without loading the playbooks themselves.
+func (fs *FileStore) ListPlaybookIDs(_ context.Context) ([]string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "playbooks"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read playbooks dir: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	return ids, nil
+}
+
 // DeletePlaybook removes a playbook and its executions from disk.
 func (fs *FileStore) DeletePlaybook(_ context.Context, id string) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	path := fs.playbookPath(id)
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+	if err := removePlaybookFile(fs.playbookPath(id)); err != nil {
 		return fmt.Errorf("delete playbook %s: %w", id, err)
 	}
 
@@ -157,9 +265,194 @@ func (fs *FileStore) DeletePlaybook(_ context.Context, id string) error {
 		return fmt.Errorf("delete executions for %s: %w", id, err)
 	}
 
+	// And the version history
+	if err := os.RemoveAll(fs.versionDir(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete versions for %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// TrashPlaybook moves a playbook's file to the trash directory, stamped with
+// deletedAt. Its executions and versions stay where they are.
+func (fs *FileStore) TrashPlaybook(_ context.Context, id string, deletedAt time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	pb, err := readPlaybookFile(fs.playbookPath(id), id)
+	if err != nil {
+		return err
+	}
+	pb.DeletedAt = &deletedAt
+	if err := fs.writePlaybookFile(fs.trashPath(id), pb); err != nil {
+		return fmt.Errorf("trash playbook %s: %w", id, err)
+	}
+	if err := removePlaybookFile(fs.playbookPath(id)); err != nil {
+		removePlaybookFile(fs.trashPath(id))
+		return fmt.Errorf("trash playbook %s: %w", id, err)
+	}
 	return nil
 }
 
+// ListTrash returns the trashed playbooks, most recently deleted first.
+func (fs *FileStore) ListTrash(_ context.Context) ([]*Playbook, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	dir := filepath.Join(fs.dataDir, "trash")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read trash dir: %w", err)
+	}
+
+	var playbooks []*Playbook
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		// Skip unreadable and malformed files, as ListPlaybooks does.
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var pb Playbook
+		if err := json.Unmarshal(data, &pb); err != nil {
+			continue
+		}
+		if err := loadEmbedding(path, &pb); err != nil {
+			continue
+		}
+		playbooks = append(playbooks, &pb)
+	}
+	sortTrash(playbooks)
+	return playbooks, nil
+}
+
+// RestoreTrashedPlaybook moves a playbook out of the trash, clears DeletedAt,
+// and returns it.
+func (fs *FileStore) RestoreTrashedPlaybook(_ context.Context, id string) (*Playbook, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	pb, err := readPlaybookFile(fs.trashPath(id), id)
+	if err != nil {
+		return nil, err
+	}
+	pb.DeletedAt = nil
+	if err := fs.writePlaybookFile(fs.playbookPath(id), pb); err != nil {
+		return nil, fmt.Errorf("restore playbook %s: %w", id, err)
+	}
+	if err := removePlaybookFile(fs.trashPath(id)); err != nil {
+		return nil, fmt.Errorf("restore playbook %s: %w", id, err)
+	}
+	return pb, nil
+}
+
+// PurgeTrashedPlaybook permanently removes a trashed playbook with its
+// executions and versions. Purging an ID that is not in the trash is not an
+// error, and never touches a live playbook.
+func (fs *FileStore) PurgeTrashedPlaybook(_ context.Context, id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := os.Remove(fs.trashPath(id)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("purge playbook %s: %w", id, err)
+	}
+	if err := removeFile(embeddingPath(fs.trashPath(id))); err != nil {
+		return fmt.Errorf("purge playbook %s: %w", id, err)
+	}
+	if err := os.RemoveAll(fs.executionDir(id)); err != nil {
+		return fmt.Errorf("purge executions for %s: %w", id, err)
+	}
+	if err := os.RemoveAll(fs.versionDir(id)); err != nil {
+		return fmt.Errorf("purge versions for %s: %w", id, err)
+	}
+	return nil
+}
+
+// readPlaybookFile loads the playbook stored at path, with its embedding from
+// a sidecar file if it has one, returning ErrNotFound if the file does not
+// exist.
+func readPlaybookFile(path, id string) (*Playbook, error) {
+	pb, err := readInlinePlaybook(path, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadEmbedding(path, pb); err != nil {
+		return nil, fmt.Errorf("playbook %s: %w", id, err)
+	}
+	return pb, nil
+}
+
+// readInlinePlaybook loads the playbook JSON at path alone, without a sidecar
+// embedding.
+func readInlinePlaybook(path, id string) (*Playbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("playbook %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("read playbook %s: %w", id, err)
+	}
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s: %w", id, err)
+	}
+	return &pb, nil
+}
+
+// sortTrash orders trashed playbooks most recently deleted first.
+func sortTrash(playbooks []*Playbook) {
+	sort.Slice(playbooks, func(i, j int) bool {
+		a, b := playbooks[i].DeletedAt, playbooks[j].DeletedAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+}
+
+// SavePlaybookVersion stores a snapshot of a playbook under its current version number.
+func (fs *FileStore) SavePlaybookVersion(_ context.Context, pb *Playbook) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	dir := fs.versionDir(pb.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create version dir: %w", err)
+	}
+
+	return atomicWriteJSON(fs.versionPath(pb.ID, pb.Version), pb)
+}
+
+// GetPlaybookVersion loads a previously saved snapshot of a playbook.
+func (fs *FileStore) GetPlaybookVersion(_ context.Context, id string, version int) (*Playbook, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	data, err := os.ReadFile(fs.versionPath(id, version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("playbook %s version %d: %w", id, version, ErrNotFound)
+		}
+		return nil, fmt.Errorf("read playbook %s version %d: %w", id, version, err)
+	}
+
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s version %d: %w", id, version, err)
+	}
+
+	return &pb, nil
+}
+
 // SaveExecution persists an execution record to disk.
 func (fs *FileStore) SaveExecution(_ context.Context, rec *ExecutionRecord) error {
 	fs.mu.Lock()
@@ -174,7 +467,12 @@ func (fs *FileStore) SaveExecution(_ context.Context, rec *ExecutionRecord) erro
 }
 
 // ListExecutions returns recent executions for a playbook, newest first.
-func (fs *FileStore) ListExecutions(_ context.Context, playbookID string, limit int) ([]*ExecutionRecord, error) {
+func (fs *FileStore) ListExecutions(ctx context.Context, playbookID string, limit int) ([]*ExecutionRecord, error) {
+	return fs.ListExecutionsFiltered(ctx, playbookID, ExecutionFilter{Limit: limit})
+}
+
+// ListExecutionsFiltered returns executions for a playbook matching the filter, newest first.
+func (fs *FileStore) ListExecutionsFiltered(_ context.Context, playbookID string, filter ExecutionFilter) ([]*ExecutionRecord, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
@@ -206,6 +504,10 @@ func (fs *FileStore) ListExecutions(_ context.Context, playbookID string, limit
 			continue
 		}
 
+		if !matchesExecutionFilter(&rec, filter) {
+			continue
+		}
+
 		records = append(records, &rec)
 	}
 
@@ -214,8 +516,8 @@ func (fs *FileStore) ListExecutions(_ context.Context, playbookID string, limit
 		return records[i].StartedAt.After(records[j].StartedAt)
 	})
 
-	if limit > 0 && len(records) > limit {
-		records = records[:limit]
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
 	}
 
 	return records, nil
@@ -223,7 +525,10 @@ func (fs *FileStore) ListExecutions(_ context.Context, playbookID string, limit
 
 // matchesFilter checks if a playbook matches the given filter criteria.
 func matchesFilter(pb *Playbook, filter ListFilter) bool {
-	if !filter.IncludeArchived && pb.Archived {
+	if !filter.IncludeArchived && !filter.OnlyArchived && pb.Archived {
+		return false
+	}
+	if filter.OnlyArchived && !pb.Archived {
 		return false
 	}
 	if filter.Category != "" && pb.Category != filter.Category {
@@ -243,13 +548,93 @@ func matchesFilter(pb *Playbook, filter ListFilter) bool {
 	return true
 }
 
+// sortPlaybooks sorts playbooks as filter.SortBy and filter.Ascending ask,
+// breaking ties by name and then ID.
+func sortPlaybooks(playbooks []*Playbook, filter ListFilter) error {
+	var key func(*Playbook) float64
+	switch filter.SortBy {
+	case "", SortByConfidence:
+		key = func(pb *Playbook) float64 { return pb.Confidence }
+	case SortByName:
+	case SortByCreated:
+		key = func(pb *Playbook) float64 { return float64(pb.CreatedAt.UnixNano()) }
+	case SortByUpdated:
+		key = func(pb *Playbook) float64 { return float64(pb.UpdatedAt.UnixNano()) }
+	case SortBySuccessRate:
+		key = func(pb *Playbook) float64 { return pb.SuccessRate }
+	case SortByExecutions:
+		key = func(pb *Playbook) float64 { return float64(pb.SuccessCount + pb.FailureCount) }
+	default:
+		return fmt.Errorf("unknown sort field %q", filter.SortBy)
+	}
+
+	byName := func(a, b *Playbook) int {
+		if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	}
+	sort.Slice(playbooks, func(i, j int) bool {
+		a, b := playbooks[i], playbooks[j]
+		c := 0
+		if key == nil {
+			c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		} else if ka, kb := key(a), key(b); ka != kb {
+			c = -1
+			if ka > kb {
+				c = 1
+			}
+		}
+		if c != 0 {
+			if !filter.Ascending {
+				c = -c
+			}
+			return c < 0
+		}
+		return byName(a, b) < 0
+	})
+	return nil
+}
+
+// DeleteExecution removes a single execution record from disk.
+func (fs *FileStore) DeleteExecution(_ context.Context, playbookID, execID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := os.Remove(fs.executionPath(playbookID, execID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete execution %s: %w", execID, err)
+	}
+	return nil
+}
+
+// matchesExecutionFilter checks if an execution record matches the given filter criteria.
+func matchesExecutionFilter(rec *ExecutionRecord, filter ExecutionFilter) bool {
+	if filter.Outcome != "" && rec.Outcome != filter.Outcome {
+		return false
+	}
+	if filter.AgentID != "" && rec.AgentID != filter.AgentID {
+		return false
+	}
+	if !filter.StartedAfter.IsZero() && !rec.StartedAt.After(filter.StartedAfter) {
+		return false
+	}
+	if !filter.StartedBefore.IsZero() && !rec.StartedAt.Before(filter.StartedBefore) {
+		return false
+	}
+	return true
+}
+
 // atomicWriteJSON writes data as JSON to a file atomically (temp file + rename).
 func atomicWriteJSON(path string, v any) error {
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal: %w", err)
 	}
+	return atomicWriteFile(path, data)
+}
 
+// atomicWriteFile writes data to a file atomically (temp file + rename).
+func atomicWriteFile(path string, data []byte) error {
 	dir := filepath.Dir(path)
 	tmp, err := os.CreateTemp(dir, ".tmp-*")
 	if err != nil {
@@ -0,0 +1,154 @@
+package playbookd
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStoreOptions configures a FileStore.
+type FileStoreOptions struct {
+	// SeparateEmbeddings keeps each playbook's embedding out of its JSON
+	// file, in a binary sidecar beside it (<id>.vec, little-endian float32s),
+	// so listing playbooks does not parse thousands of floats per file. Files
+	// of either layout are read regardless of this setting; each playbook is
+	// written in the configured layout the next time it is saved, or all at
+	// once by SplitEmbeddings.
+	SeparateEmbeddings bool
+}
+
+// embeddingPath returns the sidecar path for the playbook file at path.
+func embeddingPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".vec"
+}
+
+// writePlaybookFile saves pb to path in the configured layout, removing a
+// sidecar the file no longer uses. The sidecar is written first, so a crash in
+// between leaves the old JSON, which still reads consistently.
+func (fs *FileStore) writePlaybookFile(path string, pb *Playbook) error {
+	vec := embeddingPath(path)
+	if !fs.opts.SeparateEmbeddings || len(pb.Embedding) == 0 {
+		if err := atomicWriteJSON(path, pb); err != nil {
+			return err
+		}
+		return removeFile(vec)
+	}
+	if err := atomicWriteFile(vec, encodeEmbedding(pb.Embedding)); err != nil {
+		return fmt.Errorf("write embedding: %w", err)
+	}
+	lean := *pb
+	lean.Embedding = nil
+	return atomicWriteJSON(path, &lean)
+}
+
+// removePlaybookFile deletes the playbook file at path and its sidecar.
+// Neither existing is not an error.
+func removePlaybookFile(path string) error {
+	if err := removeFile(path); err != nil {
+		return err
+	}
+	return removeFile(embeddingPath(path))
+}
+
+// loadEmbedding fills in pb.Embedding from the sidecar of the playbook file
+// at path, if pb has no embedding inline and the sidecar exists.
+func loadEmbedding(path string, pb *Playbook) error {
+	if len(pb.Embedding) > 0 {
+		return nil
+	}
+	data, err := os.ReadFile(embeddingPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read embedding: %w", err)
+	}
+	vec, err := decodeEmbedding(data)
+	if err != nil {
+		return err
+	}
+	pb.Embedding = vec
+	return nil
+}
+
+func encodeEmbedding(vec []float32) []byte {
+	data := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+func decodeEmbedding(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("embedding sidecar is %d bytes, not a whole number of float32s", len(data))
+	}
+	vec := make([]float32, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vec, nil
+}
+
+// removeFile deletes path, ignoring a file that does not exist.
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SplitEmbeddings rewrites every live and trashed playbook whose embedding
+// is stored inline so it is kept in a sidecar file instead, and returns how
+// many it rewrote. It is the migration for turning SeparateEmbeddings on for
+// an existing data directory, and works whether or not the option is set.
+func (fs *FileStore) SplitEmbeddings(ctx context.Context) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	split := &FileStore{dataDir: fs.dataDir, opts: FileStoreOptions{SeparateEmbeddings: true}}
+	var n int
+	for _, dir := range []string{"playbooks", "trash"} {
+		entries, err := os.ReadDir(filepath.Join(fs.dataDir, dir))
+		if err != nil {
+			return n, fmt.Errorf("read %s dir: %w", dir, err)
+		}
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return n, err
+			}
+			id, ok := playbookIDFromPath(entry.Name())
+			if entry.IsDir() || !ok {
+				continue
+			}
+			path := filepath.Join(fs.dataDir, dir, entry.Name())
+			pb, err := readInlinePlaybook(path, id)
+			if err != nil {
+				return n, err
+			}
+			if len(pb.Embedding) == 0 {
+				continue
+			}
+			if err := split.writePlaybookFile(path, pb); err != nil {
+				return n, fmt.Errorf("split embedding of %s: %w", id, err)
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SplitEmbeddings moves the embeddings stored inline in playbook files into
+// sidecar files; see FileStore.SplitEmbeddings. It requires the file store.
+func (pm *PlaybookManager) SplitEmbeddings(ctx context.Context) (int, error) {
+	fs, ok := pm.store.(*FileStore)
+	if !ok {
+		return 0, fmt.Errorf("splitting embeddings requires the file store, not %T", pm.store)
+	}
+	return fs.SplitEmbeddings(ctx)
+}
@@ -0,0 +1,300 @@
+package playbookd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Compile-time check that MemStore implements Store.
+var _ Store = (*MemStore)(nil)
+
+// MemStore implements Store in memory, for tests and for embedding playbookd
+// without touching disk. Records are kept as JSON, exactly as FileStore writes
+// them, so callers never share memory with the store and unknown fields
+// survive. It is safe for concurrent use; its contents are lost when it is
+// garbage collected.
+type MemStore struct {
+	mu         sync.RWMutex
+	playbooks  map[string][]byte
+	trash      map[string][]byte
+	versions   map[string]map[int][]byte    // playbook ID -> version -> snapshot
+	executions map[string]map[string][]byte // playbook ID -> execution ID -> record
+}
+
+// NewMemStore creates an empty in-memory store.
+func NewMemStore() *MemStore {
+	return &MemStore{
+		playbooks:  make(map[string][]byte),
+		trash:      make(map[string][]byte),
+		versions:   make(map[string]map[int][]byte),
+		executions: make(map[string]map[string][]byte),
+	}
+}
+
+// SavePlaybook stores a copy of a playbook.
+func (ms *MemStore) SavePlaybook(_ context.Context, pb *Playbook) error {
+	data, err := json.Marshal(pb)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.playbooks[pb.ID] = data
+	return nil
+}
+
+// GetPlaybook loads a playbook by ID.
+func (ms *MemStore) GetPlaybook(_ context.Context, id string) (*Playbook, error) {
+	ms.mu.RLock()
+	data, ok := ms.playbooks[id]
+	ms.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("playbook %s: %w", id, ErrNotFound)
+	}
+
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s: %w", id, err)
+	}
+	return &pb, nil
+}
+
+// ListPlaybooks returns all playbooks matching the filter, sorted as it asks.
+func (ms *MemStore) ListPlaybooks(_ context.Context, filter ListFilter) ([]*Playbook, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var playbooks []*Playbook
+	for _, data := range ms.playbooks {
+		var pb Playbook
+		if err := json.Unmarshal(data, &pb); err != nil {
+			continue
+		}
+		if !matchesFilter(&pb, filter) {
+			continue
+		}
+		playbooks = append(playbooks, &pb)
+	}
+
+	if err := sortPlaybooks(playbooks, filter); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(playbooks) > filter.Limit {
+		playbooks = playbooks[:filter.Limit]
+	}
+	return playbooks, nil
+}
+
+// CountPlaybooks returns the number of playbooks matching the filter.
+func (ms *MemStore) CountPlaybooks(ctx context.Context, filter ListFilter) (int, error) {
+	filter.Limit, filter.SortBy = 0, ""
+	playbooks, err := ms.ListPlaybooks(ctx, filter)
+	return len(playbooks), err
+}
+
+// PlaybookExists reports whether the store holds a playbook with id.
+func (ms *MemStore) PlaybookExists(_ context.Context, id string) (bool, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	_, ok := ms.playbooks[id]
+	return ok, nil
+}
+
+// ListPlaybookIDs returns the IDs of all playbooks, archived ones included,
+// sorted.
+func (ms *MemStore) ListPlaybookIDs(_ context.Context) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ids := make([]string, 0, len(ms.playbooks))
+	for id := range ms.playbooks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// DeletePlaybook removes a playbook with its executions and version history.
+func (ms *MemStore) DeletePlaybook(_ context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.playbooks, id)
+	delete(ms.executions, id)
+	delete(ms.versions, id)
+	return nil
+}
+
+// TrashPlaybook moves a playbook to the trash, stamped with deletedAt.
+func (ms *MemStore) TrashPlaybook(_ context.Context, id string, deletedAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	data, ok := ms.playbooks[id]
+	if !ok {
+		return fmt.Errorf("playbook %s: %w", id, ErrNotFound)
+	}
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return fmt.Errorf("unmarshal playbook %s: %w", id, err)
+	}
+	pb.DeletedAt = &deletedAt
+	trashed, err := json.Marshal(&pb)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	ms.trash[id] = trashed
+	delete(ms.playbooks, id)
+	return nil
+}
+
+// ListTrash returns the trashed playbooks, most recently deleted first.
+func (ms *MemStore) ListTrash(_ context.Context) ([]*Playbook, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var playbooks []*Playbook
+	for _, data := range ms.trash {
+		var pb Playbook
+		if err := json.Unmarshal(data, &pb); err != nil {
+			continue
+		}
+		playbooks = append(playbooks, &pb)
+	}
+	sortTrash(playbooks)
+	return playbooks, nil
+}
+
+// RestoreTrashedPlaybook moves a playbook out of the trash, clears DeletedAt,
+// and returns it.
+func (ms *MemStore) RestoreTrashedPlaybook(_ context.Context, id string) (*Playbook, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	data, ok := ms.trash[id]
+	if !ok {
+		return nil, fmt.Errorf("playbook %s: %w", id, ErrNotFound)
+	}
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s: %w", id, err)
+	}
+	pb.DeletedAt = nil
+	restored, err := json.Marshal(&pb)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	ms.playbooks[id] = restored
+	delete(ms.trash, id)
+	return &pb, nil
+}
+
+// PurgeTrashedPlaybook permanently removes a trashed playbook with its
+// executions and version history. A live playbook with the ID is untouched.
+func (ms *MemStore) PurgeTrashedPlaybook(_ context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.trash[id]; !ok {
+		return nil
+	}
+	delete(ms.trash, id)
+	delete(ms.executions, id)
+	delete(ms.versions, id)
+	return nil
+}
+
+// SavePlaybookVersion stores a snapshot of a playbook under its current version number.
+func (ms *MemStore) SavePlaybookVersion(_ context.Context, pb *Playbook) error {
+	data, err := json.Marshal(pb)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.versions[pb.ID] == nil {
+		ms.versions[pb.ID] = make(map[int][]byte)
+	}
+	ms.versions[pb.ID][pb.Version] = data
+	return nil
+}
+
+// GetPlaybookVersion loads a previously saved snapshot of a playbook.
+func (ms *MemStore) GetPlaybookVersion(_ context.Context, id string, version int) (*Playbook, error) {
+	ms.mu.RLock()
+	data, ok := ms.versions[id][version]
+	ms.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("playbook %s version %d: %w", id, version, ErrNotFound)
+	}
+
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s version %d: %w", id, version, err)
+	}
+	return &pb, nil
+}
+
+// SaveExecution stores a copy of an execution record.
+func (ms *MemStore) SaveExecution(_ context.Context, rec *ExecutionRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.executions[rec.PlaybookID] == nil {
+		ms.executions[rec.PlaybookID] = make(map[string][]byte)
+	}
+	ms.executions[rec.PlaybookID][rec.ID] = data
+	return nil
+}
+
+// ListExecutions returns recent executions for a playbook, newest first.
+func (ms *MemStore) ListExecutions(ctx context.Context, playbookID string, limit int) ([]*ExecutionRecord, error) {
+	return ms.ListExecutionsFiltered(ctx, playbookID, ExecutionFilter{Limit: limit})
+}
+
+// ListExecutionsFiltered returns executions for a playbook matching the filter, newest first.
+func (ms *MemStore) ListExecutionsFiltered(_ context.Context, playbookID string, filter ExecutionFilter) ([]*ExecutionRecord, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var records []*ExecutionRecord
+	for _, data := range ms.executions[playbookID] {
+		var rec ExecutionRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			continue
+		}
+		if !matchesExecutionFilter(&rec, filter) {
+			continue
+		}
+		records = append(records, &rec)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].StartedAt.After(records[j].StartedAt)
+	})
+
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+// DeleteExecution removes a single execution record.
+func (ms *MemStore) DeleteExecution(_ context.Context, playbookID, execID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.executions[playbookID], execID)
+	return nil
+}
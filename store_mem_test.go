@@ -0,0 +1,41 @@
+package playbookd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd/embed"
+)
+
+func TestMemStoreConformance(t *testing.T) {
+	runStoreConformance(t, func() Store { return NewMemStore() })
+}
+
+func TestManagerWithMemStore(t *testing.T) {
+	dir := t.TempDir()
+	ms := NewMemStore()
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:   dir,
+		Store:     ms,
+		EmbedFunc: embed.Noop(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	defer pm.Close()
+	ctx := context.Background()
+
+	pb := samplePlaybook("Memory Resident")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := ms.GetPlaybook(ctx, pb.ID); err != nil {
+		t.Errorf("playbook not in the injected store: %v", err)
+	}
+	if n := searchCount(t, pm, "resident"); n != 1 {
+		t.Errorf("search: %d results, want 1", n)
+	}
+}
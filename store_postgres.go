@@ -0,0 +1,534 @@
+package playbookd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+)
+
+// Compile-time check that PostgresStore implements Store.
+var _ Store = (*PostgresStore)(nil)
+
+// PostgresStore implements Store in a PostgreSQL database, for deployments
+// where several processes share one store. Each row keeps the full JSON
+// document, so unknown fields survive as with FileStore; the columns next to
+// it exist only so filters and ordering run in SQL.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// postgresSchema creates the tables on first use. Executions and versions are
+// deleted with their playbook by DeletePlaybook rather than by a foreign key,
+// matching FileStore, which accepts executions for any playbook ID.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS playbooks (
+	id         TEXT PRIMARY KEY,
+	category   TEXT NOT NULL DEFAULT '',
+	tags       TEXT[] NOT NULL DEFAULT '{}',
+	archived   BOOLEAN NOT NULL DEFAULT FALSE,
+	confidence DOUBLE PRECISION NOT NULL DEFAULT 0,
+	data       JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS playbooks_tags_idx ON playbooks USING GIN (tags);
+
+CREATE TABLE IF NOT EXISTS playbook_versions (
+	playbook_id TEXT NOT NULL,
+	version     INTEGER NOT NULL,
+	data        JSONB NOT NULL,
+	PRIMARY KEY (playbook_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS executions (
+	playbook_id TEXT NOT NULL,
+	id          TEXT NOT NULL,
+	outcome     TEXT NOT NULL DEFAULT '',
+	agent_id    TEXT NOT NULL DEFAULT '',
+	started_at  TIMESTAMPTZ NOT NULL,
+	data        JSONB NOT NULL,
+	PRIMARY KEY (playbook_id, id)
+);
+CREATE INDEX IF NOT EXISTS executions_started_idx ON executions (playbook_id, started_at DESC);
+
+CREATE TABLE IF NOT EXISTS playbook_trash (
+	id         TEXT PRIMARY KEY,
+	deleted_at TIMESTAMPTZ NOT NULL,
+	data       JSONB NOT NULL
+);
+`
+
+// playbookWhere returns the WHERE clause selecting the playbooks that match
+// filter, or "" for all of them, adding its parameters with arg.
+func playbookWhere(filter ListFilter, arg func(any) string) string {
+	var where []string
+	switch {
+	case filter.OnlyArchived:
+		where = append(where, "archived")
+	case !filter.IncludeArchived:
+		where = append(where, "NOT archived")
+	}
+	if filter.Category != "" {
+		where = append(where, "category = "+arg(filter.Category))
+	}
+	if len(filter.Tags) > 0 {
+		where = append(where, "tags @> "+arg(filter.Tags))
+	}
+	if len(where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(where, " AND ")
+}
+
+// CountPlaybooks returns the number of playbooks matching the filter,
+// counted in SQL.
+func (ps *PostgresStore) CountPlaybooks(ctx context.Context, filter ListFilter) (int, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	var n int
+	if err := ps.db.QueryRowContext(ctx, "SELECT count(*) FROM playbooks"+playbookWhere(filter, arg), args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count playbooks: %w", err)
+	}
+	return n, nil
+}
+
+// PlaybookExists reports whether the playbooks table has a row for id.
+func (ps *PostgresStore) PlaybookExists(ctx context.Context, id string) (bool, error) {
+	var ok bool
+	if err := ps.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM playbooks WHERE id = $1)`, id).Scan(&ok); err != nil {
+		return false, fmt.Errorf("check playbook %s: %w", id, err)
+	}
+	return ok, nil
+}
+
+// postgresSortColumns maps each SortField to the SQL expression it orders by.
+var postgresSortColumns = map[SortField]string{
+	"":                "confidence",
+	SortByConfidence:  "confidence",
+	SortByName:        "lower(data->>'name')",
+	SortByCreated:     "(data->>'created_at')::timestamptz",
+	SortByUpdated:     "(data->>'updated_at')::timestamptz",
+	SortBySuccessRate: "(data->>'success_rate')::double precision",
+	SortByExecutions:  "(data->>'success_count')::int + (data->>'failure_count')::int",
+}
+
+// NewPostgresStore connects to the database at dsn (a postgres:// URL or
+// key=value connection string) and creates the tables if they do not exist.
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create postgres schema: %w", err)
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the database connection pool.
+func (ps *PostgresStore) Close() error {
+	return ps.db.Close()
+}
+
+// SavePlaybook inserts or replaces a playbook.
+func (ps *PostgresStore) SavePlaybook(ctx context.Context, pb *Playbook) error {
+	data, err := json.Marshal(pb)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	tags := pb.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	_, err = ps.db.ExecContext(ctx, `
+		INSERT INTO playbooks (id, category, tags, archived, confidence, data)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			category = EXCLUDED.category, tags = EXCLUDED.tags, archived = EXCLUDED.archived,
+			confidence = EXCLUDED.confidence, data = EXCLUDED.data`,
+		pb.ID, pb.Category, tags, pb.Archived, pb.Confidence, data)
+	if err != nil {
+		return fmt.Errorf("save playbook %s: %w", pb.ID, err)
+	}
+	return nil
+}
+
+// GetPlaybook loads a playbook by ID.
+func (ps *PostgresStore) GetPlaybook(ctx context.Context, id string) (*Playbook, error) {
+	var data []byte
+	err := ps.db.QueryRowContext(ctx, `SELECT data FROM playbooks WHERE id = $1`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("playbook %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read playbook %s: %w", id, err)
+	}
+
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s: %w", id, err)
+	}
+	return &pb, nil
+}
+
+// ListPlaybooks returns all playbooks matching the filter, sorted as it asks.
+// The filter and sort are applied in SQL.
+func (ps *PostgresStore) ListPlaybooks(ctx context.Context, filter ListFilter) ([]*Playbook, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	query := "SELECT data FROM playbooks" + playbookWhere(filter, arg)
+	order, ok := postgresSortColumns[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", filter.SortBy)
+	}
+	dir := " DESC"
+	if filter.Ascending {
+		dir = " ASC"
+	}
+	query += " ORDER BY " + order + dir + ", lower(data->>'name'), id"
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list playbooks: %w", err)
+	}
+	defer rows.Close()
+
+	var playbooks []*Playbook
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("list playbooks: %w", err)
+		}
+		var pb Playbook
+		if err := json.Unmarshal(data, &pb); err != nil {
+			// Skip malformed documents, as FileStore skips malformed files.
+			continue
+		}
+		playbooks = append(playbooks, &pb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list playbooks: %w", err)
+	}
+	return playbooks, nil
+}
+
+// ListPlaybookIDs returns the IDs of all playbooks, archived ones included,
+// sorted.
+func (ps *PostgresStore) ListPlaybookIDs(ctx context.Context) ([]string, error) {
+	rows, err := ps.db.QueryContext(ctx, `SELECT id FROM playbooks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list playbook ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("list playbook ids: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list playbook ids: %w", err)
+	}
+	return ids, nil
+}
+
+// DeletePlaybook removes a playbook with its executions and version history.
+func (ps *PostgresStore) DeletePlaybook(ctx context.Context, id string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("delete playbook %s: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		`DELETE FROM executions WHERE playbook_id = $1`,
+		`DELETE FROM playbook_versions WHERE playbook_id = $1`,
+		`DELETE FROM playbooks WHERE id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return fmt.Errorf("delete playbook %s: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete playbook %s: %w", id, err)
+	}
+	return nil
+}
+
+// TrashPlaybook moves a playbook to the playbook_trash table, stamped with
+// deletedAt.
+func (ps *PostgresStore) TrashPlaybook(ctx context.Context, id string, deletedAt time.Time) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("trash playbook %s: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	var data []byte
+	err = tx.QueryRowContext(ctx, `DELETE FROM playbooks WHERE id = $1 RETURNING data`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("playbook %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("trash playbook %s: %w", id, err)
+	}
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return fmt.Errorf("unmarshal playbook %s: %w", id, err)
+	}
+	pb.DeletedAt = &deletedAt
+	if data, err = json.Marshal(&pb); err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO playbook_trash (id, deleted_at, data) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at, data = EXCLUDED.data`,
+		id, deletedAt, data)
+	if err != nil {
+		return fmt.Errorf("trash playbook %s: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("trash playbook %s: %w", id, err)
+	}
+	return nil
+}
+
+// ListTrash returns the trashed playbooks, most recently deleted first.
+func (ps *PostgresStore) ListTrash(ctx context.Context) ([]*Playbook, error) {
+	rows, err := ps.db.QueryContext(ctx, `SELECT data FROM playbook_trash ORDER BY deleted_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+	defer rows.Close()
+
+	var playbooks []*Playbook
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("list trash: %w", err)
+		}
+		var pb Playbook
+		if err := json.Unmarshal(data, &pb); err != nil {
+			continue
+		}
+		playbooks = append(playbooks, &pb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+	return playbooks, nil
+}
+
+// RestoreTrashedPlaybook moves a playbook out of the trash, clears DeletedAt,
+// and returns it.
+func (ps *PostgresStore) RestoreTrashedPlaybook(ctx context.Context, id string) (*Playbook, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("restore playbook %s: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	var data []byte
+	err = tx.QueryRowContext(ctx, `DELETE FROM playbook_trash WHERE id = $1 RETURNING data`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("playbook %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("restore playbook %s: %w", id, err)
+	}
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s: %w", id, err)
+	}
+	pb.DeletedAt = nil
+	if data, err = json.Marshal(&pb); err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	tags := pb.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO playbooks (id, category, tags, archived, confidence, data)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		pb.ID, pb.Category, tags, pb.Archived, pb.Confidence, data)
+	if err != nil {
+		return nil, fmt.Errorf("restore playbook %s: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("restore playbook %s: %w", id, err)
+	}
+	return &pb, nil
+}
+
+// PurgeTrashedPlaybook permanently removes a trashed playbook with its
+// executions and version history. A live playbook with the ID is untouched.
+func (ps *PostgresStore) PurgeTrashedPlaybook(ctx context.Context, id string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("purge playbook %s: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM playbook_trash WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("purge playbook %s: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	for _, stmt := range []string{
+		`DELETE FROM executions WHERE playbook_id = $1`,
+		`DELETE FROM playbook_versions WHERE playbook_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return fmt.Errorf("purge playbook %s: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("purge playbook %s: %w", id, err)
+	}
+	return nil
+}
+
+// SavePlaybookVersion stores a snapshot of a playbook under its current version number.
+func (ps *PostgresStore) SavePlaybookVersion(ctx context.Context, pb *Playbook) error {
+	data, err := json.Marshal(pb)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	_, err = ps.db.ExecContext(ctx, `
+		INSERT INTO playbook_versions (playbook_id, version, data) VALUES ($1, $2, $3)
+		ON CONFLICT (playbook_id, version) DO UPDATE SET data = EXCLUDED.data`,
+		pb.ID, pb.Version, data)
+	if err != nil {
+		return fmt.Errorf("save playbook %s version %d: %w", pb.ID, pb.Version, err)
+	}
+	return nil
+}
+
+// GetPlaybookVersion loads a previously saved snapshot of a playbook.
+func (ps *PostgresStore) GetPlaybookVersion(ctx context.Context, id string, version int) (*Playbook, error) {
+	var data []byte
+	err := ps.db.QueryRowContext(ctx,
+		`SELECT data FROM playbook_versions WHERE playbook_id = $1 AND version = $2`, id, version).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("playbook %s version %d: %w", id, version, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read playbook %s version %d: %w", id, version, err)
+	}
+
+	var pb Playbook
+	if err := json.Unmarshal(data, &pb); err != nil {
+		return nil, fmt.Errorf("unmarshal playbook %s version %d: %w", id, version, err)
+	}
+	return &pb, nil
+}
+
+// SaveExecution inserts or replaces an execution record.
+func (ps *PostgresStore) SaveExecution(ctx context.Context, rec *ExecutionRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	_, err = ps.db.ExecContext(ctx, `
+		INSERT INTO executions (playbook_id, id, outcome, agent_id, started_at, data)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (playbook_id, id) DO UPDATE SET
+			outcome = EXCLUDED.outcome, agent_id = EXCLUDED.agent_id,
+			started_at = EXCLUDED.started_at, data = EXCLUDED.data`,
+		rec.PlaybookID, rec.ID, string(rec.Outcome), rec.AgentID, rec.StartedAt, data)
+	if err != nil {
+		return fmt.Errorf("save execution %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// ListExecutions returns recent executions for a playbook, newest first.
+func (ps *PostgresStore) ListExecutions(ctx context.Context, playbookID string, limit int) ([]*ExecutionRecord, error) {
+	return ps.ListExecutionsFiltered(ctx, playbookID, ExecutionFilter{Limit: limit})
+}
+
+// ListExecutionsFiltered returns executions for a playbook matching the
+// filter, newest first. The filter is applied in SQL.
+func (ps *PostgresStore) ListExecutionsFiltered(ctx context.Context, playbookID string, filter ExecutionFilter) ([]*ExecutionRecord, error) {
+	args := []any{playbookID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := []string{"playbook_id = $1"}
+	if filter.Outcome != "" {
+		where = append(where, "outcome = "+arg(string(filter.Outcome)))
+	}
+	if filter.AgentID != "" {
+		where = append(where, "agent_id = "+arg(filter.AgentID))
+	}
+	if !filter.StartedAfter.IsZero() {
+		where = append(where, "started_at > "+arg(filter.StartedAfter))
+	}
+	if !filter.StartedBefore.IsZero() {
+		where = append(where, "started_at < "+arg(filter.StartedBefore))
+	}
+
+	query := "SELECT data FROM executions WHERE " + strings.Join(where, " AND ") + " ORDER BY started_at DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list executions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*ExecutionRecord
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("list executions: %w", err)
+		}
+		var rec ExecutionRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			// Skip malformed documents, as FileStore skips malformed files.
+			continue
+		}
+		records = append(records, &rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list executions: %w", err)
+	}
+	return records, nil
+}
+
+// DeleteExecution removes a single execution record.
+func (ps *PostgresStore) DeleteExecution(ctx context.Context, playbookID, execID string) error {
+	_, err := ps.db.ExecContext(ctx, `DELETE FROM executions WHERE playbook_id = $1 AND id = $2`, playbookID, execID)
+	if err != nil {
+		return fmt.Errorf("delete execution %s: %w", execID, err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package playbookd
+
+import (
+	"os"
+	"testing"
+)
+
+// TestPostgresStoreConformance runs against the database in
+// PLAYBOOKD_TEST_POSTGRES_DSN and is skipped when it is unset. The tables are
+// emptied before each subtest.
+func TestPostgresStoreConformance(t *testing.T) {
+	dsn := os.Getenv("PLAYBOOKD_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PLAYBOOKD_TEST_POSTGRES_DSN not set")
+	}
+	ps, err := NewPostgresStore(dsn)
+	if err != nil {
+		t.Fatalf("NewPostgresStore: %v", err)
+	}
+	defer ps.Close()
+
+	runStoreConformance(t, func() Store {
+		if _, err := ps.db.Exec(`TRUNCATE playbooks, playbook_versions, executions, playbook_trash`); err != nil {
+			panic(err)
+		}
+		return ps
+	})
+}
@@ -2,6 +2,12 @@ package playbookd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -32,278 +38,666 @@ func TestNewFileStore(t *testing.T) {
 	}
 }
 
-func TestFileStoreSaveAndGetPlaybook(t *testing.T) {
+func TestFileStoreConformance(t *testing.T) {
+	runStoreConformance(t, func() Store {
+		fs, err := NewFileStore(t.TempDir())
+		if err != nil {
+			panic(err) // newStore runs on subtest goroutines, where t.Fatal is not allowed
+		}
+		return fs
+	})
+}
+
+func TestFileStoreConformanceSeparateEmbeddings(t *testing.T) {
+	runStoreConformance(t, func() Store {
+		fs, err := NewFileStore(t.TempDir(), FileStoreOptions{SeparateEmbeddings: true})
+		if err != nil {
+			panic(err)
+		}
+		return fs
+	})
+}
+
+func TestFileStoreSeparateEmbeddings(t *testing.T) {
+	ctx := context.Background()
 	dir := t.TempDir()
-	fs, err := NewFileStore(dir)
+	inline, err := NewFileStore(dir)
 	if err != nil {
 		t.Fatalf("NewFileStore: %v", err)
 	}
+	vec := []float32{0.25, -1.5, 3}
+	for _, id := range []string{"pb-1", "pb-2"} {
+		pb := newTestPlaybook(id, "Playbook "+id)
+		pb.Embedding = vec
+		if err := inline.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("SavePlaybook: %v", err)
+		}
+	}
+	if err := inline.TrashPlaybook(ctx, "pb-2", time.Now()); err != nil {
+		t.Fatalf("TrashPlaybook: %v", err)
+	}
 
-	ctx := context.Background()
-	pb := newTestPlaybook("pb-001", "My Playbook")
+	// The migration moves inline embeddings, live and trashed, into sidecars.
+	fs, err := NewFileStore(dir, FileStoreOptions{SeparateEmbeddings: true})
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	n, err := fs.SplitEmbeddings(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("SplitEmbeddings = %d, %v; want 2 playbooks split", n, err)
+	}
+	if n, err := fs.SplitEmbeddings(ctx); err != nil || n != 0 {
+		t.Errorf("second SplitEmbeddings = %d, %v; want nothing left to split", n, err)
+	}
+	data, err := os.ReadFile(fs.playbookPath("pb-1"))
+	if err != nil {
+		t.Fatalf("read playbook file: %v", err)
+	}
+	if strings.Contains(string(data), "embedding\"") {
+		t.Errorf("playbook JSON still holds the embedding: %s", data)
+	}
 
-	if err := fs.SavePlaybook(ctx, pb); err != nil {
-		t.Fatalf("SavePlaybook: %v", err)
+	got, err := fs.GetPlaybook(ctx, "pb-1")
+	if err != nil || !reflect.DeepEqual(got.Embedding, vec) {
+		t.Errorf("GetPlaybook embedding = %v, %v; want %v", got.Embedding, err, vec)
+	}
+	listed, err := fs.ListPlaybooks(ctx, ListFilter{})
+	if err != nil || len(listed) != 1 || !reflect.DeepEqual(listed[0].Embedding, vec) {
+		t.Errorf("ListPlaybooks = %v, %v; want pb-1 with its embedding", listed, err)
+	}
+	restored, err := fs.RestoreTrashedPlaybook(ctx, "pb-2")
+	if err != nil || !reflect.DeepEqual(restored.Embedding, vec) {
+		t.Errorf("RestoreTrashedPlaybook embedding = %v, %v; want %v", restored.Embedding, err, vec)
+	}
+	if _, err := os.Stat(embeddingPath(fs.trashPath("pb-2"))); !os.IsNotExist(err) {
+		t.Errorf("trash sidecar left behind after restore: %v", err)
 	}
 
-	got, err := fs.GetPlaybook(ctx, "pb-001")
+	// Saving with the option off moves the embedding back inline.
+	pb, err := inline.GetPlaybook(ctx, "pb-1")
 	if err != nil {
 		t.Fatalf("GetPlaybook: %v", err)
 	}
+	if err := inline.SavePlaybook(ctx, pb); err != nil {
+		t.Fatalf("SavePlaybook: %v", err)
+	}
+	if _, err := os.Stat(embeddingPath(fs.playbookPath("pb-1"))); !os.IsNotExist(err) {
+		t.Errorf("sidecar left behind after an inline save: %v", err)
+	}
 
-	if got.ID != pb.ID {
-		t.Errorf("ID = %q, want %q", got.ID, pb.ID)
+	if err := fs.DeletePlaybook(ctx, "pb-2"); err != nil {
+		t.Fatalf("DeletePlaybook: %v", err)
 	}
-	if got.Name != pb.Name {
-		t.Errorf("Name = %q, want %q", got.Name, pb.Name)
+	if _, err := os.Stat(embeddingPath(fs.playbookPath("pb-2"))); !os.IsNotExist(err) {
+		t.Errorf("sidecar left behind after delete: %v", err)
 	}
 }
 
-func TestFileStoreGetPlaybookNotFound(t *testing.T) {
-	dir := t.TempDir()
-	fs, _ := NewFileStore(dir)
-	ctx := context.Background()
-
-	_, err := fs.GetPlaybook(ctx, "does-not-exist")
-	if err == nil {
-		t.Fatal("expected error for missing playbook, got nil")
+// BenchmarkFileStoreListPlaybooks lists 200 playbooks with 1536-dimension
+// embeddings stored inline and in sidecar files.
+func BenchmarkFileStoreListPlaybooks(b *testing.B) {
+	for _, separate := range []bool{false, true} {
+		name := "inline"
+		if separate {
+			name = "separate"
+		}
+		b.Run(name, func(b *testing.B) {
+			ctx := context.Background()
+			fs, err := NewFileStore(b.TempDir(), FileStoreOptions{SeparateEmbeddings: separate})
+			if err != nil {
+				b.Fatalf("NewFileStore: %v", err)
+			}
+			vec := make([]float32, 1536)
+			for i := range vec {
+				vec[i] = float32(i) / 1536
+			}
+			for i := range 200 {
+				pb := newTestPlaybook(fmt.Sprintf("pb-%d", i), fmt.Sprintf("Playbook %d", i))
+				pb.Embedding = vec
+				if err := fs.SavePlaybook(ctx, pb); err != nil {
+					b.Fatalf("SavePlaybook: %v", err)
+				}
+			}
+			b.ResetTimer()
+			for range b.N {
+				if _, err := fs.ListPlaybooks(ctx, ListFilter{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
 	}
 }
 
-func TestFileStoreSavePlaybookOverwrites(t *testing.T) {
+func TestFileStorePreservesUnknownFields(t *testing.T) {
 	dir := t.TempDir()
-	fs, _ := NewFileStore(dir)
-	ctx := context.Background()
+	fs, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
 
-	pb := newTestPlaybook("pb-001", "Original")
-	if err := fs.SavePlaybook(ctx, pb); err != nil {
-		t.Fatalf("setup: %v", err)
+	ctx := context.Background()
+	if err := fs.SavePlaybook(ctx, newTestPlaybook("pb-001", "My Playbook")); err != nil {
+		t.Fatalf("SavePlaybook: %v", err)
 	}
 
-	pb.Name = "Updated"
-	if err := fs.SavePlaybook(ctx, pb); err != nil {
-		t.Fatalf("setup: %v", err)
+	// Simulate a newer binary having written a field this version doesn't know.
+	path := fs.playbookPath("pb-001")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read playbook: %v", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	raw["future_field"] = map[string]any{"enabled": true}
+	data, _ = json.Marshal(raw)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write playbook: %v", err)
 	}
 
 	got, err := fs.GetPlaybook(ctx, "pb-001")
 	if err != nil {
 		t.Fatalf("GetPlaybook: %v", err)
 	}
-	if got.Name != "Updated" {
-		t.Errorf("Name = %q, want %q", got.Name, "Updated")
+	got.Name = "Renamed"
+	if err := fs.SavePlaybook(ctx, got); err != nil {
+		t.Fatalf("SavePlaybook: %v", err)
+	}
+
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read playbook: %v", err)
+	}
+	raw = nil
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if raw["name"] != "Renamed" {
+		t.Errorf("name = %v, want %q", raw["name"], "Renamed")
+	}
+	future, ok := raw["future_field"].(map[string]any)
+	if !ok || future["enabled"] != true {
+		t.Errorf("future_field = %v, want {enabled: true}", raw["future_field"])
 	}
 }
 
-func TestFileStoreListPlaybooks(t *testing.T) {
-	dir := t.TempDir()
-	fs, _ := NewFileStore(dir)
+// runStoreConformance checks the behavior every Store implementation must
+// share. newStore is called once per subtest and must return an empty store;
+// it runs on the subtest's goroutine, so it should panic rather than call
+// t.Fatal on failure.
+func runStoreConformance(t *testing.T, newStore func() Store) {
 	ctx := context.Background()
 
-	pbs := []*Playbook{
-		{ID: "a", Name: "Alpha", Category: "ops", Tags: []string{"tag1"}, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{ID: "b", Name: "Beta", Category: "ops", Tags: []string{"tag1", "tag2"}, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{ID: "c", Name: "Gamma", Category: "dev", Tags: []string{"tag2"}, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{ID: "d", Name: "Delta", Category: "ops", Archived: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-	}
-	for _, pb := range pbs {
-		if err := fs.SavePlaybook(ctx, pb); err != nil {
+	t.Run("save and get playbook", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-001", "My Playbook")
+		if err := st.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("SavePlaybook: %v", err)
+		}
+
+		got, err := st.GetPlaybook(ctx, "pb-001")
+		if err != nil {
+			t.Fatalf("GetPlaybook: %v", err)
+		}
+		if got.ID != pb.ID {
+			t.Errorf("ID = %q, want %q", got.ID, pb.ID)
+		}
+		if got.Name != pb.Name {
+			t.Errorf("Name = %q, want %q", got.Name, pb.Name)
+		}
+		if strings.Join(got.Tags, ",") != strings.Join(pb.Tags, ",") {
+			t.Errorf("Tags = %v, want %v", got.Tags, pb.Tags)
+		}
+
+		// The store keeps its own copy.
+		got.Name = "Mutated"
+		again, err := st.GetPlaybook(ctx, "pb-001")
+		if err != nil {
+			t.Fatalf("GetPlaybook: %v", err)
+		}
+		if again.Name != pb.Name {
+			t.Errorf("after mutating a returned playbook, Name = %q, want %q", again.Name, pb.Name)
+		}
+	})
+
+	t.Run("get missing playbook", func(t *testing.T) {
+		st := newStore()
+		if _, err := st.GetPlaybook(ctx, "does-not-exist"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPlaybook error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("save overwrites", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-001", "Original")
+		if err := st.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		pb.Name = "Updated"
+		if err := st.SavePlaybook(ctx, pb); err != nil {
 			t.Fatalf("setup: %v", err)
 		}
-	}
 
-	t.Run("no filter returns non-archived", func(t *testing.T) {
-		results, err := fs.ListPlaybooks(ctx, ListFilter{})
+		got, err := st.GetPlaybook(ctx, "pb-001")
 		if err != nil {
-			t.Fatalf("ListPlaybooks: %v", err)
+			t.Fatalf("GetPlaybook: %v", err)
+		}
+		if got.Name != "Updated" {
+			t.Errorf("Name = %q, want %q", got.Name, "Updated")
+		}
+	})
+
+	t.Run("list playbooks", func(t *testing.T) {
+		st := newStore()
+		now := time.Now()
+		pbs := []*Playbook{
+			{ID: "a", Name: "Alpha", Category: "ops", Tags: []string{"tag1"}, Confidence: 0.5, CreatedAt: now.Add(-4 * time.Hour), UpdatedAt: now},
+			{ID: "b", Name: "beta", Category: "ops", Tags: []string{"tag1", "tag2"}, Confidence: 0.9, SuccessCount: 3, SuccessRate: 1, CreatedAt: now.Add(-3 * time.Hour), UpdatedAt: now},
+			{ID: "c", Name: "Gamma", Category: "dev", Tags: []string{"tag2"}, Confidence: 0.1, FailureCount: 2, CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now},
+			{ID: "d", Name: "Delta", Category: "ops", Archived: true, Confidence: 0.7, CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
 		}
-		if len(results) != 3 {
-			t.Errorf("got %d playbooks, want 3", len(results))
+		for _, pb := range pbs {
+			if err := st.SavePlaybook(ctx, pb); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+		}
+
+		tests := []struct {
+			name   string
+			filter ListFilter
+			want   []string
+		}{
+			{"no filter returns non-archived by confidence", ListFilter{}, []string{"b", "a", "c"}},
+			{"include archived returns all", ListFilter{IncludeArchived: true}, []string{"b", "d", "a", "c"}},
+			{"only archived", ListFilter{OnlyArchived: true}, []string{"d"}},
+			{"only archived with other filters", ListFilter{OnlyArchived: true, Category: "dev"}, nil},
+			{"filter by category", ListFilter{Category: "ops"}, []string{"b", "a"}},
+			{"filter by tags", ListFilter{Tags: []string{"tag1", "tag2"}}, []string{"b"}},
+			{"limit results", ListFilter{Limit: 2}, []string{"b", "a"}},
+			{"limit applies after filtering", ListFilter{Tags: []string{"tag2"}, Limit: 1}, []string{"b"}},
+			{"sort by name", ListFilter{SortBy: SortByName, Ascending: true}, []string{"a", "b", "c"}},
+			{"sort by name descending", ListFilter{SortBy: SortByName}, []string{"c", "b", "a"}},
+			{"sort by executions", ListFilter{SortBy: SortByExecutions}, []string{"b", "c", "a"}},
+			{"ties sort by name", ListFilter{SortBy: SortByExecutions, Ascending: true}, []string{"a", "c", "b"}},
+			{"sort by created", ListFilter{SortBy: SortByCreated, Ascending: true, IncludeArchived: true}, []string{"a", "b", "c", "d"}},
+			{"limit applies after sorting", ListFilter{SortBy: SortBySuccessRate, Limit: 2}, []string{"b", "a"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				results, err := st.ListPlaybooks(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("ListPlaybooks: %v", err)
+				}
+				var got []string
+				for _, pb := range results {
+					got = append(got, pb.ID)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+				if tt.filter.Limit > 0 {
+					return
+				}
+				if n, err := st.CountPlaybooks(ctx, tt.filter); err != nil || n != len(tt.want) {
+					t.Errorf("CountPlaybooks = %d, %v; want %d", n, err, len(tt.want))
+				}
+			})
+		}
+
+		if _, err := st.ListPlaybooks(ctx, ListFilter{SortBy: "size"}); err == nil {
+			t.Error("ListPlaybooks with an unknown sort field should fail")
 		}
 	})
 
-	t.Run("include archived returns all", func(t *testing.T) {
-		results, err := fs.ListPlaybooks(ctx, ListFilter{IncludeArchived: true})
+	t.Run("list empty store", func(t *testing.T) {
+		st := newStore()
+		results, err := st.ListPlaybooks(ctx, ListFilter{})
 		if err != nil {
 			t.Fatalf("ListPlaybooks: %v", err)
 		}
-		if len(results) != 4 {
-			t.Errorf("got %d playbooks, want 4", len(results))
+		if len(results) != 0 {
+			t.Errorf("got %d playbooks, want 0", len(results))
+		}
+	})
+
+	t.Run("playbook exists", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-1", "Exists")
+		pb.Archived = true
+		if err := st.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		for id, want := range map[string]bool{"pb-1": true, "pb-2": false} {
+			if ok, err := st.PlaybookExists(ctx, id); ok != want || err != nil {
+				t.Errorf("PlaybookExists(%s) = %v, %v; want %v", id, ok, err, want)
+			}
 		}
 	})
 
-	t.Run("filter by category", func(t *testing.T) {
-		results, err := fs.ListPlaybooks(ctx, ListFilter{Category: "ops"})
+	t.Run("list playbook ids", func(t *testing.T) {
+		st := newStore()
+		for _, id := range []string{"pb-b", "pb-a"} {
+			pb := newTestPlaybook(id, id)
+			pb.Archived = id == "pb-b"
+			if err := st.SavePlaybook(ctx, pb); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+		}
+		ids, err := st.ListPlaybookIDs(ctx)
 		if err != nil {
-			t.Fatalf("ListPlaybooks: %v", err)
+			t.Fatalf("ListPlaybookIDs: %v", err)
 		}
-		if len(results) != 2 {
-			t.Errorf("got %d playbooks, want 2", len(results))
+		if !reflect.DeepEqual(ids, []string{"pb-a", "pb-b"}) {
+			t.Errorf("ListPlaybookIDs = %v, want [pb-a pb-b]", ids)
 		}
 	})
 
-	t.Run("filter by tags", func(t *testing.T) {
-		results, err := fs.ListPlaybooks(ctx, ListFilter{Tags: []string{"tag1", "tag2"}})
+	t.Run("versions", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-ver", "Versioned")
+		if err := st.SavePlaybookVersion(ctx, pb); err != nil {
+			t.Fatalf("SavePlaybookVersion: %v", err)
+		}
+		pb.Version = 2
+		pb.Name = "Versioned Again"
+		if err := st.SavePlaybookVersion(ctx, pb); err != nil {
+			t.Fatalf("SavePlaybookVersion: %v", err)
+		}
+
+		got, err := st.GetPlaybookVersion(ctx, "pb-ver", 1)
 		if err != nil {
-			t.Fatalf("ListPlaybooks: %v", err)
+			t.Fatalf("GetPlaybookVersion: %v", err)
 		}
-		if len(results) != 1 {
-			t.Errorf("got %d playbooks, want 1", len(results))
+		if got.Name != "Versioned" || got.Version != 1 {
+			t.Errorf("version 1 = %q v%d, want %q v1", got.Name, got.Version, "Versioned")
 		}
-		if results[0].ID != "b" {
-			t.Errorf("got playbook %q, want %q", results[0].ID, "b")
+		if _, err := st.GetPlaybookVersion(ctx, "pb-ver", 3); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPlaybookVersion(3) error = %v, want ErrNotFound", err)
 		}
 	})
 
-	t.Run("limit results", func(t *testing.T) {
-		results, err := fs.ListPlaybooks(ctx, ListFilter{Limit: 2})
+	t.Run("delete playbook", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-del", "To Delete")
+		if err := st.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		if err := st.DeletePlaybook(ctx, "pb-del"); err != nil {
+			t.Fatalf("DeletePlaybook: %v", err)
+		}
+		if _, err := st.GetPlaybook(ctx, "pb-del"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPlaybook after delete: error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("delete missing playbook", func(t *testing.T) {
+		st := newStore()
+		// Deleting a non-existent playbook should not error.
+		if err := st.DeletePlaybook(ctx, "ghost"); err != nil {
+			t.Errorf("expected no error for non-existent delete, got: %v", err)
+		}
+	})
+
+	t.Run("delete removes executions and versions", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-cleanup", "Cleanup")
+		if err := st.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		if err := st.SavePlaybookVersion(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		rec := &ExecutionRecord{ID: "exec-cleanup", PlaybookID: "pb-cleanup", Outcome: OutcomeSuccess, StartedAt: time.Now()}
+		if err := st.SaveExecution(ctx, rec); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		other := &ExecutionRecord{ID: "exec-other", PlaybookID: "pb-other", Outcome: OutcomeSuccess, StartedAt: time.Now()}
+		if err := st.SaveExecution(ctx, other); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+
+		if err := st.DeletePlaybook(ctx, "pb-cleanup"); err != nil {
+			t.Fatalf("DeletePlaybook: %v", err)
+		}
+
+		results, err := st.ListExecutions(ctx, "pb-cleanup", 0)
 		if err != nil {
-			t.Fatalf("ListPlaybooks: %v", err)
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("expected 0 executions after delete, got %d", len(results))
 		}
-		if len(results) != 2 {
-			t.Errorf("got %d playbooks, want 2", len(results))
+		if _, err := st.GetPlaybookVersion(ctx, "pb-cleanup", pb.Version); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPlaybookVersion after delete: error = %v, want ErrNotFound", err)
+		}
+		// Other playbooks' executions are untouched.
+		if results, _ := st.ListExecutions(ctx, "pb-other", 0); len(results) != 1 {
+			t.Errorf("got %d executions for another playbook, want 1", len(results))
 		}
 	})
-}
 
-func TestFileStoreDeletePlaybook(t *testing.T) {
-	dir := t.TempDir()
-	fs, _ := NewFileStore(dir)
-	ctx := context.Background()
+	t.Run("trash and restore playbook", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-trash", "Trashed")
+		if err := st.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		rec := &ExecutionRecord{ID: "exec-trash", PlaybookID: "pb-trash", Outcome: OutcomeSuccess, StartedAt: time.Now()}
+		if err := st.SaveExecution(ctx, rec); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
 
-	pb := newTestPlaybook("pb-del", "To Delete")
-	if err := fs.SavePlaybook(ctx, pb); err != nil {
-		t.Fatalf("setup: %v", err)
-	}
+		deletedAt := time.Now().Truncate(time.Second)
+		if err := st.TrashPlaybook(ctx, "pb-trash", deletedAt); err != nil {
+			t.Fatalf("TrashPlaybook: %v", err)
+		}
+		if _, err := st.GetPlaybook(ctx, "pb-trash"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPlaybook after trash: error = %v, want ErrNotFound", err)
+		}
+		if listed, _ := st.ListPlaybooks(ctx, ListFilter{IncludeArchived: true}); len(listed) != 0 {
+			t.Errorf("ListPlaybooks after trash = %d playbooks, want 0", len(listed))
+		}
+		if ok, err := st.PlaybookExists(ctx, "pb-trash"); ok || err != nil {
+			t.Errorf("PlaybookExists after trash = %v, %v; want false", ok, err)
+		}
+		trash, err := st.ListTrash(ctx)
+		if err != nil {
+			t.Fatalf("ListTrash: %v", err)
+		}
+		if len(trash) != 1 || trash[0].ID != "pb-trash" || trash[0].DeletedAt == nil || !trash[0].DeletedAt.Equal(deletedAt) {
+			t.Fatalf("ListTrash = %+v, want pb-trash deleted at %v", trash, deletedAt)
+		}
+		if err := st.TrashPlaybook(ctx, "pb-trash", deletedAt); !errors.Is(err, ErrNotFound) {
+			t.Errorf("TrashPlaybook twice: error = %v, want ErrNotFound", err)
+		}
 
-	if err := fs.DeletePlaybook(ctx, "pb-del"); err != nil {
-		t.Fatalf("DeletePlaybook: %v", err)
-	}
+		restored, err := st.RestoreTrashedPlaybook(ctx, "pb-trash")
+		if err != nil {
+			t.Fatalf("RestoreTrashedPlaybook: %v", err)
+		}
+		if restored.DeletedAt != nil || restored.Name != "Trashed" {
+			t.Errorf("restored = %+v, want Trashed with DeletedAt cleared", restored)
+		}
+		if got, err := st.GetPlaybook(ctx, "pb-trash"); err != nil || got.DeletedAt != nil {
+			t.Errorf("GetPlaybook after restore = %+v, %v", got, err)
+		}
+		if results, _ := st.ListExecutions(ctx, "pb-trash", 0); len(results) != 1 {
+			t.Errorf("got %d executions after restore, want 1", len(results))
+		}
+		if _, err := st.RestoreTrashedPlaybook(ctx, "pb-trash"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RestoreTrashedPlaybook twice: error = %v, want ErrNotFound", err)
+		}
+	})
 
-	_, err := fs.GetPlaybook(ctx, "pb-del")
-	if err == nil {
-		t.Error("expected error after deleting, got nil")
-	}
-}
+	t.Run("purge trashed playbook", func(t *testing.T) {
+		st := newStore()
+		pb := newTestPlaybook("pb-purge", "Purged")
+		if err := st.SavePlaybook(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		if err := st.SavePlaybookVersion(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		rec := &ExecutionRecord{ID: "exec-purge", PlaybookID: "pb-purge", Outcome: OutcomeSuccess, StartedAt: time.Now()}
+		if err := st.SaveExecution(ctx, rec); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
 
-func TestFileStoreDeletePlaybookNotExist(t *testing.T) {
-	dir := t.TempDir()
-	fs, _ := NewFileStore(dir)
-	ctx := context.Background()
+		// Purging a live playbook is a no-op.
+		if err := st.PurgeTrashedPlaybook(ctx, "pb-purge"); err != nil {
+			t.Fatalf("PurgeTrashedPlaybook (live): %v", err)
+		}
+		if _, err := st.GetPlaybook(ctx, "pb-purge"); err != nil {
+			t.Fatalf("PurgeTrashedPlaybook removed a live playbook: %v", err)
+		}
 
-	// Deleting a non-existent playbook should not error.
-	if err := fs.DeletePlaybook(ctx, "ghost"); err != nil {
-		t.Errorf("expected no error for non-existent delete, got: %v", err)
-	}
-}
+		if err := st.TrashPlaybook(ctx, "pb-purge", time.Now()); err != nil {
+			t.Fatalf("TrashPlaybook: %v", err)
+		}
+		if err := st.PurgeTrashedPlaybook(ctx, "pb-purge"); err != nil {
+			t.Fatalf("PurgeTrashedPlaybook: %v", err)
+		}
+		if trash, _ := st.ListTrash(ctx); len(trash) != 0 {
+			t.Errorf("ListTrash after purge = %d playbooks, want 0", len(trash))
+		}
+		if results, _ := st.ListExecutions(ctx, "pb-purge", 0); len(results) != 0 {
+			t.Errorf("got %d executions after purge, want 0", len(results))
+		}
+		if _, err := st.GetPlaybookVersion(ctx, "pb-purge", pb.Version); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPlaybookVersion after purge: error = %v, want ErrNotFound", err)
+		}
+	})
 
-func TestFileStoreSaveAndListExecutions(t *testing.T) {
-	dir := t.TempDir()
-	fs, _ := NewFileStore(dir)
-	ctx := context.Background()
+	t.Run("save and list executions", func(t *testing.T) {
+		st := newStore()
+		base := time.Now()
+		recs := []*ExecutionRecord{
+			{ID: "exec-1", PlaybookID: "pb-exec", PlaybookVer: 1, Outcome: OutcomeSuccess, StartedAt: base.Add(-2 * time.Hour), CompletedAt: base.Add(-2*time.Hour + 5*time.Minute)},
+			{ID: "exec-2", PlaybookID: "pb-exec", PlaybookVer: 1, Outcome: OutcomeFailure, StartedAt: base.Add(-1 * time.Hour), CompletedAt: base.Add(-1*time.Hour + 3*time.Minute)},
+			{ID: "exec-3", PlaybookID: "pb-exec", PlaybookVer: 1, Outcome: OutcomeSuccess, StartedAt: base, CompletedAt: base.Add(4 * time.Minute)},
+		}
+		for _, rec := range recs {
+			if err := st.SaveExecution(ctx, rec); err != nil {
+				t.Fatalf("SaveExecution %s: %v", rec.ID, err)
+			}
+		}
 
-	pb := newTestPlaybook("pb-exec", "Exec Playbook")
-	if err := fs.SavePlaybook(ctx, pb); err != nil {
-		t.Fatalf("setup: %v", err)
-	}
-
-	base := time.Now()
-	recs := []*ExecutionRecord{
-		{
-			ID:          "exec-1",
-			PlaybookID:  "pb-exec",
-			PlaybookVer: 1,
-			Outcome:     OutcomeSuccess,
-			StartedAt:   base.Add(-2 * time.Hour),
-			CompletedAt: base.Add(-2*time.Hour + 5*time.Minute),
-		},
-		{
-			ID:          "exec-2",
-			PlaybookID:  "pb-exec",
-			PlaybookVer: 1,
-			Outcome:     OutcomeFailure,
-			StartedAt:   base.Add(-1 * time.Hour),
-			CompletedAt: base.Add(-1*time.Hour + 3*time.Minute),
-		},
-		{
-			ID:          "exec-3",
-			PlaybookID:  "pb-exec",
-			PlaybookVer: 1,
-			Outcome:     OutcomeSuccess,
-			StartedAt:   base,
-			CompletedAt: base.Add(4 * time.Minute),
-		},
-	}
-	for _, rec := range recs {
-		if err := fs.SaveExecution(ctx, rec); err != nil {
-			t.Fatalf("SaveExecution %s: %v", rec.ID, err)
-		}
-	}
-
-	t.Run("list all", func(t *testing.T) {
-		results, err := fs.ListExecutions(ctx, "pb-exec", 0)
+		results, err := st.ListExecutions(ctx, "pb-exec", 0)
 		if err != nil {
 			t.Fatalf("ListExecutions: %v", err)
 		}
-		if len(results) != 3 {
-			t.Errorf("got %d records, want 3", len(results))
+		var got []string
+		for _, r := range results {
+			got = append(got, r.ID)
 		}
 		// Should be newest first.
-		if results[0].ID != "exec-3" {
-			t.Errorf("first result = %q, want exec-3 (newest)", results[0].ID)
+		if want := "exec-3,exec-2,exec-1"; strings.Join(got, ",") != want {
+			t.Errorf("got %v, want %s", got, want)
 		}
-	})
 
-	t.Run("with limit", func(t *testing.T) {
-		results, err := fs.ListExecutions(ctx, "pb-exec", 2)
+		limited, err := st.ListExecutions(ctx, "pb-exec", 2)
 		if err != nil {
 			t.Fatalf("ListExecutions: %v", err)
 		}
-		if len(results) != 2 {
-			t.Errorf("got %d records, want 2", len(results))
+		if len(limited) != 2 || limited[0].ID != "exec-3" {
+			t.Errorf("with limit 2: got %d records starting %v, want 2 starting exec-3", len(limited), limited)
 		}
-	})
 
-	t.Run("no executions for unknown playbook", func(t *testing.T) {
-		results, err := fs.ListExecutions(ctx, "unknown-pb", 0)
+		unknown, err := st.ListExecutions(ctx, "unknown-pb", 0)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if len(results) != 0 {
-			t.Errorf("got %d records, want 0", len(results))
+		if len(unknown) != 0 {
+			t.Errorf("got %d records for unknown playbook, want 0", len(unknown))
 		}
 	})
-}
 
-func TestFileStoreDeleteAlsoRemovesExecutions(t *testing.T) {
-	dir := t.TempDir()
-	fs, _ := NewFileStore(dir)
-	ctx := context.Background()
+	t.Run("list executions filtered", func(t *testing.T) {
+		st := newStore()
+		base := time.Now()
+		recs := []*ExecutionRecord{
+			{ID: "e1", PlaybookID: "pb", AgentID: "alpha", Outcome: OutcomeSuccess, StartedAt: base.Add(-3 * time.Hour)},
+			{ID: "e2", PlaybookID: "pb", AgentID: "beta", Outcome: OutcomeFailure, StartedAt: base.Add(-2 * time.Hour)},
+			{ID: "e3", PlaybookID: "pb", AgentID: "alpha", Outcome: OutcomeFailure, StartedAt: base.Add(-1 * time.Hour)},
+			{ID: "e4", PlaybookID: "pb", AgentID: "alpha", Outcome: OutcomeFailure, StartedAt: base},
+		}
+		for _, rec := range recs {
+			if err := st.SaveExecution(ctx, rec); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+		}
 
-	pb := newTestPlaybook("pb-cleanup", "Cleanup")
-	if err := fs.SavePlaybook(ctx, pb); err != nil {
-		t.Fatalf("setup: %v", err)
-	}
+		tests := []struct {
+			name   string
+			filter ExecutionFilter
+			want   []string
+		}{
+			{"no filter", ExecutionFilter{}, []string{"e4", "e3", "e2", "e1"}},
+			{"by outcome", ExecutionFilter{Outcome: OutcomeFailure}, []string{"e4", "e3", "e2"}},
+			{"by agent", ExecutionFilter{AgentID: "alpha"}, []string{"e4", "e3", "e1"}},
+			{"outcome and agent", ExecutionFilter{Outcome: OutcomeFailure, AgentID: "alpha"}, []string{"e4", "e3"}},
+			{"started after", ExecutionFilter{StartedAfter: base.Add(-90 * time.Minute)}, []string{"e4", "e3"}},
+			{"started before", ExecutionFilter{StartedBefore: base.Add(-90 * time.Minute)}, []string{"e2", "e1"}},
+			{"limit applies after filtering", ExecutionFilter{Outcome: OutcomeFailure, Limit: 1}, []string{"e4"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				results, err := st.ListExecutionsFiltered(ctx, "pb", tt.filter)
+				if err != nil {
+					t.Fatalf("ListExecutionsFiltered: %v", err)
+				}
+				var got []string
+				for _, r := range results {
+					got = append(got, r.ID)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
 
-	rec := &ExecutionRecord{
-		ID:         "exec-cleanup",
-		PlaybookID: "pb-cleanup",
-		Outcome:    OutcomeSuccess,
-		StartedAt:  time.Now(),
-	}
-	if err := fs.SaveExecution(ctx, rec); err != nil {
-		t.Fatalf("setup: %v", err)
-	}
+	t.Run("save execution overwrites", func(t *testing.T) {
+		st := newStore()
+		rec := &ExecutionRecord{ID: "e1", PlaybookID: "pb", Outcome: OutcomeSuccess, StartedAt: time.Now()}
+		if err := st.SaveExecution(ctx, rec); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		rec.Outcome = OutcomeFailure
+		if err := st.SaveExecution(ctx, rec); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
 
-	if err := fs.DeletePlaybook(ctx, "pb-cleanup"); err != nil {
-		t.Fatalf("setup: %v", err)
-	}
+		results, err := st.ListExecutions(ctx, "pb", 0)
+		if err != nil {
+			t.Fatalf("ListExecutions: %v", err)
+		}
+		if len(results) != 1 || results[0].Outcome != OutcomeFailure {
+			t.Errorf("got %v, want one failure record", results)
+		}
+	})
 
-	// Executions should also be gone.
-	results, err := fs.ListExecutions(ctx, "pb-cleanup", 0)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if len(results) != 0 {
-		t.Errorf("expected 0 executions after delete, got %d", len(results))
-	}
+	t.Run("delete execution", func(t *testing.T) {
+		st := newStore()
+		for _, id := range []string{"e1", "e2"} {
+			if err := st.SaveExecution(ctx, &ExecutionRecord{ID: id, PlaybookID: "pb", StartedAt: time.Now()}); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+		}
+		if err := st.DeleteExecution(ctx, "pb", "e1"); err != nil {
+			t.Fatalf("DeleteExecution: %v", err)
+		}
+		if err := st.DeleteExecution(ctx, "pb", "ghost"); err != nil {
+			t.Errorf("deleting a missing execution: %v", err)
+		}
+
+		results, err := st.ListExecutions(ctx, "pb", 0)
+		if err != nil {
+			t.Fatalf("ListExecutions: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != "e2" {
+			t.Errorf("got %v, want only e2", results)
+		}
+	})
 }
@@ -0,0 +1,75 @@
+package playbookd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultSuggestLimit is the default number of suggestions Suggest returns.
+const DefaultSuggestLimit = 10
+
+// SuggestCandidateLimit caps how many playbooks Suggest loads to draw
+// suggestions from, keeping it fast on large collections.
+const SuggestCandidateLimit = 50
+
+// Suggest returns up to limit distinct playbook names and tags that complete
+// prefix, for autocompletion as a user types. A name matches if it, or one of
+// its words, starts with prefix; a tag matches if it starts with prefix. Both
+// comparisons ignore case. Suggestions from more confident playbooks come
+// first, and only the SuggestCandidateLimit most confident matching playbooks
+// are considered. The indexer must provide SuggestIDs, as BleveIndexer does;
+// otherwise Suggest returns an error.
+func (pm *PlaybookManager) Suggest(ctx context.Context, prefix string, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = DefaultSuggestLimit
+	}
+	want := strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	if want == "" {
+		return nil, nil
+	}
+
+	suggester, ok := pm.baseIndexer.(interface {
+		SuggestIDs(ctx context.Context, prefix string, limit int) ([]string, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("suggest: indexer %T does not support prefix suggestions", pm.baseIndexer)
+	}
+	ids, err := suggester.SuggestIDs(ctx, prefix, SuggestCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]*Playbook, 0, len(ids))
+	for _, id := range ids {
+		pb, err := pm.store.GetPlaybook(ctx, id)
+		if err != nil {
+			continue // Skip if playbook was deleted since it was indexed
+		}
+		candidates = append(candidates, pb)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	seen := make(map[string]bool)
+	var suggestions []string
+	add := func(s string) {
+		if !seen[s] && len(suggestions) < limit {
+			seen[s] = true
+			suggestions = append(suggestions, s)
+		}
+	}
+	for _, pb := range candidates {
+		name := strings.ToLower(strings.Join(strings.Fields(pb.Name), " "))
+		if strings.HasPrefix(name, want) || strings.Contains(name, " "+want) {
+			add(pb.Name)
+		}
+		for _, tag := range pb.Tags {
+			if strings.HasPrefix(strings.ToLower(tag), want) {
+				add(tag)
+			}
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,61 @@
+package playbookd
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestManagerSuggest(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name      string
+		tags      []string
+		successes int
+		failures  int
+	}{
+		{"Deploy Service", []string{"deployment", "go"}, 2, 3},
+		{"Deploy Worker", []string{"deployment"}, 10, 0},
+		{"Rollback Deploy", []string{"rollback"}, 0, 0},
+		{"Database Migration", []string{"db"}, 1, 0},
+		{"Running Migrations", []string{"Happy-Path"}, 0, 0},
+	} {
+		pb := samplePlaybook(tt.name)
+		pb.Tags = tt.tags
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		recordOutcomes(t, pm, pb.ID, OutcomeSuccess, tt.successes)
+		recordOutcomes(t, pm, pb.ID, OutcomeFailure, tt.failures)
+	}
+
+	for _, tt := range []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"dep", 0, []string{"Deploy Worker", "deployment", "Deploy Service", "Rollback Deploy"}},
+		{"DEPLOY S", 0, []string{"Deploy Service"}},
+		{"dep", 2, []string{"Deploy Worker", "deployment"}},
+		{"data", 0, []string{"Database Migration"}},
+		// Complete words match, although the index stems them for search.
+		{"deploy", 0, []string{"Deploy Worker", "deployment", "Deploy Service", "Rollback Deploy"}},
+		{"deployment", 0, []string{"deployment"}},
+		{"migration", 0, []string{"Database Migration", "Running Migrations"}},
+		{"running", 0, []string{"Running Migrations"}},
+		{"happy", 0, []string{"Happy-Path"}},
+		{"Happy-Path", 0, []string{"Happy-Path"}},
+		{"zzz", 0, nil},
+		{"  ", 0, nil},
+	} {
+		got, err := pm.Suggest(ctx, tt.prefix, tt.limit)
+		if err != nil {
+			t.Fatalf("Suggest(%q): %v", tt.prefix, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Suggest(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+		}
+	}
+}
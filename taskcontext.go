@@ -0,0 +1,42 @@
+package playbookd
+
+import "context"
+
+// TaskContextLimit is how many distinct task contexts a playbook keeps in
+// TaskContexts, taken from its most recent executions.
+const TaskContextLimit = 20
+
+// SearchByTaskContext finds playbooks by the task contexts agents recorded
+// when running them (ExecutionRecord.TaskContext), such as "database
+// migration" or "production incident drill", even when the playbook itself
+// never mentions the task. It is a BM25 search of TaskContextField with the
+// default limit and minimum score.
+//
+// Each playbook indexes the distinct contexts of its recent executions, kept
+// in TaskContexts by RecordExecution, so executions recorded before the
+// field existed only count once the playbook is run again.
+func (pm *PlaybookManager) SearchByTaskContext(ctx context.Context, text string) ([]SearchResult, error) {
+	return pm.Search(ctx, SearchQuery{
+		Text:   text,
+		Mode:   SearchModeBM25,
+		Fields: []string{TaskContextField},
+	})
+}
+
+// recentTaskContexts returns the distinct non-empty task contexts of execs,
+// which are newest first, up to TaskContextLimit.
+func recentTaskContexts(execs []*ExecutionRecord) []string {
+	var contexts []string
+	seen := make(map[string]bool)
+	for _, rec := range execs {
+		if rec.TaskContext == "" || seen[rec.TaskContext] {
+			continue
+		}
+		seen[rec.TaskContext] = true
+		contexts = append(contexts, rec.TaskContext)
+		if len(contexts) == TaskContextLimit {
+			break
+		}
+	}
+	return contexts
+}
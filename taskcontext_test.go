@@ -0,0 +1,72 @@
+package playbookd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSearchByTaskContext(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	schema := samplePlaybook("Apply Schema Changes")
+	backup := samplePlaybook("Snapshot Volumes")
+	if err := pm.CreateBatch(ctx, []*Playbook{schema, backup}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	record := func(id, task string) {
+		t.Helper()
+		if err := pm.RecordExecution(ctx, &ExecutionRecord{
+			PlaybookID:  id,
+			Outcome:     OutcomeSuccess,
+			TaskContext: task,
+			StartedAt:   time.Now(),
+			CompletedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("RecordExecution: %v", err)
+		}
+	}
+	record(schema.ID, "postgres database migration for billing")
+	record(schema.ID, "postgres database migration for billing")
+	record(schema.ID, "")
+	record(backup.ID, "production incident drill")
+
+	got, err := pm.Get(ctx, schema.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !slices.Equal(got.TaskContexts, []string{"postgres database migration for billing"}) {
+		t.Errorf("TaskContexts = %q, want the one distinct context", got.TaskContexts)
+	}
+
+	results, err := pm.SearchByTaskContext(ctx, "database migrations")
+	if err != nil {
+		t.Fatalf("SearchByTaskContext: %v", err)
+	}
+	if len(results) != 1 || results[0].Playbook.ID != schema.ID {
+		t.Errorf("database migrations = %v, want [%s]", resultIDs(results), schema.ID)
+	}
+
+	// Task contexts stay out of ordinary searches.
+	results, err = pm.Search(ctx, SearchQuery{Text: "drill", Mode: SearchModeBM25, MinScore: NoMinScore})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("default fields matched a task context: %v", resultIDs(results))
+	}
+}
+
+func TestRecentTaskContexts(t *testing.T) {
+	var execs []*ExecutionRecord
+	for i := range TaskContextLimit + 5 {
+		execs = append(execs, &ExecutionRecord{TaskContext: fmt.Sprintf("task %d", i%(TaskContextLimit+2))})
+	}
+	got := recentTaskContexts(execs)
+	if len(got) != TaskContextLimit || got[0] != "task 0" || got[TaskContextLimit-1] != fmt.Sprintf("task %d", TaskContextLimit-1) {
+		t.Errorf("recentTaskContexts = %q, want the first %d distinct contexts", got, TaskContextLimit)
+	}
+}
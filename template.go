@@ -0,0 +1,73 @@
+package playbookd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TemplateMode selects how a CategoryTemplate is enforced.
+type TemplateMode string
+
+const (
+	// TemplateModeValidate rejects playbooks missing a required step.
+	TemplateModeValidate TemplateMode = "validate"
+	// TemplateModeScaffold adds missing required steps as placeholders on Create.
+	TemplateModeScaffold TemplateMode = "scaffold"
+)
+
+// CategoryTemplate lists steps every playbook in a category must have. Steps
+// are matched by Action, ignoring case and surrounding whitespace.
+type CategoryTemplate struct {
+	Mode          TemplateMode // validate or scaffold (default validate)
+	RequiredSteps []string     // Required step actions, e.g. "Escalate"
+}
+
+// missingSteps returns the required steps that pb has no step for.
+func (t CategoryTemplate) missingSteps(pb *Playbook) []string {
+	have := make(map[string]bool, len(pb.Steps))
+	for _, s := range pb.Steps {
+		have[normalizeStepName(s.Action)] = true
+	}
+	var missing []string
+	for _, name := range t.RequiredSteps {
+		if !have[normalizeStepName(name)] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// validate returns an error wrapping ErrInvalidPlaybook if pb is missing a
+// required step. Scaffold templates never fail validation.
+func (t CategoryTemplate) validate(pb *Playbook) error {
+	if t.Mode == TemplateModeScaffold {
+		return nil
+	}
+	if missing := t.missingSteps(pb); len(missing) > 0 {
+		return fmt.Errorf("%w: category %q requires steps %s", ErrInvalidPlaybook, pb.Category, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// scaffold appends a placeholder step for each required step pb is missing.
+func (t CategoryTemplate) scaffold(pb *Playbook) {
+	if t.Mode != TemplateModeScaffold {
+		return
+	}
+	order := 0
+	for _, s := range pb.Steps {
+		order = max(order, s.Order)
+	}
+	for _, name := range t.missingSteps(pb) {
+		order++
+		pb.Steps = append(pb.Steps, Step{
+			Order:  order,
+			Action: name,
+			Notes:  fmt.Sprintf("Placeholder required by the %q category template", pb.Category),
+		})
+	}
+}
+
+func normalizeStepName(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
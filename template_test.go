@@ -0,0 +1,120 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/lucas-stellet/playbookd/embed"
+)
+
+func newTemplateManager(t *testing.T) *PlaybookManager {
+	t.Helper()
+	pm, err := NewPlaybookManager(ManagerConfig{
+		DataDir:   t.TempDir(),
+		EmbedFunc: embed.Noop(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		CategoryTemplates: map[string]CategoryTemplate{
+			"incident": {Mode: TemplateModeValidate, RequiredSteps: []string{"Escalate"}},
+			"release":  {Mode: TemplateModeScaffold, RequiredSteps: []string{"Tag release", "Announce"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPlaybookManager: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	return pm
+}
+
+func TestCategoryTemplateValidate(t *testing.T) {
+	pm := newTemplateManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Database Outage")
+	pb.Category = "incident"
+	if err := pm.Create(ctx, pb); !errors.Is(err, ErrInvalidPlaybook) {
+		t.Fatalf("Create without required step: err = %v, want ErrInvalidPlaybook", err)
+	}
+
+	pb.Steps = append(pb.Steps, Step{Order: 3, Action: " escalate "})
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create with required step: %v", err)
+	}
+
+	// Update is held to the same template.
+	pb.Steps = pb.Steps[:2]
+	if err := pm.Update(ctx, pb); !errors.Is(err, ErrInvalidPlaybook) {
+		t.Errorf("Update removing required step: err = %v, want ErrInvalidPlaybook", err)
+	}
+
+	// Other categories are unaffected.
+	other := samplePlaybook("Unrelated")
+	if err := pm.Create(ctx, other); err != nil {
+		t.Errorf("Create in category without template: %v", err)
+	}
+}
+
+func TestCategoryTemplateScaffold(t *testing.T) {
+	pm := newTemplateManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Ship v2")
+	pb.Category = "release"
+	pb.Steps = append(pb.Steps, Step{Order: 3, Action: "Announce"})
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Steps) != 4 {
+		t.Fatalf("Steps = %d, want 4 (one placeholder added)", len(got.Steps))
+	}
+	added := got.Steps[3]
+	if added.Action != "Tag release" || added.Order != 4 || added.Notes == "" {
+		t.Errorf("placeholder step = %+v, want Tag release at order 4 with a note", added)
+	}
+
+	// Scaffold templates don't block later edits.
+	got.Steps = got.Steps[:2]
+	if err := pm.Update(ctx, got); err != nil {
+		t.Errorf("Update: %v", err)
+	}
+}
+
+func TestCategoryTemplateImport(t *testing.T) {
+	pm := newTemplateManager(t)
+	ctx := context.Background()
+
+	// Import goes through the same preparation as Create.
+	created := samplePlaybook("Ship v3")
+	created.Category = "release"
+	if err := pm.Create(ctx, created); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	imported := samplePlaybook("Ship v3")
+	imported.ID = "imported-release"
+	imported.Category = "release"
+	incident := samplePlaybook("Database Outage")
+	incident.ID = "imported-incident"
+	incident.Category = "incident"
+	result, err := pm.Import(ctx, []*Playbook{imported, incident})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Imported != 1 || len(result.Failed) != 1 || result.Failed[0].Name != "Database Outage" {
+		t.Errorf("Import = %+v, want the release imported and the incident without Escalate failed", result)
+	}
+
+	got, err := pm.Get(ctx, "imported-release")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Steps) != len(created.Steps) || got.Steps[len(got.Steps)-1].Action != created.Steps[len(created.Steps)-1].Action {
+		t.Errorf("imported Steps = %+v, want the scaffold Create applies: %+v", got.Steps, created.Steps)
+	}
+}
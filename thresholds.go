@@ -0,0 +1,38 @@
+package playbookd
+
+// ThresholdSet overrides the confidence thresholds for the playbooks of one
+// category, set through ManagerConfig.CategoryThresholds. A zero field falls
+// back to the global default.
+type ThresholdSet struct {
+	PositiveMinConfidence float64 // SearchWithContext: minimum confidence to be a proven example (default DefaultPositiveMinConfidence)
+	NegativeMaxConfidence float64 // SearchWithContext: maximum confidence to be a failed example (default DefaultNegativeMaxConfidence)
+	PruneMinConfidence    float64 // Prune: confidence below which an old playbook is archived (default ManagerConfig.MinConfidence)
+}
+
+// positiveMinConfidence returns the threshold for pb to be a proven example:
+// the query's, if set, then its category's, then the default.
+func (pm *PlaybookManager) positiveMinConfidence(pb *Playbook, query float64) float64 {
+	return firstNonZero(query, pm.cfg.CategoryThresholds[pb.Category].PositiveMinConfidence, DefaultPositiveMinConfidence)
+}
+
+// negativeMaxConfidence returns the threshold for pb to be a failed example:
+// the query's, if set, then its category's, then the default.
+func (pm *PlaybookManager) negativeMaxConfidence(pb *Playbook, query float64) float64 {
+	return firstNonZero(query, pm.cfg.CategoryThresholds[pb.Category].NegativeMaxConfidence, DefaultNegativeMaxConfidence)
+}
+
+// pruneMinConfidence returns the confidence below which Prune may archive pb:
+// the one passed to Prune, if set, then its category's, then
+// ManagerConfig.MinConfidence.
+func (pm *PlaybookManager) pruneMinConfidence(pb *Playbook, opts float64) float64 {
+	return firstNonZero(opts, pm.cfg.CategoryThresholds[pb.Category].PruneMinConfidence, pm.cfg.MinConfidence)
+}
+
+func firstNonZero(values ...float64) float64 {
+	for _, v := range values {
+		if v != 0 {
+			return v
+		}
+	}
+	return 0
+}
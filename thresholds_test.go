@@ -0,0 +1,102 @@
+package playbookd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCategoryThresholdsContrastive(t *testing.T) {
+	pm := newTestManager(t)
+	pm.cfg.CategoryThresholds = map[string]ThresholdSet{
+		"security": {PositiveMinConfidence: 0.9},
+	}
+	ctx := context.Background()
+
+	security := samplePlaybook("Rotate Deploy Keys")
+	security.Category = "security"
+	formatting := samplePlaybook("Format Deploy Config")
+	formatting.Category = "formatting"
+	if err := pm.CreateBatch(ctx, []*Playbook{security, formatting}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	recordOutcomes(t, pm, security.ID, OutcomeSuccess, 10)
+	recordOutcomes(t, pm, formatting.ID, OutcomeSuccess, 10)
+
+	a, _ := pm.Get(ctx, security.ID)
+	b, _ := pm.Get(ctx, formatting.ID)
+	if a.Confidence != b.Confidence {
+		t.Fatalf("setup: confidences differ: %v and %v", a.Confidence, b.Confidence)
+	}
+
+	cr, err := pm.SearchWithContext(ctx, ContrastiveQuery{
+		SearchQuery:    SearchQuery{Text: "deploy", Mode: SearchModeBM25},
+		IncludeNeutral: true,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithContext: %v", err)
+	}
+	if len(cr.Positive) != 1 || cr.Positive[0].Playbook.ID != formatting.ID {
+		t.Errorf("Positive = %v, want only the formatting playbook", resultIDs(cr.Positive))
+	}
+	if len(cr.Neutral) != 1 || cr.Neutral[0].Playbook.ID != security.ID {
+		t.Errorf("Neutral = %v, want only the security playbook", resultIDs(cr.Neutral))
+	}
+
+	// A threshold on the query applies to every category.
+	cr, err = pm.SearchWithContext(ctx, ContrastiveQuery{
+		SearchQuery:           SearchQuery{Text: "deploy", Mode: SearchModeBM25},
+		PositiveMinConfidence: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithContext: %v", err)
+	}
+	if len(cr.Positive) != 2 {
+		t.Errorf("Positive = %v with an explicit threshold, want both playbooks", resultIDs(cr.Positive))
+	}
+}
+
+func TestCategoryThresholdsPrune(t *testing.T) {
+	pm := newTestManager(t)
+	pm.cfg.CategoryThresholds = map[string]ThresholdSet{
+		"security": {PruneMinConfidence: 0.6},
+	}
+	ctx := context.Background()
+
+	oldTime := time.Now().Add(-180 * 24 * time.Hour)
+	ids := map[string]string{}
+	for _, category := range []string{"security", "formatting"} {
+		pb := samplePlaybook("Stale " + category)
+		pb.Category = category
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		stored, err := pm.Get(ctx, pb.ID)
+		if err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		stored.CreatedAt = oldTime
+		stored.UpdatedAt = oldTime
+		stored.Confidence = 0.4
+		if err := pm.store.SavePlaybook(ctx, stored); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		ids[category] = pb.ID
+	}
+
+	result, err := pm.Prune(ctx, PruneOptions{MaxAge: 90 * 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if got := result.IDs(); len(got) != 1 || got[0] != ids["security"] {
+		t.Errorf("pruned %v, want only the security playbook %s", got, ids["security"])
+	}
+}
+
+func resultIDs(results []SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Playbook.ID
+	}
+	return ids
+}
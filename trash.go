@@ -0,0 +1,100 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ListTrash returns the playbooks removed by Delete that have not been purged,
+// most recently deleted first. Each has DeletedAt set.
+func (pm *PlaybookManager) ListTrash(ctx context.Context) ([]*Playbook, error) {
+	playbooks, err := pm.store.ListTrash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+	return playbooks, nil
+}
+
+// RestoreFromTrash brings back a playbook removed by Delete, with its
+// executions and versions, and re-indexes it. If another playbook has taken
+// its slug in the meantime, the restored playbook gets a numbered one.
+// Restoring a playbook that is not in the trash returns ErrNotFound.
+func (pm *PlaybookManager) RestoreFromTrash(ctx context.Context, id string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pb, err := pm.store.RestoreTrashedPlaybook(ctx, id)
+	if err != nil {
+		return fmt.Errorf("restore playbook: %w", err)
+	}
+
+	slug, err := pm.uniqueSlug(ctx, pb.Slug, pb.ID)
+	if err != nil {
+		return err
+	}
+	if slug != pb.Slug {
+		pb.Slug = slug
+		if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+			return fmt.Errorf("save playbook: %w", err)
+		}
+	}
+
+	if err := pm.indexer.Index(ctx, pb); err != nil {
+		return fmt.Errorf("re-index playbook: %w", err)
+	}
+	pm.emit(EventUntrashed, pb.ID, nil)
+	return nil
+}
+
+// Purge permanently deletes a playbook with its executions and versions,
+// whether it is live or in the trash. Purging an unknown ID is not an error.
+func (pm *PlaybookManager) Purge(ctx context.Context, id string) error {
+	live := true
+	if _, err := pm.store.GetPlaybook(ctx, id); errors.Is(err, ErrNotFound) {
+		live = false
+	} else if err != nil {
+		return fmt.Errorf("get playbook: %w", err)
+	}
+
+	if err := pm.store.DeletePlaybook(ctx, id); err != nil {
+		return fmt.Errorf("delete playbook: %w", err)
+	}
+	if err := pm.store.PurgeTrashedPlaybook(ctx, id); err != nil {
+		return fmt.Errorf("purge playbook: %w", err)
+	}
+	if err := pm.indexer.Remove(ctx, id); err != nil {
+		return fmt.Errorf("playbook %s was deleted from the store but not removed from the index: %w", id, err)
+	}
+	if live {
+		pm.metrics.PlaybookDeleted()
+	}
+	pm.emit(EventPurged, id, nil)
+	return nil
+}
+
+// EmptyTrash permanently deletes the trashed playbooks that were deleted more
+// than olderThan ago; zero or less empties the whole trash. It returns the
+// number of playbooks purged, which is accurate even when it stops early with
+// an error.
+func (pm *PlaybookManager) EmptyTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	playbooks, err := pm.store.ListTrash(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list trash: %w", err)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	purged := 0
+	for _, pb := range playbooks {
+		if olderThan > 0 && pb.DeletedAt != nil && pb.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := pm.store.PurgeTrashedPlaybook(ctx, pb.ID); err != nil {
+			return purged, fmt.Errorf("purge playbook %s: %w", pb.ID, err)
+		}
+		purged++
+		pm.emit(EventPurged, pb.ID, nil)
+	}
+	return purged, nil
+}
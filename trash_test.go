@@ -0,0 +1,107 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerTrash(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Deploy Service")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	recordOutcomes(t, pm, pb.ID, OutcomeSuccess, 1)
+
+	if err := pm.Delete(ctx, pb.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := pm.Get(ctx, pb.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: error = %v, want ErrNotFound", err)
+	}
+	if n := searchCount(t, pm, "deploy"); n != 0 {
+		t.Errorf("search after Delete found %d results, want 0", n)
+	}
+	trash, err := pm.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != pb.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("ListTrash = %+v, want the deleted playbook", trash)
+	}
+
+	// A new playbook takes the slug while the old one is in the trash.
+	other := samplePlaybook("Deploy Service")
+	if err := pm.Create(ctx, other); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if err := pm.RestoreFromTrash(ctx, pb.ID); err != nil {
+		t.Fatalf("RestoreFromTrash: %v", err)
+	}
+	got, err := pm.Get(ctx, pb.ID)
+	if err != nil {
+		t.Fatalf("Get after restore: %v", err)
+	}
+	if got.Slug != "deploy-service-2" || got.DeletedAt != nil {
+		t.Errorf("restored Slug = %q, DeletedAt = %v; want deploy-service-2 and nil", got.Slug, got.DeletedAt)
+	}
+	if execs, _ := pm.ListExecutions(ctx, pb.ID, 0); len(execs) != 1 {
+		t.Errorf("got %d executions after restore, want 1", len(execs))
+	}
+	if n := searchCount(t, pm, "deploy"); n != 2 {
+		t.Errorf("search after restore found %d results, want 2", n)
+	}
+	if err := pm.RestoreFromTrash(ctx, pb.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RestoreFromTrash of a live playbook: error = %v, want ErrNotFound", err)
+	}
+
+	if err := pm.Purge(ctx, pb.ID); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if _, err := pm.Get(ctx, pb.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Purge: error = %v, want ErrNotFound", err)
+	}
+	if trash, _ := pm.ListTrash(ctx); len(trash) != 0 {
+		t.Errorf("ListTrash after Purge = %d playbooks, want 0", len(trash))
+	}
+	if execs, _ := pm.ListExecutions(ctx, pb.ID, 0); len(execs) != 0 {
+		t.Errorf("got %d executions after Purge, want 0", len(execs))
+	}
+}
+
+func TestManagerEmptyTrash(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	old := samplePlaybook("Old Deploy")
+	recent := samplePlaybook("Recent Deploy")
+	for _, pb := range []*Playbook{old, recent} {
+		if err := pm.Create(ctx, pb); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	if err := pm.store.TrashPlaybook(ctx, old.ID, time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatalf("TrashPlaybook: %v", err)
+	}
+	if err := pm.Delete(ctx, recent.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	purged, err := pm.EmptyTrash(ctx, 24*time.Hour)
+	if err != nil || purged != 1 {
+		t.Fatalf("EmptyTrash(24h) = %d, %v; want 1, nil", purged, err)
+	}
+	trash, _ := pm.ListTrash(ctx)
+	if len(trash) != 1 || trash[0].ID != recent.ID {
+		t.Fatalf("ListTrash = %+v, want only the recent playbook", trash)
+	}
+
+	if purged, err := pm.EmptyTrash(ctx, 0); err != nil || purged != 1 {
+		t.Errorf("EmptyTrash(0) = %d, %v; want 1, nil", purged, err)
+	}
+}
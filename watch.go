@@ -0,0 +1,149 @@
+package playbookd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long Watch waits after the last change to a
+// playbook file before re-indexing it.
+const DefaultWatchDebounce = 250 * time.Millisecond
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	Debounce time.Duration // Quiet period before a changed file is synced (default DefaultWatchDebounce)
+
+	// OnSync, if set, is called after each changed playbook is synced, with
+	// the error from SyncIndex (nil on success).
+	OnSync func(id string, err error)
+}
+
+// SyncIndex re-reads a playbook from the store and updates its index entry:
+// it is indexed if present, or removed from the index if it was deleted or
+// archived.
+func (pm *PlaybookManager) SyncIndex(ctx context.Context, id string) error {
+	pb, err := pm.store.GetPlaybook(ctx, id)
+	if errors.Is(err, ErrNotFound) || (err == nil && pb.Archived) {
+		return pm.indexer.Remove(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	return pm.indexer.Index(ctx, pb)
+}
+
+// reconcileIndex indexes every stored playbook and removes index entries with
+// no matching playbook, returning how many of each it did.
+func (pm *PlaybookManager) reconcileIndex(ctx context.Context) (indexed, removed int, err error) {
+	playbooks, err := pm.store.ListPlaybooks(ctx, ListFilter{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("list playbooks: %w", err)
+	}
+	if err := pm.indexer.Reindex(ctx, playbooks); err != nil {
+		return 0, 0, fmt.Errorf("index playbooks: %w", err)
+	}
+	report, err := pm.Repair(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(playbooks), len(report.OrphanedInIndex), nil
+}
+
+// Watch keeps the search index in sync with playbook files changed outside the
+// manager, such as by hand edits. It first reconciles the whole index with the
+// store, then watches the playbooks directory and syncs each created,
+// modified, or deleted file once it has been quiet for the debounce period.
+// Stored embeddings are indexed as they are; edits to the text do not
+// regenerate them. Watch blocks until ctx is done and then returns nil. It
+// requires the file store.
+func (pm *PlaybookManager) Watch(ctx context.Context, opts WatchOptions) error {
+	fs, ok := pm.store.(*FileStore)
+	if !ok {
+		return fmt.Errorf("watch requires the file store, not %T", pm.store)
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultWatchDebounce
+	}
+	dir := filepath.Join(fs.dataDir, "playbooks")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer watcher.Close()
+	// Watch before reconciling so changes made during the reconcile are not missed.
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+
+	indexed, removed, err := pm.reconcileIndex(ctx)
+	if err != nil {
+		return fmt.Errorf("reconcile index: %w", err)
+	}
+	pm.log.Info("index reconciled", "indexed", indexed, "removed", removed)
+
+	// Each changed ID gets a timer that is reset by further changes; when it
+	// fires, the ID is sent to ready and synced on this goroutine.
+	timers := make(map[string]*time.Timer)
+	ready := make(chan string)
+	defer func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			id, ok := playbookIDFromPath(ev.Name)
+			if !ok || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if t, ok := timers[id]; ok {
+				t.Reset(opts.Debounce)
+				continue
+			}
+			timers[id] = time.AfterFunc(opts.Debounce, func() {
+				select {
+				case ready <- id:
+				case <-ctx.Done():
+				}
+			})
+		case id := <-ready:
+			delete(timers, id)
+			err := pm.SyncIndex(ctx, id)
+			if err != nil {
+				pm.log.Warn("sync playbook", "id", id, "error", err)
+			}
+			if opts.OnSync != nil {
+				opts.OnSync(id, err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			pm.log.Warn("watch error", "error", err)
+		}
+	}
+}
+
+// playbookIDFromPath returns the playbook ID of a file in the playbooks
+// directory, skipping temporary files left by atomic writes and editors.
+func playbookIDFromPath(path string) (string, bool) {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+		return "", false
+	}
+	return strings.TrimSuffix(name, ".json"), true
+}
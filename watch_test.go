@@ -0,0 +1,138 @@
+package playbookd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManagerSyncIndex(t *testing.T) {
+	pm := newTestManager(t)
+	ctx := context.Background()
+
+	pb := samplePlaybook("Sync Target")
+	if err := pm.Create(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	// Hand-edit the file, then sync it.
+	pb.Description = "mentions zeppelin now"
+	if err := pm.store.SavePlaybook(ctx, pb); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := pm.SyncIndex(ctx, pb.ID); err != nil {
+		t.Fatalf("SyncIndex: %v", err)
+	}
+	if n := searchCount(t, pm, "zeppelin"); n != 1 {
+		t.Errorf("after editing: %d results, want 1", n)
+	}
+
+	if err := os.Remove(filepath.Join(pm.cfg.DataDir, "playbooks", pb.ID+".json")); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := pm.SyncIndex(ctx, pb.ID); err != nil {
+		t.Fatalf("SyncIndex: %v", err)
+	}
+	if n := searchCount(t, pm, "zeppelin"); n != 0 {
+		t.Errorf("after deleting: %d results, want 0", n)
+	}
+}
+
+func TestManagerWatch(t *testing.T) {
+	pm := newTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Drift from before the watch starts is reconciled on startup.
+	orphan := samplePlaybook("Orphaned Walrus")
+	if err := pm.Create(ctx, orphan); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.Remove(filepath.Join(pm.cfg.DataDir, "playbooks", orphan.ID+".json")); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	synced := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- pm.Watch(ctx, WatchOptions{
+			Debounce: 20 * time.Millisecond,
+			OnSync:   func(id string, err error) { synced <- id },
+		})
+	}()
+	waitFor(t, "orphan removed from the index", func() bool {
+		_, extra, err := pm.IndexDrift(ctx)
+		return err == nil && len(extra) == 0
+	})
+
+	// A file written outside the manager is indexed.
+	pb := samplePlaybook("Handwritten Narwhal")
+	pb.ID = "handwritten"
+	data, err := json.Marshal(pb)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	path := filepath.Join(pm.cfg.DataDir, "playbooks", pb.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	waitForSync(t, synced, pb.ID)
+	if n := searchCount(t, pm, "narwhal"); n != 1 {
+		t.Errorf("after writing the file: %d results, want 1", n)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	waitForSync(t, synced, pb.ID)
+	if n := searchCount(t, pm, "narwhal"); n != 0 {
+		t.Errorf("after removing the file: %d results, want 0", n)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Watch returned %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+}
+
+func searchCount(t *testing.T, pm *PlaybookManager, text string) int {
+	t.Helper()
+	results, err := pm.Search(context.Background(), SearchQuery{Text: text, Mode: SearchModeBM25})
+	if err != nil {
+		t.Fatalf("Search(%q): %v", text, err)
+	}
+	return len(results)
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitForSync(t *testing.T, synced <-chan string, id string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-synced:
+			if got == id {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s to sync", id)
+		}
+	}
+}